@@ -246,6 +246,8 @@ func loadConfig(configFile string) (*config.Config, error) {
 	// This is needed because Viper's AutomaticEnv only works for keys that exist in config
 	_ = viper.BindEnv("api.auth.api_key", "AGENTFIELD_API_KEY")
 	_ = viper.BindEnv("api.auth.api_key", "AGENTFIELD_API_AUTH_API_KEY")
+	// AGENTFIELD_SERVER_SALT does not follow the nested-key naming, so bind it explicitly too
+	_ = viper.BindEnv("features.did.server_salt", "AGENTFIELD_SERVER_SALT")
 
 	// Skip config file reading if explicitly set to /dev/null or empty
 	if configFile != "/dev/null" && configFile != "" {
@@ -47,6 +47,20 @@ func TestLoadConfig_DefaultsApplied(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_ServerSaltFromEnv(t *testing.T) {
+	t.Setenv("AGENTFIELD_CONFIG_FILE", "")
+	t.Setenv("AGENTFIELD_SERVER_SALT", "container-a")
+	viper.Reset()
+
+	cfg, err := loadConfig("/dev/null")
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+	if cfg.Features.DID.ServerSalt != "container-a" {
+		t.Fatalf("expected server salt from AGENTFIELD_SERVER_SALT, got %q", cfg.Features.DID.ServerSalt)
+	}
+}
+
 func TestLoadConfig_ConfigFileValues(t *testing.T) {
 	viper.Reset()
 
@@ -1,8 +1,6 @@
 package application
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"path/filepath"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/cli/framework"
@@ -28,9 +26,10 @@ func CreateServiceContainer(cfg *config.Config, agentfieldHome string) *framewor
 	storageFactory := &storageInterface.StorageFactory{}
 	storageProvider, _, err := storageFactory.CreateStorage(cfg.Storage)
 	if err != nil {
-		// Log error - database storage initialization failed
-		// In production, this should be handled more gracefully
-		storageProvider = nil
+		// Fall back to a provider whose operations fail with
+		// storage.ErrStorageUnavailable rather than handing out a nil.
+		logger.Logger.Warn().Err(err).Msg("failed to initialize storage; storage-backed features are unavailable")
+		storageProvider = storageInterface.NewNoopStorage()
 	}
 
 	// Create services
@@ -52,42 +51,30 @@ func CreateServiceContainer(cfg *config.Config, agentfieldHome string) *framewor
 			keystoreService = nil
 		}
 
-		// Create DID registry with database storage (required)
-		if storageProvider != nil {
-			didRegistry = didServices.NewDIDRegistryWithStorage(storageProvider)
-		} else {
-			// DID registry requires database storage, skip if not available
+		// Create DID registry with database storage (required). Initialization
+		// fails against the noop provider, which leaves the registry disabled.
+		didRegistry = didServices.NewDIDRegistryWithStorage(storageProvider)
+		if err := didRegistry.Initialize(); err != nil {
+			// Log error but continue
 			didRegistry = nil
 		}
 
-		if didRegistry != nil {
-			if err := didRegistry.Initialize(); err != nil {
-				// Log error but continue
-				didRegistry = nil
-			}
-		}
-
 		// Create DID service
 		if keystoreService != nil && didRegistry != nil {
 			didService = didServices.NewDIDService(&cfg.Features.DID, keystoreService, didRegistry)
 
 			// Generate af server ID based on agentfield home directory
 			// This ensures each agentfield instance has a unique ID while being deterministic
-			agentfieldServerID := generateAgentFieldServerID(agentfieldHome)
+			agentfieldServerID := didServices.GenerateAgentFieldServerID(agentfieldHome, cfg.Features.DID.ServerSalt)
 			if err := didService.Initialize(agentfieldServerID); err != nil {
 				logger.Logger.Warn().Err(err).Msg("failed to initialize DID service")
 				didService = nil
 			} else {
 				// Create VC service with database storage (required)
-				if storageProvider != nil {
-					vcService = didServices.NewVCService(&cfg.Features.DID, didService, storageProvider)
-				}
-
-				if vcService != nil {
-					if err := vcService.Initialize(); err != nil {
-						logger.Logger.Warn().Err(err).Msg("failed to initialize VC service")
-						vcService = nil
-					}
+				vcService = didServices.NewVCService(&cfg.Features.DID, didService, storageProvider)
+				if err := vcService.Initialize(); err != nil {
+					logger.Logger.Warn().Err(err).Msg("failed to initialize VC service")
+					vcService = nil
 				}
 			}
 		}
@@ -111,23 +98,3 @@ func CreateServiceContainerWithDefaults(agentfieldHome string) *framework.Servic
 	cfg := &config.Config{} // This will be enhanced when config is properly structured
 	return CreateServiceContainer(cfg, agentfieldHome)
 }
-
-// generateAgentFieldServerID creates a deterministic af server ID based on the agentfield home directory.
-// This ensures each agentfield instance has a unique ID while being deterministic for the same installation.
-func generateAgentFieldServerID(agentfieldHome string) string {
-	// Use the absolute path of agentfield home to generate a deterministic ID
-	absPath, err := filepath.Abs(agentfieldHome)
-	if err != nil {
-		// Fallback to the original path if absolute path fails
-		absPath = agentfieldHome
-	}
-
-	// Create a hash of the agentfield home path to generate a unique but deterministic ID
-	hash := sha256.Sum256([]byte(absPath))
-
-	// Use first 16 characters of the hex hash as the af server ID
-	// This provides uniqueness while keeping the ID manageable
-	agentfieldServerID := hex.EncodeToString(hash[:])[:16]
-
-	return agentfieldServerID
-}
@@ -2,8 +2,8 @@ package application
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/config"
@@ -42,6 +42,23 @@ func TestCreateServiceContainerDIDWithoutStorageFallback(t *testing.T) {
 	}
 }
 
+func TestCreateServiceContainerFallsBackToNoopStorage(t *testing.T) {
+	t.Parallel()
+
+	agentfieldHome := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Storage.Mode = "invalid"
+
+	container := CreateServiceContainer(cfg, agentfieldHome)
+
+	if container.StorageProvider == nil {
+		t.Fatalf("expected a noop storage provider when storage initialisation fails")
+	}
+	if err := container.StorageProvider.HealthCheck(context.Background()); !errors.Is(err, storagecfg.ErrStorageUnavailable) {
+		t.Fatalf("expected ErrStorageUnavailable, got %v", err)
+	}
+}
+
 func TestCreateServiceContainerWithLocalDID(t *testing.T) {
 	t.Parallel()
 
@@ -62,7 +79,7 @@ func TestCreateServiceContainerWithLocalDID(t *testing.T) {
 		Local: cfg.Storage.Local,
 	}
 	if err := probe.Initialize(ctx, storageConfig); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, storagecfg.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping DID container test")
 		}
 		t.Fatalf("failed to initialise local storage: %v", err)
@@ -81,6 +81,10 @@ type DIDConfig struct {
 	KeyRotationDays  int            `yaml:"key_rotation_days" mapstructure:"key_rotation_days" default:"90"`
 	VCRequirements   VCRequirements `yaml:"vc_requirements" mapstructure:"vc_requirements"`
 	Keystore         KeystoreConfig `yaml:"keystore" mapstructure:"keystore"`
+
+	// ServerSalt is mixed into the af server ID so installs sharing a home
+	// path get distinct IDs. Empty keeps the path-only ID.
+	ServerSalt string `yaml:"server_salt" mapstructure:"server_salt"`
 }
 
 // VCRequirements holds VC generation requirements.
@@ -122,6 +126,11 @@ type CORSConfig struct {
 type AuthConfig struct {
 	// APIKey is checked against headers or query params. Empty disables auth.
 	APIKey string `yaml:"api_key" mapstructure:"api_key"`
+	// TenantKeys maps tenant IDs to API keys scoped to that tenant. Requests
+	// with a tenant key only see and write that tenant's data, whatever
+	// X-Tenant-ID says; APIKey keeps operator access to every tenant. Tenant
+	// keys are accepted on the HTTP API only.
+	TenantKeys map[string]string `yaml:"tenant_keys" mapstructure:"tenant_keys"`
 	// SkipPaths allows bypassing auth for specific endpoints (e.g., health).
 	SkipPaths []string `yaml:"skip_paths" mapstructure:"skip_paths"`
 }
@@ -209,4 +218,9 @@ func applyEnvOverrides(cfg *Config) {
 			cfg.AgentField.NodeHealth.HeartbeatStaleThreshold = d
 		}
 	}
+
+	// DID server ID salt
+	if salt := os.Getenv("AGENTFIELD_SERVER_SALT"); salt != "" {
+		cfg.Features.DID.ServerSalt = salt
+	}
 }
@@ -1,7 +1,11 @@
 // agentfield/internal/core/domain/models.go
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // AgentNode represents a running agent instance
 type AgentNode struct {
@@ -42,8 +46,13 @@ type ProcessSpec struct {
 	LogFile     string            `json:"log_file"`
 }
 
+// CurrentRegistryVersion is the installed.json format version written by
+// this build. Files without a version predate versioning and are version 0.
+const CurrentRegistryVersion = 1
+
 // InstallationRegistry tracks installed packages
 type InstallationRegistry struct {
+	Version   int                         `json:"version"`
 	Installed map[string]InstalledPackage `json:"installed"`
 }
 
@@ -70,9 +79,65 @@ type MCPConfig struct {
 
 // MCPServer represents an MCP server configuration
 type MCPServer struct {
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Enabled bool   `json:"enabled"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Command   string `json:"command,omitempty"`
+	Transport string `json:"transport,omitempty"` // "stdio" or "http"; inferred when empty
+	Enabled   bool   `json:"enabled"`
+}
+
+// MCP server transports
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+)
+
+// EffectiveTransport returns the configured transport, inferring "http" when
+// only a URL is set and "stdio" otherwise.
+func (s MCPServer) EffectiveTransport() string {
+	if s.Transport != "" {
+		return s.Transport
+	}
+	if s.URL != "" && s.Command == "" {
+		return MCPTransportHTTP
+	}
+	return MCPTransportStdio
+}
+
+// MCPServerValidationError identifies the first invalid MCP server entry.
+type MCPServerValidationError struct {
+	Index  int
+	Field  string
+	Reason string
+}
+
+func (e *MCPServerValidationError) Error() string {
+	return fmt.Sprintf("mcp.servers[%d].%s: %s", e.Index, e.Field, e.Reason)
+}
+
+// Validate checks that every server has a name and the command or URL its
+// transport needs, returning an *MCPServerValidationError for the first
+// invalid entry.
+func (c MCPConfig) Validate() error {
+	for i, server := range c.Servers {
+		if strings.TrimSpace(server.Name) == "" {
+			return &MCPServerValidationError{Index: i, Field: "name", Reason: "must not be empty"}
+		}
+
+		switch server.EffectiveTransport() {
+		case MCPTransportStdio:
+			if strings.TrimSpace(server.Command) == "" {
+				return &MCPServerValidationError{Index: i, Field: "command", Reason: "is required for stdio transport"}
+			}
+		case MCPTransportHTTP:
+			if strings.TrimSpace(server.URL) == "" {
+				return &MCPServerValidationError{Index: i, Field: "url", Reason: "is required for http transport"}
+			}
+		default:
+			return &MCPServerValidationError{Index: i, Field: "transport", Reason: fmt.Sprintf("unsupported transport %q", server.Transport)}
+		}
+	}
+	return nil
 }
 
 // InstallOptions represents options for package installation
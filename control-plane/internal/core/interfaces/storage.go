@@ -20,5 +20,6 @@ type RegistryStorage interface {
 
 type ConfigStorage interface {
 	LoadAgentFieldConfig(path string) (*domain.AgentFieldConfig, error)
+	LoadAgentFieldConfigStrict(path string) (*domain.AgentFieldConfig, error)
 	SaveAgentFieldConfig(path string, config *domain.AgentFieldConfig) error
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 )
 
@@ -59,6 +60,8 @@ func (h *DIDHandlers) RegisterAgent(c *gin.Context) {
 		return
 	}
 
+	req.TenantID = middleware.TenantID(c)
+
 	response, err := h.didService.RegisterAgent(&req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register agent"})
@@ -89,6 +92,8 @@ func (h *DIDHandlers) ResolveDID(c *gin.Context) {
 		"component_type":  identity.ComponentType,
 		"function_name":   identity.FunctionName,
 		"derivation_path": identity.DerivationPath,
+		"revoked":         identity.Revoked,
+		"rate_limit":      identity.RateLimit,
 	})
 }
 
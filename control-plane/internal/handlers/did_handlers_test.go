@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
 	"github.com/gin-gonic/gin"
@@ -151,6 +152,35 @@ func TestRegisterAgentHandler_Success(t *testing.T) {
 	require.Equal(t, "did:example:agent", payload.IdentityPackage.AgentDID.DID)
 }
 
+func TestRegisterAgentHandler_UsesAuthenticatedTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got *types.DIDRegistrationRequest
+	handler := NewDIDHandlers(&fakeDIDService{
+		registerFn: func(req *types.DIDRegistrationRequest) (*types.DIDRegistrationResponse, error) {
+			got = req
+			return &types.DIDRegistrationResponse{Success: true}, nil
+		},
+	}, &fakeVCService{})
+
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth(middleware.AuthConfig{TenantKeys: map[string]string{"acme": "acme-key"}}))
+	router.POST("/api/v1/did/register", handler.RegisterAgent)
+
+	// A tenant in the body is ignored; the key decides
+	reqBody := `{"agent_node_id":"node-1","tenant_id":"globex"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/did/register", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "acme-key")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NotNil(t, got)
+	require.Equal(t, "acme", got.TenantID)
+}
+
 func TestVerifyVCHandler_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -189,6 +219,7 @@ func TestResolveDIDHandler(t *testing.T) {
 	var payload map[string]any
 	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
 	require.Equal(t, "did:example:123", payload["did"])
+	require.Equal(t, false, payload["revoked"])
 }
 
 func TestGetWorkflowVCChainHandler(t *testing.T) {
@@ -18,6 +18,7 @@ import (
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/events"
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/services"
 	"github.com/Agent-Field/agentfield/control-plane/internal/utils"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
@@ -504,7 +505,7 @@ func (c *executionController) handleStatusUpdate(ctx *gin.Context) {
 			current.DurationMS = pointerInt64(duration)
 		}
 
-		if normalizedStatus == string(types.ExecutionStatusSucceeded) || normalizedStatus == string(types.ExecutionStatusFailed) || normalizedStatus == string(types.ExecutionStatusCancelled) || normalizedStatus == string(types.ExecutionStatusTimeout) {
+		if types.IsTerminalExecutionStatus(normalizedStatus) {
 			if req.CompletedAt != nil && !req.CompletedAt.IsZero() {
 				completed := req.CompletedAt.UTC()
 				current.CompletedAt = &completed
@@ -850,6 +851,7 @@ func (c *executionController) prepareExecution(ctx context.Context, ginCtx *gin.
 		ExecutionID:       executionID,
 		RunID:             runID,
 		ParentExecutionID: headers.parentExecutionID,
+		TenantID:          headers.tenantID,
 		AgentNodeID:       agent.ID,
 		ReasonerID:        target.TargetName,
 		NodeID:            target.NodeID,
@@ -1109,6 +1111,7 @@ type executionHeaders struct {
 	parentExecutionID *string
 	sessionID         *string
 	actorID           *string
+	tenantID          string
 }
 
 func readExecutionHeaders(ctx *gin.Context) executionHeaders {
@@ -1137,6 +1140,7 @@ func readExecutionHeaders(ctx *gin.Context) executionHeaders {
 		parentExecutionID: parentPtr,
 		sessionID:         sessionPtr,
 		actorID:           actorPtr,
+		tenantID:          middleware.TenantID(ctx),
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/services" // Import services package
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
@@ -524,6 +525,7 @@ func RegisterNodeHandler(storageProvider storage.StorageProvider, uiService *ser
 				AgentNodeID: newNode.ID,
 				Reasoners:   newNode.Reasoners,
 				Skills:      newNode.Skills,
+				TenantID:    middleware.TenantID(c),
 			}
 
 			// Enhanced DID service handles differential analysis and routing automatically
@@ -1307,6 +1309,7 @@ func RegisterServerlessAgentHandler(storageProvider storage.StorageProvider, uiS
 				AgentNodeID: newNode.ID,
 				Reasoners:   newNode.Reasoners,
 				Skills:      newNode.Skills,
+				TenantID:    middleware.TenantID(c),
 			}
 
 			didResponse, err := didService.RegisterAgent(didReq)
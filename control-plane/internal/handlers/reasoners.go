@@ -11,6 +11,7 @@ import (
 	"time" // Added for time.Now()
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/internal/utils" // Added for ID generation
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"      // Added for new types
@@ -204,12 +205,14 @@ func ExecuteReasonerHandler(storageProvider storage.StorageProvider) gin.Handler
 				parentExecutionID: parentPtr,
 				sessionID:         sessionPtr,
 				actorID:           actorPtr,
+				tenantID:          middleware.TenantID(c),
 			}
 			now := time.Now().UTC()
 			exec := &types.Execution{
 				ExecutionID:       executionID,
 				RunID:             workflowID,
 				ParentExecutionID: parentPtr,
+				TenantID:          headers.tenantID,
 				AgentNodeID:       nodeID,
 				ReasonerID:        reasonerName,
 				NodeID:            nodeID,
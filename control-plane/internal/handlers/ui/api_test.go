@@ -3,6 +3,7 @@ package ui
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -38,7 +39,7 @@ func TestGetNodesSummaryHandler_Structure(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -84,7 +85,7 @@ func TestGetNodeDetailsHandler_Structure(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -129,7 +130,7 @@ func TestGetNodeStatusHandler_Structure(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -169,7 +170,7 @@ func TestRefreshNodeStatusHandler_Structure(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -209,7 +210,7 @@ func TestBulkNodeStatusHandler_Validation(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -266,7 +267,7 @@ func TestGetDashboardSummaryHandler_Structure(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -307,7 +308,7 @@ func TestAPIErrorHandling(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -361,7 +362,7 @@ func TestAPIMethodValidation(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -406,7 +407,7 @@ func TestAPIResponseFormat(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -476,9 +476,14 @@ func (m *MockStorageProvider) ListAgentFieldServerDIDs(ctx context.Context) ([]*
 	return args.Get(0).([]*types.AgentFieldServerDIDInfo), args.Error(1)
 }
 
+func (m *MockStorageProvider) DeleteAgentFieldServerDID(ctx context.Context, agentfieldServerID string) error {
+	args := m.Called(ctx, agentfieldServerID)
+	return args.Error(0)
+}
+
 // Agent DID operations
-func (m *MockStorageProvider) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int) error {
-	args := m.Called(ctx, agentID, agentDID, agentfieldServerDID, publicKeyJWK, derivationIndex)
+func (m *MockStorageProvider) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int) error {
+	args := m.Called(ctx, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK, derivationIndex)
 	return args.Error(0)
 }
 
@@ -490,6 +495,14 @@ func (m *MockStorageProvider) GetAgentDID(ctx context.Context, agentID string) (
 	return args.Get(0).(*types.AgentDIDInfo), args.Error(1)
 }
 
+func (m *MockStorageProvider) ListAgentDIDsForTenant(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*types.AgentDIDInfo), args.Error(1)
+}
+
 func (m *MockStorageProvider) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -498,6 +511,29 @@ func (m *MockStorageProvider) ListAgentDIDs(ctx context.Context) ([]*types.Agent
 	return args.Get(0).([]*types.AgentDIDInfo), args.Error(1)
 }
 
+func (m *MockStorageProvider) UpdateAgentDIDStatus(ctx context.Context, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) error {
+	args := m.Called(ctx, agentfieldServerID, agentNodeID, status, reason)
+	return args.Error(0)
+}
+
+func (m *MockStorageProvider) UpdateAgentDIDStatuses(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) error {
+	args := m.Called(ctx, agentfieldServerID, agentNodeIDs, status, reason)
+	return args.Error(0)
+}
+
+func (m *MockStorageProvider) SetAgentDIDRateLimit(ctx context.Context, agentfieldServerID, agentNodeID string, policy *types.RateLimitPolicy) error {
+	args := m.Called(ctx, agentfieldServerID, agentNodeID, policy)
+	return args.Error(0)
+}
+
+func (m *MockStorageProvider) GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error) {
+	args := m.Called(ctx, agentNodeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*types.AgentDIDStatusChange), args.Error(1)
+}
+
 // Component DID operations
 func (m *MockStorageProvider) StoreComponentDID(ctx context.Context, componentID, componentDID, agentDID, componentType, componentName string, derivationIndex int) error {
 	args := m.Called(ctx, componentID, componentDID, agentDID, componentType, componentName, derivationIndex)
@@ -520,6 +556,35 @@ func (m *MockStorageProvider) ListComponentDIDs(ctx context.Context, agentDID st
 	return args.Get(0).([]*types.ComponentDIDInfo), args.Error(1)
 }
 
+func (m *MockStorageProvider) RotateAgentFieldServerRoot(ctx context.Context, agentfieldServerID, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) error {
+	args := m.Called(ctx, agentfieldServerID, newRootDID, newMasterSeed, rotatedAt, rotations)
+	return args.Error(0)
+}
+
+func (m *MockStorageProvider) GetRotatedDID(ctx context.Context, did string) (*types.RotatedDIDInfo, error) {
+	args := m.Called(ctx, did)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.RotatedDIDInfo), args.Error(1)
+}
+
+func (m *MockStorageProvider) ListAgentComponentDIDs(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error) {
+	args := m.Called(ctx, agentNodeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*types.ComponentDID), args.Error(1)
+}
+
+func (m *MockStorageProvider) CountComponentDIDsByType(ctx context.Context, agentfieldServerID string) (map[string]int, error) {
+	args := m.Called(ctx, agentfieldServerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
 // Execution VC operations
 func (m *MockStorageProvider) StoreExecutionVC(ctx context.Context, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status string, vcDocument []byte, signature string, storageURI string, documentSizeBytes int64) error {
 	args := m.Called(ctx, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status, vcDocument, signature)
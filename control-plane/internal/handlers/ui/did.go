@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/services"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
@@ -649,7 +650,7 @@ func (h *DIDHandler) GetDIDResolutionBundleHandler(c *gin.Context) {
 	var resolutionStatus string = "not_found"
 
 	// Try to find the DID in agent DIDs
-	agentDIDs, err := h.storage.ListAgentDIDs(c.Request.Context())
+	agentDIDs, err := h.storage.ListAgentDIDsForTenant(c.Request.Context(), middleware.TenantID(c))
 	if err == nil {
 		for _, agentDID := range agentDIDs {
 			if agentDID.DID == did {
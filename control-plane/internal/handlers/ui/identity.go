@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 	"github.com/gin-gonic/gin"
@@ -90,7 +91,7 @@ func (h *IdentityHandlers) GetDIDStats(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// Get all agent DIDs
-	agentDIDs, err := h.storage.ListAgentDIDs(ctx)
+	agentDIDs, err := h.storage.ListAgentDIDsForTenant(ctx, middleware.TenantID(c))
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to list agent DIDs")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get DID stats"})
@@ -144,7 +145,7 @@ func (h *IdentityHandlers) SearchDIDs(c *gin.Context) {
 
 	// Search agents if type is "all" or "agent"
 	if didType == "all" || didType == "agent" {
-		agentDIDs, err := h.storage.ListAgentDIDs(ctx)
+		agentDIDs, err := h.storage.ListAgentDIDsForTenant(ctx, middleware.TenantID(c))
 		if err == nil {
 			for i := range agentDIDs {
 				agent := agentDIDs[i]
@@ -224,7 +225,7 @@ func (h *IdentityHandlers) ListAgents(c *gin.Context) {
 		limit = 50
 	}
 
-	agentDIDs, err := h.storage.ListAgentDIDs(ctx)
+	agentDIDs, err := h.storage.ListAgentDIDsForTenant(ctx, middleware.TenantID(c))
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to list agent DIDs")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list agents"})
@@ -301,7 +302,7 @@ func (h *IdentityHandlers) GetAgentDetails(c *gin.Context) {
 	}
 
 	// Find the agent DID
-	agentDIDs, err := h.storage.ListAgentDIDs(ctx)
+	agentDIDs, err := h.storage.ListAgentDIDsForTenant(ctx, middleware.TenantID(c))
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to list agent DIDs")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent details"})
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -63,7 +64,7 @@ func setupTestEnvironment(t *testing.T) (*storage.LocalStorage, *mockForwarder,
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -344,7 +345,7 @@ func TestGetStatusHandler_NoForwarder(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
@@ -433,7 +434,7 @@ func TestRedriveHandler_NoForwarder(t *testing.T) {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
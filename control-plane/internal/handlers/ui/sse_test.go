@@ -2,9 +2,9 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
@@ -31,7 +31,7 @@ func setupTestStorage(t *testing.T) storage.StorageProvider {
 
 	realStorage := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	err := realStorage.Initialize(ctx, cfg)
-	if err != nil && strings.Contains(strings.ToLower(err.Error()), "fts5") {
+	if errors.Is(err, storage.ErrFTS5Unavailable) {
 		t.Skip("sqlite3 compiled without FTS5")
 	}
 	require.NoError(t, err)
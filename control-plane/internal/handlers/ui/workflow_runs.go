@@ -10,6 +10,7 @@ import (
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/handlers"
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
@@ -96,8 +97,11 @@ func (h *WorkflowRunHandler) ListWorkflowRunsHandler(c *gin.Context) {
 	pageSize := parsePositiveIntWithin(c.DefaultQuery("page_size", "20"), 20, 1, 200)
 	offset := (page - 1) * pageSize
 
+	tenantID := middleware.TenantID(c)
+
 	// Build filter for run aggregation query
 	filter := types.ExecutionFilter{
+		TenantID:       &tenantID,
 		Limit:          pageSize,
 		Offset:         offset,
 		SortBy:         sanitizeRunSortField(c.DefaultQuery("sort_by", "updated_at")),
@@ -199,8 +203,7 @@ func convertAggregationToSummary(agg *storage.RunSummaryAggregation) WorkflowRun
 	summary.Status = deriveStatusFromCounts(agg.StatusCounts, agg.ActiveExecutions)
 
 	// Check if terminal
-	summary.Terminal = summary.Status == string(types.ExecutionStatusSucceeded) ||
-		summary.Status == string(types.ExecutionStatusFailed)
+	summary.Terminal = types.IsTerminalExecutionStatus(summary.Status)
 
 	// Calculate duration if completed
 	if summary.Terminal {
@@ -236,6 +239,12 @@ func deriveStatusFromCounts(statusCounts map[string]int, activeExecutions int) s
 		return string(types.ExecutionStatusSucceeded)
 	}
 
+	// Otherwise completed work with warnings surfaces as such, matching the
+	// precedence of the DAG endpoints.
+	if statusCounts[string(types.ExecutionStatusSucceededWithWarnings)] > 0 {
+		return string(types.ExecutionStatusSucceededWithWarnings)
+	}
+
 	// Default to succeeded if no active work
 	return string(types.ExecutionStatusSucceeded)
 }
@@ -248,8 +257,10 @@ func (h *WorkflowRunHandler) GetWorkflowRunDetailHandler(c *gin.Context) {
 		return
 	}
 
+	tenantID := middleware.TenantID(c)
 	filter := types.ExecutionFilter{
 		RunID:          &runID,
+		TenantID:       &tenantID,
 		SortBy:         "started_at",
 		SortDescending: false,
 		Limit:          10000,
@@ -265,7 +276,7 @@ func (h *WorkflowRunHandler) GetWorkflowRunDetailHandler(c *gin.Context) {
 		return
 	}
 
-	dag, timeline, status, name, _, _, _ := handlers.BuildWorkflowDAG(executions)
+	dag, timeline, status, name, _, _ := handlers.BuildWorkflowDAG(executions)
 	apiExecutions := buildAPIExecutions(timeline)
 
 	completed, failed := countOutcomeSteps(executions)
@@ -286,7 +297,7 @@ func (h *WorkflowRunHandler) GetWorkflowRunDetailHandler(c *gin.Context) {
 	}
 	_ = name
 
-	if agg := h.loadRunSummary(ctx, runID); agg != nil {
+	if agg := h.loadRunSummary(ctx, runID, tenantID); agg != nil {
 		detail.Run.TotalSteps = agg.TotalExecutions
 		detail.Run.CompletedSteps = agg.StatusCounts[string(types.ExecutionStatusSucceeded)]
 		detail.Run.FailedSteps =
@@ -337,7 +348,7 @@ func summarizeRun(runID string, executions []*types.Execution) WorkflowRunSummar
 		return sortedExecutions[i].StartedAt.Before(sortedExecutions[j].StartedAt)
 	})
 
-	dag, _, status, name, sessionID, actorID, maxDepth := handlers.BuildWorkflowDAG(sortedExecutions)
+	dag, _, status, name, meta, maxDepth := handlers.BuildWorkflowDAG(sortedExecutions)
 
 	summary.RootExecutionID = dag.ExecutionID
 	if name != "" {
@@ -351,8 +362,8 @@ func summarizeRun(runID string, executions []*types.Execution) WorkflowRunSummar
 	if dag.AgentNodeID != "" {
 		summary.AgentID = &dag.AgentNodeID
 	}
-	summary.SessionID = sessionID
-	summary.ActorID = actorID
+	summary.SessionID = meta.SessionID
+	summary.ActorID = meta.ActorID
 	summary.StartedAt = sortedExecutions[0].StartedAt
 	summary.UpdatedAt = sortedExecutions[len(sortedExecutions)-1].StartedAt
 	summary.Status = status
@@ -400,11 +411,12 @@ func summarizeRun(runID string, executions []*types.Execution) WorkflowRunSummar
 	return summary
 }
 
-func (h *WorkflowRunHandler) loadRunSummary(ctx context.Context, runID string) *storage.RunSummaryAggregation {
+func (h *WorkflowRunHandler) loadRunSummary(ctx context.Context, runID, tenantID string) *storage.RunSummaryAggregation {
 	filter := types.ExecutionFilter{
-		RunID:  &runID,
-		Limit:  1,
-		Offset: 0,
+		RunID:    &runID,
+		TenantID: &tenantID,
+		Limit:    1,
+		Offset:   0,
 	}
 
 	summaries, _, err := h.storage.QueryRunSummaries(ctx, filter)
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertAggregationToSummary_Status(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		counts       map[string]int
+		active       int
+		wantStatus   string
+		wantTerminal bool
+	}{
+		{"succeeded", map[string]int{"succeeded": 3}, 0, "succeeded", true},
+		{"warnings", map[string]int{"succeeded": 2, "succeeded_with_warnings": 1}, 0, "succeeded_with_warnings", true},
+		{"failure beats warnings", map[string]int{"failed": 1, "succeeded_with_warnings": 1}, 0, "failed", true},
+		{"running beats warnings", map[string]int{"running": 1, "succeeded_with_warnings": 1}, 1, "running", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := convertAggregationToSummary(&storage.RunSummaryAggregation{
+				RunID:            "run-1",
+				StatusCounts:     tt.counts,
+				ActiveExecutions: tt.active,
+				EarliestStarted:  start,
+				LatestStarted:    start.Add(time.Second),
+			})
+			require.Equal(t, tt.wantStatus, summary.Status)
+			require.Equal(t, tt.wantTerminal, summary.Terminal)
+			if tt.wantTerminal {
+				require.NotNil(t, summary.DurationMs)
+				require.Equal(t, int64(1000), *summary.DurationMs)
+			} else {
+				require.Nil(t, summary.CompletedAt)
+			}
+		})
+	}
+}
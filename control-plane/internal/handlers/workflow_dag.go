@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
@@ -19,12 +22,79 @@ type executionRecordProvider interface {
 	GetExecutionRecord(ctx context.Context, executionID string) (*types.Execution, error)
 }
 
-type executionGraphService struct {
+// ExecutionGraphService assembles workflow DAGs from stored executions. It is
+// independent of any HTTP framework; the gin handlers in this file wrap it.
+type ExecutionGraphService struct {
 	store executionRecordProvider
 }
 
-func newExecutionGraphService(storageProvider storage.StorageProvider) *executionGraphService {
-	return &executionGraphService{store: storageProvider}
+// NewExecutionGraphService returns a service that reads executions from storageProvider.
+func NewExecutionGraphService(storageProvider storage.StorageProvider) *ExecutionGraphService {
+	return newExecutionGraphService(storageProvider)
+}
+
+func newExecutionGraphService(storageProvider storage.StorageProvider) *ExecutionGraphService {
+	return &ExecutionGraphService{store: storageProvider}
+}
+
+var (
+	// ErrWorkflowIDRequired is returned by BuildResponse when runID is empty.
+	ErrWorkflowIDRequired = errors.New("workflowId or workflow_id is required")
+	// ErrWorkflowNotFound is returned by BuildResponse when a run has no executions.
+	ErrWorkflowNotFound = errors.New("workflow not found")
+)
+
+// ReasonerNameResolver maps a reasoner ID to a human-friendly display name.
+type ReasonerNameResolver func(reasonerID string) (displayName string)
+
+// DAGOptions controls how a workflow DAG is assembled.
+type DAGOptions struct {
+	// Lightweight returns a flat timeline without the nested tree or notes.
+	// It only applies to BuildResponse.
+	Lightweight bool
+	// ResolveReasonerName, when set, populates ReasonerName on every node.
+	ResolveReasonerName ReasonerNameResolver
+	// MaxDepth is the deepest tree level the builder descends to; children
+	// below it are dropped and flagged with a depth-limit diagnostic.
+	// Zero means DefaultMaxDAGDepth.
+	MaxDepth int
+	// TenantID restricts the run to one tenant's executions. When empty, the
+	// builder keeps the tenant of the run's root execution. Executions from
+	// any other tenant are never combined into the DAG.
+	TenantID string
+	// ReasonerIDs, when set, narrows the tree and timeline to executions of
+	// these reasoners plus their ancestors. Status, depth and node counts
+	// still describe the whole run. It only applies to BuildResponse.
+	ReasonerIDs []string
+}
+
+func (o DAGOptions) maxDepth() int {
+	if o.MaxDepth <= 0 {
+		return DefaultMaxDAGDepth
+	}
+	return o.MaxDepth
+}
+
+func (o DAGOptions) reasonerName(reasonerID string) string {
+	if o.ResolveReasonerName == nil {
+		return ""
+	}
+	return o.ResolveReasonerName(reasonerID)
+}
+
+// DAGResponse holds the result of BuildResponse. Exactly one of Full or
+// Lightweight is set, depending on DAGOptions.Lightweight.
+type DAGResponse struct {
+	Full        *WorkflowDAGResponse
+	Lightweight *WorkflowDAGLightweightResponse
+}
+
+// Body returns the populated response for JSON encoding.
+func (r DAGResponse) Body() interface{} {
+	if r.Lightweight != nil {
+		return r.Lightweight
+	}
+	return r.Full
 }
 
 type WorkflowDAGNode struct {
@@ -32,18 +102,45 @@ type WorkflowDAGNode struct {
 	ExecutionID       string                `json:"execution_id"`
 	AgentNodeID       string                `json:"agent_node_id"`
 	ReasonerID        string                `json:"reasoner_id"`
+	ReasonerName      string                `json:"reasoner_name,omitempty"`
 	Status            string                `json:"status"`
 	StartedAt         string                `json:"started_at"`
 	CompletedAt       *string               `json:"completed_at,omitempty"`
 	DurationMS        *int64                `json:"duration_ms,omitempty"`
+	InputBytes        *int64                `json:"input_bytes,omitempty"`
+	OutputBytes       *int64                `json:"output_bytes,omitempty"`
 	ParentExecutionID *string               `json:"parent_execution_id,omitempty"`
 	WorkflowDepth     int                   `json:"workflow_depth"`
+	AttemptNumber     int                   `json:"attempt_number"`
+	StartOffsetMS     *int64                `json:"start_offset_ms,omitempty"`
+	QueueWaitMS       *int64                `json:"queue_wait_ms,omitempty"`
 	Children          []WorkflowDAGNode     `json:"children"`
 	Notes             []types.ExecutionNote `json:"notes"`
 	NotesCount        int                   `json:"notes_count"`
 	LatestNote        *types.ExecutionNote  `json:"latest_note,omitempty"`
+	Diagnostics       []DAGDiagnostic       `json:"diagnostics,omitempty"`
+}
+
+// DAGDiagnostic records a data-quality issue detected while building a DAG node.
+type DAGDiagnostic struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
+const (
+	// DAGDiagnosticClockSkew marks a node whose start precedes the run start,
+	// or whose completion precedes its own start.
+	DAGDiagnosticClockSkew = "clock-skew"
+	// DAGDiagnosticMissingStart marks a node with no recorded start time.
+	DAGDiagnosticMissingStart = "missing-start"
+	// DAGDiagnosticDepthLimit marks a node whose children were not expanded
+	// because the tree reached DAGOptions.MaxDepth.
+	DAGDiagnosticDepthLimit = "depth-limit"
+)
+
+// DefaultMaxDAGDepth bounds tree recursion when DAGOptions.MaxDepth is unset.
+const DefaultMaxDAGDepth = 1000
+
 type WorkflowDAGResponse struct {
 	RootWorkflowID string            `json:"root_workflow_id"`
 	WorkflowStatus string            `json:"workflow_status"`
@@ -65,15 +162,18 @@ type SessionWorkflowsResponse struct {
 }
 
 type WorkflowDAGLightweightNode struct {
-	ExecutionID       string  `json:"execution_id"`
-	ParentExecutionID *string `json:"parent_execution_id,omitempty"`
-	AgentNodeID       string  `json:"agent_node_id"`
-	ReasonerID        string  `json:"reasoner_id"`
-	Status            string  `json:"status"`
-	StartedAt         string  `json:"started_at"`
-	CompletedAt       *string `json:"completed_at,omitempty"`
-	DurationMS        *int64  `json:"duration_ms,omitempty"`
-	WorkflowDepth     int     `json:"workflow_depth"`
+	ExecutionID       string          `json:"execution_id"`
+	ParentExecutionID *string         `json:"parent_execution_id,omitempty"`
+	AgentNodeID       string          `json:"agent_node_id"`
+	ReasonerID        string          `json:"reasoner_id"`
+	ReasonerName      string          `json:"reasoner_name,omitempty"`
+	Status            string          `json:"status"`
+	StartedAt         string          `json:"started_at"`
+	CompletedAt       *string         `json:"completed_at,omitempty"`
+	DurationMS        *int64          `json:"duration_ms,omitempty"`
+	WorkflowDepth     int             `json:"workflow_depth"`
+	StartOffsetMS     *int64          `json:"start_offset_ms,omitempty"`
+	Diagnostics       []DAGDiagnostic `json:"diagnostics,omitempty"`
 }
 
 type WorkflowDAGLightweightResponse struct {
@@ -93,61 +193,99 @@ func GetWorkflowDAGHandler(storageProvider storage.StorageProvider) gin.HandlerF
 	return svc.handleGetWorkflowDAG
 }
 
-func (s *executionGraphService) handleGetWorkflowDAG(c *gin.Context) {
-	ctx := c.Request.Context()
+func (s *ExecutionGraphService) handleGetWorkflowDAG(c *gin.Context) {
 	runID := strings.TrimSpace(c.Param("workflowId"))
 	if runID == "" {
 		runID = strings.TrimSpace(c.Param("workflow_id"))
 	}
-	if runID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "workflowId or workflow_id is required"})
+
+	opts := DAGOptions{
+		Lightweight: isLightweightRequest(c),
+		TenantID:    middleware.TenantID(c),
+		ReasonerIDs: reasonerIDsQuery(c),
+	}
+	response, err := s.BuildResponse(c.Request.Context(), runID, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrWorkflowIDRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrWorkflowNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	executions, err := s.loadRunExecutions(ctx, runID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to load workflow: %v", err)})
+	if response.Full == nil {
+		c.JSON(http.StatusOK, response.Body())
 		return
 	}
+
+	// Stream the tree so large runs are not marshaled into one buffer.
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := encodeDAGResponse(c.Writer, response.Full, opts); err != nil {
+		logger.Logger.Warn().Err(err).Str("run_id", runID).Msg("failed to stream workflow DAG")
+		c.Abort()
+	}
+}
+
+// BuildResponse loads the executions for runID and assembles its DAG.
+// It returns ErrWorkflowIDRequired for an empty runID and ErrWorkflowNotFound
+// when the run has no executions.
+func (s *ExecutionGraphService) BuildResponse(ctx context.Context, runID string, opts DAGOptions) (DAGResponse, error) {
+	runID = strings.TrimSpace(runID)
+	if runID == "" {
+		return DAGResponse{}, ErrWorkflowIDRequired
+	}
+
+	executions, err := s.loadRunExecutions(ctx, runID, opts.TenantID)
+	if err != nil {
+		return DAGResponse{}, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	executions = scopeExecutionsToTenant(executions, opts.TenantID)
 	if len(executions) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "workflow not found"})
-		return
+		return DAGResponse{}, ErrWorkflowNotFound
 	}
 
-	if isLightweightRequest(c) {
-		timeline, workflowStatus, workflowName, sessionID, actorID, maxDepth := buildLightweightExecutionDAG(executions)
+	if opts.Lightweight {
+		timeline, workflowStatus, workflowName, meta, maxDepth := buildLightweightExecutionDAGWithOptions(executions, opts)
+		if len(opts.ReasonerIDs) > 0 {
+			timeline = filterLightweightNodes(timeline, reasonerFilterSet(executions, opts.ReasonerIDs))
+		}
 
-		response := WorkflowDAGLightweightResponse{
+		return DAGResponse{Lightweight: &WorkflowDAGLightweightResponse{
 			RootWorkflowID: runID,
 			WorkflowStatus: workflowStatus,
 			WorkflowName:   workflowName,
-			SessionID:      sessionID,
-			ActorID:        actorID,
+			SessionID:      meta.SessionID,
+			ActorID:        meta.ActorID,
 			TotalNodes:     len(executions),
 			MaxDepth:       maxDepth,
 			Timeline:       timeline,
 			Mode:           "lightweight",
-		}
-
-		c.JSON(http.StatusOK, response)
-		return
+		}}, nil
 	}
 
-	dag, timeline, workflowStatus, workflowName, sessionID, actorID, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, workflowStatus, workflowName, meta, maxDepth := buildExecutionDAGWithOptions(executions, opts)
+	if len(opts.ReasonerIDs) > 0 {
+		keep := reasonerFilterSet(executions, opts.ReasonerIDs)
+		pruneDAG(&dag, keep)
+		timeline = filterDAGNodes(timeline, keep)
+	}
 
-	response := WorkflowDAGResponse{
+	return DAGResponse{Full: &WorkflowDAGResponse{
 		RootWorkflowID: runID,
 		WorkflowStatus: workflowStatus,
 		WorkflowName:   workflowName,
-		SessionID:      sessionID,
-		ActorID:        actorID,
+		SessionID:      meta.SessionID,
+		ActorID:        meta.ActorID,
 		TotalNodes:     len(executions),
 		MaxDepth:       maxDepth,
 		DAG:            dag,
 		Timeline:       timeline,
-	}
-
-	c.JSON(http.StatusOK, response)
+	}}, nil
 }
 
 func GetWorkflowChildrenHandler(storageProvider storage.StorageProvider) gin.HandlerFunc {
@@ -155,7 +293,7 @@ func GetWorkflowChildrenHandler(storageProvider storage.StorageProvider) gin.Han
 	return svc.handleGetWorkflowChildren
 }
 
-func (s *executionGraphService) handleGetWorkflowChildren(c *gin.Context) {
+func (s *ExecutionGraphService) handleGetWorkflowChildren(c *gin.Context) {
 	ctx := c.Request.Context()
 	parent := strings.TrimSpace(c.Param("workflow_id"))
 	if parent == "" {
@@ -166,7 +304,9 @@ func (s *executionGraphService) handleGetWorkflowChildren(c *gin.Context) {
 		return
 	}
 
+	tenantID := middleware.TenantID(c)
 	filter := types.ExecutionFilter{
+		TenantID:          &tenantID,
 		ParentExecutionID: &parent,
 		SortBy:            "started_at",
 		SortDescending:    false,
@@ -196,7 +336,7 @@ func GetSessionWorkflowsHandler(storageProvider storage.StorageProvider) gin.Han
 	return svc.handleGetSessionWorkflows
 }
 
-func (s *executionGraphService) handleGetSessionWorkflows(c *gin.Context) {
+func (s *ExecutionGraphService) handleGetSessionWorkflows(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := strings.TrimSpace(c.Param("session_id"))
 	if sessionID == "" {
@@ -204,7 +344,9 @@ func (s *executionGraphService) handleGetSessionWorkflows(c *gin.Context) {
 		return
 	}
 
+	tenantID := middleware.TenantID(c)
 	filter := types.ExecutionFilter{
+		TenantID:       &tenantID,
 		SessionID:      &sessionID,
 		SortBy:         "started_at",
 		SortDescending: false,
@@ -232,13 +374,13 @@ func (s *executionGraphService) handleGetSessionWorkflows(c *gin.Context) {
 	var actorID *string
 
 	for runID, execs := range grouped {
-		dag, _, _, _, sessionPtr, actorPtr, _ := buildExecutionDAG(execs)
+		dag, _, _, _, meta, _ := buildExecutionDAGWithOptions(execs, DAGOptions{TenantID: tenantID})
 		dag.WorkflowID = runID
-		if actorPtr != nil && actorID == nil {
-			actorID = actorPtr
+		if meta.ActorID != nil && actorID == nil {
+			actorID = meta.ActorID
 		}
-		if sessionPtr != nil && *sessionPtr != "" {
-			sessionID = *sessionPtr
+		if meta.SessionID != nil {
+			sessionID = *meta.SessionID
 		}
 		dag.WorkflowDepth = 0
 		rootNodes = append(rootNodes, dag)
@@ -256,18 +398,81 @@ func (s *executionGraphService) handleGetSessionWorkflows(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func (s *executionGraphService) loadRunExecutions(ctx context.Context, runID string) ([]*types.Execution, error) {
+// loadRunExecutions returns the executions of runID, restricted to tenantID
+// unless it is empty.
+func (s *ExecutionGraphService) loadRunExecutions(ctx context.Context, runID, tenantID string) ([]*types.Execution, error) {
 	filter := types.ExecutionFilter{
 		RunID:          &runID,
 		SortBy:         "started_at",
 		SortDescending: false,
 	}
+	if tenantID != "" {
+		filter.TenantID = &tenantID
+	}
 	return s.store.QueryExecutionRecords(ctx, filter)
 }
 
-func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []WorkflowDAGNode, string, string, *string, *string, int) {
+// scopeExecutionsToTenant drops executions that do not belong to tenantID, or
+// to the root execution's tenant when tenantID is empty. Storage already
+// filters by tenant; this keeps a bad query from merging tenants in a DAG.
+func scopeExecutionsToTenant(executions []*types.Execution, tenantID string) []*types.Execution {
+	if tenantID == "" {
+		tenantID = rootExecutionTenant(executions)
+	}
+	tenantID = types.NormalizeTenantID(tenantID)
+
+	scoped := executions[:0:0]
+	dropped := 0
+	for _, exec := range executions {
+		if exec != nil && types.NormalizeTenantID(exec.TenantID) != tenantID {
+			dropped++
+			continue
+		}
+		scoped = append(scoped, exec)
+	}
+	if dropped == 0 {
+		return executions
+	}
+	logger.Logger.Warn().
+		Str("tenant_id", tenantID).
+		Int("dropped", dropped).
+		Msg("dropped executions from other tenants while building workflow DAG")
+	return scoped
+}
+
+// rootExecutionTenant returns the tenant of the first execution without a
+// parent, falling back to the first execution.
+func rootExecutionTenant(executions []*types.Execution) string {
+	var first *types.Execution
+	for _, exec := range executions {
+		if exec == nil {
+			continue
+		}
+		if exec.ParentExecutionID == nil || *exec.ParentExecutionID == "" {
+			return exec.TenantID
+		}
+		if first == nil {
+			first = exec
+		}
+	}
+	if first == nil {
+		return ""
+	}
+	return first.TenantID
+}
+
+func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []WorkflowDAGNode, string, string, RunMetadata, int) {
+	return buildExecutionDAGWithOptions(executions, DAGOptions{})
+}
+
+func buildExecutionDAGWithOptions(executions []*types.Execution, opts DAGOptions) (WorkflowDAGNode, []WorkflowDAGNode, string, string, RunMetadata, int) {
+	executions = scopeExecutionsToTenant(executions, opts.TenantID)
 	execMap := make(map[string]*types.Execution, len(executions))
 	childrenMap := make(map[string][]*types.Execution)
+	// Parent IDs are copied out once, so records that share one pointer (such
+	// as the address of a reused loop variable) see the same parent in every
+	// pass below even if the pointee changes.
+	parents := make(map[string]string, len(executions))
 	var rootExec *types.Execution
 
 	for _, exec := range executions {
@@ -277,6 +482,7 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		execMap[exec.ExecutionID] = exec
 		if exec.ParentExecutionID != nil && *exec.ParentExecutionID != "" {
 			parent := *exec.ParentExecutionID
+			parents[exec.ExecutionID] = parent
 			childrenMap[parent] = append(childrenMap[parent], exec)
 		} else if rootExec == nil {
 			rootExec = exec
@@ -287,6 +493,9 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		rootExec = executions[0]
 	}
 
+	runStart := earliestStart(executions)
+	attempts := attemptNumbers(executions, parents)
+	depthLimit := opts.maxDepth()
 	var maxDepth int
 	visited := make(map[string]bool)
 	var buildNode func(exec *types.Execution, depth int) WorkflowDAGNode
@@ -304,12 +513,22 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		defer delete(visited, exec.ExecutionID)
 
 		node := executionToDAGNode(exec, depth)
+		node.ParentExecutionID = stableParentID(parents, exec.ExecutionID)
+		node.AttemptNumber = attempts[exec.ExecutionID]
+		node.ReasonerName = opts.reasonerName(exec.ReasonerID)
+		node.StartOffsetMS, node.Diagnostics = startOffset(exec, runStart, node.Diagnostics)
+		node.Diagnostics = completionSkew(exec, node.Diagnostics)
 		if depth > maxDepth {
 			maxDepth = depth
 		}
 
 		children := childrenMap[exec.ExecutionID]
-		if len(children) > 0 {
+		if len(children) > 0 && depth >= depthLimit {
+			node.Diagnostics = append(node.Diagnostics, DAGDiagnostic{
+				Code:    DAGDiagnosticDepthLimit,
+				Message: fmt.Sprintf("execution %s has %d children beyond the maximum depth of %d; subtree omitted", exec.ExecutionID, len(children), depthLimit),
+			})
+		} else if len(children) > 0 {
 			node.Children = make([]WorkflowDAGNode, 0, len(children))
 			for _, child := range children {
 				node.Children = append(node.Children, buildNode(child, depth+1))
@@ -320,6 +539,9 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 	}
 
 	dag := buildNode(rootExec, 0)
+	if rootExec != nil {
+		dag.QueueWaitMS = queueWait(rootExec)
+	}
 
 	// Compute depth for each execution (same logic as lightweight DAG)
 	depthCache := make(map[string]int, len(executions))
@@ -340,8 +562,8 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		defer delete(computing, exec.ExecutionID)
 
 		depth := 0
-		if exec.ParentExecutionID != nil && *exec.ParentExecutionID != "" {
-			if parent, ok := execMap[*exec.ParentExecutionID]; ok {
+		if parentID := parents[exec.ExecutionID]; parentID != "" {
+			if parent, ok := execMap[parentID]; ok {
 				depth = computeDepth(parent) + 1
 			}
 		}
@@ -360,6 +582,11 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		// Compute the actual depth from parent relationships
 		depth := computeDepth(exec)
 		node := executionToDAGNode(exec, depth)
+		node.ParentExecutionID = stableParentID(parents, exec.ExecutionID)
+		node.AttemptNumber = attempts[exec.ExecutionID]
+		node.ReasonerName = opts.reasonerName(exec.ReasonerID)
+		node.StartOffsetMS, node.Diagnostics = startOffset(exec, runStart, node.Diagnostics)
+		node.Diagnostics = completionSkew(exec, node.Diagnostics)
 		node.Children = nil
 		timeline = append(timeline, node)
 	}
@@ -370,43 +597,236 @@ func buildExecutionDAG(executions []*types.Execution) (WorkflowDAGNode, []Workfl
 		workflowName = rootExec.ReasonerID
 	}
 
-	var sessionID, actorID *string
-	if rootExec != nil {
-		sessionID = rootExec.SessionID
-		actorID = rootExec.ActorID
+	return dag, timeline, status, workflowName, runMetadata(rootExec, executions), maxDepth
+}
+
+// RunMetadata holds run-level attributes recorded on individual executions.
+type RunMetadata struct {
+	SessionID *string
+	ActorID   *string
+}
+
+// runMetadata takes each field from root when it is set there, and otherwise
+// from the earliest execution that carries it, so a run whose root did not
+// record its actor still reports the actor a child recorded. Empty values
+// count as unset.
+func runMetadata(root *types.Execution, executions []*types.Execution) RunMetadata {
+	ordered := make([]*types.Execution, 0, len(executions)+1)
+	if root != nil {
+		ordered = append(ordered, root)
+	}
+	rest := make([]*types.Execution, 0, len(executions))
+	for _, exec := range executions {
+		if exec != nil && exec != root {
+			rest = append(rest, exec)
+		}
 	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		if !rest[i].StartedAt.Equal(rest[j].StartedAt) {
+			return rest[i].StartedAt.Before(rest[j].StartedAt)
+		}
+		return rest[i].ExecutionID < rest[j].ExecutionID
+	})
+	ordered = append(ordered, rest...)
 
-	return dag, timeline, status, workflowName, sessionID, actorID, maxDepth
+	var meta RunMetadata
+	for _, exec := range ordered {
+		if meta.SessionID == nil && exec.SessionID != nil && *exec.SessionID != "" {
+			meta.SessionID = exec.SessionID
+		}
+		if meta.ActorID == nil && exec.ActorID != nil && *exec.ActorID != "" {
+			meta.ActorID = exec.ActorID
+		}
+		if meta.SessionID != nil && meta.ActorID != nil {
+			break
+		}
+	}
+	return meta
+}
+
+// attemptNumbers numbers executions that share a parent and reasoner by
+// StartedAt, so retries of one logical step read as attempts 1, 2, ... There
+// is no stored attempt field, so repeated calls of a reasoner by the same
+// parent are numbered the same way.
+func attemptNumbers(executions []*types.Execution, parents map[string]string) map[string]int {
+	type stepKey struct {
+		parentID   string
+		reasonerID string
+	}
+	groups := make(map[stepKey][]*types.Execution)
+	for _, exec := range executions {
+		if exec == nil {
+			continue
+		}
+		key := stepKey{parentID: parents[exec.ExecutionID], reasonerID: exec.ReasonerID}
+		groups[key] = append(groups[key], exec)
+	}
+
+	attempts := make(map[string]int, len(executions))
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			if !group[i].StartedAt.Equal(group[j].StartedAt) {
+				return group[i].StartedAt.Before(group[j].StartedAt)
+			}
+			return group[i].ExecutionID < group[j].ExecutionID
+		})
+		for i, exec := range group {
+			attempts[exec.ExecutionID] = i + 1
+		}
+	}
+	return attempts
+}
+
+// stableParentID returns a pointer to the parent recorded for executionID,
+// so returned nodes never share a pointer with the input executions.
+func stableParentID(parents map[string]string, executionID string) *string {
+	parent, ok := parents[executionID]
+	if !ok {
+		return nil
+	}
+	return &parent
+}
+
+// copyParentID returns a pointer to a copy of *parentID, so a node never
+// shares the pointer of its source record, which may alias other records.
+func copyParentID(parentID *string) *string {
+	if parentID == nil {
+		return nil
+	}
+	parent := *parentID
+	return &parent
 }
 
 // BuildWorkflowDAG exposes the DAG construction logic for other packages (UI handlers).
-func BuildWorkflowDAG(executions []*types.Execution) (WorkflowDAGNode, []WorkflowDAGNode, string, string, *string, *string, int) {
+func BuildWorkflowDAG(executions []*types.Execution) (WorkflowDAGNode, []WorkflowDAGNode, string, string, RunMetadata, int) {
 	return buildExecutionDAG(executions)
 }
 
-func buildLightweightExecutionDAG(executions []*types.Execution) ([]WorkflowDAGLightweightNode, string, string, *string, *string, int) {
-	if len(executions) == 0 {
-		return []WorkflowDAGLightweightNode{}, "", "", nil, nil, 0
+// BuildWorkflowDAGWithOptions is BuildWorkflowDAG with explicit build options.
+func BuildWorkflowDAGWithOptions(executions []*types.Execution, opts DAGOptions) (WorkflowDAGNode, []WorkflowDAGNode, string, string, RunMetadata, int) {
+	return buildExecutionDAGWithOptions(executions, opts)
+}
+
+func buildLightweightExecutionDAG(executions []*types.Execution) ([]WorkflowDAGLightweightNode, string, string, RunMetadata, int) {
+	return buildLightweightExecutionDAGWithOptions(executions, DAGOptions{})
+}
+
+func buildLightweightExecutionDAGWithOptions(executions []*types.Execution, opts DAGOptions) ([]WorkflowDAGLightweightNode, string, string, RunMetadata, int) {
+	executions = scopeExecutionsToTenant(executions, opts.TenantID)
+	timeline := buildTimeline(executions, opts)
+	if len(timeline) == 0 {
+		return []WorkflowDAGLightweightNode{}, "", "", RunMetadata{}, 0
 	}
 
+	var maxDepth int
+	for _, node := range timeline {
+		if node.WorkflowDepth > maxDepth {
+			maxDepth = node.WorkflowDepth
+		}
+	}
+
+	// The root is the earliest execution without a parent, falling back to
+	// the earliest execution overall.
+	rootID := timeline[0].ExecutionID
+	for _, node := range timeline {
+		if node.ParentExecutionID == nil || *node.ParentExecutionID == "" {
+			rootID = node.ExecutionID
+			break
+		}
+	}
+	var rootExec *types.Execution
+	for _, exec := range executions {
+		if exec != nil && exec.ExecutionID == rootID {
+			rootExec = exec
+			break
+		}
+	}
+
+	status := deriveOverallStatus(executions)
+	workflowName := ""
+	if rootExec != nil && rootExec.ReasonerID != "" {
+		workflowName = rootExec.ReasonerID
+	}
+
+	return timeline, status, workflowName, runMetadata(rootExec, executions), maxDepth
+}
+
+// BuildTimeline returns executions as a flat list ordered by StartedAt, each
+// node annotated with its depth in the run and its start offset. Unlike the
+// DAG builders it derives no run status or metadata, and it leaves the input
+// slice untouched. Nil entries are skipped.
+func BuildTimeline(executions []*types.Execution) []WorkflowDAGLightweightNode {
+	return buildTimeline(executions, DAGOptions{})
+}
+
+func buildTimeline(executions []*types.Execution, opts DAGOptions) []WorkflowDAGLightweightNode {
+	ordered := make([]*types.Execution, 0, len(executions))
 	execMap := make(map[string]*types.Execution, len(executions))
 	for _, exec := range executions {
 		if exec == nil {
 			continue
 		}
+		ordered = append(ordered, exec)
 		execMap[exec.ExecutionID] = exec
 	}
 
-	depthCache := make(map[string]int, len(executions))
-	computing := make(map[string]bool) // Track executions currently being computed to detect cycles
-	var maxDepth int
+	computeDepth := depthFunc(execMap, len(ordered))
 
-	var computeDepth func(exec *types.Execution) int
-	computeDepth = func(exec *types.Execution) int {
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].StartedAt.Before(ordered[j].StartedAt)
+	})
+
+	runStart := earliestStart(ordered)
+	timeline := make([]WorkflowDAGLightweightNode, 0, len(ordered))
+	for _, exec := range ordered {
+		node := executionToLightweightNode(exec, computeDepth(exec))
+		node.ReasonerName = opts.reasonerName(exec.ReasonerID)
+		node.StartOffsetMS, node.Diagnostics = startOffset(exec, runStart, node.Diagnostics)
+		node.Diagnostics = completionSkew(exec, node.Diagnostics)
+		timeline = append(timeline, node)
+	}
+	return timeline
+}
+
+// RunSummary returns a run's overall status, its maximum depth and the number
+// of executions in it, without building timeline nodes. The results match
+// the status and max depth of the lightweight DAG for the same executions,
+// including its scoping to the root execution's tenant. Nil entries are
+// skipped; a run with no executions has an empty status.
+func RunSummary(executions []*types.Execution) (status string, maxDepth int, nodeCount int) {
+	executions = scopeExecutionsToTenant(executions, "")
+	execMap := make(map[string]*types.Execution, len(executions))
+	for _, exec := range executions {
+		if exec != nil {
+			execMap[exec.ExecutionID] = exec
+			nodeCount++
+		}
+	}
+	if nodeCount == 0 {
+		return "", 0, 0
+	}
+
+	computeDepth := depthFunc(execMap, len(execMap))
+	for _, exec := range executions {
 		if exec == nil {
-			return 0
+			continue
+		}
+		if depth := computeDepth(exec); depth > maxDepth {
+			maxDepth = depth
 		}
+	}
+	return deriveOverallStatus(executions), maxDepth, nodeCount
+}
+
+// depthFunc returns a memoized function giving an execution's distance from
+// its top-most ancestor in execMap. Parents missing from execMap end the
+// chain, and a cycle is broken by treating the revisited execution as depth 0.
+func depthFunc(execMap map[string]*types.Execution, sizeHint int) func(*types.Execution) int {
+	depthCache := make(map[string]int, sizeHint)
+	computing := make(map[string]bool) // Track executions currently being computed to detect cycles
 
+	var computeDepth func(exec *types.Execution) int
+	computeDepth = func(exec *types.Execution) int {
 		if depth, ok := depthCache[exec.ExecutionID]; ok {
 			return depth
 		}
@@ -418,116 +838,202 @@ func buildLightweightExecutionDAG(executions []*types.Execution) ([]WorkflowDAGL
 		computing[exec.ExecutionID] = true
 		defer delete(computing, exec.ExecutionID)
 
+		// Read the parent ID once; the pointer may be shared with other records.
 		depth := 0
-		if exec.ParentExecutionID != nil && *exec.ParentExecutionID != "" {
-			if parent, ok := execMap[*exec.ParentExecutionID]; ok {
+		if parentID := copyParentID(exec.ParentExecutionID); parentID != nil && *parentID != "" {
+			if parent, ok := execMap[*parentID]; ok {
 				depth = computeDepth(parent) + 1
 			}
 		}
 
-		if depth > maxDepth {
-			maxDepth = depth
-		}
-
 		depthCache[exec.ExecutionID] = depth
 		return depth
 	}
+	return computeDepth
+}
 
-	sort.Slice(executions, func(i, j int) bool {
-		return executions[i].StartedAt.Before(executions[j].StartedAt)
-	})
+func executionToDAGNode(exec *types.Execution, depth int) WorkflowDAGNode {
+	started, completed := dagTimestamps(exec)
+
+	return WorkflowDAGNode{
+		WorkflowID:        exec.RunID,
+		ExecutionID:       exec.ExecutionID,
+		AgentNodeID:       exec.AgentNodeID,
+		ReasonerID:        exec.ReasonerID,
+		Status:            types.NormalizeExecutionStatus(exec.Status),
+		StartedAt:         started,
+		CompletedAt:       completed,
+		DurationMS:        exec.DurationMS,
+		InputBytes:        payloadSize(exec.InputPayload),
+		OutputBytes:       payloadSize(exec.ResultPayload),
+		ParentExecutionID: copyParentID(exec.ParentExecutionID),
+		WorkflowDepth:     depth,
+		Notes:             []types.ExecutionNote{},
+		NotesCount:        0,
+	}
+}
 
-	timeline := make([]WorkflowDAGLightweightNode, 0, len(executions))
+// dagTimestamps formats an execution's start and completion times in UTC.
+// Ingestion paths disagree on time zones, so rendering every node in one zone
+// keeps timestamps comparable as strings and with offsets computed here.
+func dagTimestamps(exec *types.Execution) (string, *string) {
+	started := exec.StartedAt.UTC().Format(time.RFC3339)
+	if exec.CompletedAt == nil {
+		return started, nil
+	}
+	completed := exec.CompletedAt.UTC().Format(time.RFC3339)
+	return started, &completed
+}
+
+// earliestStart returns the earliest non-zero StartedAt across executions,
+// which is treated as the start of the run for offset calculations.
+func earliestStart(executions []*types.Execution) time.Time {
+	var earliest time.Time
 	for _, exec := range executions {
-		if exec == nil {
+		if exec == nil || exec.StartedAt.IsZero() {
 			continue
 		}
+		if earliest.IsZero() || exec.StartedAt.Before(earliest) {
+			earliest = exec.StartedAt
+		}
+	}
+	return earliest
+}
 
-		depth := computeDepth(exec)
-		node := executionToLightweightNode(exec, depth)
-		timeline = append(timeline, node)
+// payloadSize returns the stored size of an inline payload in bytes, or nil
+// when none is recorded (not yet produced, or offloaded to a payload URI). A
+// recorded but empty payload reports 0.
+func payloadSize(payload []byte) *int64 {
+	if payload == nil {
+		return nil
 	}
+	size := int64(len(payload))
+	return &size
+}
 
-	rootExec := executions[0]
-	for _, exec := range executions {
-		if exec.ParentExecutionID == nil || *exec.ParentExecutionID == "" {
-			rootExec = exec
-			break
-		}
+// queueWait returns how long the execution waited between being enqueued and
+// starting, in milliseconds, or nil when either timestamp is missing. A start
+// recorded before the enqueue time is treated as no wait.
+func queueWait(exec *types.Execution) *int64 {
+	if exec.EnqueuedAt == nil || exec.EnqueuedAt.IsZero() || exec.StartedAt.IsZero() {
+		return nil
+	}
+	wait := exec.StartedAt.Sub(*exec.EnqueuedAt).Milliseconds()
+	if wait < 0 {
+		wait = 0
 	}
+	return &wait
+}
 
-	status := deriveOverallStatus(executions)
-	workflowName := ""
-	if rootExec != nil && rootExec.ReasonerID != "" {
-		workflowName = rootExec.ReasonerID
+// startOffset computes the execution's start offset from runStart in
+// milliseconds. Executions without a start time get no offset and a
+// missing-start diagnostic; negative offsets (clock skew) are clamped to zero
+// and reported through an appended diagnostic.
+func startOffset(exec *types.Execution, runStart time.Time, diagnostics []DAGDiagnostic) (*int64, []DAGDiagnostic) {
+	if runStart.IsZero() {
+		return nil, diagnostics
 	}
 
-	var sessionID, actorID *string
-	if rootExec != nil {
-		sessionID = rootExec.SessionID
-		actorID = rootExec.ActorID
+	if exec.StartedAt.IsZero() {
+		diagnostics = append(diagnostics, DAGDiagnostic{
+			Code:    DAGDiagnosticMissingStart,
+			Message: fmt.Sprintf("execution %s has no start time; offset omitted", exec.ExecutionID),
+		})
+		return nil, diagnostics
 	}
 
-	return timeline, status, workflowName, sessionID, actorID, maxDepth
+	offset := exec.StartedAt.Sub(runStart).Milliseconds()
+	if offset < 0 {
+		diagnostics = append(diagnostics, DAGDiagnostic{
+			Code:    DAGDiagnosticClockSkew,
+			Message: fmt.Sprintf("execution %s starts %dms before the run start; offset clamped to 0", exec.ExecutionID, -offset),
+		})
+		offset = 0
+	}
+	return &offset, diagnostics
 }
 
-func executionToDAGNode(exec *types.Execution, depth int) WorkflowDAGNode {
-	started := exec.StartedAt.Format(time.RFC3339)
-	var completed *string
-	if exec.CompletedAt != nil {
-		formatted := exec.CompletedAt.Format(time.RFC3339)
-		completed = &formatted
+// completionSkew appends a clock-skew diagnostic when the execution's
+// completion time precedes its start time. Timestamps are left as recorded.
+func completionSkew(exec *types.Execution, diagnostics []DAGDiagnostic) []DAGDiagnostic {
+	if exec.CompletedAt == nil || exec.CompletedAt.IsZero() || exec.StartedAt.IsZero() {
+		return diagnostics
 	}
-
-	return WorkflowDAGNode{
-		WorkflowID:        exec.RunID,
-		ExecutionID:       exec.ExecutionID,
-		AgentNodeID:       exec.AgentNodeID,
-		ReasonerID:        exec.ReasonerID,
-		Status:            types.NormalizeExecutionStatus(exec.Status),
-		StartedAt:         started,
-		CompletedAt:       completed,
-		DurationMS:        exec.DurationMS,
-		ParentExecutionID: exec.ParentExecutionID,
-		WorkflowDepth:     depth,
-		Notes:             []types.ExecutionNote{},
-		NotesCount:        0,
+	if skew := exec.StartedAt.Sub(*exec.CompletedAt).Milliseconds(); skew > 0 {
+		diagnostics = append(diagnostics, DAGDiagnostic{
+			Code:    DAGDiagnosticClockSkew,
+			Message: fmt.Sprintf("execution %s completes %dms before it starts", exec.ExecutionID, skew),
+		})
 	}
+	return diagnostics
+}
+
+// OverallStatus derives the canonical status of a run from its executions,
+// using the same rules the DAG endpoints report. Statuses are normalized first
+// and then resolved by precedence, highest wins:
+//
+//  1. failed
+//  2. running (any running, pending, or queued execution)
+//  3. timeout
+//  4. cancelled
+//  5. succeeded_with_warnings
+//  6. succeeded (everything else, including an empty slice)
+//
+// A failure dominates so a run surfaces as failed as soon as any execution
+// fails, even while siblings are still in flight. Nil entries are ignored.
+func OverallStatus(executions []*types.Execution) string {
+	return deriveOverallStatus(executions)
 }
 
 func deriveOverallStatus(executions []*types.Execution) string {
 	hasRunning := false
 	hasFailed := false
+	hasTimeout := false
+	hasCancelled := false
+	hasWarnings := false
 	for _, exec := range executions {
+		if exec == nil {
+			continue
+		}
 		status := types.NormalizeExecutionStatus(exec.Status)
 		switch status {
 		case string(types.ExecutionStatusRunning), string(types.ExecutionStatusPending), string(types.ExecutionStatusQueued):
 			hasRunning = true
 		case string(types.ExecutionStatusFailed):
 			hasFailed = true
+		case string(types.ExecutionStatusTimeout):
+			hasTimeout = true
+		case string(types.ExecutionStatusCancelled):
+			hasCancelled = true
+		case string(types.ExecutionStatusSucceededWithWarnings):
+			hasWarnings = true
 		}
 	}
-	// Priority: running > failed > succeeded
+	// Priority: failed > running > timeout > cancelled > succeeded_with_warnings > succeeded
+	if hasFailed {
+		return string(types.ExecutionStatusFailed)
+	}
 	if hasRunning {
 		return string(types.ExecutionStatusRunning)
 	}
-	if hasFailed {
-		return string(types.ExecutionStatusFailed)
+	if hasTimeout {
+		return string(types.ExecutionStatusTimeout)
+	}
+	if hasCancelled {
+		return string(types.ExecutionStatusCancelled)
+	}
+	if hasWarnings {
+		return string(types.ExecutionStatusSucceededWithWarnings)
 	}
 	return string(types.ExecutionStatusSucceeded)
 }
 
 func executionToLightweightNode(exec *types.Execution, depth int) WorkflowDAGLightweightNode {
-	started := exec.StartedAt.Format(time.RFC3339)
-	var completed *string
-	if exec.CompletedAt != nil {
-		formatted := exec.CompletedAt.Format(time.RFC3339)
-		completed = &formatted
-	}
+	started, completed := dagTimestamps(exec)
 
 	return WorkflowDAGLightweightNode{
 		ExecutionID:       exec.ExecutionID,
-		ParentExecutionID: exec.ParentExecutionID,
+		ParentExecutionID: copyParentID(exec.ParentExecutionID),
 		AgentNodeID:       exec.AgentNodeID,
 		ReasonerID:        exec.ReasonerID,
 		Status:            types.NormalizeExecutionStatus(exec.Status),
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// TimelineAccumulator builds the lightweight timeline of a run incrementally
+// as its executions arrive, e.g. while tailing a run in progress. Snapshot
+// returns the timeline the batch builders produce for the executions added so
+// far, in the order they were added.
+//
+// An execution that starts no earlier than every execution already added, is
+// not the parent of one of them and does not move the run start costs O(1)
+// to add. Anything else, such as a late parent or a re-added execution,
+// marks the timeline stale and the next Snapshot rebuilds it in one pass.
+//
+// Executions are not scoped to a tenant; callers add a single tenant's
+// executions. A TimelineAccumulator is not safe for concurrent use.
+type TimelineAccumulator struct {
+	opts DAGOptions
+
+	ordered    []*types.Execution
+	byID       map[string]*types.Execution
+	referenced map[string]bool // IDs named as a parent by an added execution
+	runStart   time.Time
+
+	nodes  []WorkflowDAGLightweightNode
+	depths map[string]int
+	stale  bool
+}
+
+// NewTimelineAccumulator returns an empty accumulator whose nodes are built
+// with opts, as buildTimeline would.
+func NewTimelineAccumulator(opts DAGOptions) *TimelineAccumulator {
+	return &TimelineAccumulator{
+		opts:       opts,
+		byID:       make(map[string]*types.Execution),
+		referenced: make(map[string]bool),
+		depths:     make(map[string]int),
+	}
+}
+
+// Add records exec. Adding an execution ID again replaces the earlier
+// record, so polls can re-add executions whose status changed. Nil entries
+// are skipped.
+func (a *TimelineAccumulator) Add(exec *types.Execution) {
+	if exec == nil {
+		return
+	}
+
+	if previous, ok := a.byID[exec.ExecutionID]; ok {
+		a.remove(previous)
+		a.stale = true
+	}
+	if a.referenced[exec.ExecutionID] {
+		a.stale = true
+	}
+	if !exec.StartedAt.IsZero() && (a.runStart.IsZero() || exec.StartedAt.Before(a.runStart)) {
+		if len(a.ordered) > 0 {
+			a.stale = true
+		}
+		a.runStart = exec.StartedAt
+	}
+
+	// Insert after every execution that starts no later, matching the stable
+	// sort of the batch builder over executions in arrival order.
+	i := sort.Search(len(a.ordered), func(i int) bool {
+		return a.ordered[i].StartedAt.After(exec.StartedAt)
+	})
+	if i < len(a.ordered) {
+		a.stale = true
+	}
+	a.ordered = append(a.ordered, nil)
+	copy(a.ordered[i+1:], a.ordered[i:])
+	a.ordered[i] = exec
+
+	a.byID[exec.ExecutionID] = exec
+	if exec.ParentExecutionID != nil && *exec.ParentExecutionID != "" {
+		a.referenced[*exec.ParentExecutionID] = true
+	}
+
+	if a.stale {
+		return
+	}
+	depth := 0
+	if exec.ParentExecutionID != nil && *exec.ParentExecutionID != "" {
+		if parentDepth, ok := a.depths[*exec.ParentExecutionID]; ok {
+			depth = parentDepth + 1
+		}
+	}
+	a.depths[exec.ExecutionID] = depth
+	a.nodes = append(a.nodes, a.node(exec, depth))
+}
+
+// Snapshot returns the current timeline. The returned slice is the caller's.
+func (a *TimelineAccumulator) Snapshot() []WorkflowDAGLightweightNode {
+	if a.stale {
+		a.nodes = buildTimeline(a.ordered, a.opts)
+		a.depths = make(map[string]int, len(a.nodes))
+		for _, node := range a.nodes {
+			a.depths[node.ExecutionID] = node.WorkflowDepth
+		}
+		a.stale = false
+	}
+	return append([]WorkflowDAGLightweightNode{}, a.nodes...)
+}
+
+// Len returns the number of executions added.
+func (a *TimelineAccumulator) Len() int {
+	return len(a.ordered)
+}
+
+// node builds the timeline node of exec the way buildTimeline does.
+func (a *TimelineAccumulator) node(exec *types.Execution, depth int) WorkflowDAGLightweightNode {
+	node := executionToLightweightNode(exec, depth)
+	node.ReasonerName = a.opts.reasonerName(exec.ReasonerID)
+	node.StartOffsetMS, node.Diagnostics = startOffset(exec, a.runStart, node.Diagnostics)
+	node.Diagnostics = completionSkew(exec, node.Diagnostics)
+	return node
+}
+
+// remove drops exec from the ordered executions. The run start is recomputed
+// since exec may have been the earliest.
+func (a *TimelineAccumulator) remove(exec *types.Execution) {
+	for i, candidate := range a.ordered {
+		if candidate == exec {
+			a.ordered = append(a.ordered[:i], a.ordered[i+1:]...)
+			break
+		}
+	}
+	delete(a.byID, exec.ExecutionID)
+	a.runStart = earliestStart(a.ordered)
+}
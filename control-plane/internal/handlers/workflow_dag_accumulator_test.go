@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimelineAccumulator_MatchesBatch(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID, childID, lateParentID := "exec-root", "exec-child", "exec-late-parent"
+	skewed := base.Add(time.Second)
+
+	arrivals := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", ReasonerID: "plan", Status: "running", StartedAt: base.Add(time.Second)},
+		{ExecutionID: childID, RunID: "run-1", ReasonerID: "research", Status: "running", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-tie", RunID: "run-1", ReasonerID: "fetch", Status: "queued", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &childID},
+		nil,
+		// Starts before the root: the run start moves and offsets shift.
+		{ExecutionID: "exec-early", RunID: "run-1", ReasonerID: "warmup", Status: "succeeded", StartedAt: base, CompletedAt: &skewed},
+		// Child of an execution that has not arrived yet.
+		{ExecutionID: "exec-orphan", RunID: "run-1", ReasonerID: "summarize", Status: "running", StartedAt: base.Add(4 * time.Second), ParentExecutionID: &lateParentID},
+		{ExecutionID: lateParentID, RunID: "run-1", ReasonerID: "review", Status: "running", StartedAt: base.Add(3 * time.Second), ParentExecutionID: &childID},
+		{ExecutionID: "exec-no-start", RunID: "run-1", ReasonerID: "audit", Status: "pending"},
+		{ExecutionID: "exec-tail", RunID: "run-1", ReasonerID: "publish", Status: "running", StartedAt: base.Add(5 * time.Second), ParentExecutionID: &lateParentID},
+	}
+
+	opts := DAGOptions{ResolveReasonerName: func(id string) string { return "name:" + id }}
+	acc := NewTimelineAccumulator(opts)
+	var seen []*types.Execution
+	for _, exec := range arrivals {
+		acc.Add(exec)
+		seen = append(seen, exec)
+
+		batch, _, _, _, _ := buildLightweightExecutionDAGWithOptions(append([]*types.Execution(nil), seen...), opts)
+		require.Equal(t, batch, acc.Snapshot(), "after %d executions", len(seen))
+	}
+	require.Equal(t, len(arrivals)-1, acc.Len())
+
+	// Re-adding an execution replaces it, as a poll after a status change would.
+	done := *arrivals[1]
+	done.Status = "succeeded"
+	acc.Add(&done)
+	latest := []*types.Execution{arrivals[0], arrivals[2], arrivals[4], arrivals[5], arrivals[6], arrivals[7], arrivals[8], &done}
+	batch, _, _, _, _ := buildLightweightExecutionDAGWithOptions(latest, opts)
+	snapshot := acc.Snapshot()
+	require.Equal(t, batch, snapshot)
+	require.Equal(t, len(arrivals)-1, acc.Len())
+
+	// Snapshots are copies.
+	snapshot[0].Status = "mutated"
+	require.NotEqual(t, "mutated", acc.Snapshot()[0].Status)
+}
+
+func TestTimelineAccumulator_InOrderArrivalsStayIncremental(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	acc := NewTimelineAccumulator(DAGOptions{})
+	var seen []*types.Execution
+	parentID := ""
+	for i := 0; i < 5; i++ {
+		exec := &types.Execution{ExecutionID: "exec-" + string(rune('a'+i)), RunID: "run-1", Status: "running", StartedAt: base.Add(time.Duration(i) * time.Second)}
+		if parentID != "" {
+			parent := parentID
+			exec.ParentExecutionID = &parent
+		}
+		parentID = exec.ExecutionID
+		acc.Add(exec)
+		seen = append(seen, exec)
+		require.False(t, acc.stale, "in-order arrival %d forced a rebuild", i)
+	}
+
+	batch, _, _, _, maxDepth := buildLightweightExecutionDAG(seen)
+	require.Equal(t, batch, acc.Snapshot())
+	require.Equal(t, 4, maxDepth)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DAGStepMatch pairs the nth call of a reasoner in one run with the nth call
+// of the same reasoner in another. Left or Right is nil when the step only
+// happened in one of the runs.
+type DAGStepMatch struct {
+	ReasonerID string                      `json:"reasoner_id"`
+	Sequence   int                         `json:"sequence"`
+	Left       *WorkflowDAGLightweightNode `json:"left"`
+	Right      *WorkflowDAGLightweightNode `json:"right"`
+	// DurationDeltaMS is the right duration minus the left one, set only when
+	// both steps report a duration.
+	DurationDeltaMS *int64 `json:"duration_delta_ms,omitempty"`
+}
+
+// WorkflowRunComparisonResponse aligns the lightweight timelines of two runs.
+type WorkflowRunComparisonResponse struct {
+	LeftRunID      string         `json:"left_run_id"`
+	RightRunID     string         `json:"right_run_id"`
+	LeftStatus     string         `json:"left_status"`
+	RightStatus    string         `json:"right_status"`
+	Steps          []DAGStepMatch `json:"steps"`
+	MatchedSteps   int            `json:"matched_steps"`
+	LeftOnlySteps  int            `json:"left_only_steps"`
+	RightOnlySteps int            `json:"right_only_steps"`
+}
+
+type dagStepKey struct {
+	reasonerID string
+	sequence   int
+}
+
+// DiffDAGs aligns two timelines by reasoner sequence: the nth call of a
+// reasoner in left is matched with the nth call of it in right. Steps without
+// a counterpart are kept as gaps, so runs with different topologies still
+// line up. The result follows left's order, with right-only steps placed
+// before the next matched step that follows them in right.
+func DiffDAGs(left, right []WorkflowDAGLightweightNode) []DAGStepMatch {
+	leftKeys := dagStepKeys(left)
+	rightKeys := dagStepKeys(right)
+
+	rightIndex := make(map[dagStepKey]int, len(right))
+	for j, key := range rightKeys {
+		rightIndex[key] = j
+	}
+	matched := make([]bool, len(right))
+	partner := make([]int, len(left))
+	for i, key := range leftKeys {
+		partner[i] = -1
+		if j, ok := rightIndex[key]; ok {
+			partner[i] = j
+			matched[j] = true
+		}
+	}
+
+	steps := make([]DAGStepMatch, 0, len(left)+len(right))
+	rightOnly := func(j int) {
+		node := right[j]
+		steps = append(steps, DAGStepMatch{ReasonerID: node.ReasonerID, Sequence: rightKeys[j].sequence, Right: &node})
+	}
+
+	next := 0
+	for i := range left {
+		leftNode := left[i]
+		p := partner[i]
+		if p < 0 {
+			steps = append(steps, DAGStepMatch{ReasonerID: leftNode.ReasonerID, Sequence: leftKeys[i].sequence, Left: &leftNode})
+			continue
+		}
+		for ; next < p; next++ {
+			if !matched[next] {
+				rightOnly(next)
+			}
+		}
+		if next == p {
+			next++
+		}
+
+		rightNode := right[p]
+		step := DAGStepMatch{ReasonerID: leftNode.ReasonerID, Sequence: leftKeys[i].sequence, Left: &leftNode, Right: &rightNode}
+		if leftNode.DurationMS != nil && rightNode.DurationMS != nil {
+			delta := *rightNode.DurationMS - *leftNode.DurationMS
+			step.DurationDeltaMS = &delta
+		}
+		steps = append(steps, step)
+	}
+	for ; next < len(right); next++ {
+		if !matched[next] {
+			rightOnly(next)
+		}
+	}
+	return steps
+}
+
+func dagStepKeys(nodes []WorkflowDAGLightweightNode) []dagStepKey {
+	seen := make(map[string]int, len(nodes))
+	keys := make([]dagStepKey, len(nodes))
+	for i, node := range nodes {
+		keys[i] = dagStepKey{reasonerID: node.ReasonerID, sequence: seen[node.ReasonerID]}
+		seen[node.ReasonerID]++
+	}
+	return keys
+}
+
+func GetWorkflowRunComparisonHandler(storageProvider storage.StorageProvider) gin.HandlerFunc {
+	svc := newExecutionGraphService(storageProvider)
+	return svc.handleCompareWorkflowRuns
+}
+
+func (s *ExecutionGraphService) handleCompareWorkflowRuns(c *gin.Context) {
+	opts := DAGOptions{TenantID: middleware.TenantID(c)}
+	response, err := s.CompareRuns(c.Request.Context(), c.Param("workflowId"), c.Param("otherWorkflowId"), opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrWorkflowIDRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrWorkflowNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CompareRuns builds the lightweight timeline of each run and aligns them
+// with DiffDAGs. It returns ErrWorkflowIDRequired if either ID is empty and
+// ErrWorkflowNotFound if either run has no executions in opts.TenantID.
+func (s *ExecutionGraphService) CompareRuns(ctx context.Context, leftRunID, rightRunID string, opts DAGOptions) (*WorkflowRunComparisonResponse, error) {
+	leftRunID = strings.TrimSpace(leftRunID)
+	rightRunID = strings.TrimSpace(rightRunID)
+	if leftRunID == "" || rightRunID == "" {
+		return nil, ErrWorkflowIDRequired
+	}
+
+	response := &WorkflowRunComparisonResponse{LeftRunID: leftRunID, RightRunID: rightRunID}
+	var timelines [2][]WorkflowDAGLightweightNode
+	for i, runID := range []string{leftRunID, rightRunID} {
+		executions, err := s.loadRunExecutions(ctx, runID, opts.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workflow %s: %w", runID, err)
+		}
+		executions = scopeExecutionsToTenant(executions, opts.TenantID)
+		if len(executions) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, runID)
+		}
+
+		timeline, status, _, _, _ := buildLightweightExecutionDAGWithOptions(executions, opts)
+		timelines[i] = timeline
+		if i == 0 {
+			response.LeftStatus = status
+		} else {
+			response.RightStatus = status
+		}
+	}
+
+	response.Steps = DiffDAGs(timelines[0], timelines[1])
+	for _, step := range response.Steps {
+		switch {
+		case step.Left != nil && step.Right != nil:
+			response.MatchedSteps++
+		case step.Left != nil:
+			response.LeftOnlySteps++
+		default:
+			response.RightOnlySteps++
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func dagDiffNode(executionID, reasonerID string, durationMS *int64) WorkflowDAGLightweightNode {
+	return WorkflowDAGLightweightNode{ExecutionID: executionID, ReasonerID: reasonerID, DurationMS: durationMS}
+}
+
+func TestDiffDAGs_AlignsByReasonerSequence(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	left := []WorkflowDAGLightweightNode{
+		dagDiffNode("l1", "plan", ms(100)),
+		dagDiffNode("l2", "search", ms(200)),
+		dagDiffNode("l3", "search", ms(300)),
+		dagDiffNode("l4", "summarize", nil),
+	}
+	right := []WorkflowDAGLightweightNode{
+		dagDiffNode("r1", "plan", ms(150)),
+		dagDiffNode("r2", "retry", ms(50)),
+		dagDiffNode("r3", "search", ms(180)),
+		dagDiffNode("r4", "summarize", ms(90)),
+	}
+
+	steps := DiffDAGs(left, right)
+
+	type row struct {
+		left, right string
+		sequence    int
+	}
+	var rows []row
+	for _, step := range steps {
+		var r row
+		r.sequence = step.Sequence
+		if step.Left != nil {
+			r.left = step.Left.ExecutionID
+		}
+		if step.Right != nil {
+			r.right = step.Right.ExecutionID
+		}
+		rows = append(rows, r)
+	}
+	require.Equal(t, []row{
+		{"l1", "r1", 0},
+		{"", "r2", 0},
+		{"l2", "r3", 0},
+		{"l3", "", 1},
+		{"l4", "r4", 0},
+	}, rows)
+
+	require.Equal(t, int64(50), *steps[0].DurationDeltaMS)
+	require.Equal(t, int64(-20), *steps[2].DurationDeltaMS)
+	require.Nil(t, steps[3].DurationDeltaMS)
+	// A missing duration on either side leaves the delta unset
+	require.Nil(t, steps[4].DurationDeltaMS)
+}
+
+func TestDiffDAGs_EmptyRuns(t *testing.T) {
+	require.Empty(t, DiffDAGs(nil, nil))
+
+	steps := DiffDAGs(nil, []WorkflowDAGLightweightNode{dagDiffNode("r1", "plan", nil)})
+	require.Len(t, steps, 1)
+	require.Nil(t, steps[0].Left)
+	require.Equal(t, "r1", steps[0].Right.ExecutionID)
+}
+
+func TestHandleCompareWorkflowRuns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newTestExecutionStorage(nil)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	fast, slow := int64(100), int64(400)
+	for _, exec := range []*types.Execution{
+		{ExecutionID: "a-root", RunID: "run-a", ReasonerID: "plan", Status: "succeeded", StartedAt: base, DurationMS: &fast},
+		{ExecutionID: "b-root", RunID: "run-b", ReasonerID: "plan", Status: "failed", StartedAt: base, DurationMS: &slow},
+		{ExecutionID: "b-child", RunID: "run-b", ReasonerID: "retry", Status: "failed", StartedAt: base.Add(time.Second)},
+	} {
+		require.NoError(t, store.CreateExecutionRecord(ctx, exec))
+	}
+	svc := &ExecutionGraphService{store: store}
+
+	router := gin.New()
+	router.GET("/workflows/:workflowId/compare/:otherWorkflowId", svc.handleCompareWorkflowRuns)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/workflows/run-a/compare/run-b", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp WorkflowRunComparisonResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "run-a", resp.LeftRunID)
+	require.Equal(t, "run-b", resp.RightRunID)
+	require.Equal(t, string(types.ExecutionStatusSucceeded), resp.LeftStatus)
+	require.Equal(t, string(types.ExecutionStatusFailed), resp.RightStatus)
+	require.Equal(t, 1, resp.MatchedSteps)
+	require.Equal(t, 0, resp.LeftOnlySteps)
+	require.Equal(t, 1, resp.RightOnlySteps)
+	require.Len(t, resp.Steps, 2)
+	require.Equal(t, int64(300), *resp.Steps[0].DurationDeltaMS)
+	require.Nil(t, resp.Steps[1].Left)
+	require.Equal(t, "b-child", resp.Steps[1].Right.ExecutionID)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/workflows/run-a/compare/run-missing", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
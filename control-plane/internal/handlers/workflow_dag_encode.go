@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// dagNodeHead and dagNodeTail hold the WorkflowDAGNode fields that precede
+// and follow Children, so a node can be written around its streamed
+// children. They must list the same fields, tags and order as
+// WorkflowDAGNode.
+type dagNodeHead struct {
+	WorkflowID        string  `json:"workflow_id"`
+	ExecutionID       string  `json:"execution_id"`
+	AgentNodeID       string  `json:"agent_node_id"`
+	ReasonerID        string  `json:"reasoner_id"`
+	ReasonerName      string  `json:"reasoner_name,omitempty"`
+	Status            string  `json:"status"`
+	StartedAt         string  `json:"started_at"`
+	CompletedAt       *string `json:"completed_at,omitempty"`
+	DurationMS        *int64  `json:"duration_ms,omitempty"`
+	InputBytes        *int64  `json:"input_bytes,omitempty"`
+	OutputBytes       *int64  `json:"output_bytes,omitempty"`
+	ParentExecutionID *string `json:"parent_execution_id,omitempty"`
+	WorkflowDepth     int     `json:"workflow_depth"`
+	AttemptNumber     int     `json:"attempt_number"`
+	StartOffsetMS     *int64  `json:"start_offset_ms,omitempty"`
+	QueueWaitMS       *int64  `json:"queue_wait_ms,omitempty"`
+}
+
+type dagNodeTail struct {
+	Notes       []types.ExecutionNote `json:"notes"`
+	NotesCount  int                   `json:"notes_count"`
+	LatestNote  *types.ExecutionNote  `json:"latest_note,omitempty"`
+	Diagnostics []DAGDiagnostic       `json:"diagnostics,omitempty"`
+}
+
+// dagResponseHead holds the WorkflowDAGResponse fields that precede DAG.
+type dagResponseHead struct {
+	RootWorkflowID string  `json:"root_workflow_id"`
+	WorkflowStatus string  `json:"workflow_status"`
+	WorkflowName   string  `json:"workflow_name"`
+	SessionID      *string `json:"session_id,omitempty"`
+	ActorID        *string `json:"actor_id,omitempty"`
+	TotalNodes     int     `json:"total_nodes"`
+	MaxDepth       int     `json:"max_depth"`
+}
+
+// EncodeDAG writes root to w as JSON, one node at a time, so the tree is
+// never marshaled into a single buffer. The output is byte-for-byte what
+// json.Marshal(root) produces. Trees nested deeper than opts.MaxDepth are
+// rejected.
+func EncodeDAG(w io.Writer, root WorkflowDAGNode, opts DAGOptions) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeDAGNode(bw, &root, 0, opts.maxDepth()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeDAGResponse streams a full DAG response to w. The output matches
+// json.Marshal(resp).
+func encodeDAGResponse(w io.Writer, resp *WorkflowDAGResponse, opts DAGOptions) error {
+	bw := bufio.NewWriter(w)
+
+	head, err := json.Marshal(dagResponseHead{
+		RootWorkflowID: resp.RootWorkflowID,
+		WorkflowStatus: resp.WorkflowStatus,
+		WorkflowName:   resp.WorkflowName,
+		SessionID:      resp.SessionID,
+		ActorID:        resp.ActorID,
+		TotalNodes:     resp.TotalNodes,
+		MaxDepth:       resp.MaxDepth,
+	})
+	if err != nil {
+		return err
+	}
+	bw.Write(head[:len(head)-1])
+	bw.WriteString(`,"dag":`)
+	if err := encodeDAGNode(bw, &resp.DAG, 0, opts.maxDepth()); err != nil {
+		return err
+	}
+	bw.WriteString(`,"timeline":`)
+	if err := encodeDAGNodes(bw, resp.Timeline, 0, opts.maxDepth()); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('}'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeDAGNode(bw *bufio.Writer, node *WorkflowDAGNode, level, maxLevel int) error {
+	if level > maxLevel {
+		return fmt.Errorf("DAG nesting exceeds max depth %d", maxLevel)
+	}
+
+	head, err := json.Marshal(dagNodeHead{
+		WorkflowID:        node.WorkflowID,
+		ExecutionID:       node.ExecutionID,
+		AgentNodeID:       node.AgentNodeID,
+		ReasonerID:        node.ReasonerID,
+		ReasonerName:      node.ReasonerName,
+		Status:            node.Status,
+		StartedAt:         node.StartedAt,
+		CompletedAt:       node.CompletedAt,
+		DurationMS:        node.DurationMS,
+		InputBytes:        node.InputBytes,
+		OutputBytes:       node.OutputBytes,
+		ParentExecutionID: node.ParentExecutionID,
+		WorkflowDepth:     node.WorkflowDepth,
+		AttemptNumber:     node.AttemptNumber,
+		StartOffsetMS:     node.StartOffsetMS,
+		QueueWaitMS:       node.QueueWaitMS,
+	})
+	if err != nil {
+		return err
+	}
+	tail, err := json.Marshal(dagNodeTail{
+		Notes:       node.Notes,
+		NotesCount:  node.NotesCount,
+		LatestNote:  node.LatestNote,
+		Diagnostics: node.Diagnostics,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Both halves always contain a field, so dropping the closing and
+	// opening braces leaves well-formed members to join around children.
+	bw.Write(head[:len(head)-1])
+	bw.WriteString(`,"children":`)
+	if err := encodeDAGNodes(bw, node.Children, level+1, maxLevel); err != nil {
+		return err
+	}
+	bw.WriteByte(',')
+	_, err = bw.Write(tail[1:])
+	return err
+}
+
+func encodeDAGNodes(bw *bufio.Writer, nodes []WorkflowDAGNode, level, maxLevel int) error {
+	if nodes == nil {
+		_, err := bw.WriteString("null")
+		return err
+	}
+	bw.WriteByte('[')
+	for i := range nodes {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if err := encodeDAGNode(bw, &nodes[i], level, maxLevel); err != nil {
+			return err
+		}
+	}
+	return bw.WriteByte(']')
+}
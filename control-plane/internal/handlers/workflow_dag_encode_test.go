@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func dagEncodeFixtures() map[string][]*types.Execution {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	enqueued := base.Add(-time.Second)
+	completed := base.Add(3 * time.Second)
+	duration := int64(3000)
+	session := "session-<1>"
+	rootID := "exec-root"
+	childID := "exec-child"
+
+	return map[string][]*types.Execution{
+		"single": {
+			{ExecutionID: rootID, RunID: "run-1", Status: "running", StartedAt: base},
+		},
+		"nested": {
+			{
+				ExecutionID: rootID, RunID: "run-1", Status: "succeeded", ReasonerID: "planner & co",
+				SessionID: &session, EnqueuedAt: &enqueued, StartedAt: base, CompletedAt: &completed, DurationMS: &duration,
+				InputPayload: json.RawMessage(`{"q":"<b>"}`), ResultPayload: json.RawMessage(`"ok"`),
+				Notes: []types.ExecutionNote{{Message: "started", Tags: []string{"a"}, Timestamp: base}},
+			},
+			{ExecutionID: childID, RunID: "run-1", Status: "failed", ParentExecutionID: &rootID, StartedAt: base.Add(time.Second)},
+			{ExecutionID: "exec-grandchild", RunID: "run-1", Status: "succeeded", ParentExecutionID: &childID, StartedAt: base.Add(-2 * time.Second)},
+			{ExecutionID: "exec-sibling", RunID: "run-1", Status: "queued", ParentExecutionID: &rootID},
+		},
+	}
+}
+
+func TestEncodeDAG_MatchesMarshal(t *testing.T) {
+	for name, executions := range dagEncodeFixtures() {
+		t.Run(name, func(t *testing.T) {
+			dag, timeline, status, workflowName, meta, maxDepth := buildExecutionDAG(executions)
+
+			want, err := json.Marshal(dag)
+			require.NoError(t, err)
+			var got bytes.Buffer
+			require.NoError(t, EncodeDAG(&got, dag, DAGOptions{}))
+			require.Equal(t, string(want), got.String())
+
+			resp := &WorkflowDAGResponse{
+				RootWorkflowID: "run-1",
+				WorkflowStatus: status,
+				WorkflowName:   workflowName,
+				SessionID:      meta.SessionID,
+				ActorID:        meta.ActorID,
+				TotalNodes:     len(executions),
+				MaxDepth:       maxDepth,
+				DAG:            dag,
+				Timeline:       timeline,
+			}
+			want, err = json.Marshal(resp)
+			require.NoError(t, err)
+			got.Reset()
+			require.NoError(t, encodeDAGResponse(&got, resp, DAGOptions{}))
+			require.Equal(t, string(want), got.String())
+		})
+	}
+}
+
+func TestEncodeDAG_NilAndEmptyChildren(t *testing.T) {
+	for _, node := range []WorkflowDAGNode{{ExecutionID: "nil-children"}, {ExecutionID: "empty", Children: []WorkflowDAGNode{}}} {
+		want, err := json.Marshal(node)
+		require.NoError(t, err)
+		var got bytes.Buffer
+		require.NoError(t, EncodeDAG(&got, node, DAGOptions{}))
+		require.Equal(t, string(want), got.String())
+	}
+}
+
+func TestEncodeDAG_RejectsTreesDeeperThanMaxDepth(t *testing.T) {
+	root := WorkflowDAGNode{ExecutionID: "a", Children: []WorkflowDAGNode{
+		{ExecutionID: "b", Children: []WorkflowDAGNode{{ExecutionID: "c"}}},
+	}}
+
+	require.NoError(t, EncodeDAG(&bytes.Buffer{}, root, DAGOptions{MaxDepth: 2}))
+	require.Error(t, EncodeDAG(&bytes.Buffer{}, root, DAGOptions{MaxDepth: 1}))
+}
+
+// The split structs must stay in sync with WorkflowDAGNode for the streamed
+// output to match.
+func TestDAGNodeHeadTailMirrorWorkflowDAGNode(t *testing.T) {
+	var fields []reflect.StructField
+	for _, typ := range []reflect.Type{reflect.TypeOf(dagNodeHead{}), reflect.TypeOf(dagNodeTail{})} {
+		for i := 0; i < typ.NumField(); i++ {
+			fields = append(fields, typ.Field(i))
+		}
+		if typ == reflect.TypeOf(dagNodeHead{}) {
+			children, _ := reflect.TypeOf(WorkflowDAGNode{}).FieldByName("Children")
+			fields = append(fields, children)
+		}
+	}
+
+	node := reflect.TypeOf(WorkflowDAGNode{})
+	require.Equal(t, node.NumField(), len(fields))
+	for i, field := range fields {
+		require.Equal(t, node.Field(i).Name, field.Name)
+		require.Equal(t, node.Field(i).Type, field.Type)
+		require.Equal(t, node.Field(i).Tag, field.Tag)
+	}
+}
+
+func TestHandleGetWorkflowDAG_StreamsFullResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newTestExecutionStorage(nil)
+	ctx := context.Background()
+	for _, exec := range dagEncodeFixtures()["nested"] {
+		// The test store returns records in map order, so keep one child per
+		// parent to make sibling order, and therefore the body, deterministic.
+		if exec.ExecutionID == "exec-sibling" {
+			continue
+		}
+		require.NoError(t, store.CreateExecutionRecord(ctx, exec))
+	}
+	svc := &ExecutionGraphService{store: store}
+
+	router := gin.New()
+	router.GET("/workflows/:workflowId/dag", svc.handleGetWorkflowDAG)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/workflows/run-1/dag", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	response, err := svc.BuildResponse(ctx, "run-1", DAGOptions{})
+	require.NoError(t, err)
+	want, err := json.Marshal(response.Body())
+	require.NoError(t, err)
+	require.Equal(t, string(want), rec.Body.String())
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// reasonerFilterSet returns the IDs of the executions kept when a run is
+// narrowed to reasonerIDs: every execution of one of those reasoners plus all
+// of its ancestors, so the filtered tree stays connected.
+func reasonerFilterSet(executions []*types.Execution, reasonerIDs []string) map[string]bool {
+	wanted := make(map[string]bool, len(reasonerIDs))
+	for _, id := range reasonerIDs {
+		wanted[id] = true
+	}
+
+	execMap := make(map[string]*types.Execution, len(executions))
+	for _, exec := range executions {
+		if exec != nil {
+			execMap[exec.ExecutionID] = exec
+		}
+	}
+
+	keep := make(map[string]bool)
+	for _, exec := range executions {
+		if exec == nil || !wanted[exec.ReasonerID] {
+			continue
+		}
+		// Walk up until an already-kept ancestor; keep also guards against
+		// parent cycles.
+		for current := exec; current != nil && !keep[current.ExecutionID]; {
+			keep[current.ExecutionID] = true
+			if current.ParentExecutionID == nil || *current.ParentExecutionID == "" {
+				break
+			}
+			current = execMap[*current.ParentExecutionID]
+		}
+	}
+	return keep
+}
+
+// pruneDAG drops every descendant of node that is neither in keep nor above a
+// node that is, and reports whether anything under node, or node itself, is
+// kept. Callers leave the root in place even when nothing matches.
+func pruneDAG(node *WorkflowDAGNode, keep map[string]bool) bool {
+	if len(node.Children) > 0 {
+		children := make([]WorkflowDAGNode, 0, len(node.Children))
+		for _, child := range node.Children {
+			if pruneDAG(&child, keep) {
+				children = append(children, child)
+			}
+		}
+		node.Children = children
+	}
+	return keep[node.ExecutionID] || len(node.Children) > 0
+}
+
+// filterDAGNodes returns the nodes whose execution is in keep.
+func filterDAGNodes(nodes []WorkflowDAGNode, keep map[string]bool) []WorkflowDAGNode {
+	filtered := make([]WorkflowDAGNode, 0, len(nodes))
+	for _, node := range nodes {
+		if keep[node.ExecutionID] {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// filterLightweightNodes returns the nodes whose execution is in keep.
+func filterLightweightNodes(nodes []WorkflowDAGLightweightNode, keep map[string]bool) []WorkflowDAGLightweightNode {
+	filtered := make([]WorkflowDAGLightweightNode, 0, len(nodes))
+	for _, node := range nodes {
+		if keep[node.ExecutionID] {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// reasonerIDsQuery reads the reasoner_id query parameter, which may be
+// repeated or hold a comma-separated list.
+func reasonerIDsQuery(c *gin.Context) []string {
+	var ids []string
+	for _, value := range c.QueryArray("reasoner_id") {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildResponse_ReasonerFilterKeepsAncestors(t *testing.T) {
+	store := newTestExecutionStorage(nil)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID, childID := "exec-root", "exec-child"
+
+	for _, exec := range []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", ReasonerID: "plan", Status: "succeeded", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", ReasonerID: "research", Status: "succeeded", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-grandchild", RunID: "run-1", ReasonerID: "summarize", Status: "succeeded", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &childID},
+		{ExecutionID: "exec-sibling", RunID: "run-1", ReasonerID: "fetch", Status: "failed", StartedAt: base.Add(3 * time.Second), ParentExecutionID: &rootID},
+	} {
+		require.NoError(t, store.CreateExecutionRecord(ctx, exec))
+	}
+	svc := &ExecutionGraphService{store: store}
+
+	full, err := svc.BuildResponse(ctx, "run-1", DAGOptions{ReasonerIDs: []string{"summarize"}})
+	require.NoError(t, err)
+	dag := full.Full.DAG
+	require.Equal(t, rootID, dag.ExecutionID)
+	require.Len(t, dag.Children, 1, "the unmatched sibling is pruned")
+	require.Equal(t, childID, dag.Children[0].ExecutionID, "the matched node's parent is kept")
+	require.Len(t, dag.Children[0].Children, 1)
+	require.Equal(t, "exec-grandchild", dag.Children[0].Children[0].ExecutionID)
+	require.Len(t, full.Full.Timeline, 3)
+
+	// Summary fields still describe the whole run
+	require.Equal(t, "failed", full.Full.WorkflowStatus)
+	require.Equal(t, 2, full.Full.MaxDepth)
+	require.Equal(t, 4, full.Full.TotalNodes)
+
+	light, err := svc.BuildResponse(ctx, "run-1", DAGOptions{Lightweight: true, ReasonerIDs: []string{"summarize", "fetch"}})
+	require.NoError(t, err)
+	require.Len(t, light.Lightweight.Timeline, 4)
+	require.Equal(t, "failed", light.Lightweight.WorkflowStatus)
+
+	none, err := svc.BuildResponse(ctx, "run-1", DAGOptions{ReasonerIDs: []string{"missing"}})
+	require.NoError(t, err)
+	require.Equal(t, rootID, none.Full.DAG.ExecutionID)
+	require.Empty(t, none.Full.DAG.Children)
+	require.Empty(t, none.Full.Timeline)
+}
+
+func TestReasonerIDsQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/dag?reasoner_id=plan,%20summarize&reasoner_id=fetch&reasoner_id=", nil)
+
+	require.Equal(t, []string{"plan", "summarize", "fetch"}, reasonerIDsQuery(c))
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// WallClockDuration returns the span in milliseconds from the earliest
+// StartedAt to the latest CompletedAt across the subtree rooted at root. Unlike
+// SummedDuration it does not double-count children that ran in parallel.
+//
+// openEnded is true when any node in the subtree has not completed; the
+// returned duration then only covers up to the latest known completion (or
+// start) time. Node timestamps are RFC3339, so the result has second precision.
+func WallClockDuration(root WorkflowDAGNode) (durationMS int64, openEnded bool) {
+	var earliest, latest time.Time
+
+	var walk func(node WorkflowDAGNode)
+	walk = func(node WorkflowDAGNode) {
+		if started, err := time.Parse(time.RFC3339, node.StartedAt); err == nil {
+			if earliest.IsZero() || started.Before(earliest) {
+				earliest = started
+			}
+			if latest.IsZero() || started.After(latest) {
+				latest = started
+			}
+		}
+
+		if node.CompletedAt == nil {
+			openEnded = true
+		} else if completed, err := time.Parse(time.RFC3339, *node.CompletedAt); err == nil {
+			if latest.IsZero() || completed.After(latest) {
+				latest = completed
+			}
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	if earliest.IsZero() || !latest.After(earliest) {
+		return 0, openEnded
+	}
+	return latest.Sub(earliest).Milliseconds(), openEnded
+}
+
+// SummedDuration returns the sum of DurationMS across the subtree rooted at
+// root. Dividing it by WallClockDuration gives the run's parallelism.
+func SummedDuration(root WorkflowDAGNode) int64 {
+	var total int64
+	if root.DurationMS != nil {
+		total = *root.DurationMS
+	}
+	for _, child := range root.Children {
+		total += SummedDuration(child)
+	}
+	return total
+}
+
+// StalledNodes returns the running nodes in the subtree rooted at root that
+// started more than threshold before now, in depth-first order. Nodes that
+// have completed, or whose status is anything other than running, are never
+// reported. The returned nodes have their Children cleared.
+func StalledNodes(root WorkflowDAGNode, threshold time.Duration, now time.Time) []WorkflowDAGNode {
+	var stalled []WorkflowDAGNode
+
+	var walk func(node WorkflowDAGNode)
+	walk = func(node WorkflowDAGNode) {
+		if node.CompletedAt == nil && types.NormalizeExecutionStatus(node.Status) == string(types.ExecutionStatusRunning) {
+			if started, err := time.Parse(time.RFC3339, node.StartedAt); err == nil && now.Sub(started) > threshold {
+				flat := node
+				flat.Children = nil
+				stalled = append(stalled, flat)
+			}
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return stalled
+}
+
+// SubtreeSuccessRate returns, for each node in the tree rooted at root, the
+// fraction of its subtree (the node and all its descendants) that succeeded,
+// keyed by execution ID.
+//
+// Only nodes in a terminal status are counted: succeeded and
+// succeeded_with_warnings are successes, and failed, cancelled and timeout are
+// failures. Running, pending, queued and unknown nodes are left out of both
+// the numerator and the denominator, so a subtree still in flight is rated on
+// what has finished so far. A node whose subtree has no terminal node yet is
+// absent from the map.
+func SubtreeSuccessRate(root WorkflowDAGNode) map[string]float64 {
+	rates := make(map[string]float64)
+
+	var walk func(node *WorkflowDAGNode) (succeeded, finished int)
+	walk = func(node *WorkflowDAGNode) (succeeded, finished int) {
+		for i := range node.Children {
+			s, f := walk(&node.Children[i])
+			succeeded += s
+			finished += f
+		}
+
+		switch types.NormalizeExecutionStatus(node.Status) {
+		case string(types.ExecutionStatusSucceeded), string(types.ExecutionStatusSucceededWithWarnings):
+			succeeded++
+			finished++
+		case string(types.ExecutionStatusFailed), string(types.ExecutionStatusCancelled), string(types.ExecutionStatusTimeout):
+			finished++
+		}
+
+		if finished > 0 {
+			rates[node.ExecutionID] = float64(succeeded) / float64(finished)
+		}
+		return succeeded, finished
+	}
+	walk(&root)
+
+	return rates
+}
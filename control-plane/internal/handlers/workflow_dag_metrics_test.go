@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWallClockDuration_ParallelChildren(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	at := func(seconds int) *time.Time {
+		ts := base.Add(time.Duration(seconds) * time.Second)
+		return &ts
+	}
+	ms := func(v int64) *int64 { return &v }
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base, CompletedAt: at(10), DurationMS: ms(10000)},
+		// Two children running in parallel for 8s each
+		{ExecutionID: "exec-a", RunID: "run-1", Status: "succeeded", StartedAt: *at(1), CompletedAt: at(9), DurationMS: ms(8000), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-b", RunID: "run-1", Status: "succeeded", StartedAt: *at(1), CompletedAt: at(9), DurationMS: ms(8000), ParentExecutionID: &rootID},
+	}
+
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+
+	wallClock, openEnded := WallClockDuration(dag)
+	require.False(t, openEnded)
+	require.Equal(t, int64(10000), wallClock)
+	require.Equal(t, int64(26000), SummedDuration(dag))
+
+	// A child's own subtree reports its own span.
+	childWallClock, _ := WallClockDuration(dag.Children[0])
+	require.Equal(t, int64(8000), childWallClock)
+}
+
+func TestWallClockDuration_OpenEnded(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	done := base.Add(3 * time.Second)
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "running", StartedAt: base},
+		{ExecutionID: "exec-a", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(time.Second), CompletedAt: &done, ParentExecutionID: &rootID},
+	}
+
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+
+	wallClock, openEnded := WallClockDuration(dag)
+	require.True(t, openEnded)
+	require.Equal(t, int64(3000), wallClock)
+
+	// A node without timestamps has no measurable span.
+	wallClock, openEnded = WallClockDuration(WorkflowDAGNode{})
+	require.True(t, openEnded)
+	require.Equal(t, int64(0), wallClock)
+}
+
+func TestStalledNodes(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	childID := "exec-child"
+	completed := base.Add(time.Minute)
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "running", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", Status: "running", StartedAt: base.Add(time.Minute), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-hung", RunID: "run-1", Status: "running", StartedAt: base.Add(2 * time.Minute), ParentExecutionID: &childID},
+		{ExecutionID: "exec-fresh", RunID: "run-1", Status: "running", StartedAt: base.Add(9 * time.Minute), ParentExecutionID: &childID},
+		// A long-finished node is never stalled, whatever its duration
+		{ExecutionID: "exec-done", RunID: "run-1", Status: "succeeded", StartedAt: base, CompletedAt: &completed, ParentExecutionID: &rootID},
+		{ExecutionID: "exec-queued", RunID: "run-1", Status: "queued", StartedAt: base, ParentExecutionID: &rootID},
+	}
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+	now := base.Add(10 * time.Minute)
+
+	ids := func(nodes []WorkflowDAGNode) []string {
+		out := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			require.Nil(t, node.Children)
+			out = append(out, node.ExecutionID)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{rootID, childID, "exec-hung"}, ids(StalledNodes(dag, 5*time.Minute, now)))
+	// Elapsed time must exceed the threshold, not just reach it
+	require.Equal(t, []string{rootID}, ids(StalledNodes(dag, 9*time.Minute, now)))
+	require.Empty(t, StalledNodes(dag, time.Hour, now))
+	require.Len(t, dag.Children, 3, "the DAG itself is left intact")
+}
+
+func TestSubtreeSuccessRate(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	childID := "exec-child"
+	idleID := "exec-idle"
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", Status: "succeeded_with_warnings", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-failed", RunID: "run-1", Status: "failed", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &childID},
+		{ExecutionID: "exec-timeout", RunID: "run-1", Status: "timeout", StartedAt: base.Add(3 * time.Second), ParentExecutionID: &childID},
+		// Nodes still in flight are excluded from the rate
+		{ExecutionID: "exec-running", RunID: "run-1", Status: "running", StartedAt: base.Add(4 * time.Second), ParentExecutionID: &childID},
+		{ExecutionID: idleID, RunID: "run-1", Status: "queued", StartedAt: base.Add(5 * time.Second), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-pending", RunID: "run-1", Status: "pending", StartedAt: base.Add(6 * time.Second), ParentExecutionID: &idleID},
+	}
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+
+	rates := SubtreeSuccessRate(dag)
+	require.Equal(t, map[string]float64{
+		rootID:         2.0 / 4.0,
+		childID:        1.0 / 3.0,
+		"exec-failed":  0,
+		"exec-timeout": 0,
+	}, rates)
+
+	require.Empty(t, SubtreeSuccessRate(WorkflowDAGNode{ExecutionID: "exec-new", Status: "running"}))
+}
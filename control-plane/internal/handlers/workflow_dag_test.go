@@ -2,11 +2,12 @@ package handlers
 
 import (
 	"context"
-	"strings"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage/storagetest"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
 	"github.com/stretchr/testify/require"
@@ -23,7 +24,7 @@ func TestBuildExecutionDAG_Simple(t *testing.T) {
 		},
 	}
 
-	dag, timeline, status, workflowName, sessionID, actorID, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, status, workflowName, meta, maxDepth := buildExecutionDAG(executions)
 
 	require.NotNil(t, dag)
 	require.Equal(t, "exec-1", dag.ExecutionID)
@@ -33,8 +34,8 @@ func TestBuildExecutionDAG_Simple(t *testing.T) {
 	require.Len(t, timeline, 1)
 	require.Equal(t, "succeeded", status)
 	require.Equal(t, "reasoner-1", workflowName)
-	require.Nil(t, sessionID)
-	require.Nil(t, actorID)
+	require.Nil(t, meta.SessionID)
+	require.Nil(t, meta.ActorID)
 	require.Equal(t, 0, maxDepth)
 }
 
@@ -61,7 +62,7 @@ func TestBuildExecutionDAG_WithParentChild(t *testing.T) {
 		},
 	}
 
-	dag, timeline, status, _, _, _, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, status, _, _, maxDepth := buildExecutionDAG(executions)
 
 	require.NotNil(t, dag)
 	require.Equal(t, parentID, dag.ExecutionID)
@@ -103,7 +104,7 @@ func TestBuildExecutionDAG_MultipleChildren(t *testing.T) {
 		},
 	}
 
-	dag, timeline, _, _, _, _, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, _, _, _, maxDepth := buildExecutionDAG(executions)
 
 	require.NotNil(t, dag)
 	require.Equal(t, parentID, dag.ExecutionID)
@@ -119,6 +120,85 @@ func TestBuildExecutionDAG_MultipleChildren(t *testing.T) {
 	require.Equal(t, 1, maxDepth)
 }
 
+func TestBuildExecutionDAG_AliasedParentPointers(t *testing.T) {
+	base := time.Now()
+	executions := []*types.Execution{
+		{ExecutionID: "exec-root", RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: "exec-mid", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(time.Second)},
+	}
+	rootID, midID := "exec-root", "exec-mid"
+	executions[1].ParentExecutionID = &rootID
+
+	// Ingestion code that hands every leaf the same parent pointer.
+	shared := new(string)
+	*shared = midID
+	for i, id := range []string{"exec-leaf-1", "exec-leaf-2"} {
+		executions = append(executions, &types.Execution{
+			ExecutionID:       id,
+			RunID:             "run-1",
+			Status:            "succeeded",
+			StartedAt:         base.Add(time.Duration(i+2) * time.Second),
+			ParentExecutionID: shared,
+		})
+	}
+
+	dag, timeline, _, _, _, maxDepth := buildExecutionDAG(executions)
+	lightweight := BuildTimeline(executions)
+	// Reusing the pointer afterwards must not rewrite the built nodes.
+	*shared = rootID
+
+	require.Equal(t, rootID, dag.ExecutionID)
+	require.Len(t, dag.Children, 1)
+	mid := dag.Children[0]
+	require.Equal(t, midID, mid.ExecutionID)
+	require.Len(t, mid.Children, 2)
+	for _, leaf := range mid.Children {
+		require.Equal(t, midID, *leaf.ParentExecutionID)
+		require.Equal(t, 2, leaf.WorkflowDepth)
+		require.Empty(t, leaf.Children)
+	}
+	require.Equal(t, 2, maxDepth)
+
+	require.Len(t, timeline, 4)
+	for _, node := range timeline[2:] {
+		require.Equal(t, midID, *node.ParentExecutionID)
+		require.Equal(t, 2, node.WorkflowDepth)
+	}
+
+	require.Len(t, lightweight, 4)
+	for _, node := range lightweight[2:] {
+		require.Equal(t, midID, *node.ParentExecutionID)
+		require.Equal(t, 2, node.WorkflowDepth)
+	}
+}
+
+func TestBuildExecutionDAG_AttemptNumbers(t *testing.T) {
+	base := time.Now()
+	rootID := "exec-root"
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", ReasonerID: "planner", Status: "succeeded", StartedAt: base},
+		// Listed out of order so numbering has to follow StartedAt.
+		{ExecutionID: "exec-fetch-2", RunID: "run-1", ReasonerID: "fetch", Status: "succeeded", ParentExecutionID: &rootID, StartedAt: base.Add(3 * time.Second)},
+		{ExecutionID: "exec-fetch-1", RunID: "run-1", ReasonerID: "fetch", Status: "failed", ParentExecutionID: &rootID, StartedAt: base.Add(time.Second)},
+		{ExecutionID: "exec-summarize", RunID: "run-1", ReasonerID: "summarize", Status: "succeeded", ParentExecutionID: &rootID, StartedAt: base.Add(4 * time.Second)},
+	}
+
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
+
+	require.Equal(t, 1, dag.AttemptNumber)
+	attempts := make(map[string]int)
+	for _, child := range dag.Children {
+		attempts[child.ExecutionID] = child.AttemptNumber
+	}
+	require.Equal(t, map[string]int{"exec-fetch-1": 1, "exec-fetch-2": 2, "exec-summarize": 1}, attempts)
+
+	for _, node := range timeline {
+		if node.ExecutionID != rootID {
+			require.Equal(t, attempts[node.ExecutionID], node.AttemptNumber, node.ExecutionID)
+		}
+	}
+}
+
 func TestBuildExecutionDAG_DeepHierarchy(t *testing.T) {
 	rootID := "exec-root"
 	level1ID := "exec-level1"
@@ -148,7 +228,7 @@ func TestBuildExecutionDAG_DeepHierarchy(t *testing.T) {
 		},
 	}
 
-	dag, timeline, _, _, _, _, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, _, _, _, maxDepth := buildExecutionDAG(executions)
 
 	require.NotNil(t, dag)
 	require.Equal(t, rootID, dag.ExecutionID)
@@ -163,14 +243,14 @@ func TestBuildExecutionDAG_DeepHierarchy(t *testing.T) {
 func TestBuildExecutionDAG_EmptyExecutions(t *testing.T) {
 	executions := []*types.Execution{}
 
-	dag, timeline, status, workflowName, sessionID, actorID, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, status, workflowName, meta, maxDepth := buildExecutionDAG(executions)
 
 	require.Equal(t, WorkflowDAGNode{}, dag)
 	require.Empty(t, timeline)
 	require.Equal(t, "succeeded", status) // deriveOverallStatus returns "succeeded" for empty executions
 	require.Empty(t, workflowName)
-	require.Nil(t, sessionID)
-	require.Nil(t, actorID)
+	require.Nil(t, meta.SessionID)
+	require.Nil(t, meta.ActorID)
 	require.Equal(t, 0, maxDepth)
 }
 
@@ -179,14 +259,14 @@ func TestBuildExecutionDAG_NilExecutions(t *testing.T) {
 	// so we test with empty slice instead
 	executions := []*types.Execution{}
 
-	dag, timeline, status, workflowName, sessionID, actorID, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, status, workflowName, meta, maxDepth := buildExecutionDAG(executions)
 
 	require.Equal(t, WorkflowDAGNode{}, dag)
 	require.Empty(t, timeline)
 	require.Equal(t, "succeeded", status) // deriveOverallStatus returns "succeeded" for empty executions
 	require.Empty(t, workflowName)
-	require.Nil(t, sessionID)
-	require.Nil(t, actorID)
+	require.Nil(t, meta.SessionID)
+	require.Nil(t, meta.ActorID)
 	require.Equal(t, 0, maxDepth)
 }
 
@@ -256,15 +336,15 @@ func TestBuildLightweightExecutionDAG_Simple(t *testing.T) {
 		},
 	}
 
-	timeline, status, workflowName, sessionID, actorID, maxDepth := buildLightweightExecutionDAG(executions)
+	timeline, status, workflowName, meta, maxDepth := buildLightweightExecutionDAG(executions)
 
 	require.Len(t, timeline, 1)
 	require.Equal(t, "exec-1", timeline[0].ExecutionID)
 	require.Equal(t, 0, timeline[0].WorkflowDepth)
 	require.Equal(t, "succeeded", status)
 	require.Equal(t, "reasoner-1", workflowName)
-	require.Nil(t, sessionID)
-	require.Nil(t, actorID)
+	require.Nil(t, meta.SessionID)
+	require.Nil(t, meta.ActorID)
 	require.Equal(t, 0, maxDepth)
 }
 
@@ -289,7 +369,7 @@ func TestBuildLightweightExecutionDAG_WithParentChild(t *testing.T) {
 		},
 	}
 
-	timeline, status, _, _, _, maxDepth := buildLightweightExecutionDAG(executions)
+	timeline, status, _, _, maxDepth := buildLightweightExecutionDAG(executions)
 
 	require.Len(t, timeline, 2)
 	require.Equal(t, parentID, timeline[0].ExecutionID)
@@ -300,16 +380,78 @@ func TestBuildLightweightExecutionDAG_WithParentChild(t *testing.T) {
 	require.Equal(t, 1, maxDepth)
 }
 
+func TestBuildTimeline(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	childID := "exec-child"
+
+	// Input is deliberately out of order, with a nil entry.
+	executions := []*types.Execution{
+		{ExecutionID: "exec-grandchild", RunID: "run-1", Status: "running", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &childID},
+		nil,
+		{ExecutionID: rootID, RunID: "run-1", Status: "running", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", Status: "succeeded", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
+	}
+	input := append([]*types.Execution(nil), executions...)
+
+	timeline := BuildTimeline(executions)
+
+	require.Len(t, timeline, 3)
+	var ids []string
+	var depths []int
+	for _, node := range timeline {
+		ids = append(ids, node.ExecutionID)
+		depths = append(depths, node.WorkflowDepth)
+	}
+	require.Equal(t, []string{rootID, childID, "exec-grandchild"}, ids)
+	require.Equal(t, []int{0, 1, 2}, depths)
+	require.Equal(t, int64(2000), *timeline[2].StartOffsetMS)
+	require.Equal(t, input, executions, "input order is left untouched")
+
+	require.Empty(t, BuildTimeline(nil))
+}
+
+func TestRunSummary(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	childID := "exec-child"
+	loopA, loopB := "exec-loop-a", "exec-loop-b"
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", Status: "failed", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
+		nil,
+		{ExecutionID: "exec-grandchild", RunID: "run-1", Status: "running", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &childID},
+		// A parent cycle must not hang the depth walk.
+		{ExecutionID: loopA, RunID: "run-1", Status: "succeeded", StartedAt: base, ParentExecutionID: &loopB},
+		{ExecutionID: loopB, RunID: "run-1", Status: "succeeded", StartedAt: base, ParentExecutionID: &loopA},
+	}
+
+	status, maxDepth, nodeCount := RunSummary(executions)
+	require.Equal(t, string(types.ExecutionStatusFailed), status)
+	require.Equal(t, 2, maxDepth)
+	require.Equal(t, 5, nodeCount)
+
+	_, lightStatus, _, _, lightDepth := buildLightweightExecutionDAG(executions)
+	require.Equal(t, lightStatus, status)
+	require.Equal(t, lightDepth, maxDepth)
+
+	status, maxDepth, nodeCount = RunSummary([]*types.Execution{nil})
+	require.Empty(t, status)
+	require.Zero(t, maxDepth)
+	require.Zero(t, nodeCount)
+}
+
 func TestBuildLightweightExecutionDAG_EmptyExecutions(t *testing.T) {
 	executions := []*types.Execution{}
 
-	timeline, status, workflowName, sessionID, actorID, maxDepth := buildLightweightExecutionDAG(executions)
+	timeline, status, workflowName, meta, maxDepth := buildLightweightExecutionDAG(executions)
 
 	require.Empty(t, timeline)
 	require.Empty(t, status)
 	require.Empty(t, workflowName)
-	require.Nil(t, sessionID)
-	require.Nil(t, actorID)
+	require.Nil(t, meta.SessionID)
+	require.Nil(t, meta.ActorID)
 	require.Equal(t, 0, maxDepth)
 }
 
@@ -366,7 +508,7 @@ func TestBuildExecutionDAG_ComplexHierarchy(t *testing.T) {
 		},
 	}
 
-	dag, timeline, status, _, _, _, maxDepth := buildExecutionDAG(executions)
+	dag, timeline, status, _, _, maxDepth := buildExecutionDAG(executions)
 
 	require.NotNil(t, dag)
 	require.Equal(t, rootID, dag.ExecutionID)
@@ -415,11 +557,11 @@ func TestBuildExecutionDAG_MixedStatuses(t *testing.T) {
 		},
 	}
 
-	_, _, status, _, _, _, _ := buildExecutionDAG(executions)
+	_, _, status, _, _, _ := buildExecutionDAG(executions)
 
-	// deriveOverallStatus priority: running > failed > succeeded
-	// Running has highest priority as it indicates active workflow
-	require.Equal(t, "running", status)
+	// deriveOverallStatus priority: failed > running > succeeded
+	// A failure surfaces even while other executions are still active
+	require.Equal(t, "failed", status)
 }
 
 func TestBuildExecutionDAG_OrphanedChild(t *testing.T) {
@@ -434,7 +576,7 @@ func TestBuildExecutionDAG_OrphanedChild(t *testing.T) {
 		},
 	}
 
-	dag, timeline, _, _, _, _, _ := buildExecutionDAG(executions)
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
 
 	// Should still build DAG with orphaned child as root
 	require.NotNil(t, dag)
@@ -466,7 +608,7 @@ func TestBuildExecutionDAG_CycleDetection(t *testing.T) {
 	}
 
 	// Should not crash, but behavior may be undefined
-	dag, timeline, _, _, _, _, _ := buildExecutionDAG(executions)
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
 	require.NotNil(t, dag)
 	require.Len(t, timeline, 2)
 }
@@ -488,24 +630,63 @@ func TestBuildExecutionDAG_WithSessionAndActor(t *testing.T) {
 		},
 	}
 
-	_, _, _, _, sessionIDOut, actorIDOut, _ := buildExecutionDAG(executions)
+	_, _, _, _, meta, _ := buildExecutionDAG(executions)
 
-	require.NotNil(t, sessionIDOut)
-	require.Equal(t, sessionID, *sessionIDOut)
-	require.NotNil(t, actorIDOut)
-	require.Equal(t, actorID, *actorIDOut)
+	require.NotNil(t, meta.SessionID)
+	require.Equal(t, sessionID, *meta.SessionID)
+	require.NotNil(t, meta.ActorID)
+	require.Equal(t, actorID, *meta.ActorID)
+}
+
+func TestBuildExecutionDAG_RunMetadataFromChild(t *testing.T) {
+	base := time.Now()
+	rootID := "exec-root"
+	empty := ""
+	session := "session-root"
+	childActor := "actor-child"
+	laterActor := "actor-later"
+
+	executions := []*types.Execution{
+		{ExecutionID: "exec-late", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &rootID, ActorID: &laterActor},
+		{ExecutionID: "exec-child", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID, ActorID: &childActor},
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base, SessionID: &session, ActorID: &empty},
+	}
+
+	_, _, _, _, meta, _ := buildExecutionDAG(executions)
+	require.NotNil(t, meta.SessionID)
+	require.Equal(t, session, *meta.SessionID)
+	require.NotNil(t, meta.ActorID)
+	require.Equal(t, childActor, *meta.ActorID)
+
+	_, _, _, light, _ := buildLightweightExecutionDAG(executions)
+	require.Equal(t, meta, light)
 }
 
 func TestDeriveOverallStatus_PriorityOrder(t *testing.T) {
-	// Test status priority: running > failed > succeeded
+	// Test status priority: failed > running > succeeded
 	tests := []struct {
 		name     string
 		statuses []string
 		expected string
 	}{
 		{
-			name:     "running has highest priority",
+			name:     "failed has highest priority",
 			statuses: []string{"succeeded", "running", "failed"},
+			expected: "failed",
+		},
+		{
+			name:     "failed has priority over running",
+			statuses: []string{"running", "failed"},
+			expected: "failed",
+		},
+		{
+			name:     "running has priority over succeeded",
+			statuses: []string{"succeeded", "running"},
+			expected: "running",
+		},
+		{
+			name:     "running has priority over timeout and cancelled",
+			statuses: []string{"timeout", "running", "cancelled"},
 			expected: "running",
 		},
 		{
@@ -528,6 +709,46 @@ func TestDeriveOverallStatus_PriorityOrder(t *testing.T) {
 			statuses: []string{"succeeded", "pending", "succeeded"},
 			expected: "running",
 		},
+		{
+			name:     "failed has priority over timeout and cancelled",
+			statuses: []string{"cancelled", "timeout", "failed"},
+			expected: "failed",
+		},
+		{
+			name:     "timeout has priority over cancelled",
+			statuses: []string{"succeeded", "cancelled", "timeout"},
+			expected: "timeout",
+		},
+		{
+			name:     "cancelled has priority over succeeded",
+			statuses: []string{"succeeded", "canceled"},
+			expected: "cancelled",
+		},
+		{
+			name:     "running has priority over timeout",
+			statuses: []string{"timeout", "in_progress"},
+			expected: "running",
+		},
+		{
+			name:     "warnings downgrade succeeded",
+			statuses: []string{"succeeded", "succeeded_with_warnings", "succeeded"},
+			expected: "succeeded_with_warnings",
+		},
+		{
+			name:     "failed has priority over warnings",
+			statuses: []string{"succeeded_with_warnings", "failed"},
+			expected: "failed",
+		},
+		{
+			name:     "running has priority over warnings",
+			statuses: []string{"succeeded_with_warnings", "running"},
+			expected: "running",
+		},
+		{
+			name:     "cancelled has priority over warnings",
+			statuses: []string{"succeeded_with_warnings", "cancelled"},
+			expected: "cancelled",
+		},
 	}
 
 	for _, tt := range tests {
@@ -544,6 +765,18 @@ func TestDeriveOverallStatus_PriorityOrder(t *testing.T) {
 	}
 }
 
+func TestOverallStatus_MatchesDerivedStatus(t *testing.T) {
+	executions := []*types.Execution{
+		{Status: "succeeded"},
+		nil,
+		{Status: "failed"},
+	}
+
+	require.Equal(t, deriveOverallStatus(executions), OverallStatus(executions))
+	require.Equal(t, "failed", OverallStatus(executions))
+	require.Equal(t, "succeeded", OverallStatus(nil))
+}
+
 func TestBuildLightweightExecutionDAG_ComplexHierarchy(t *testing.T) {
 	rootID := "exec-root"
 	level1ID := "exec-level1"
@@ -574,7 +807,7 @@ func TestBuildLightweightExecutionDAG_ComplexHierarchy(t *testing.T) {
 		},
 	}
 
-	timeline, status, workflowName, _, _, maxDepth := buildLightweightExecutionDAG(executions)
+	timeline, status, workflowName, _, maxDepth := buildLightweightExecutionDAG(executions)
 
 	require.Len(t, timeline, 3)
 	require.Equal(t, "succeeded", status)
@@ -648,47 +881,372 @@ func TestExecutionToLightweightNode(t *testing.T) {
 	require.Equal(t, duration, *node.DurationMS)
 }
 
-func TestIsLightweightRequest(t *testing.T) {
-	// This would require gin.Context, so we'll test the logic conceptually
-	// The function checks for query params "mode=lightweight" or "lightweight=true/1"
+func TestBuildExecutionDAG_StartOffsets(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: "exec-a", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(1500 * time.Millisecond), ParentExecutionID: &rootID},
+		{ExecutionID: "exec-b", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(3 * time.Second), ParentExecutionID: &rootID},
+	}
+
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
+
+	require.Equal(t, int64(0), *dag.StartOffsetMS)
+	require.Len(t, dag.Children, 2)
+	require.Equal(t, int64(1500), *dag.Children[0].StartOffsetMS)
+	require.Equal(t, int64(3000), *dag.Children[1].StartOffsetMS)
+	require.Empty(t, dag.Diagnostics)
+
+	require.Equal(t, int64(0), *timeline[0].StartOffsetMS)
+	require.Equal(t, int64(1500), *timeline[1].StartOffsetMS)
+	require.Equal(t, int64(3000), *timeline[2].StartOffsetMS)
+
+	lightweight, _, _, _, _ := buildLightweightExecutionDAG(executions)
+	require.Equal(t, int64(0), *lightweight[0].StartOffsetMS)
+	require.Equal(t, int64(1500), *lightweight[1].StartOffsetMS)
+	require.Equal(t, int64(3000), *lightweight[2].StartOffsetMS)
 }
 
-func TestNewExecutionGraphService(t *testing.T) {
-	provider, ctx := setupTestStorage(t)
+func TestBuildExecutionDAG_MixedTimeZones(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	local := time.FixedZone("CEST", 2*60*60)
+	rootID := "exec-root"
+	// Recorded in local time: 14:00:05+02:00 is 12:00:05Z
+	childStart := time.Date(2025, 1, 1, 14, 0, 5, 0, local)
+	childDone := childStart.Add(2 * time.Second)
+	// Completion recorded before the start
+	skewedDone := base.Add(time.Second)
 
-	svc := newExecutionGraphService(provider)
-	require.NotNil(t, svc)
-	require.NotNil(t, svc.store)
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: "exec-local", RunID: "run-1", Status: "succeeded", StartedAt: childStart, CompletedAt: &childDone, ParentExecutionID: &rootID},
+		{ExecutionID: "exec-skewed", RunID: "run-1", Status: "succeeded", StartedAt: base.Add(3 * time.Second), CompletedAt: &skewedDone, ParentExecutionID: &rootID},
+	}
+
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
+	require.Empty(t, dag.Diagnostics)
+	require.Len(t, dag.Children, 2)
 
-	_ = ctx
+	localNode := dag.Children[0]
+	require.Equal(t, "2025-01-01T12:00:05Z", localNode.StartedAt)
+	require.Equal(t, "2025-01-01T12:00:07Z", *localNode.CompletedAt)
+	require.Equal(t, int64(5000), *localNode.StartOffsetMS)
+	require.Empty(t, localNode.Diagnostics)
+
+	wantSkew := []DAGDiagnostic{{
+		Code:    DAGDiagnosticClockSkew,
+		Message: "execution exec-skewed completes 2000ms before it starts",
+	}}
+	require.Equal(t, wantSkew, dag.Children[1].Diagnostics)
+
+	// The timeline orders by absolute start time, not by wall-clock digits
+	require.Equal(t, "exec-skewed", timeline[1].ExecutionID)
+	require.Equal(t, int64(3000), *timeline[1].StartOffsetMS)
+	require.Equal(t, wantSkew, timeline[1].Diagnostics)
+	require.Equal(t, int64(5000), *timeline[2].StartOffsetMS)
+
+	lightweight, _, _, _, _ := buildLightweightExecutionDAG(executions)
+	require.Equal(t, "2025-01-01T12:00:05Z", lightweight[2].StartedAt)
+	require.Equal(t, int64(5000), *lightweight[2].StartOffsetMS)
+	require.Equal(t, wantSkew, lightweight[1].Diagnostics)
+
+	wallClock, _ := WallClockDuration(dag)
+	require.Equal(t, int64(7000), wallClock)
 }
 
-// Helper function from other test files
-func setupTestStorage(t *testing.T) (storage.StorageProvider, context.Context) {
-	t.Helper()
+func TestBuildExecutionDAG_StartOffsetMissingStart(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
 
-	ctx := context.Background()
-	tempDir := t.TempDir()
-	cfg := storage.StorageConfig{
-		Mode: "local",
-		Local: storage.LocalStorageConfig{
-			DatabasePath: tempDir + "/test.db",
-			KVStorePath:  tempDir + "/test.bolt",
-		},
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base},
+		{ExecutionID: "exec-unknown", RunID: "run-1", Status: "succeeded", ParentExecutionID: &rootID},
+	}
+
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+
+	require.Len(t, dag.Children, 1)
+	child := dag.Children[0]
+	require.Nil(t, child.StartOffsetMS)
+	require.Equal(t, []DAGDiagnostic{{
+		Code:    DAGDiagnosticMissingStart,
+		Message: "execution exec-unknown has no start time; offset omitted",
+	}}, child.Diagnostics)
+	require.Empty(t, dag.Diagnostics)
+}
+
+func TestBuildExecutionDAG_QueueWait(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	enqueued := base.Add(-1500 * time.Millisecond)
+	rootID := "exec-root"
+
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", EnqueuedAt: &enqueued, StartedAt: base},
+		{ExecutionID: "exec-child", RunID: "run-1", Status: "succeeded", ParentExecutionID: &rootID, StartedAt: base.Add(time.Second)},
+	}
+
+	dag, _, _, _, _, _ := buildExecutionDAG(executions)
+
+	require.NotNil(t, dag.QueueWaitMS)
+	require.Equal(t, int64(1500), *dag.QueueWaitMS)
+	require.Len(t, dag.Children, 1)
+	require.Nil(t, dag.Children[0].QueueWaitMS)
+
+	executions[0].EnqueuedAt = nil
+	dag, _, _, _, _, _ = buildExecutionDAG(executions)
+	require.Nil(t, dag.QueueWaitMS)
+}
+
+func TestExecutionToDAGNode_PayloadSizes(t *testing.T) {
+	uri := "payloads/exec-1/input.json"
+	node := executionToDAGNode(&types.Execution{
+		ExecutionID:   "exec-1",
+		InputPayload:  json.RawMessage(`{"prompt":"hi"}`),
+		ResultPayload: json.RawMessage(`{}`),
+		StartedAt:     time.Now(),
+	}, 0)
+	require.NotNil(t, node.InputBytes)
+	require.Equal(t, int64(15), *node.InputBytes)
+	require.NotNil(t, node.OutputBytes)
+	require.Equal(t, int64(2), *node.OutputBytes)
+
+	// Sizes are unknown for offloaded or not-yet-produced payloads.
+	node = executionToDAGNode(&types.Execution{ExecutionID: "exec-2", InputURI: &uri, StartedAt: time.Now()}, 0)
+	require.Nil(t, node.InputBytes)
+	require.Nil(t, node.OutputBytes)
+
+	// A recorded empty payload is known to be zero bytes.
+	node = executionToDAGNode(&types.Execution{
+		ExecutionID:   "exec-3",
+		InputPayload:  json.RawMessage{},
+		ResultPayload: json.RawMessage{},
+		StartedAt:     time.Now(),
+	}, 0)
+	require.NotNil(t, node.InputBytes)
+	require.Equal(t, int64(0), *node.InputBytes)
+	require.NotNil(t, node.OutputBytes)
+	require.Equal(t, int64(0), *node.OutputBytes)
+}
+
+func TestStartOffset_ClampsClockSkew(t *testing.T) {
+	runStart := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	exec := &types.Execution{ExecutionID: "exec-early", StartedAt: runStart.Add(-250 * time.Millisecond)}
+
+	offset, diagnostics := startOffset(exec, runStart, nil)
+
+	require.NotNil(t, offset)
+	require.Equal(t, int64(0), *offset)
+	require.Equal(t, []DAGDiagnostic{{
+		Code:    DAGDiagnosticClockSkew,
+		Message: "execution exec-early starts 250ms before the run start; offset clamped to 0",
+	}}, diagnostics)
+}
+
+func TestGetExecutionChildren_OmitsStartOffset(t *testing.T) {
+	node := executionToDAGNode(&types.Execution{ExecutionID: "exec-child", StartedAt: time.Now()}, 0)
+
+	encoded, err := json.Marshal(node)
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "start_offset_ms")
+}
+
+func TestBuildExecutionDAG_ReasonerNameResolver(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+	executions := []*types.Execution{
+		{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", ReasonerID: "summarize", StartedAt: base},
+		{ExecutionID: "exec-child", RunID: "run-1", Status: "succeeded", ReasonerID: "unknown", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
 	}
 
-	provider := storage.NewLocalStorage(storage.LocalStorageConfig{})
-	err := provider.Initialize(ctx, cfg)
-	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
-			t.Skip("sqlite3 compiled without FTS5; skipping test")
+	// Without a resolver the field stays empty.
+	dag, timeline, _, _, _, _ := buildExecutionDAG(executions)
+	require.Empty(t, dag.ReasonerName)
+	require.Empty(t, timeline[0].ReasonerName)
+
+	names := map[string]string{"summarize": "Summarize Document"}
+	opts := DAGOptions{ResolveReasonerName: func(reasonerID string) string { return names[reasonerID] }}
+
+	dag, timeline, _, _, _, _ = buildExecutionDAGWithOptions(executions, opts)
+	require.Equal(t, "Summarize Document", dag.ReasonerName)
+	require.Empty(t, dag.Children[0].ReasonerName)
+	require.Equal(t, "Summarize Document", timeline[0].ReasonerName)
+	require.Equal(t, "summarize", timeline[0].ReasonerID)
+
+	lightweight, _, _, _, _ := buildLightweightExecutionDAGWithOptions(executions, opts)
+	require.Equal(t, "Summarize Document", lightweight[0].ReasonerName)
+	require.Empty(t, lightweight[1].ReasonerName)
+}
+
+func TestBuildExecutionDAG_DepthLimit(t *testing.T) {
+	const chainLength = 2000
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	executions := make([]*types.Execution, 0, chainLength)
+	for i := 0; i < chainLength; i++ {
+		exec := &types.Execution{
+			ExecutionID: fmt.Sprintf("exec-%d", i),
+			RunID:       "run-deep",
+			Status:      "succeeded",
+			StartedAt:   base.Add(time.Duration(i) * time.Millisecond),
 		}
-		require.NoError(t, err)
+		if i > 0 {
+			parent := fmt.Sprintf("exec-%d", i-1)
+			exec.ParentExecutionID = &parent
+		}
+		executions = append(executions, exec)
 	}
 
-	t.Cleanup(func() {
-		_ = provider.Close(ctx)
+	var dag WorkflowDAGNode
+	var timeline []WorkflowDAGNode
+	require.NotPanics(t, func() {
+		dag, timeline, _, _, _, _ = buildExecutionDAG(executions)
 	})
+	require.Len(t, timeline, chainLength)
+
+	node := dag
+	for depth := 0; depth < DefaultMaxDAGDepth; depth++ {
+		require.Len(t, node.Children, 1, "depth %d", depth)
+		node = node.Children[0]
+	}
+	require.Equal(t, DefaultMaxDAGDepth, node.WorkflowDepth)
+	require.Empty(t, node.Children)
+	require.Len(t, node.Diagnostics, 1)
+	require.Equal(t, DAGDiagnosticDepthLimit, node.Diagnostics[0].Code)
+
+	dag, _, _, _, _, _ = buildExecutionDAGWithOptions(executions[:5], DAGOptions{MaxDepth: 2})
+	require.Len(t, dag.Children, 1)
+	require.Len(t, dag.Children[0].Children, 1)
+	limited := dag.Children[0].Children[0]
+	require.Empty(t, limited.Children)
+	require.Equal(t, []DAGDiagnostic{{
+		Code:    DAGDiagnosticDepthLimit,
+		Message: "execution exec-2 has 1 children beyond the maximum depth of 2; subtree omitted",
+	}}, limited.Diagnostics)
+}
+
+func TestExecutionGraphServiceBuildResponse(t *testing.T) {
+	store := newTestExecutionStorage(nil)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+
+	require.NoError(t, store.CreateExecutionRecord(ctx, &types.Execution{ExecutionID: rootID, RunID: "run-1", Status: "succeeded", StartedAt: base}))
+	require.NoError(t, store.CreateExecutionRecord(ctx, &types.Execution{ExecutionID: "exec-child", RunID: "run-1", Status: "failed", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID}))
+
+	svc := &ExecutionGraphService{store: store}
+
+	full, err := svc.BuildResponse(ctx, " run-1 ", DAGOptions{})
+	require.NoError(t, err)
+	require.Nil(t, full.Lightweight)
+	require.NotNil(t, full.Full)
+	require.Equal(t, "run-1", full.Full.RootWorkflowID)
+	require.Equal(t, "failed", full.Full.WorkflowStatus)
+	require.Equal(t, 2, full.Full.TotalNodes)
+	require.Equal(t, rootID, full.Full.DAG.ExecutionID)
+	require.Len(t, full.Full.DAG.Children, 1)
+	require.Same(t, full.Full, full.Body())
+
+	light, err := svc.BuildResponse(ctx, "run-1", DAGOptions{Lightweight: true})
+	require.NoError(t, err)
+	require.Nil(t, light.Full)
+	require.NotNil(t, light.Lightweight)
+	require.Equal(t, "lightweight", light.Lightweight.Mode)
+	require.Len(t, light.Lightweight.Timeline, 2)
+	require.Same(t, light.Lightweight, light.Body())
+
+	_, err = svc.BuildResponse(ctx, "", DAGOptions{})
+	require.ErrorIs(t, err, ErrWorkflowIDRequired)
+
+	_, err = svc.BuildResponse(ctx, "run-missing", DAGOptions{})
+	require.ErrorIs(t, err, ErrWorkflowNotFound)
+}
+
+func TestBuildExecutionDAGNeverMixesTenants(t *testing.T) {
+	base := time.Now()
+	rootID := "exec-root"
+	executions := []*types.Execution{
+		{ExecutionID: "exec-foreign", RunID: "run-1", TenantID: "globex", Status: "failed", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID},
+		{ExecutionID: rootID, RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base},
+		{ExecutionID: "exec-child", RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &rootID},
+	}
 
-	return provider, ctx
+	// Without an explicit tenant the root execution's tenant wins.
+	dag, timeline, status, _, _, _ := buildExecutionDAG(executions)
+	require.Equal(t, rootID, dag.ExecutionID)
+	require.Len(t, dag.Children, 1)
+	require.Equal(t, "exec-child", dag.Children[0].ExecutionID)
+	require.Len(t, timeline, 2)
+	require.Equal(t, "succeeded", status)
+
+	light, _, _, _, _ := buildLightweightExecutionDAGWithOptions(executions, DAGOptions{TenantID: "globex"})
+	require.Len(t, light, 1)
+	require.Equal(t, "exec-foreign", light[0].ExecutionID)
+
+	// Executions without a tenant belong to the default one.
+	legacy := []*types.Execution{{ExecutionID: "exec-legacy", RunID: "run-2", StartedAt: base}}
+	light, _, _, _, _ = buildLightweightExecutionDAGWithOptions(legacy, DAGOptions{TenantID: types.DefaultTenantID})
+	require.Len(t, light, 1)
+}
+
+func TestRunSummaryNeverMixesTenants(t *testing.T) {
+	base := time.Now()
+	rootID := "exec-root"
+	childID := "exec-child"
+	executions := []*types.Execution{
+		{ExecutionID: "exec-foreign", RunID: "run-1", TenantID: "globex", Status: "failed", StartedAt: base.Add(time.Second), ParentExecutionID: &childID},
+		{ExecutionID: rootID, RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base},
+		{ExecutionID: childID, RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &rootID},
+	}
+
+	status, maxDepth, nodeCount := RunSummary(executions)
+	require.Equal(t, "succeeded", status)
+	require.Equal(t, 1, maxDepth)
+	require.Equal(t, 2, nodeCount)
+
+	_, lightStatus, _, _, lightDepth := buildLightweightExecutionDAG(executions)
+	require.Equal(t, lightStatus, status)
+	require.Equal(t, lightDepth, maxDepth)
+}
+
+func TestExecutionGraphServiceBuildResponseScopesTenant(t *testing.T) {
+	// The test store ignores ExecutionFilter.TenantID, standing in for a
+	// query that leaks rows from other tenants.
+	store := newTestExecutionStorage(nil)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootID := "exec-root"
+
+	require.NoError(t, store.CreateExecutionRecord(ctx, &types.Execution{ExecutionID: rootID, RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base}))
+	require.NoError(t, store.CreateExecutionRecord(ctx, &types.Execution{ExecutionID: "exec-child", RunID: "run-1", TenantID: "acme", Status: "succeeded", StartedAt: base.Add(time.Second), ParentExecutionID: &rootID}))
+	require.NoError(t, store.CreateExecutionRecord(ctx, &types.Execution{ExecutionID: "exec-foreign", RunID: "run-1", TenantID: "globex", Status: "failed", StartedAt: base.Add(2 * time.Second), ParentExecutionID: &rootID}))
+
+	svc := &ExecutionGraphService{store: store}
+
+	full, err := svc.BuildResponse(ctx, "run-1", DAGOptions{TenantID: "acme"})
+	require.NoError(t, err)
+	require.Equal(t, 2, full.Full.TotalNodes)
+	require.Equal(t, "succeeded", full.Full.WorkflowStatus)
+	require.Len(t, full.Full.DAG.Children, 1)
+	require.Equal(t, "exec-child", full.Full.DAG.Children[0].ExecutionID)
+
+	light, err := svc.BuildResponse(ctx, "run-1", DAGOptions{TenantID: "globex", Lightweight: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, light.Lightweight.TotalNodes)
+	require.Equal(t, "exec-foreign", light.Lightweight.Timeline[0].ExecutionID)
+
+	_, err = svc.BuildResponse(ctx, "run-1", DAGOptions{TenantID: types.DefaultTenantID})
+	require.ErrorIs(t, err, ErrWorkflowNotFound)
+}
+
+func TestIsLightweightRequest(t *testing.T) {
+	// This would require gin.Context, so we'll test the logic conceptually
+	// The function checks for query params "mode=lightweight" or "lightweight=true/1"
+}
+
+func TestNewExecutionGraphService(t *testing.T) {
+	svc := newExecutionGraphService(storagetest.NewMock())
+	require.NotNil(t, svc)
+	require.NotNil(t, svc.store)
 }
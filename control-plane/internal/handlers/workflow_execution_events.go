@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Agent-Field/agentfield/control-plane/internal/server/middleware"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 	"github.com/gin-gonic/gin"
 )
@@ -50,7 +51,9 @@ func WorkflowExecutionEventHandler(store ExecutionStore) gin.HandlerFunc {
 		}
 
 		if existing == nil {
-			if err := store.CreateExecutionRecord(ctx, buildExecutionRecordFromEvent(&req, now)); err != nil {
+			record := buildExecutionRecordFromEvent(&req, now)
+			record.TenantID = middleware.TenantID(c)
+			if err := store.CreateExecutionRecord(ctx, record); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create execution: %v", err)})
 				return
 			}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
-	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -33,7 +32,7 @@ func setupTestStorage(t *testing.T, ctx context.Context) storage.StorageProvider
 
 	provider := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	if err := provider.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, storage.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5 support")
 		}
 		require.NoError(t, err)
@@ -2,6 +2,7 @@
 package storage
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -42,6 +43,22 @@ func (s *LocalConfigStorage) LoadAgentFieldConfig(path string) (*domain.AgentFie
 	return &config, nil
 }
 
+// LoadAgentFieldConfigStrict loads the config like LoadAgentFieldConfig and
+// additionally validates the MCP server entries, so misconfigured servers fail
+// at load time instead of silently never starting.
+func (s *LocalConfigStorage) LoadAgentFieldConfigStrict(path string) (*domain.AgentFieldConfig, error) {
+	config, err := s.LoadAgentFieldConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.MCP.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
 func (s *LocalConfigStorage) SaveAgentFieldConfig(path string, config *domain.AgentFieldConfig) error {
 	data, err := yaml.Marshal(config)
 	if err != nil {
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/core/domain"
+)
+
+func TestLoadAgentFieldConfigStrict(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		wantIndex int
+		wantField string
+	}{
+		{
+			name: "valid stdio and http servers",
+			yaml: `mcp:
+  servers:
+    - name: files
+      command: npx @mcp/files
+      enabled: true
+    - name: search
+      url: https://mcp.example.com
+      enabled: true
+`,
+			wantIndex: -1,
+		},
+		{
+			name: "missing name",
+			yaml: `mcp:
+  servers:
+    - name: files
+      command: npx @mcp/files
+    - url: https://mcp.example.com
+`,
+			wantIndex: 1,
+			wantField: "name",
+		},
+		{
+			name: "stdio without command",
+			yaml: `mcp:
+  servers:
+    - name: files
+      transport: stdio
+      url: https://mcp.example.com
+`,
+			wantIndex: 0,
+			wantField: "command",
+		},
+		{
+			name: "http without url",
+			yaml: `mcp:
+  servers:
+    - name: search
+      transport: http
+`,
+			wantIndex: 0,
+			wantField: "url",
+		},
+		{
+			name: "unknown transport",
+			yaml: `mcp:
+  servers:
+    - name: search
+      transport: carrier-pigeon
+      url: https://mcp.example.com
+`,
+			wantIndex: 0,
+			wantField: "transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agentfield.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			store := NewLocalConfigStorage(NewFileSystemAdapter())
+
+			// The non-strict loader never validates.
+			if _, err := store.LoadAgentFieldConfig(path); err != nil {
+				t.Fatalf("LoadAgentFieldConfig: %v", err)
+			}
+
+			config, err := store.LoadAgentFieldConfigStrict(path)
+			if tt.wantIndex < 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(config.MCP.Servers) != 2 {
+					t.Fatalf("expected 2 servers, got %d", len(config.MCP.Servers))
+				}
+				return
+			}
+
+			var validationErr *domain.MCPServerValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected MCPServerValidationError, got %v", err)
+			}
+			if validationErr.Index != tt.wantIndex || validationErr.Field != tt.wantField {
+				t.Fatalf("expected servers[%d].%s, got servers[%d].%s", tt.wantIndex, tt.wantField, validationErr.Index, validationErr.Field)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/core/domain"
 	"github.com/Agent-Field/agentfield/control-plane/internal/core/interfaces"
+
+	"gopkg.in/yaml.v3"
 )
 
 type LocalRegistryStorage struct {
@@ -22,9 +27,22 @@ func NewLocalRegistryStorage(fs interfaces.FileSystemAdapter, path string) inter
 	}
 }
 
+// registryMigrations decodes a registry file of the keyed version into the
+// current shape. Add an entry whenever CurrentRegistryVersion is bumped.
+var registryMigrations = map[int]func(data []byte) (*domain.InstallationRegistry, error){
+	0: func(data []byte) (*domain.InstallationRegistry, error) {
+		return decodeLegacyRegistry(data, json.Unmarshal)
+	},
+}
+
+// LoadRegistry reads the registry at the store path. JSON files older than
+// domain.CurrentRegistryVersion are migrated and rewritten in place. YAML
+// files (installed.yaml) are converted in memory only: the package installer
+// owns them and keeps fields the domain shape does not carry.
 func (s *LocalRegistryStorage) LoadRegistry() (*domain.InstallationRegistry, error) {
 	if !s.fs.Exists(s.storePath) {
 		return &domain.InstallationRegistry{
+			Version:   domain.CurrentRegistryVersion,
 			Installed: make(map[string]domain.InstalledPackage),
 		}, nil
 	}
@@ -34,15 +52,53 @@ func (s *LocalRegistryStorage) LoadRegistry() (*domain.InstallationRegistry, err
 		return nil, err
 	}
 
-	var registry domain.InstallationRegistry
-	if err := json.Unmarshal(data, &registry); err != nil {
+	if !isJSONObject(data) {
+		registry, err := decodeLegacyRegistry(data, yaml.Unmarshal)
+		if err != nil {
+			return nil, fmt.Errorf("parse registry %s: %w", s.storePath, err)
+		}
+		return registry, nil
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
 		return nil, err
 	}
 
-	return &registry, nil
+	if header.Version == domain.CurrentRegistryVersion {
+		var registry domain.InstallationRegistry
+		if err := json.Unmarshal(data, &registry); err != nil {
+			return nil, err
+		}
+		if registry.Installed == nil {
+			registry.Installed = make(map[string]domain.InstalledPackage)
+		}
+		return &registry, nil
+	}
+
+	migrate, ok := registryMigrations[header.Version]
+	if !ok {
+		return nil, fmt.Errorf("registry %s has unsupported version %d (this build supports up to %d)", s.storePath, header.Version, domain.CurrentRegistryVersion)
+	}
+	registry, err := migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("migrate registry %s from version %d: %w", s.storePath, header.Version, err)
+	}
+
+	migrated, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(s.storePath, migrated); err != nil {
+		return nil, fmt.Errorf("rewrite migrated registry %s: %w", s.storePath, err)
+	}
+	return registry, nil
 }
 
 func (s *LocalRegistryStorage) SaveRegistry(registry *domain.InstallationRegistry) error {
+	registry.Version = domain.CurrentRegistryVersion
 	data, err := json.MarshalIndent(registry, "", "  ")
 	if err != nil {
 		return err
@@ -78,3 +134,82 @@ func (s *LocalRegistryStorage) SavePackage(name string, pkg *domain.InstalledPac
 	registry.Installed[name] = *pkg
 	return s.SaveRegistry(registry)
 }
+
+// legacyRegistry accepts both the unversioned installed.json layout and the
+// installed.yaml layout written by the package installer. Timestamps are
+// kept as text because installed.yaml stores them as strings.
+type legacyRegistry struct {
+	Installed map[string]legacyInstalledPackage `json:"installed" yaml:"installed"`
+}
+
+type legacyInstalledPackage struct {
+	Name        string            `json:"name" yaml:"name"`
+	Version     string            `json:"version" yaml:"version"`
+	Path        string            `json:"path" yaml:"path"`
+	Environment map[string]string `json:"environment" yaml:"environment"`
+	InstalledAt string            `json:"installed_at" yaml:"installed_at"`
+}
+
+func decodeLegacyRegistry(data []byte, unmarshal func([]byte, interface{}) error) (*domain.InstallationRegistry, error) {
+	var legacy legacyRegistry
+	if err := unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	registry := &domain.InstallationRegistry{
+		Version:   domain.CurrentRegistryVersion,
+		Installed: make(map[string]domain.InstalledPackage, len(legacy.Installed)),
+	}
+	for key, pkg := range legacy.Installed {
+		name := pkg.Name
+		if name == "" {
+			name = key
+		}
+		// An unparseable timestamp is left zero rather than failing the
+		// whole registry.
+		installedAt, _ := time.Parse(time.RFC3339, pkg.InstalledAt)
+		registry.Installed[key] = domain.InstalledPackage{
+			Name:        name,
+			Version:     pkg.Version,
+			Path:        pkg.Path,
+			Environment: pkg.Environment,
+			InstalledAt: installedAt,
+		}
+	}
+	return registry, nil
+}
+
+func isJSONObject(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, so a crash mid-write never leaves a truncated registry.
+func writeFileAtomic(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpFile.Close()
+		if err != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}()
+
+	if _, err = tmpFile.Write(data); err != nil {
+		return err
+	}
+	if err = tmpFile.Chmod(0644); err != nil {
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
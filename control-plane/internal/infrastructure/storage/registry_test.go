@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/core/domain"
+)
+
+func TestLoadRegistry_MigratesUnversionedJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	legacy := `{"installed":{"mypkg":{"name":"mypkg","version":"1.2.0","path":"/pkgs/mypkg","environment":{"A":"1"},"installed_at":"2024-05-01T10:00:00Z"}}}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewLocalRegistryStorage(NewFileSystemAdapter(), path).LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	want := domain.InstalledPackage{
+		Name:        "mypkg",
+		Version:     "1.2.0",
+		Path:        "/pkgs/mypkg",
+		Environment: map[string]string{"A": "1"},
+		InstalledAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	assertInstalledPackage(t, registry, "mypkg", want)
+
+	// The file is rewritten in the current format, with no temp files left.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rewritten domain.InstallationRegistry
+	if err := json.Unmarshal(data, &rewritten); err != nil {
+		t.Fatalf("rewritten registry is not valid JSON: %v", err)
+	}
+	if rewritten.Version != domain.CurrentRegistryVersion {
+		t.Fatalf("rewritten version = %d, want %d", rewritten.Version, domain.CurrentRegistryVersion)
+	}
+	assertInstalledPackage(t, &rewritten, "mypkg", want)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only installed.json after migration, found %d entries", len(entries))
+	}
+}
+
+func TestLoadRegistry_ReadsInstalledYAMLWithoutRewriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.yaml")
+	installed := "installed:\n  test-package:\n    name: Test Package\n    version: \"1.0.0\"\n    description: Test description\n    path: /pkgs/test\n    source: local\n    installed_at: \"2024-05-01T10:00:00Z\"\n"
+	if err := os.WriteFile(path, []byte(installed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewLocalRegistryStorage(NewFileSystemAdapter(), path).LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	assertInstalledPackage(t, registry, "test-package", domain.InstalledPackage{
+		Name:        "Test Package",
+		Version:     "1.0.0",
+		Path:        "/pkgs/test",
+		InstalledAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != installed {
+		t.Fatalf("installed.yaml was rewritten:\n%s", data)
+	}
+}
+
+func TestLoadRegistry_CurrentAndNewerVersions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "installed.json")
+	store := NewLocalRegistryStorage(NewFileSystemAdapter(), path)
+
+	pkg := domain.InstalledPackage{Name: "mypkg", Version: "2.0.0", Path: "/pkgs/mypkg"}
+	if err := store.SavePackage("mypkg", &pkg); err != nil {
+		t.Fatalf("SavePackage: %v", err)
+	}
+	registry, err := store.LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	if registry.Version != domain.CurrentRegistryVersion {
+		t.Fatalf("version = %d, want %d", registry.Version, domain.CurrentRegistryVersion)
+	}
+	assertInstalledPackage(t, registry, "mypkg", pkg)
+
+	newer := `{"version":99,"installed":{}}`
+	if err := os.WriteFile(path, []byte(newer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.LoadRegistry(); err == nil {
+		t.Fatal("expected an error for a registry newer than this build")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != newer {
+		t.Fatal("a newer registry must not be rewritten")
+	}
+}
+
+func assertInstalledPackage(t *testing.T, registry *domain.InstallationRegistry, key string, want domain.InstalledPackage) {
+	t.Helper()
+	got, ok := registry.Installed[key]
+	if !ok {
+		t.Fatalf("package %q missing from registry", key)
+	}
+	if got.Name != want.Name || got.Version != want.Version || got.Path != want.Path || !got.InstalledAt.Equal(want.InstalledAt) {
+		t.Fatalf("package %q = %+v, want %+v", key, got, want)
+	}
+	if len(got.Environment) != len(want.Environment) {
+		t.Fatalf("package %q environment = %v, want %v", key, got.Environment, want.Environment)
+	}
+	for k, v := range want.Environment {
+		if got.Environment[k] != v {
+			t.Fatalf("package %q environment = %v, want %v", key, got.Environment, want.Environment)
+		}
+	}
+}
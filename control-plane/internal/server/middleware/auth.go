@@ -4,13 +4,31 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 	"github.com/gin-gonic/gin"
 )
 
+// tenantContextKey is the gin context key holding the authenticated tenant.
+const tenantContextKey = "agentfield.tenant_id"
+
 // AuthConfig mirrors server configuration for HTTP authentication.
 type AuthConfig struct {
-	APIKey    string
-	SkipPaths []string
+	APIKey string
+	// TenantKeys maps a tenant ID to an API key that authenticates as that
+	// tenant only.
+	TenantKeys map[string]string
+	SkipPaths  []string
+}
+
+// TenantID returns the tenant of the request. A request authenticated with a
+// tenant key belongs to that key's tenant; otherwise, including when auth is
+// disabled, the X-Tenant-ID header is used. The result is normalized, so an
+// unscoped request reports types.DefaultTenantID.
+func TenantID(c *gin.Context) string {
+	if tenantID := c.GetString(tenantContextKey); tenantID != "" {
+		return tenantID
+	}
+	return types.NormalizeTenantID(c.GetHeader(types.TenantIDHeader))
 }
 
 // APIKeyAuth enforces API key authentication via header, bearer token, or query param.
@@ -20,9 +38,16 @@ func APIKeyAuth(config AuthConfig) gin.HandlerFunc {
 		skipPathSet[p] = struct{}{}
 	}
 
+	tenantByKey := make(map[string]string, len(config.TenantKeys))
+	for tenantID, key := range config.TenantKeys {
+		if key != "" {
+			tenantByKey[key] = types.NormalizeTenantID(tenantID)
+		}
+	}
+
 	return func(c *gin.Context) {
 		// No auth configured, allow everything.
-		if config.APIKey == "" {
+		if config.APIKey == "" && len(tenantByKey) == 0 {
 			c.Next()
 			return
 		}
@@ -33,8 +58,9 @@ func APIKeyAuth(config AuthConfig) gin.HandlerFunc {
 			return
 		}
 
-		// Always allow health and metrics by default
-		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/health") || c.Request.URL.Path == "/health" || c.Request.URL.Path == "/metrics" {
+		// Always allow health, readiness and metrics by default
+		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/health") || c.Request.URL.Path == "/health" ||
+			c.Request.URL.Path == "/api/v1/ready" || c.Request.URL.Path == "/ready" || c.Request.URL.Path == "/metrics" {
 			c.Next()
 			return
 		}
@@ -64,7 +90,14 @@ func APIKeyAuth(config AuthConfig) gin.HandlerFunc {
 			apiKey = c.Query("api_key")
 		}
 
-		if apiKey != config.APIKey {
+		// The global key has operator scope and may act for any tenant.
+		if config.APIKey != "" && apiKey == config.APIKey {
+			c.Next()
+			return
+		}
+
+		tenantID, ok := tenantByKey[apiKey]
+		if !ok || apiKey == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error":   "unauthorized",
 				"message": "invalid or missing API key",
@@ -72,6 +105,16 @@ func APIKeyAuth(config AuthConfig) gin.HandlerFunc {
 			return
 		}
 
+		// A tenant key pins the tenant; it cannot be switched with the header.
+		if header := c.GetHeader(types.TenantIDHeader); header != "" && types.NormalizeTenantID(header) != tenantID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "API key is not valid for the requested tenant",
+			})
+			return
+		}
+		c.Set(tenantContextKey, tenantID)
+
 		c.Next()
 	}
 }
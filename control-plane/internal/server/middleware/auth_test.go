@@ -199,6 +199,20 @@ func TestAPIKeyAuth_SkipRootHealthEndpoint(t *testing.T) {
 	assert.Equal(t, "healthy", resp["status"])
 }
 
+func TestAPIKeyAuth_SkipReadinessEndpoint(t *testing.T) {
+	router := gin.New()
+	router.Use(APIKeyAuth(AuthConfig{APIKey: "secret-key"}))
+	router.GET("/ready", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/ready", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/ready", "/api/v1/ready"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "path %s should be accessible", path)
+	}
+}
+
 func TestAPIKeyAuth_SkipUIPath(t *testing.T) {
 	router := setupRouter(AuthConfig{APIKey: "secret-key"})
 
@@ -304,3 +318,62 @@ func TestAPIKeyAuth_MultipleSkipPaths(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAPIKeyAuth_TenantKeys(t *testing.T) {
+	router := gin.New()
+	router.Use(APIKeyAuth(AuthConfig{
+		APIKey:     "operator-key",
+		TenantKeys: map[string]string{"acme": "acme-key", "globex": "globex-key"},
+	}))
+	router.GET("/api/v1/tenant", func(c *gin.Context) { c.String(http.StatusOK, TenantID(c)) })
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		tenant     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"tenant key pins its tenant", "acme-key", "", http.StatusOK, "acme"},
+		{"matching header is allowed", "acme-key", "acme", http.StatusOK, "acme"},
+		{"other tenant is forbidden", "acme-key", "globex", http.StatusForbidden, ""},
+		{"operator key honors header", "operator-key", "globex", http.StatusOK, "globex"},
+		{"operator key defaults tenant", "operator-key", "", http.StatusOK, "default"},
+		{"unknown key is rejected", "other-key", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantTenant, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_TenantKeysWithoutGlobalKey(t *testing.T) {
+	router := gin.New()
+	router.Use(APIKeyAuth(AuthConfig{TenantKeys: map[string]string{"acme": "acme-key"}}))
+	router.GET("/api/v1/tenant", func(c *gin.Context) { c.String(http.StatusOK, TenantID(c)) })
+
+	// Tenant keys alone still enable auth
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+	req.Header.Set("Authorization", "Bearer acme-key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", w.Body.String())
+}
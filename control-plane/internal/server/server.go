@@ -2,8 +2,6 @@ package server
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
@@ -12,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/config"
@@ -56,6 +55,11 @@ type AgentFieldServer struct {
 	config                *config.Config
 	storageHealthOverride func(context.Context) gin.H
 	cacheHealthOverride   func(context.Context) gin.H
+	didHealthOverride     func(context.Context) gin.H
+	// didHealthMu guards the cached DID self-test result served by /ready.
+	didHealthMu        sync.Mutex
+	didHealthCached    gin.H
+	didHealthCheckedAt time.Time
 	// DID Services
 	keystoreService *services.KeystoreService
 	didService      *services.DIDService
@@ -199,7 +203,7 @@ func NewAgentFieldServer(cfg *config.Config) (*AgentFieldServer, error) {
 		}
 
 		// Generate af server ID based on agentfield home directory
-		agentfieldServerID := generateAgentFieldServerID(agentfieldHome)
+		agentfieldServerID := services.GenerateAgentFieldServerID(agentfieldHome, cfg.Features.DID.ServerSalt)
 
 		// Initialize af server DID with dynamic ID
 		fmt.Printf("🧠 Initializing af server DID (ID: %s)...\n", agentfieldServerID)
@@ -539,6 +543,73 @@ func (s *AgentFieldServer) healthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, healthStatus)
 }
 
+// didSelfTestTTL is how long readinessCheckHandler reuses a DID self-test
+// result. The self-test writes keystore and registry rows, so probes must not
+// run it on every request.
+const didSelfTestTTL = 30 * time.Second
+
+// readinessCheckHandler reports whether the server can serve traffic. Unlike
+// healthCheckHandler it exercises the DID subsystem end to end, so it is kept
+// off /health, which load balancers poll and which must stay read-only.
+func (s *AgentFieldServer) readinessCheckHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	readiness := gin.H{
+		"status":    "ready",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"checks":    gin.H{},
+	}
+
+	allReady := true
+	checks := readiness["checks"].(gin.H)
+
+	if s.storage != nil || s.storageHealthOverride != nil {
+		storageHealth := s.checkStorageHealth(ctx)
+		checks["storage"] = storageHealth
+		if storageHealth["status"] != "healthy" {
+			allReady = false
+		}
+	} else {
+		checks["storage"] = gin.H{
+			"status":  "unhealthy",
+			"message": "storage not initialized",
+		}
+		allReady = false
+	}
+
+	if s.didService != nil || s.didHealthOverride != nil {
+		didHealth := s.cachedDIDHealth(ctx)
+		checks["did"] = didHealth
+		if didHealth["status"] != "healthy" {
+			allReady = false
+		}
+	}
+
+	if !allReady {
+		readiness["status"] = "not_ready"
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+
+	c.JSON(http.StatusOK, readiness)
+}
+
+// cachedDIDHealth returns the last DID self-test result while it is younger
+// than didSelfTestTTL and runs the self-test again otherwise. Concurrent
+// callers wait for a single run.
+func (s *AgentFieldServer) cachedDIDHealth(ctx context.Context) gin.H {
+	s.didHealthMu.Lock()
+	defer s.didHealthMu.Unlock()
+
+	if s.didHealthCached != nil && time.Since(s.didHealthCheckedAt) < didSelfTestTTL {
+		return s.didHealthCached
+	}
+	s.didHealthCached = s.checkDIDHealth(ctx)
+	s.didHealthCheckedAt = time.Now()
+	return s.didHealthCached
+}
+
 // checkStorageHealth performs storage-specific health checks
 func (s *AgentFieldServer) checkStorageHealth(ctx context.Context) gin.H {
 	if s.storageHealthOverride != nil {
@@ -609,6 +680,34 @@ func (s *AgentFieldServer) checkCacheHealth(ctx context.Context) gin.H {
 	}
 }
 
+// checkDIDHealth runs the DID service self-test
+func (s *AgentFieldServer) checkDIDHealth(ctx context.Context) gin.H {
+	if s.didHealthOverride != nil {
+		return s.didHealthOverride(ctx)
+	}
+
+	startTime := time.Now()
+
+	if err := s.didService.SelfTest(ctx); err != nil {
+		health := gin.H{
+			"status":        "unhealthy",
+			"message":       err.Error(),
+			"response_time": time.Since(startTime).Milliseconds(),
+		}
+		var selfTestErr *services.DIDSelfTestError
+		if errors.As(err, &selfTestErr) {
+			health["component"] = selfTestErr.Component
+		}
+		return health
+	}
+
+	return gin.H{
+		"status":        "healthy",
+		"message":       "DID service is operational",
+		"response_time": time.Since(startTime).Milliseconds(),
+	}
+}
+
 func (s *AgentFieldServer) setupRoutes() {
 	// Configure CORS from configuration
 	corsConfig := cors.Config{
@@ -660,10 +759,11 @@ func (s *AgentFieldServer) setupRoutes() {
 
 	// API key authentication middleware (supports headers + api_key query param)
 	s.Router.Use(middleware.APIKeyAuth(middleware.AuthConfig{
-		APIKey:    s.config.API.Auth.APIKey,
-		SkipPaths: s.config.API.Auth.SkipPaths,
+		APIKey:     s.config.API.Auth.APIKey,
+		TenantKeys: s.config.API.Auth.TenantKeys,
+		SkipPaths:  s.config.API.Auth.SkipPaths,
 	}))
-	if s.config.API.Auth.APIKey != "" {
+	if s.config.API.Auth.APIKey != "" || len(s.config.API.Auth.TenantKeys) > 0 {
 		logger.Logger.Info().Msg("🔐 API key authentication enabled")
 	}
 
@@ -672,6 +772,8 @@ func (s *AgentFieldServer) setupRoutes() {
 
 	// Public health check endpoint for load balancers and container orchestration (e.g., Railway, K8s)
 	s.Router.GET("/health", s.healthCheckHandler)
+	// Readiness probe, including the DID self-test
+	s.Router.GET("/ready", s.readinessCheckHandler)
 
 	// Serve UI files - embedded or filesystem based on availability
 	if s.config.UI.Enabled {
@@ -838,6 +940,7 @@ func (s *AgentFieldServer) setupRoutes() {
 			workflows := uiAPI.Group("/workflows")
 			{
 				workflows.GET("/:workflowId/dag", handlers.GetWorkflowDAGHandler(s.storage))
+				workflows.GET("/:workflowId/compare/:otherWorkflowId", handlers.GetWorkflowRunComparisonHandler(s.storage))
 				workflows.DELETE("/:workflowId/cleanup", handlers.CleanupWorkflowHandler(s.storage))
 				didHandler := ui.NewDIDHandler(s.storage, s.didService, s.vcService)
 				workflows.POST("/vc-status", didHandler.GetWorkflowVCStatusBatchHandler)
@@ -913,6 +1016,7 @@ func (s *AgentFieldServer) setupRoutes() {
 	{
 		// Health check endpoint for container orchestration
 		agentAPI.GET("/health", s.healthCheckHandler)
+		agentAPI.GET("/ready", s.readinessCheckHandler)
 
 		// Discovery endpoints
 		discovery := agentAPI.Group("/discovery")
@@ -1144,23 +1248,3 @@ func (s *AgentFieldServer) setupRoutes() {
 		})
 	}
 }
-
-// generateAgentFieldServerID creates a deterministic af server ID based on the agentfield home directory.
-// This ensures each agentfield instance has a unique ID while being deterministic for the same installation.
-func generateAgentFieldServerID(agentfieldHome string) string {
-	// Use the absolute path of agentfield home to generate a deterministic ID
-	absPath, err := filepath.Abs(agentfieldHome)
-	if err != nil {
-		// Fallback to the original path if absolute path fails
-		absPath = agentfieldHome
-	}
-
-	// Create a hash of the agentfield home path to generate a unique but deterministic ID
-	hash := sha256.Sum256([]byte(absPath))
-
-	// Use first 16 characters of the hex hash as the af server ID
-	// This provides uniqueness while keeping the ID manageable
-	agentfieldServerID := hex.EncodeToString(hash[:])[:16]
-
-	return agentfieldServerID
-}
@@ -3,8 +3,8 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +30,7 @@ func TestListReasonersAggregatesNodes(t *testing.T) {
 
 	localStore := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	if err := localStore.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, storage.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping reasoner aggregation test")
 		}
 		require.NoError(t, err)
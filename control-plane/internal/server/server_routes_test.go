@@ -36,6 +36,9 @@ func (s *stubStorage) GetAgent(ctx context.Context, id string) (*types.AgentNode
 func (s *stubStorage) CreateExecutionRecord(ctx context.Context, execution *types.Execution) error {
 	return nil
 }
+func (s *stubStorage) UpsertExecution(ctx context.Context, execution *types.Execution) error {
+	return nil
+}
 func (s *stubStorage) GetExecutionRecord(ctx context.Context, executionID string) (*types.Execution, error) {
 	return nil, nil
 }
@@ -45,6 +48,12 @@ func (s *stubStorage) UpdateExecutionRecord(ctx context.Context, executionID str
 func (s *stubStorage) QueryExecutionRecords(ctx context.Context, filter types.ExecutionFilter) ([]*types.Execution, error) {
 	return nil, nil
 }
+func (s *stubStorage) SearchExecutions(ctx context.Context, query string, tenantID *string, limit int) ([]*types.Execution, error) {
+	return nil, nil
+}
+func (s *stubStorage) SubscribeExecutions(ctx context.Context, runID string, tenantID *string) (<-chan *types.Execution, error) {
+	return nil, nil
+}
 func (s *stubStorage) RegisterExecutionWebhook(ctx context.Context, webhook *types.ExecutionWebhook) error {
 	return nil
 }
@@ -119,6 +128,9 @@ func (s *stubStorage) CleanupOldExecutions(ctx context.Context, retentionPeriod
 func (s *stubStorage) MarkStaleExecutions(ctx context.Context, staleAfter time.Duration, limit int) (int, error) {
 	return 0, nil
 }
+func (s *stubStorage) Compact(ctx context.Context) (*types.CompactionResult, error) {
+	return &types.CompactionResult{}, nil
+}
 func (s *stubStorage) CleanupWorkflow(ctx context.Context, workflowID string, dryRun bool) (*types.WorkflowCleanupResult, error) {
 	return &types.WorkflowCleanupResult{
 		Success:        true,
@@ -289,9 +301,12 @@ func (s *stubStorage) GetAgentFieldServerDID(ctx context.Context, agentfieldServ
 func (s *stubStorage) ListAgentFieldServerDIDs(ctx context.Context) ([]*types.AgentFieldServerDIDInfo, error) {
 	return nil, nil
 }
+func (s *stubStorage) DeleteAgentFieldServerDID(ctx context.Context, agentfieldServerID string) error {
+	return nil
+}
 
 // Agent DID operations
-func (s *stubStorage) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int) error {
+func (s *stubStorage) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int) error {
 	return nil
 }
 func (s *stubStorage) GetAgentDID(ctx context.Context, agentID string) (*types.AgentDIDInfo, error) {
@@ -300,6 +315,21 @@ func (s *stubStorage) GetAgentDID(ctx context.Context, agentID string) (*types.A
 func (s *stubStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error) {
 	return nil, nil
 }
+func (s *stubStorage) ListAgentDIDsForTenant(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error) {
+	return nil, nil
+}
+func (s *stubStorage) UpdateAgentDIDStatus(ctx context.Context, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) error {
+	return nil
+}
+func (s *stubStorage) UpdateAgentDIDStatuses(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) error {
+	return nil
+}
+func (s *stubStorage) SetAgentDIDRateLimit(ctx context.Context, agentfieldServerID, agentNodeID string, policy *types.RateLimitPolicy) error {
+	return nil
+}
+func (s *stubStorage) GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error) {
+	return nil, nil
+}
 
 // Component DID operations
 func (s *stubStorage) StoreComponentDID(ctx context.Context, componentID, componentDID, agentDID, componentType, componentName string, derivationIndex int) error {
@@ -311,9 +341,21 @@ func (s *stubStorage) GetComponentDID(ctx context.Context, componentID string) (
 func (s *stubStorage) ListComponentDIDs(ctx context.Context, agentDID string) ([]*types.ComponentDIDInfo, error) {
 	return nil, nil
 }
+func (s *stubStorage) RotateAgentFieldServerRoot(ctx context.Context, agentfieldServerID, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) error {
+	return nil
+}
+func (s *stubStorage) GetRotatedDID(ctx context.Context, did string) (*types.RotatedDIDInfo, error) {
+	return nil, nil
+}
+func (s *stubStorage) ListAgentComponentDIDs(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error) {
+	return nil, nil
+}
+func (s *stubStorage) CountComponentDIDsByType(ctx context.Context, agentfieldServerID string) (map[string]int, error) {
+	return nil, nil
+}
 
 // Multi-step DID operations
-func (s *stubStorage) StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int, components []storage.ComponentDIDRequest) error {
+func (s *stubStorage) StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int, components []storage.ComponentDIDRequest) error {
 	return nil
 }
 
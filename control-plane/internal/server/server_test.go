@@ -165,6 +165,58 @@ func TestHealthCheckHandlerHealthy(t *testing.T) {
 	}
 }
 
+func TestReadinessCheckHandlerCachesDIDSelfTest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	selfTests := 0
+	srv := &AgentFieldServer{
+		storageHealthOverride: func(context.Context) gin.H { return gin.H{"status": "healthy"} },
+		didHealthOverride: func(context.Context) gin.H {
+			selfTests++
+			return gin.H{"status": "unhealthy", "component": "keystore"}
+		},
+	}
+
+	serve := func(handler gin.HandlerFunc, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, path, nil)
+		handler(c)
+		return w
+	}
+
+	// /health never runs the DID self-test
+	if w := serve(srv.healthCheckHandler, "/health"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 status from /health, got %d", w.Code)
+	}
+	if selfTests != 0 {
+		t.Fatalf("expected /health to skip the DID self-test, ran %d times", selfTests)
+	}
+
+	for i := 0; i < 3; i++ {
+		w := serve(srv.readinessCheckHandler, "/ready")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 status from /ready, got %d", w.Code)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		didCheck := payload["checks"].(map[string]any)["did"].(map[string]any)
+		if didCheck["component"] != "keystore" {
+			t.Fatalf("expected keystore failure, got %+v", didCheck)
+		}
+	}
+	if selfTests != 1 {
+		t.Fatalf("expected one DID self-test within the TTL, ran %d times", selfTests)
+	}
+
+	srv.didHealthCheckedAt = time.Now().Add(-didSelfTestTTL)
+	serve(srv.readinessCheckHandler, "/ready")
+	if selfTests != 2 {
+		t.Fatalf("expected the DID self-test to rerun after the TTL, ran %d times", selfTests)
+	}
+}
+
 func TestHealthCheckHandlerCacheOptional(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	srv := &AgentFieldServer{
@@ -228,22 +280,6 @@ func TestHealthCheckHandlerWithoutStorage(t *testing.T) {
 	}
 }
 
-func TestGenerateAgentFieldServerIDDeterministic(t *testing.T) {
-	dir1 := filepath.Join("/tmp", "agentfield-test-1")
-	dir2 := filepath.Join("/tmp", "agentfield-test-2")
-
-	id1 := generateAgentFieldServerID(dir1)
-	id1Again := generateAgentFieldServerID(dir1)
-	id2 := generateAgentFieldServerID(dir2)
-
-	if id1 != id1Again {
-		t.Fatal("expected deterministic ID for same path")
-	}
-	if id1 == id2 {
-		t.Fatal("expected different IDs for different paths")
-	}
-}
-
 func TestUnregisterAgentFromMonitoring_NoNodeID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	srv := &AgentFieldServer{}
@@ -2,9 +2,13 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
@@ -15,6 +19,38 @@ type DIDRegistry struct {
 	mu              sync.RWMutex
 	registries      map[string]*types.DIDRegistry
 	storageProvider storage.StorageProvider
+	report          InitializeReport
+	observers       []DIDRegistryObserver
+}
+
+// DIDRegistryObserver is notified of agent DID changes once they have been
+// committed to storage, e.g. to mirror the registry into an external
+// directory. Observers run synchronously on the calling goroutine, after the
+// registry lock is released; errors they return are logged and never fail
+// the operation. Agents are passed as copies.
+type DIDRegistryObserver interface {
+	// OnAgentRegistered is called for each agent DID newly stored for an af server.
+	OnAgentRegistered(agentfieldServerID string, agent types.AgentDIDInfo) error
+	// OnStatusChanged is called when an agent moves to a status other than
+	// revoked. agent.Status holds the new status.
+	OnStatusChanged(agentfieldServerID string, agent types.AgentDIDInfo, previous types.AgentDIDStatus) error
+	// OnRevoked is called instead of OnStatusChanged when an agent is revoked.
+	OnRevoked(agentfieldServerID string, agent types.AgentDIDInfo) error
+}
+
+// InitializeReport summarizes what the last Initialize call loaded.
+type InitializeReport struct {
+	Registries   int
+	AgentsLoaded int
+	// Skipped lists agents left out because their stored data was malformed.
+	Skipped []SkippedAgentDID
+}
+
+// SkippedAgentDID is an agent that Initialize could not load.
+type SkippedAgentDID struct {
+	AgentNodeID string
+	DID         string
+	Reason      string
 }
 
 // NewDIDRegistryWithStorage creates a new DID registry instance with database storage.
@@ -25,7 +61,9 @@ func NewDIDRegistryWithStorage(storageProvider storage.StorageProvider) *DIDRegi
 	}
 }
 
-// Initialize initializes the DID registry storage.
+// Initialize initializes the DID registry storage. Agents whose stored data
+// is malformed are logged and skipped so the rest still load; InitializeReport
+// lists them.
 func (r *DIDRegistry) Initialize() error {
 	if r.storageProvider == nil {
 		return fmt.Errorf("storage provider not available")
@@ -35,31 +73,173 @@ func (r *DIDRegistry) Initialize() error {
 	return r.loadRegistriesFromDatabase()
 }
 
+// AddObserver registers an observer for agent DID changes. Observers are
+// called in the order they were added.
+func (r *DIDRegistry) AddObserver(observer DIDRegistryObserver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observers = append(r.observers, observer)
+}
+
+// agentStatusChange is a committed status transition waiting to be reported
+// to observers.
+type agentStatusChange struct {
+	agent    types.AgentDIDInfo
+	previous types.AgentDIDStatus
+}
+
+// notifyRegistered reports newly stored agents to observers.
+func notifyRegistered(observers []DIDRegistryObserver, agentfieldServerID string, agents []types.AgentDIDInfo) {
+	for _, agent := range agents {
+		for _, observer := range observers {
+			if err := observer.OnAgentRegistered(agentfieldServerID, copyAgentDIDInfo(agent)); err != nil {
+				log.Printf("DID registry observer failed on registration of agent %s: %v", agent.AgentNodeID, err)
+			}
+		}
+	}
+}
+
+// notifyStatusChanges reports committed status transitions to observers,
+// routing revocations to OnRevoked.
+func notifyStatusChanges(observers []DIDRegistryObserver, agentfieldServerID string, changes []agentStatusChange) {
+	for _, change := range changes {
+		for _, observer := range observers {
+			agent := copyAgentDIDInfo(change.agent)
+			var err error
+			if agent.Status == types.AgentDIDStatusRevoked {
+				err = observer.OnRevoked(agentfieldServerID, agent)
+			} else {
+				err = observer.OnStatusChanged(agentfieldServerID, agent, change.previous)
+			}
+			if err != nil {
+				log.Printf("DID registry observer failed on status change of agent %s to %s: %v", agent.AgentNodeID, agent.Status, err)
+			}
+		}
+	}
+}
+
+// InitializeReport returns the summary of the last Initialize call.
+func (r *DIDRegistry) InitializeReport() InitializeReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := r.report
+	report.Skipped = append([]SkippedAgentDID(nil), r.report.Skipped...)
+	return report
+}
+
+// LookupRegistry retrieves a DID registry for a af server. The bool reports
+// whether the registry was ever created, so callers can tell a missing
+// registry from one that exists but has no agents yet.
+func (r *DIDRegistry) LookupRegistry(agentfieldServerID string) (*types.DIDRegistry, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	registry, exists := r.registries[agentfieldServerID]
+	if !exists {
+		return nil, false, nil
+	}
+
+	return registry, true, nil
+}
+
 // GetRegistry retrieves a DID registry for a af server.
 // Returns (nil, nil) if registry doesn't exist, (nil, error) for actual errors.
+// Use LookupRegistry when the caller needs to branch on existence.
 func (r *DIDRegistry) GetRegistry(agentfieldServerID string) (*types.DIDRegistry, error) {
+	registry, _, err := r.LookupRegistry(agentfieldServerID)
+	return registry, err
+}
+
+// RegistrySnapshot is a deep copy of an af server's DID registry that can be
+// iterated and serialized without holding the registry lock. The master seed
+// is deliberately left out so snapshots are safe to export.
+type RegistrySnapshot struct {
+	AgentFieldServerID string                        `json:"agentfield_server_id"`
+	RootDID            string                        `json:"root_did"`
+	AgentNodes         map[string]types.AgentDIDInfo `json:"agent_nodes"`
+	TotalDIDs          int                           `json:"total_dids"`
+	CreatedAt          time.Time                     `json:"created_at"`
+	LastKeyRotation    time.Time                     `json:"last_key_rotation"`
+	SnapshotAt         time.Time                     `json:"snapshot_at"`
+}
+
+// SnapshotRegistry returns a deep copy of the registry for an af server.
+// Unlike GetRegistry, later status updates never show through the returned value.
+func (r *DIDRegistry) SnapshotRegistry(agentfieldServerID string) (*RegistrySnapshot, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	registry, exists := r.registries[agentfieldServerID]
 	if !exists {
-		// Return nil, nil for "not found" to distinguish from actual errors
-		return nil, nil
+		return nil, fmt.Errorf("registry not found for af server: %s", agentfieldServerID)
+	}
+
+	agentNodes := make(map[string]types.AgentDIDInfo, len(registry.AgentNodes))
+	for id, agentInfo := range registry.AgentNodes {
+		agentNodes[id] = copyAgentDIDInfo(agentInfo)
+	}
+
+	return &RegistrySnapshot{
+		AgentFieldServerID: registry.AgentFieldServerID,
+		RootDID:            registry.RootDID,
+		AgentNodes:         agentNodes,
+		TotalDIDs:          registry.TotalDIDs,
+		CreatedAt:          registry.CreatedAt,
+		LastKeyRotation:    registry.LastKeyRotation,
+		SnapshotAt:         time.Now().UTC(),
+	}, nil
+}
+
+// copyAgentDIDInfo deep-copies an agent's DID info, including component maps,
+// key material, capability/tag slices, and the rate-limit policy.
+func copyAgentDIDInfo(info types.AgentDIDInfo) types.AgentDIDInfo {
+	copied := info
+	copied.PublicKeyJWK = append(json.RawMessage(nil), info.PublicKeyJWK...)
+
+	if info.Reasoners != nil {
+		copied.Reasoners = make(map[string]types.ReasonerDIDInfo, len(info.Reasoners))
+		for id, reasoner := range info.Reasoners {
+			reasoner.PublicKeyJWK = append(json.RawMessage(nil), reasoner.PublicKeyJWK...)
+			reasoner.Capabilities = append([]string(nil), reasoner.Capabilities...)
+			copied.Reasoners[id] = reasoner
+		}
+	}
+
+	if info.Skills != nil {
+		copied.Skills = make(map[string]types.SkillDIDInfo, len(info.Skills))
+		for id, skill := range info.Skills {
+			skill.PublicKeyJWK = append(json.RawMessage(nil), skill.PublicKeyJWK...)
+			skill.Tags = append([]string(nil), skill.Tags...)
+			copied.Skills[id] = skill
+		}
+	}
+
+	if info.RateLimit != nil {
+		rateLimit := *info.RateLimit
+		copied.RateLimit = &rateLimit
 	}
 
-	return registry, nil
+	return copied
 }
 
-// StoreRegistry stores a DID registry for a af server.
+// StoreRegistry stores a DID registry for a af server. Observers are told
+// about each agent DID the call newly wrote to storage.
 func (r *DIDRegistry) StoreRegistry(registry *types.DIDRegistry) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 
 	// Store in memory
 	r.registries[registry.AgentFieldServerID] = registry
 
 	// Persist to database
-	return r.saveRegistryToDatabase(registry)
+	registered, err := r.saveRegistryToDatabase(registry)
+	observers := r.observers
+	r.mu.Unlock()
+
+	// Agents stored before a later failure are committed, so report them too
+	notifyRegistered(observers, registry.AgentFieldServerID, registered)
+	return err
 }
 
 // ListRegistries lists all af server registries.
@@ -75,21 +255,82 @@ func (r *DIDRegistry) ListRegistries() ([]*types.DIDRegistry, error) {
 	return registries, nil
 }
 
-// DeleteRegistry deletes a DID registry for a af server.
+// DeleteRegistry deletes a DID registry for a af server from storage and
+// memory. Registries that still hold agent DIDs cannot be deleted.
 func (r *DIDRegistry) DeleteRegistry(agentfieldServerID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Remove from memory
-	delete(r.registries, agentfieldServerID)
+	if r.storageProvider == nil {
+		return fmt.Errorf("storage provider not available")
+	}
+	if err := r.storageProvider.DeleteAgentFieldServerDID(context.Background(), agentfieldServerID); err != nil {
+		return fmt.Errorf("failed to delete af server DID: %w", err)
+	}
 
-	// TODO: Add database deletion method to storage interface
-	// For now, we'll just remove from memory
+	delete(r.registries, agentfieldServerID)
 	return nil
 }
 
 // UpdateAgentStatus updates the status of an agent DID.
 func (r *DIDRegistry) UpdateAgentStatus(agentfieldServerID, agentNodeID string, status types.AgentDIDStatus) error {
+	return r.UpdateAgentStatusWithReason(agentfieldServerID, agentNodeID, status, "")
+}
+
+// UpdateAgentStatusWithReason updates the status of an agent DID and records the
+// transition, with an optional reason, in the status history.
+func (r *DIDRegistry) UpdateAgentStatusWithReason(agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) error {
+	observers, change, err := r.updateAgentStatus(agentfieldServerID, agentNodeID, status, reason)
+	if err != nil {
+		return err
+	}
+	if change.previous != status {
+		notifyStatusChanges(observers, agentfieldServerID, []agentStatusChange{change})
+	}
+	return nil
+}
+
+// updateAgentStatus commits a single status change and returns what
+// observers should be told once the lock is released.
+func (r *DIDRegistry) updateAgentStatus(agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) ([]DIDRegistryObserver, agentStatusChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registry, exists := r.registries[agentfieldServerID]
+	if !exists {
+		return nil, agentStatusChange{}, fmt.Errorf("registry not found for af server: %s", agentfieldServerID)
+	}
+
+	agentInfo, exists := registry.AgentNodes[agentNodeID]
+	if !exists {
+		return nil, agentStatusChange{}, fmt.Errorf("agent not found: %s", agentNodeID)
+	}
+
+	if r.storageProvider == nil {
+		return nil, agentStatusChange{}, fmt.Errorf("storage provider not available")
+	}
+
+	// Status and history are written in one storage transaction
+	if err := r.storageProvider.UpdateAgentDIDStatus(context.Background(), agentfieldServerID, agentNodeID, status, reason); err != nil {
+		return nil, agentStatusChange{}, fmt.Errorf("failed to update agent status: %w", err)
+	}
+
+	previous := agentInfo.Status
+	agentInfo.Status = status
+	registry.AgentNodes[agentNodeID] = agentInfo
+	return r.observers, agentStatusChange{agent: copyAgentDIDInfo(agentInfo), previous: previous}, nil
+}
+
+// SetAgentRateLimit attaches a rate-limit policy to an agent DID and persists
+// it. The registry only stores the policy; enforcement is left to callers.
+func (r *DIDRegistry) SetAgentRateLimit(agentfieldServerID, agentNodeID string, policy types.RateLimitPolicy) error {
+	if policy.RequestsPerMinute <= 0 {
+		return fmt.Errorf("rate limit requests per minute must be positive, got %d", policy.RequestsPerMinute)
+	}
+	if policy.Burst < 0 {
+		return fmt.Errorf("rate limit burst cannot be negative, got %d", policy.Burst)
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -103,11 +344,198 @@ func (r *DIDRegistry) UpdateAgentStatus(agentfieldServerID, agentNodeID string,
 		return fmt.Errorf("agent not found: %s", agentNodeID)
 	}
 
-	agentInfo.Status = status
+	if r.storageProvider == nil {
+		return fmt.Errorf("storage provider not available")
+	}
+
+	if err := r.storageProvider.SetAgentDIDRateLimit(context.Background(), agentfieldServerID, agentNodeID, &policy); err != nil {
+		return fmt.Errorf("failed to set agent rate limit: %w", err)
+	}
+
+	agentInfo.RateLimit = &policy
 	registry.AgentNodes[agentNodeID] = agentInfo
+	return nil
+}
+
+// UpdateAllAgentStatus moves every agent on an af server that matches filter
+// to status in a single storage transaction. A nil filter matches all agents.
+// Agents already in status, or for which the transition is not allowed (such
+// as leaving revoked), are skipped. It returns the number of agents updated.
+func (r *DIDRegistry) UpdateAllAgentStatus(agentfieldServerID string, status types.AgentDIDStatus, filter func(types.AgentDIDInfo) bool) (int, error) {
+	observers, changes, err := r.updateAllAgentStatus(agentfieldServerID, status, filter)
+	if err != nil {
+		return 0, err
+	}
+	notifyStatusChanges(observers, agentfieldServerID, changes)
+	return len(changes), nil
+}
+
+// updateAllAgentStatus commits a bulk status change and returns what
+// observers should be told once the lock is released.
+func (r *DIDRegistry) updateAllAgentStatus(agentfieldServerID string, status types.AgentDIDStatus, filter func(types.AgentDIDInfo) bool) ([]DIDRegistryObserver, []agentStatusChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registry, exists := r.registries[agentfieldServerID]
+	if !exists {
+		return nil, nil, fmt.Errorf("registry not found for af server: %s", agentfieldServerID)
+	}
+
+	var agentNodeIDs []string
+	for agentNodeID, agentInfo := range registry.AgentNodes {
+		if filter != nil && !filter(agentInfo) {
+			continue
+		}
+		if !isValidAgentDIDStatusTransition(agentInfo.Status, status) {
+			continue
+		}
+		agentNodeIDs = append(agentNodeIDs, agentNodeID)
+	}
+	if len(agentNodeIDs) == 0 {
+		return nil, nil, nil
+	}
+	sort.Strings(agentNodeIDs)
+
+	if r.storageProvider == nil {
+		return nil, nil, fmt.Errorf("storage provider not available")
+	}
+
+	if err := r.storageProvider.UpdateAgentDIDStatuses(context.Background(), agentfieldServerID, agentNodeIDs, status, ""); err != nil {
+		return nil, nil, fmt.Errorf("failed to update agent statuses: %w", err)
+	}
+
+	changes := make([]agentStatusChange, 0, len(agentNodeIDs))
+	for _, agentNodeID := range agentNodeIDs {
+		agentInfo := registry.AgentNodes[agentNodeID]
+		previous := agentInfo.Status
+		agentInfo.Status = status
+		registry.AgentNodes[agentNodeID] = agentInfo
+		changes = append(changes, agentStatusChange{agent: copyAgentDIDInfo(agentInfo), previous: previous})
+	}
+	return r.observers, changes, nil
+}
+
+// isValidAgentDIDStatusTransition reports whether an agent DID may move from
+// one status to another. Revocation is terminal.
+func isValidAgentDIDStatusTransition(from, to types.AgentDIDStatus) bool {
+	validTransitions := map[types.AgentDIDStatus][]types.AgentDIDStatus{
+		types.AgentDIDStatusActive:   {types.AgentDIDStatusInactive, types.AgentDIDStatusRevoked},
+		types.AgentDIDStatusInactive: {types.AgentDIDStatusActive, types.AgentDIDStatusRevoked},
+	}
+
+	for _, allowed := range validTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyRootRotation persists a root key rotation computed from an earlier
+// snapshot and, once storage commits, moves the live registry onto the new
+// root. Only the root and the rotated DIDs and public keys are taken from
+// rotated; everything else about each agent, such as a status or rate limit
+// set since the snapshot, is kept from the live entry. It fails without
+// changes if an agent or component DID was added, removed or replaced since
+// the snapshot.
+func (r *DIDRegistry) ApplyRootRotation(rotated *types.DIDRegistry, rotations []types.DIDKeyRotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Persist changes to database
-	return r.saveRegistryToDatabase(registry)
+	current, exists := r.registries[rotated.AgentFieldServerID]
+	if !exists {
+		return fmt.Errorf("registry not found for af server: %s", rotated.AgentFieldServerID)
+	}
+	merged, err := mergeRootRotation(current, rotated, rotations)
+	if err != nil {
+		return err
+	}
+
+	if r.storageProvider == nil {
+		return fmt.Errorf("storage provider not available")
+	}
+
+	if err := r.storageProvider.RotateAgentFieldServerRoot(context.Background(), rotated.AgentFieldServerID,
+		rotated.RootDID, rotated.MasterSeed, rotated.LastKeyRotation, rotations); err != nil {
+		return fmt.Errorf("failed to persist root rotation: %w", err)
+	}
+
+	r.registries[rotated.AgentFieldServerID] = merged
+	return nil
+}
+
+// mergeRootRotation returns a copy of current moved onto the root of rotated,
+// with every agent and component DID replaced as recorded in rotations.
+// current is not modified, so a failed commit leaves the live registry
+// intact.
+func mergeRootRotation(current, rotated *types.DIDRegistry, rotations []types.DIDKeyRotation) (*types.DIDRegistry, error) {
+	changed := fmt.Errorf("registry for af server %s changed during rotation; retry", rotated.AgentFieldServerID)
+	if len(current.AgentNodes) != len(rotated.AgentNodes) {
+		return nil, changed
+	}
+
+	byOldDID := make(map[string]types.DIDKeyRotation, len(rotations))
+	for _, rotation := range rotations {
+		byOldDID[rotation.OldDID] = rotation
+	}
+	rotate := func(componentType, did string) (types.DIDKeyRotation, bool) {
+		rotation, ok := byOldDID[did]
+		return rotation, ok && rotation.ComponentType == componentType
+	}
+
+	merged := *current
+	merged.RootDID = rotated.RootDID
+	merged.MasterSeed = rotated.MasterSeed
+	merged.LastKeyRotation = rotated.LastKeyRotation
+	merged.AgentNodes = make(map[string]types.AgentDIDInfo, len(current.AgentNodes))
+
+	for agentNodeID, live := range current.AgentNodes {
+		if _, ok := rotated.AgentNodes[agentNodeID]; !ok {
+			return nil, changed
+		}
+		agentInfo := copyAgentDIDInfo(live)
+		rotation, ok := rotate("agent", agentInfo.DID)
+		if !ok {
+			return nil, changed
+		}
+		agentInfo.DID, agentInfo.PublicKeyJWK = rotation.NewDID, json.RawMessage(rotation.NewPublicKeyJWK)
+
+		for name, reasonerInfo := range agentInfo.Reasoners {
+			rotation, ok := rotate("reasoner", reasonerInfo.DID)
+			if !ok {
+				return nil, changed
+			}
+			reasonerInfo.DID, reasonerInfo.PublicKeyJWK = rotation.NewDID, json.RawMessage(rotation.NewPublicKeyJWK)
+			agentInfo.Reasoners[name] = reasonerInfo
+		}
+		for name, skillInfo := range agentInfo.Skills {
+			rotation, ok := rotate("skill", skillInfo.DID)
+			if !ok {
+				return nil, changed
+			}
+			skillInfo.DID, skillInfo.PublicKeyJWK = rotation.NewDID, json.RawMessage(rotation.NewPublicKeyJWK)
+			agentInfo.Skills[name] = skillInfo
+		}
+		merged.AgentNodes[agentNodeID] = agentInfo
+	}
+	return &merged, nil
+}
+
+// GetRotatedDID returns the rotation record for a DID retired by a root key
+// rotation, or (nil, nil) if the DID was never rotated.
+func (r *DIDRegistry) GetRotatedDID(did string) (*types.RotatedDIDInfo, error) {
+	if r.storageProvider == nil {
+		return nil, fmt.Errorf("storage provider not available")
+	}
+	return r.storageProvider.GetRotatedDID(context.Background(), did)
+}
+
+// GetAgentStatusHistory returns the recorded status transitions for an agent, oldest first.
+func (r *DIDRegistry) GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error) {
+	if r.storageProvider == nil {
+		return nil, fmt.Errorf("storage provider not available")
+	}
+	return r.storageProvider.GetAgentStatusHistory(ctx, agentNodeID)
 }
 
 // FindDIDByComponent finds a DID by component type and function name.
@@ -213,6 +641,32 @@ func (r *DIDRegistry) GetAgentDIDs(agentfieldServerID, agentNodeID string) (*typ
 	}, nil
 }
 
+// ComponentCounts returns the number of stored component DIDs per component type
+// (e.g. "reasoner", "skill") across all agents belonging to a af server.
+// Counts are aggregated by storage from the persisted component rows rather
+// than the in-memory registry so they remain accurate after partial reloads.
+func (r *DIDRegistry) ComponentCounts(agentfieldServerID string) (map[string]int, error) {
+	if r.storageProvider == nil {
+		return nil, fmt.Errorf("storage provider not available")
+	}
+
+	ctx := context.Background()
+	serverInfo, err := r.storageProvider.GetAgentFieldServerDID(ctx, agentfieldServerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get af server DID: %w", err)
+	}
+	if serverInfo == nil {
+		return nil, fmt.Errorf("registry not found for af server: %s", agentfieldServerID)
+	}
+
+	counts, err := r.storageProvider.CountComponentDIDsByType(ctx, agentfieldServerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count component DIDs: %w", err)
+	}
+
+	return counts, nil
+}
+
 // loadRegistriesFromDatabase loads all registries from the database.
 func (r *DIDRegistry) loadRegistriesFromDatabase() error {
 	if r.storageProvider == nil {
@@ -226,6 +680,24 @@ func (r *DIDRegistry) loadRegistriesFromDatabase() error {
 		return fmt.Errorf("failed to list af server DIDs: %w", err)
 	}
 
+	// Load agent DIDs, skipping rows whose stored JSON is corrupt
+	report := InitializeReport{}
+	agentDIDs, err := r.storageProvider.ListAgentDIDs(ctx)
+	var malformed *storage.MalformedAgentDIDsError
+	switch {
+	case errors.As(err, &malformed):
+		for _, row := range malformed.Rows {
+			log.Printf("Skipping malformed agent DID during registry load: agent=%s did=%s: %v", row.AgentNodeID, row.DID, row.Err)
+			report.Skipped = append(report.Skipped, SkippedAgentDID{
+				AgentNodeID: row.AgentNodeID,
+				DID:         row.DID,
+				Reason:      row.Err.Error(),
+			})
+		}
+	case err != nil:
+		return fmt.Errorf("failed to list agent DIDs: %w", err)
+	}
+
 	// Create registries for each af server
 	for _, agentfieldServerDIDInfo := range agentfieldServerDIDs {
 		registry := &types.DIDRegistry{
@@ -238,12 +710,6 @@ func (r *DIDRegistry) loadRegistriesFromDatabase() error {
 			LastKeyRotation:    agentfieldServerDIDInfo.LastKeyRotation,
 		}
 
-		// Load agent DIDs for this af server
-		agentDIDs, err := r.storageProvider.ListAgentDIDs(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to list agent DIDs: %w", err)
-		}
-
 		for _, agentDIDInfo := range agentDIDs {
 			// Filter agents for this af server (assuming we can match by some criteria)
 			// For now, we'll add all agents to the default af server
@@ -253,10 +719,12 @@ func (r *DIDRegistry) loadRegistriesFromDatabase() error {
 				DID:                agentDIDInfo.DID,
 				AgentNodeID:        agentDIDInfo.AgentNodeID,
 				AgentFieldServerID: agentfieldServerDIDInfo.AgentFieldServerID,
+				TenantID:           agentDIDInfo.TenantID,
 				PublicKeyJWK:       agentDIDInfo.PublicKeyJWK,
 				DerivationPath:     agentDIDInfo.DerivationPath,
 				Status:             agentDIDInfo.Status,
 				RegisteredAt:       agentDIDInfo.RegisteredAt,
+				RateLimit:          agentDIDInfo.RateLimit,
 				Reasoners:          make(map[string]types.ReasonerDIDInfo),
 				Skills:             make(map[string]types.SkillDIDInfo),
 			}
@@ -295,18 +763,24 @@ func (r *DIDRegistry) loadRegistriesFromDatabase() error {
 
 			registry.AgentNodes[agentInfo.AgentNodeID] = agentInfo
 			registry.TotalDIDs++
+			report.AgentsLoaded++
 		}
 
 		r.registries[agentfieldServerDIDInfo.AgentFieldServerID] = registry
+		report.Registries++
 	}
 
+	r.mu.Lock()
+	r.report = report
+	r.mu.Unlock()
 	return nil
 }
 
-// saveRegistryToDatabase saves a registry to the database.
-func (r *DIDRegistry) saveRegistryToDatabase(registry *types.DIDRegistry) error {
+// saveRegistryToDatabase saves a registry to the database and returns the
+// agents it newly stored. Agents already in storage are skipped.
+func (r *DIDRegistry) saveRegistryToDatabase(registry *types.DIDRegistry) ([]types.AgentDIDInfo, error) {
 	if r.storageProvider == nil {
-		return fmt.Errorf("storage provider not available")
+		return nil, fmt.Errorf("storage provider not available")
 	}
 
 	ctx := context.Background()
@@ -320,9 +794,11 @@ func (r *DIDRegistry) saveRegistryToDatabase(registry *types.DIDRegistry) error
 		registry.LastKeyRotation,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to store af server DID: %w", err)
+		return nil, fmt.Errorf("failed to store af server DID: %w", err)
 	}
 
+	var registered []types.AgentDIDInfo
+
 	// Store each agent DID and its components using transaction-safe method
 	for _, agentInfo := range registry.AgentNodes {
 		// Extract derivation index from path (simplified)
@@ -361,6 +837,7 @@ func (r *DIDRegistry) saveRegistryToDatabase(registry *types.DIDRegistry) error
 			agentInfo.AgentNodeID,
 			agentInfo.DID,
 			registry.AgentFieldServerID, // Use af server ID instead of root DID
+			agentInfo.TenantID,
 			string(agentInfo.PublicKeyJWK),
 			derivationIndex,
 			components,
@@ -368,18 +845,19 @@ func (r *DIDRegistry) saveRegistryToDatabase(registry *types.DIDRegistry) error
 		if err != nil {
 			// Enhanced error handling for different constraint types
 			if validationErr, ok := err.(*storage.ValidationError); ok {
-				return fmt.Errorf("validation failed for agent %s: %w", agentInfo.AgentNodeID, validationErr)
+				return registered, fmt.Errorf("validation failed for agent %s: %w", agentInfo.AgentNodeID, validationErr)
 			}
 			if fkErr, ok := err.(*storage.ForeignKeyConstraintError); ok {
-				return fmt.Errorf("foreign key constraint violation for agent %s: %w", agentInfo.AgentNodeID, fkErr)
+				return registered, fmt.Errorf("foreign key constraint violation for agent %s: %w", agentInfo.AgentNodeID, fkErr)
 			}
 			if dupErr, ok := err.(*storage.DuplicateDIDError); ok {
 				log.Printf("Skipping duplicate DID entry during registry sync: %s (agent=%s)", dupErr.DID, agentInfo.AgentNodeID)
 				continue
 			}
-			return fmt.Errorf("failed to store agent DID %s with components: %w", agentInfo.AgentNodeID, err)
+			return registered, fmt.Errorf("failed to store agent DID %s with components: %w", agentInfo.AgentNodeID, err)
 		}
+		registered = append(registered, agentInfo)
 	}
 
-	return nil
+	return registered, nil
 }
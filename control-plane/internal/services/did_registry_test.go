@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage/storagetest"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
 	"github.com/stretchr/testify/require"
@@ -28,7 +30,7 @@ func setupTestStorage(t *testing.T) (storage.StorageProvider, context.Context) {
 
 	provider := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	if err := provider.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, storage.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping DID registry test")
 		}
 		require.NoError(t, err)
@@ -42,7 +44,7 @@ func setupTestStorage(t *testing.T) (storage.StorageProvider, context.Context) {
 }
 
 func TestDIDRegistryInitializeAndLookup(t *testing.T) {
-	provider, ctx := setupTestStorage(t)
+	provider, ctx := storagetest.NewMock(), context.Background()
 
 	agentfieldID := "agentfield-1"
 	now := time.Now().UTC().Truncate(time.Second)
@@ -66,7 +68,7 @@ func TestDIDRegistryInitializeAndLookup(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", agentfieldID, "{}", 0, components))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", agentfieldID, "", "{}", 0, components))
 
 	registry := NewDIDRegistryWithStorage(provider)
 	require.NoError(t, registry.Initialize())
@@ -103,3 +105,374 @@ func TestDIDRegistryInitializeAndLookup(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, registries, 1)
 }
+
+// malformedDIDStorage reports one extra agent DID row as malformed.
+type malformedDIDStorage struct {
+	storage.StorageProvider
+	malformed storage.MalformedAgentDID
+}
+
+func (s *malformedDIDStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error) {
+	infos, err := s.StorageProvider.ListAgentDIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return infos, &storage.MalformedAgentDIDsError{Rows: []storage.MalformedAgentDID{s.malformed}}
+}
+
+func TestDIDRegistryInitializeSkipsMalformedAgents(t *testing.T) {
+	provider, ctx := storagetest.NewMock(), context.Background()
+
+	// An empty store initializes cleanly
+	empty := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, empty.Initialize())
+	require.Equal(t, InitializeReport{}, empty.InitializeReport())
+
+	agentfieldID := "agentfield-1"
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, agentfieldID, "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-good", "did:agent:good", agentfieldID, "", "{}", 0, nil))
+
+	registry := NewDIDRegistryWithStorage(&malformedDIDStorage{
+		StorageProvider: provider,
+		malformed: storage.MalformedAgentDID{
+			AgentNodeID: "agent-bad",
+			DID:         "did:agent:bad",
+			Err:         errors.New("failed to parse reasoners JSON: unexpected end of JSON input"),
+		},
+	})
+	require.NoError(t, registry.Initialize())
+
+	loaded, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.Contains(t, loaded.AgentNodes, "agent-good")
+	require.NotContains(t, loaded.AgentNodes, "agent-bad")
+
+	report := registry.InitializeReport()
+	require.Equal(t, 1, report.Registries)
+	require.Equal(t, 1, report.AgentsLoaded)
+	require.Equal(t, []SkippedAgentDID{{
+		AgentNodeID: "agent-bad",
+		DID:         "did:agent:bad",
+		Reason:      "failed to parse reasoners JSON: unexpected end of JSON input",
+	}}, report.Skipped)
+}
+
+func TestDIDRegistryLookupRegistryDistinguishesMissingFromEmpty(t *testing.T) {
+	provider, ctx := storagetest.NewMock(), context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, "agentfield-empty", "did:agentfield:root", []byte("seed"), now, now))
+
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+
+	missing, exists, err := registry.LookupRegistry("agentfield-missing")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Nil(t, missing)
+
+	empty, exists, err := registry.LookupRegistry("agentfield-empty")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.NotNil(t, empty)
+	require.Empty(t, empty.AgentNodes)
+
+	legacy, err := registry.GetRegistry("agentfield-missing")
+	require.NoError(t, err)
+	require.Nil(t, legacy)
+}
+
+func TestDIDRegistryComponentCounts(t *testing.T) {
+	provider, ctx := storagetest.NewMock(), context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, "agentfield-1", "did:agentfield:root-1", []byte("seed"), now, now))
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, "agentfield-2", "did:agentfield:root-2", []byte("seed"), now, now))
+
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", "agentfield-1", "", "{}", 0, []storage.ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:1", ComponentType: "reasoner", ComponentName: "r1", PublicKeyJWK: "{}", DerivationIndex: 1},
+		{ComponentDID: "did:reasoner:2", ComponentType: "reasoner", ComponentName: "r2", PublicKeyJWK: "{}", DerivationIndex: 2},
+		{ComponentDID: "did:skill:1", ComponentType: "skill", ComponentName: "s1", PublicKeyJWK: "{}", DerivationIndex: 3},
+	}))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-2", "did:agent:2", "agentfield-1", "", "{}", 1, []storage.ComponentDIDRequest{
+		{ComponentDID: "did:skill:2", ComponentType: "skill", ComponentName: "s2", PublicKeyJWK: "{}", DerivationIndex: 4},
+	}))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-3", "did:agent:3", "agentfield-2", "", "{}", 2, []storage.ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:3", ComponentType: "reasoner", ComponentName: "r3", PublicKeyJWK: "{}", DerivationIndex: 5},
+	}))
+
+	// The registry is intentionally not initialized: counts come from storage.
+	registry := NewDIDRegistryWithStorage(provider)
+
+	counts, err := registry.ComponentCounts("agentfield-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"reasoner": 2, "skill": 2}, counts)
+
+	counts, err = registry.ComponentCounts("agentfield-2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"reasoner": 1}, counts)
+
+	_, err = registry.ComponentCounts("missing")
+	require.Error(t, err)
+}
+
+func TestDIDRegistryStatusHistory(t *testing.T) {
+	provider, ctx := storagetest.NewMock(), context.Background()
+
+	agentfieldID := "agentfield-1"
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, agentfieldID, "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", agentfieldID, "", "{}", 0, nil))
+
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+
+	require.NoError(t, registry.UpdateAgentStatusWithReason(agentfieldID, "agent-1", types.AgentDIDStatusInactive, "suspended for review"))
+	require.NoError(t, registry.UpdateAgentStatus(agentfieldID, "agent-1", types.AgentDIDStatusRevoked))
+
+	history, err := registry.GetAgentStatusHistory(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.Equal(t, types.AgentDIDStatusActive, history[0].PreviousStatus)
+	require.Equal(t, types.AgentDIDStatusInactive, history[0].NewStatus)
+	require.Equal(t, "suspended for review", history[0].Reason)
+	require.Equal(t, agentfieldID, history[0].AgentFieldServerID)
+	require.False(t, history[0].ChangedAt.IsZero())
+
+	require.Equal(t, types.AgentDIDStatusInactive, history[1].PreviousStatus)
+	require.Equal(t, types.AgentDIDStatusRevoked, history[1].NewStatus)
+	require.Empty(t, history[1].Reason)
+
+	stored, err := provider.GetAgentDID(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Equal(t, types.AgentDIDStatusRevoked, stored.Status)
+
+	// Unknown agents fail without recording history
+	require.Error(t, provider.UpdateAgentDIDStatus(ctx, agentfieldID, "missing", types.AgentDIDStatusRevoked, ""))
+	missing, err := registry.GetAgentStatusHistory(ctx, "missing")
+	require.NoError(t, err)
+	require.Empty(t, missing)
+}
+
+func TestDIDRegistryRateLimitSurvivesReload(t *testing.T) {
+	provider, ctx := setupTestStorage(t)
+
+	agentfieldID := "agentfield-1"
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, agentfieldID, "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", agentfieldID, "", "{}", 0, nil))
+	require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, "agent-2", "did:agent:2", agentfieldID, "", "{}", 1, nil))
+
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+
+	policy := types.RateLimitPolicy{RequestsPerMinute: 120, Burst: 20}
+	require.NoError(t, registry.SetAgentRateLimit(agentfieldID, "agent-1", policy))
+
+	require.Error(t, registry.SetAgentRateLimit(agentfieldID, "agent-1", types.RateLimitPolicy{RequestsPerMinute: 0}))
+	require.Error(t, registry.SetAgentRateLimit(agentfieldID, "agent-1", types.RateLimitPolicy{RequestsPerMinute: 10, Burst: -1}))
+	require.Error(t, registry.SetAgentRateLimit(agentfieldID, "missing", policy))
+	require.Error(t, registry.SetAgentRateLimit("agentfield-missing", "agent-1", policy))
+
+	reloaded := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, reloaded.Initialize())
+
+	loaded, err := reloaded.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.AgentNodes["agent-1"].RateLimit)
+	require.Equal(t, policy, *loaded.AgentNodes["agent-1"].RateLimit)
+	require.Nil(t, loaded.AgentNodes["agent-2"].RateLimit)
+
+	stored, err := provider.GetAgentDID(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Equal(t, &policy, stored.RateLimit)
+}
+
+func TestDIDRegistryUpdateAllAgentStatus(t *testing.T) {
+	provider, ctx := storagetest.NewMock(), context.Background()
+
+	agentfieldID := "agentfield-1"
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, provider.StoreAgentFieldServerDID(ctx, agentfieldID, "did:agentfield:root", []byte("seed"), now, now))
+	for i, agentID := range []string{"agent-a", "agent-b", "agent-c"} {
+		require.NoError(t, provider.StoreAgentDIDWithComponents(ctx, agentID, "did:agent:"+agentID, agentfieldID, "", "{}", i, nil))
+	}
+
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+	require.NoError(t, registry.UpdateAgentStatus(agentfieldID, "agent-c", types.AgentDIDStatusRevoked))
+
+	// Revoked agents are skipped, the filter narrows the rest
+	updated, err := registry.UpdateAllAgentStatus(agentfieldID, types.AgentDIDStatusInactive, func(info types.AgentDIDInfo) bool {
+		return info.AgentNodeID != "agent-b"
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+
+	updated, err = registry.UpdateAllAgentStatus(agentfieldID, types.AgentDIDStatusInactive, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+
+	// Everything is already suspended or revoked
+	updated, err = registry.UpdateAllAgentStatus(agentfieldID, types.AgentDIDStatusInactive, nil)
+	require.NoError(t, err)
+	require.Zero(t, updated)
+
+	loaded, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.Equal(t, types.AgentDIDStatusInactive, loaded.AgentNodes["agent-a"].Status)
+	require.Equal(t, types.AgentDIDStatusInactive, loaded.AgentNodes["agent-b"].Status)
+	require.Equal(t, types.AgentDIDStatusRevoked, loaded.AgentNodes["agent-c"].Status)
+
+	for _, agentID := range []string{"agent-a", "agent-b"} {
+		stored, err := provider.GetAgentDID(ctx, agentID)
+		require.NoError(t, err)
+		require.Equal(t, types.AgentDIDStatusInactive, stored.Status)
+	}
+
+	// A failure part way through leaves every agent unchanged
+	loaded.AgentNodes["agent-unstored"] = types.AgentDIDInfo{AgentNodeID: "agent-unstored", Status: types.AgentDIDStatusInactive}
+	_, err = registry.UpdateAllAgentStatus(agentfieldID, types.AgentDIDStatusActive, nil)
+	require.Error(t, err)
+
+	stored, err := provider.GetAgentDID(ctx, "agent-a")
+	require.NoError(t, err)
+	require.Equal(t, types.AgentDIDStatusInactive, stored.Status)
+	require.Equal(t, types.AgentDIDStatusInactive, loaded.AgentNodes["agent-a"].Status)
+
+	_, err = registry.UpdateAllAgentStatus("missing", types.AgentDIDStatusInactive, nil)
+	require.Error(t, err)
+}
+
+func TestDIDRegistrySnapshotRegistryIsDeepCopy(t *testing.T) {
+	registry := NewDIDRegistryWithStorage(nil)
+	registry.registries["agentfield-1"] = &types.DIDRegistry{
+		AgentFieldServerID: "agentfield-1",
+		MasterSeed:         []byte("secret-seed"),
+		RootDID:            "did:agentfield:root",
+		TotalDIDs:          2,
+		AgentNodes: map[string]types.AgentDIDInfo{
+			"agent-1": {
+				DID:          "did:agent:1",
+				AgentNodeID:  "agent-1",
+				PublicKeyJWK: json.RawMessage(`{"kty":"OKP"}`),
+				Status:       types.AgentDIDStatusActive,
+				Reasoners: map[string]types.ReasonerDIDInfo{
+					"plan": {DID: "did:reasoner:1", Capabilities: []string{"planning"}},
+				},
+				Skills: map[string]types.SkillDIDInfo{
+					"search": {DID: "did:skill:1", Tags: []string{"web"}},
+				},
+			},
+		},
+	}
+
+	snapshot, err := registry.SnapshotRegistry("agentfield-1")
+	require.NoError(t, err)
+	require.Equal(t, "did:agentfield:root", snapshot.RootDID)
+	require.Equal(t, 2, snapshot.TotalDIDs)
+	require.False(t, snapshot.SnapshotAt.IsZero())
+
+	// Mutating the snapshot must not reach the live registry.
+	agent := snapshot.AgentNodes["agent-1"]
+	agent.PublicKeyJWK[2] = 'X'
+	agent.Reasoners["plan"].Capabilities[0] = "mutated"
+	agent.Skills["search"].Tags[0] = "mutated"
+	delete(agent.Reasoners, "plan")
+	snapshot.AgentNodes["agent-2"] = types.AgentDIDInfo{}
+
+	live := registry.registries["agentfield-1"]
+	require.Len(t, live.AgentNodes, 1)
+	require.JSONEq(t, `{"kty":"OKP"}`, string(live.AgentNodes["agent-1"].PublicKeyJWK))
+	require.Equal(t, []string{"planning"}, live.AgentNodes["agent-1"].Reasoners["plan"].Capabilities)
+	require.Equal(t, []string{"web"}, live.AgentNodes["agent-1"].Skills["search"].Tags)
+
+	// Later updates to the live registry must not show through the snapshot.
+	liveAgent := live.AgentNodes["agent-1"]
+	liveAgent.Status = types.AgentDIDStatusRevoked
+	live.AgentNodes["agent-1"] = liveAgent
+	require.Equal(t, types.AgentDIDStatusActive, snapshot.AgentNodes["agent-1"].Status)
+
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "master_seed")
+
+	_, err = registry.SnapshotRegistry("missing")
+	require.Error(t, err)
+}
+
+type recordingDIDObserver struct {
+	registry *DIDRegistry
+	events   []string
+	err      error
+}
+
+func (o *recordingDIDObserver) OnAgentRegistered(agentfieldServerID string, agent types.AgentDIDInfo) error {
+	// Observers may read the registry; the lock must already be released.
+	if _, _, err := o.registry.LookupRegistry(agentfieldServerID); err != nil {
+		return err
+	}
+	o.events = append(o.events, "registered "+agentfieldServerID+"/"+agent.AgentNodeID)
+	return o.err
+}
+
+func (o *recordingDIDObserver) OnStatusChanged(agentfieldServerID string, agent types.AgentDIDInfo, previous types.AgentDIDStatus) error {
+	o.events = append(o.events, "status "+agent.AgentNodeID+" "+string(previous)+"->"+string(agent.Status))
+	return o.err
+}
+
+func (o *recordingDIDObserver) OnRevoked(agentfieldServerID string, agent types.AgentDIDInfo) error {
+	o.events = append(o.events, "revoked "+agent.AgentNodeID)
+	return o.err
+}
+
+func TestDIDRegistryObservers(t *testing.T) {
+	provider := storagetest.NewMock()
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+
+	failing := &recordingDIDObserver{registry: registry, err: errors.New("bus unavailable")}
+	recorder := &recordingDIDObserver{registry: registry}
+	registry.AddObserver(failing)
+	registry.AddObserver(recorder)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	stored := &types.DIDRegistry{
+		AgentFieldServerID: "agentfield-1",
+		RootDID:            "did:agentfield:root",
+		MasterSeed:         []byte("seed"),
+		AgentNodes: map[string]types.AgentDIDInfo{
+			"agent-a": {DID: "did:agent:a", AgentNodeID: "agent-a", PublicKeyJWK: json.RawMessage("{}"), Status: types.AgentDIDStatusActive},
+		},
+		CreatedAt:       now,
+		LastKeyRotation: now,
+	}
+	// Observer errors are logged, not returned
+	require.NoError(t, registry.StoreRegistry(stored))
+
+	// Re-storing only reports agents that were not already in storage
+	stored.AgentNodes["agent-b"] = types.AgentDIDInfo{DID: "did:agent:b", AgentNodeID: "agent-b", PublicKeyJWK: json.RawMessage("{}"), Status: types.AgentDIDStatusActive}
+	require.NoError(t, registry.StoreRegistry(stored))
+
+	require.NoError(t, registry.UpdateAgentStatus("agentfield-1", "agent-a", types.AgentDIDStatusInactive))
+	// A no-op update is not a change
+	require.NoError(t, registry.UpdateAgentStatus("agentfield-1", "agent-a", types.AgentDIDStatusInactive))
+	updated, err := registry.UpdateAllAgentStatus("agentfield-1", types.AgentDIDStatusRevoked, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, updated)
+
+	// Failed writes notify nobody
+	require.Error(t, registry.UpdateAgentStatus("agentfield-1", "agent-missing", types.AgentDIDStatusInactive))
+
+	want := []string{
+		"registered agentfield-1/agent-a",
+		"registered agentfield-1/agent-b",
+		"status agent-a active->inactive",
+		"revoked agent-a",
+		"revoked agent-b",
+	}
+	require.Equal(t, want, recorder.events)
+	require.Equal(t, want, failing.events)
+}
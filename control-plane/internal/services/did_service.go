@@ -9,7 +9,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/config"
 	"github.com/Agent-Field/agentfield/control-plane/internal/logger"
@@ -45,12 +49,12 @@ func (s *DIDService) Initialize(agentfieldServerID string) error {
 	s.agentfieldServerID = agentfieldServerID
 
 	// Check if af server already has a DID registry
-	registry, err := s.registry.GetRegistry(agentfieldServerID)
+	_, exists, err := s.registry.LookupRegistry(agentfieldServerID)
 	if err != nil {
 		return fmt.Errorf("failed to check existing registry: %w", err)
 	}
 
-	if registry == nil {
+	if !exists {
 		// Create new af server registry
 		masterSeed := make([]byte, 32)
 		if _, err := rand.Read(masterSeed); err != nil {
@@ -64,7 +68,7 @@ func (s *DIDService) Initialize(agentfieldServerID string) error {
 		}
 
 		// Create and store registry
-		registry = &types.DIDRegistry{
+		registry := &types.DIDRegistry{
 			AgentFieldServerID: agentfieldServerID,
 			MasterSeed:         masterSeed,
 			RootDID:            rootDID,
@@ -83,6 +87,118 @@ func (s *DIDService) Initialize(agentfieldServerID string) error {
 	return nil
 }
 
+// DIDSelfTestError reports which part of the DID subsystem failed SelfTest:
+// "signing", "keystore", "registry" or "storage".
+type DIDSelfTestError struct {
+	Component string
+	Err       error
+}
+
+func (e *DIDSelfTestError) Error() string {
+	return fmt.Sprintf("DID self-test failed in %s: %v", e.Component, e.Err)
+}
+
+func (e *DIDSelfTestError) Unwrap() error {
+	return e.Err
+}
+
+// SelfTest checks that the DID subsystem works end to end after Initialize.
+// It signs and verifies a nonce with an ephemeral key, round-trips that key
+// through the keystore, and stores, reads back and deletes a throwaway
+// registry. Failures are returned as a *DIDSelfTestError naming the broken
+// component. Nothing is left behind on success. It returns nil when the DID
+// system is disabled.
+func (s *DIDService) SelfTest(ctx context.Context) (err error) {
+	if !s.config.Enabled {
+		return nil
+	}
+	fail := func(component string, err error) error {
+		return &DIDSelfTestError{Component: component, Err: err}
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return fail("signing", fmt.Errorf("failed to generate self-test ID: %w", err))
+	}
+	testID := fmt.Sprintf("selftest-%x", suffix)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fail("signing", fmt.Errorf("failed to generate key: %w", err))
+	}
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return fail("signing", fmt.Errorf("failed to generate nonce: %w", err))
+	}
+	if !ed25519.Verify(publicKey, nonce, ed25519.Sign(privateKey, nonce)) {
+		return fail("signing", fmt.Errorf("signature over nonce did not verify"))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.keystore == nil {
+		return fail("keystore", fmt.Errorf("keystore not configured"))
+	}
+	if err := s.keystore.StoreKey(testID, privateKey.Seed()); err != nil {
+		return fail("keystore", err)
+	}
+	defer func() {
+		if deleteErr := s.keystore.DeleteKey(testID); deleteErr != nil && err == nil {
+			err = fail("keystore", deleteErr)
+		}
+	}()
+	seed, err := s.keystore.RetrieveKey(testID)
+	if err != nil {
+		return fail("keystore", err)
+	}
+	if !ed25519.NewKeyFromSeed(seed).Equal(privateKey) {
+		return fail("keystore", fmt.Errorf("retrieved key does not match stored key"))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.registry == nil {
+		return fail("registry", fmt.Errorf("registry not configured"))
+	}
+	// Clean up even if storing fails part way, so no throwaway entry lingers.
+	defer func() {
+		if deleteErr := s.registry.DeleteRegistry(testID); deleteErr != nil && err == nil {
+			err = fail("storage", deleteErr)
+		}
+	}()
+	rootDID := s.generateDIDKey(publicKey)
+	now := time.Now()
+	if err := s.registry.StoreRegistry(&types.DIDRegistry{
+		AgentFieldServerID: testID,
+		MasterSeed:         nonce,
+		RootDID:            rootDID,
+		AgentNodes:         make(map[string]types.AgentDIDInfo),
+		CreatedAt:          now,
+		LastKeyRotation:    now,
+	}); err != nil {
+		// The in-memory registry cannot fail; this is the write to storage.
+		return fail("storage", err)
+	}
+	loaded, exists, err := s.registry.LookupRegistry(testID)
+	if err != nil {
+		return fail("registry", err)
+	}
+	if !exists || loaded.RootDID != rootDID {
+		return fail("registry", fmt.Errorf("stored registry %s could not be read back", testID))
+	}
+
+	persisted, err := s.registry.storageProvider.GetAgentFieldServerDID(ctx, testID)
+	if err != nil {
+		return fail("storage", err)
+	}
+	if persisted == nil || persisted.RootDID != rootDID {
+		return fail("storage", fmt.Errorf("stored registry %s was not persisted", testID))
+	}
+	return nil
+}
+
 // GetAgentFieldServerID returns the af server ID for this DID service instance.
 // This method provides dynamic af server ID resolution instead of hardcoded "default".
 func (s *DIDService) GetAgentFieldServerID() (string, error) {
@@ -104,12 +220,12 @@ func (s *DIDService) validateAgentFieldServerRegistry() error {
 		return err
 	}
 
-	registry, err := s.registry.GetRegistry(agentfieldServerID)
+	_, exists, err := s.registry.LookupRegistry(agentfieldServerID)
 	if err != nil {
 		return fmt.Errorf("failed to get af server registry: %w", err)
 	}
 
-	if registry == nil {
+	if !exists {
 		return fmt.Errorf("af server registry not found for ID: %s - ensure Initialize() was called", agentfieldServerID)
 	}
 
@@ -125,7 +241,10 @@ func (s *DIDService) GetRegistry(agentfieldServerID string) (*types.DIDRegistry,
 }
 
 // RegisterAgent generates DIDs for an agent node and all its components.
-// Enhanced to support partial registration for existing agents.
+// Registration is idempotent per agent node: re-registering an identical
+// reasoner/skill set returns the existing identity package and changes
+// nothing, while a changed set adds and removes only the differing
+// components. Either way the response's Diff reports what changed.
 func (s *DIDService) RegisterAgent(req *types.DIDRegistrationRequest) (*types.DIDRegistrationResponse, error) {
 	if !s.config.Enabled {
 		return &types.DIDRegistrationResponse{
@@ -142,6 +261,14 @@ func (s *DIDService) RegisterAgent(req *types.DIDRegistrationRequest) (*types.DI
 		}, nil
 	}
 
+	req, err := normalizeRegistrationRequest(req)
+	if err != nil {
+		return &types.DIDRegistrationResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid registration request: %v", err),
+		}, nil
+	}
+
 	// Check if agent already exists
 	existingAgent, err := s.GetExistingAgentDID(req.AgentNodeID)
 	if err != nil && err.Error() != fmt.Sprintf("agent not found: %s", req.AgentNodeID) {
@@ -152,6 +279,15 @@ func (s *DIDService) RegisterAgent(req *types.DIDRegistrationRequest) (*types.DI
 	}
 
 	if existingAgent != nil {
+		// An agent node belongs to the tenant it first registered in; another
+		// tenant cannot take it over by registering the same ID.
+		if types.NormalizeTenantID(existingAgent.TenantID) != req.TenantID {
+			return &types.DIDRegistrationResponse{
+				Success: false,
+				Error:   fmt.Sprintf("agent %s is registered in another tenant", req.AgentNodeID),
+			}, nil
+		}
+
 		// Perform differential analysis
 		newReasonerIDs := extractReasonerIDs(req.Reasoners)
 		newSkillIDs := extractSkillIDs(req.Skills)
@@ -171,11 +307,16 @@ func (s *DIDService) RegisterAgent(req *types.DIDRegistrationRequest) (*types.DI
 				Success:         true,
 				Message:         "No changes detected, registration skipped",
 				IdentityPackage: identityPackage,
+				Diff:            diffResult,
 			}, nil
 		}
 
 		// Handle partial registration
-		return s.handlePartialRegistration(req, diffResult)
+		resp, err := s.handlePartialRegistration(req, diffResult)
+		if resp != nil && resp.Success {
+			resp.Diff = diffResult
+		}
+		return resp, err
 	}
 
 	// Handle new registration (existing logic)
@@ -316,6 +457,7 @@ func (s *DIDService) handleNewRegistration(req *types.DIDRegistrationRequest) (*
 	agentDIDInfo := types.AgentDIDInfo{
 		DID:            agentDID,
 		AgentNodeID:    req.AgentNodeID,
+		TenantID:       req.TenantID,
 		PublicKeyJWK:   json.RawMessage(agentPubKey),
 		DerivationPath: agentPath,
 		Reasoners:      reasonerInfos,
@@ -364,7 +506,8 @@ func (s *DIDService) handleNewRegistration(req *types.DIDRegistrationRequest) (*
 	}, nil
 }
 
-// ResolveDID resolves a DID to its public key and metadata.
+// ResolveDID resolves a DID to its public key and metadata. DIDs of a revoked
+// agent, including its reasoners and skills, still resolve with Revoked set.
 func (s *DIDService) ResolveDID(did string) (*types.DIDIdentity, error) {
 	if !s.config.Enabled {
 		return nil, fmt.Errorf("DID system is disabled")
@@ -412,6 +555,12 @@ func (s *DIDService) ResolveDID(did string) (*types.DIDIdentity, error) {
 
 	// Search through all agent nodes and their components
 	for _, agentInfo := range registry.AgentNodes {
+		revoked := agentInfo.Status == types.AgentDIDStatusRevoked
+		var rateLimit *types.RateLimitPolicy
+		if agentInfo.RateLimit != nil {
+			policy := *agentInfo.RateLimit
+			rateLimit = &policy
+		}
 		if agentInfo.DID == did {
 			// Regenerate private key from master seed and derivation path
 			privateKeyJWK, err := s.regeneratePrivateKeyJWK(registry.MasterSeed, agentInfo.DerivationPath)
@@ -425,6 +574,8 @@ func (s *DIDService) ResolveDID(did string) (*types.DIDIdentity, error) {
 				PublicKeyJWK:   string(agentInfo.PublicKeyJWK),
 				DerivationPath: agentInfo.DerivationPath,
 				ComponentType:  "agent",
+				Revoked:        revoked,
+				RateLimit:      rateLimit,
 			}, nil
 		}
 
@@ -444,6 +595,8 @@ func (s *DIDService) ResolveDID(did string) (*types.DIDIdentity, error) {
 					DerivationPath: reasonerInfo.DerivationPath,
 					ComponentType:  "reasoner",
 					FunctionName:   reasonerInfo.FunctionName,
+					Revoked:        revoked,
+					RateLimit:      rateLimit,
 				}, nil
 			}
 		}
@@ -464,14 +617,136 @@ func (s *DIDService) ResolveDID(did string) (*types.DIDIdentity, error) {
 					DerivationPath: skillInfo.DerivationPath,
 					ComponentType:  "skill",
 					FunctionName:   skillInfo.FunctionName,
+					Revoked:        revoked,
+					RateLimit:      rateLimit,
 				}, nil
 			}
 		}
 	}
 
+	// DIDs retired by a root rotation still resolve to their former public key
+	rotated, err := s.registry.GetRotatedDID(did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up rotated DID %s: %w", did, err)
+	}
+	if rotated != nil {
+		return &types.DIDIdentity{
+			DID:            rotated.DID,
+			PublicKeyJWK:   string(rotated.PublicKeyJWK),
+			DerivationPath: rotated.DerivationPath,
+			ComponentType:  rotated.ComponentType,
+			RotatedTo:      rotated.ReplacedBy,
+		}, nil
+	}
+
 	return nil, fmt.Errorf("DID not found: %s", did)
 }
 
+// RotateServerRoot replaces the af server's master seed and root DID, then
+// re-derives every agent, reasoner, and skill key along its existing
+// derivation path. The new DIDs and public keys are persisted in a single
+// storage transaction together with LastKeyRotation, and each retired DID
+// remains resolvable with its old public key and a RotatedTo pointer. The old
+// seed is discarded, so a rotation can only be undone by restoring a backup.
+func (s *DIDService) RotateServerRoot(agentfieldServerID string) error {
+	if !s.config.Enabled {
+		return fmt.Errorf("DID system is disabled")
+	}
+
+	current, err := s.registry.GetRegistry(agentfieldServerID)
+	if err != nil {
+		return fmt.Errorf("failed to get DID registry: %w", err)
+	}
+	if current == nil {
+		return fmt.Errorf("af server registry not found for ID: %s", agentfieldServerID)
+	}
+	oldRootPublicKey, err := s.regeneratePublicKeyJWK(current.MasterSeed, "m/44'/0'")
+	if err != nil {
+		return fmt.Errorf("failed to regenerate current root public key: %w", err)
+	}
+
+	snapshot, err := s.registry.SnapshotRegistry(agentfieldServerID)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot DID registry: %w", err)
+	}
+
+	masterSeed := make([]byte, 32)
+	if _, err := rand.Read(masterSeed); err != nil {
+		return fmt.Errorf("failed to generate master seed: %w", err)
+	}
+
+	rootDID, _, rootPublicKey, err := s.generateDIDWithKeys(masterSeed, "m/44'/0'")
+	if err != nil {
+		return fmt.Errorf("failed to generate root DID: %w", err)
+	}
+
+	rotations := []types.DIDKeyRotation{{
+		OldDID:          snapshot.RootDID,
+		NewDID:          rootDID,
+		ComponentType:   "agentfield_server",
+		OldPublicKeyJWK: oldRootPublicKey,
+		NewPublicKeyJWK: rootPublicKey,
+		DerivationPath:  "m/44'/0'",
+	}}
+
+	// rederive computes the replacement DID and public key for one identity
+	rederive := func(componentType, oldDID string, oldPublicKey json.RawMessage, derivationPath string) (string, json.RawMessage, error) {
+		did, _, publicKey, err := s.generateDIDWithKeys(masterSeed, derivationPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to re-derive %s DID %s: %w", componentType, oldDID, err)
+		}
+		rotations = append(rotations, types.DIDKeyRotation{
+			OldDID:          oldDID,
+			NewDID:          did,
+			ComponentType:   componentType,
+			OldPublicKeyJWK: string(oldPublicKey),
+			NewPublicKeyJWK: publicKey,
+			DerivationPath:  derivationPath,
+		})
+		return did, json.RawMessage(publicKey), nil
+	}
+
+	// The snapshot is a deep copy, so it can be rewritten in place.
+	for agentNodeID, agentInfo := range snapshot.AgentNodes {
+		agentInfo.DID, agentInfo.PublicKeyJWK, err = rederive("agent", agentInfo.DID, agentInfo.PublicKeyJWK, agentInfo.DerivationPath)
+		if err != nil {
+			return err
+		}
+		for name, reasonerInfo := range agentInfo.Reasoners {
+			reasonerInfo.DID, reasonerInfo.PublicKeyJWK, err = rederive("reasoner", reasonerInfo.DID, reasonerInfo.PublicKeyJWK, reasonerInfo.DerivationPath)
+			if err != nil {
+				return err
+			}
+			agentInfo.Reasoners[name] = reasonerInfo
+		}
+		for name, skillInfo := range agentInfo.Skills {
+			skillInfo.DID, skillInfo.PublicKeyJWK, err = rederive("skill", skillInfo.DID, skillInfo.PublicKeyJWK, skillInfo.DerivationPath)
+			if err != nil {
+				return err
+			}
+			agentInfo.Skills[name] = skillInfo
+		}
+		snapshot.AgentNodes[agentNodeID] = agentInfo
+	}
+
+	rotated := &types.DIDRegistry{
+		AgentFieldServerID: snapshot.AgentFieldServerID,
+		MasterSeed:         masterSeed,
+		RootDID:            rootDID,
+		AgentNodes:         snapshot.AgentNodes,
+		TotalDIDs:          snapshot.TotalDIDs,
+		CreatedAt:          snapshot.CreatedAt,
+		LastKeyRotation:    time.Now(),
+	}
+
+	if err := s.registry.ApplyRootRotation(rotated, rotations); err != nil {
+		return err
+	}
+
+	logger.Logger.Info().Msgf("Rotated root key for af server %s: %d DIDs re-derived", agentfieldServerID, len(rotations))
+	return nil
+}
+
 // generateDIDWithKeys generates a DID with private and public keys from master seed and derivation path.
 func (s *DIDService) generateDIDWithKeys(masterSeed []byte, derivationPath string) (string, string, string, error) {
 	// Derive private key using simplified BIP32-style derivation
@@ -781,6 +1056,14 @@ func (s *DIDService) PerformDifferentialAnalysis(agentNodeID string, newReasoner
 		UpdatedSkillIDs:    setIntersection(newSkillIDs, existingSkillIDs),
 	}
 
+	// Existing IDs come from map iteration; sort for a stable diff.
+	for _, ids := range [][]string{
+		result.NewReasonerIDs, result.RemovedReasonerIDs, result.UpdatedReasonerIDs,
+		result.NewSkillIDs, result.RemovedSkillIDs, result.UpdatedSkillIDs,
+	} {
+		sort.Strings(ids)
+	}
+
 	result.RequiresUpdate = len(result.NewReasonerIDs) > 0 ||
 		len(result.RemovedReasonerIDs) > 0 ||
 		len(result.NewSkillIDs) > 0 ||
@@ -824,6 +1107,79 @@ func setIntersection(a, b []string) []string {
 	return result
 }
 
+// componentIDPattern is the character set of a reasoner or skill ID.
+var componentIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// normalizeComponentID returns the canonical form of a reasoner or skill ID:
+// trimmed, with its casing kept, since SDKs look component DIDs up by the
+// function name they registered. IDs containing path separators, inner
+// whitespace, or other characters outside [A-Za-z0-9._-] are rejected. A
+// blank ID normalizes to "" and is skipped by registration as before.
+func normalizeComponentID(componentType, id string) (string, error) {
+	normalized := strings.TrimSpace(id)
+	switch {
+	case normalized == "":
+		return "", nil
+	case strings.ContainsAny(normalized, `/\`):
+		return "", fmt.Errorf("%s ID %q must not contain path separators", componentType, id)
+	case strings.IndexFunc(normalized, unicode.IsSpace) >= 0:
+		return "", fmt.Errorf("%s ID %q must not contain whitespace", componentType, id)
+	case !componentIDPattern.MatchString(normalized):
+		return "", fmt.Errorf("%s ID %q may only contain letters, digits, '.', '_' and '-'", componentType, id)
+	}
+	return normalized, nil
+}
+
+// normalizeRegistrationRequest returns a copy of req with every reasoner and
+// skill ID normalized. IDs that differ only in case or padding are rejected
+// rather than registered as separate components.
+func normalizeRegistrationRequest(req *types.DIDRegistrationRequest) (*types.DIDRegistrationRequest, error) {
+	normalized := *req
+	normalized.TenantID = types.NormalizeTenantID(req.TenantID)
+	normalized.Reasoners = make([]types.ReasonerDefinition, len(req.Reasoners))
+	seen := make(map[string]string, len(req.Reasoners))
+	for i, reasoner := range req.Reasoners {
+		id, err := normalizeComponentID("reasoner", reasoner.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkComponentIDCollision(seen, "reasoner", id); err != nil {
+			return nil, err
+		}
+		reasoner.ID = id
+		normalized.Reasoners[i] = reasoner
+	}
+
+	normalized.Skills = make([]types.SkillDefinition, len(req.Skills))
+	seen = make(map[string]string, len(req.Skills))
+	for i, skill := range req.Skills {
+		id, err := normalizeComponentID("skill", skill.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkComponentIDCollision(seen, "skill", id); err != nil {
+			return nil, err
+		}
+		skill.ID = id
+		normalized.Skills[i] = skill
+	}
+	return &normalized, nil
+}
+
+// checkComponentIDCollision records id in seen, keyed case-insensitively, and
+// rejects it when a different spelling of the same ID was seen before.
+func checkComponentIDCollision(seen map[string]string, componentType, id string) error {
+	if id == "" {
+		return nil
+	}
+	key := strings.ToLower(id)
+	if previous, dup := seen[key]; dup && previous != id {
+		return fmt.Errorf("%s IDs %q and %q differ only in case", componentType, previous, id)
+	}
+	seen[key] = id
+	return nil
+}
+
 // extractReasonerIDs extracts reasoner IDs from reasoner definitions.
 func extractReasonerIDs(reasoners []types.ReasonerDefinition) []string {
 	ids := make([]string, 0, len(reasoners))
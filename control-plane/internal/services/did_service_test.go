@@ -2,11 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Agent-Field/agentfield/control-plane/internal/config"
 	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage/storagetest"
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
 
 	"github.com/stretchr/testify/require"
@@ -58,10 +62,18 @@ func TestDIDServiceRegisterAgentAndResolve(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, agents)
 
+	require.NoError(t, service.SelfTest(ctx))
+	servers, err := provider.ListAgentFieldServerDIDs(ctx)
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+
 	agentIdentity := resp.IdentityPackage.AgentDID
 	resolved, err := service.ResolveDID(agentIdentity.DID)
 	require.NoError(t, err)
 	require.Equal(t, agentIdentity.DID, resolved.DID)
+	require.NotEmpty(t, resolved.PublicKeyJWK)
+	require.JSONEq(t, agentIdentity.PublicKeyJWK, resolved.PublicKeyJWK)
+	require.False(t, resolved.Revoked)
 
 	reasonerIdentity := resp.IdentityPackage.ReasonerDIDs["reasoner.fn"]
 	resolvedReasoner, err := service.ResolveDID(reasonerIdentity.DID)
@@ -72,6 +84,168 @@ func TestDIDServiceRegisterAgentAndResolve(t *testing.T) {
 	resolvedSkill, err := service.ResolveDID(skillIdentity.DID)
 	require.NoError(t, err)
 	require.Equal(t, skillIdentity.DID, resolvedSkill.DID)
+	require.Nil(t, resolvedSkill.RateLimit)
+
+	policy := types.RateLimitPolicy{RequestsPerMinute: 60, Burst: 10}
+	require.NoError(t, registry.SetAgentRateLimit(agentfieldID, "agent-alpha", policy))
+	resolved, err = service.ResolveDID(agentIdentity.DID)
+	require.NoError(t, err)
+	require.Equal(t, &policy, resolved.RateLimit)
+
+	// Revoked DIDs keep resolving to their key so verifiers can decide
+	require.NoError(t, registry.UpdateAgentStatus(agentfieldID, "agent-alpha", types.AgentDIDStatusRevoked))
+	resolved, err = service.ResolveDID(agentIdentity.DID)
+	require.NoError(t, err)
+	require.True(t, resolved.Revoked)
+	require.JSONEq(t, agentIdentity.PublicKeyJWK, resolved.PublicKeyJWK)
+	resolvedSkill, err = service.ResolveDID(skillIdentity.DID)
+	require.NoError(t, err)
+	require.True(t, resolvedSkill.Revoked)
+	require.NotEmpty(t, resolvedSkill.PublicKeyJWK)
+}
+
+func TestDIDServiceRotateServerRoot(t *testing.T) {
+	service, registry, provider, ctx, agentfieldID := setupDIDTestEnvironment(t)
+
+	resp, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+		AgentNodeID: "agent-alpha",
+		Reasoners:   []types.ReasonerDefinition{{ID: "reasoner.fn"}},
+		Skills:      []types.SkillDefinition{{ID: "skill.fn"}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	oldAgent := resp.IdentityPackage.AgentDID
+	oldReasoner := resp.IdentityPackage.ReasonerDIDs["reasoner.fn"]
+
+	before, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	oldRootDID := before.RootDID
+	oldRotation := before.LastKeyRotation
+
+	require.NoError(t, service.RotateServerRoot(agentfieldID))
+
+	after, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.NotEqual(t, oldRootDID, after.RootDID)
+	require.True(t, after.LastKeyRotation.After(oldRotation))
+
+	agent := after.AgentNodes["agent-alpha"]
+	require.NotEqual(t, oldAgent.DID, agent.DID)
+	require.Equal(t, oldAgent.DerivationPath, agent.DerivationPath)
+	newReasoner := agent.Reasoners["reasoner.fn"]
+	require.NotEqual(t, oldReasoner.DID, newReasoner.DID)
+
+	// New DIDs resolve with regenerated private keys.
+	resolved, err := service.ResolveDID(agent.DID)
+	require.NoError(t, err)
+	require.NotEmpty(t, resolved.PrivateKeyJWK)
+	require.Empty(t, resolved.RotatedTo)
+
+	// Old DIDs still resolve, as rotated, to their former public keys.
+	resolvedOld, err := service.ResolveDID(oldReasoner.DID)
+	require.NoError(t, err)
+	require.Equal(t, newReasoner.DID, resolvedOld.RotatedTo)
+	require.JSONEq(t, oldReasoner.PublicKeyJWK, resolvedOld.PublicKeyJWK)
+	require.Empty(t, resolvedOld.PrivateKeyJWK)
+
+	resolvedOldRoot, err := service.ResolveDID(oldRootDID)
+	require.NoError(t, err)
+	require.Equal(t, after.RootDID, resolvedOldRoot.RotatedTo)
+
+	// Storage carries the new identities.
+	serverInfo, err := provider.GetAgentFieldServerDID(ctx, agentfieldID)
+	require.NoError(t, err)
+	require.Equal(t, after.RootDID, serverInfo.RootDID)
+	components, err := provider.ListAgentComponentDIDs(ctx, "agent-alpha")
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+	for _, component := range components {
+		require.Equal(t, agent.DID, component.AgentDID)
+		require.NotEqual(t, oldReasoner.DID, component.DID)
+	}
+
+	require.Error(t, service.RotateServerRoot("missing-server"))
+}
+
+func TestDIDRegistryApplyRootRotationKeepsInterleavedUpdates(t *testing.T) {
+	service, registry, provider, ctx, agentfieldID := setupDIDTestEnvironment(t)
+
+	register := func(skills ...types.SkillDefinition) {
+		resp, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+			AgentNodeID: "agent-alpha",
+			Reasoners:   []types.ReasonerDefinition{{ID: "reasoner.fn"}},
+			Skills:      skills,
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success)
+	}
+	// rotate re-keys every DID of a snapshot the way RotateServerRoot does,
+	// without deriving real keys.
+	rotate := func() (*types.DIDRegistry, []types.DIDKeyRotation) {
+		snapshot, err := registry.SnapshotRegistry(agentfieldID)
+		require.NoError(t, err)
+		rotations := []types.DIDKeyRotation{{OldDID: snapshot.RootDID, NewDID: snapshot.RootDID + "-rotated", ComponentType: "agentfield_server"}}
+		rekey := func(componentType, did string) (string, json.RawMessage) {
+			rotations = append(rotations, types.DIDKeyRotation{OldDID: did, NewDID: did + "-rotated", ComponentType: componentType, NewPublicKeyJWK: `{"kty":"OKP"}`})
+			return did + "-rotated", json.RawMessage(`{"kty":"OKP"}`)
+		}
+		for id, agentInfo := range snapshot.AgentNodes {
+			agentInfo.DID, agentInfo.PublicKeyJWK = rekey("agent", agentInfo.DID)
+			for name, reasonerInfo := range agentInfo.Reasoners {
+				reasonerInfo.DID, reasonerInfo.PublicKeyJWK = rekey("reasoner", reasonerInfo.DID)
+				agentInfo.Reasoners[name] = reasonerInfo
+			}
+			for name, skillInfo := range agentInfo.Skills {
+				skillInfo.DID, skillInfo.PublicKeyJWK = rekey("skill", skillInfo.DID)
+				agentInfo.Skills[name] = skillInfo
+			}
+			snapshot.AgentNodes[id] = agentInfo
+		}
+		return &types.DIDRegistry{
+			AgentFieldServerID: snapshot.AgentFieldServerID,
+			MasterSeed:         []byte("rotated-master-seed-0123456789ab"),
+			RootDID:            snapshot.RootDID + "-rotated",
+			AgentNodes:         snapshot.AgentNodes,
+			TotalDIDs:          snapshot.TotalDIDs,
+			CreatedAt:          snapshot.CreatedAt,
+			LastKeyRotation:    time.Now(),
+		}, rotations
+	}
+
+	register(types.SkillDefinition{ID: "skill.fn"})
+	before, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	oldDID := before.AgentNodes["agent-alpha"].DID
+
+	// Status and rate-limit updates land between the snapshot and the apply.
+	rotated, rotations := rotate()
+	require.NoError(t, registry.UpdateAgentStatus(agentfieldID, "agent-alpha", types.AgentDIDStatusInactive))
+	require.NoError(t, registry.SetAgentRateLimit(agentfieldID, "agent-alpha", types.RateLimitPolicy{RequestsPerMinute: 30, Burst: 5}))
+	require.NoError(t, registry.ApplyRootRotation(rotated, rotations))
+
+	after, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	agent := after.AgentNodes["agent-alpha"]
+	require.Equal(t, oldDID+"-rotated", agent.DID)
+	require.Equal(t, rotated.RootDID, after.RootDID)
+	require.Equal(t, types.AgentDIDStatusInactive, agent.Status)
+	require.NotNil(t, agent.RateLimit)
+	require.Equal(t, 30, agent.RateLimit.RequestsPerMinute)
+	stored, err := provider.GetAgentDID(ctx, "agent-alpha")
+	require.NoError(t, err)
+	require.Equal(t, agent.DID, stored.DID)
+	require.Equal(t, types.AgentDIDStatusInactive, stored.Status)
+
+	// A component registered after the snapshot has no rotated DID, so the
+	// rotation is refused and the live registry is left alone.
+	rotated, rotations = rotate()
+	register(types.SkillDefinition{ID: "skill.fn"}, types.SkillDefinition{ID: "skill.new"})
+	err = registry.ApplyRootRotation(rotated, rotations)
+	require.ErrorContains(t, err, "changed during rotation")
+	unchanged, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.Equal(t, after.RootDID, unchanged.RootDID)
+	require.Contains(t, unchanged.AgentNodes["agent-alpha"].Skills, "skill.new")
 }
 
 func TestDIDServiceValidateRegistryFailure(t *testing.T) {
@@ -101,6 +275,64 @@ func TestDIDServiceValidateRegistryFailure(t *testing.T) {
 	_ = ctx
 }
 
+// failingServerDIDStorage fails af server DID writes once fail is set.
+type failingServerDIDStorage struct {
+	storage.StorageProvider
+	fail bool
+}
+
+func (s *failingServerDIDStorage) StoreAgentFieldServerDID(ctx context.Context, agentfieldServerID, rootDID string, masterSeed []byte, createdAt, lastKeyRotation time.Time) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.StorageProvider.StoreAgentFieldServerDID(ctx, agentfieldServerID, rootDID, masterSeed, createdAt, lastKeyRotation)
+}
+
+func TestDIDServiceSelfTest(t *testing.T) {
+	ctx := context.Background()
+	provider := &failingServerDIDStorage{StorageProvider: storagetest.NewMock()}
+	registry := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, registry.Initialize())
+
+	keystoreCfg := &config.KeystoreConfig{Path: filepath.Join(t.TempDir(), "keys"), Type: "local"}
+	ks, err := NewKeystoreService(keystoreCfg)
+	require.NoError(t, err)
+	service := NewDIDService(&config.DIDConfig{Enabled: true}, ks, registry)
+	require.NoError(t, service.Initialize("agentfield-test"))
+
+	require.NoError(t, service.SelfTest(ctx))
+
+	// Nothing is left behind.
+	servers, err := provider.ListAgentFieldServerDIDs(ctx)
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	require.Equal(t, "agentfield-test", servers[0].AgentFieldServerID)
+	registries, err := registry.ListRegistries()
+	require.NoError(t, err)
+	require.Len(t, registries, 1)
+	keys, err := ks.ListKeys()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	var selfTestErr *DIDSelfTestError
+	provider.fail = true
+	err = service.SelfTest(ctx)
+	require.ErrorAs(t, err, &selfTestErr)
+	require.Equal(t, "storage", selfTestErr.Component)
+	require.ErrorContains(t, err, "disk full")
+	registries, err = registry.ListRegistries()
+	require.NoError(t, err)
+	require.Len(t, registries, 1)
+
+	provider.fail = false
+	keystoreCfg.Type = "vault"
+	err = service.SelfTest(ctx)
+	require.ErrorAs(t, err, &selfTestErr)
+	require.Equal(t, "keystore", selfTestErr.Component)
+
+	require.NoError(t, NewDIDService(&config.DIDConfig{Enabled: false}, nil, nil).SelfTest(ctx))
+}
+
 func TestDIDService_ResolveDID_RootDID(t *testing.T) {
 	service, registry, _, _, agentfieldID := setupDIDTestEnvironment(t)
 
@@ -150,7 +382,7 @@ func TestDIDService_ResolveDID_DisabledSystem(t *testing.T) {
 }
 
 func TestDIDService_RegisterAgent_ExistingAgent_NoChanges(t *testing.T) {
-	service, _, _, _, _ := setupDIDTestEnvironment(t)
+	service, registry, _, _, agentfieldID := setupDIDTestEnvironment(t)
 
 	// Register agent first time
 	req1 := &types.DIDRegistrationRequest{
@@ -175,6 +407,155 @@ func TestDIDService_RegisterAgent_ExistingAgent_NoChanges(t *testing.T) {
 	require.True(t, resp2.Success)
 	require.Contains(t, resp2.Message, "No changes detected")
 	require.Equal(t, resp1.IdentityPackage.AgentDID.DID, resp2.IdentityPackage.AgentDID.DID)
+	require.Equal(t, resp1.IdentityPackage.ReasonerDIDs["reasoner1"].DID, resp2.IdentityPackage.ReasonerDIDs["reasoner1"].DID)
+	require.Equal(t, resp1.IdentityPackage.SkillDIDs["skill1"].DID, resp2.IdentityPackage.SkillDIDs["skill1"].DID)
+	require.NotNil(t, resp2.Diff)
+	require.False(t, resp2.Diff.RequiresUpdate)
+
+	// The registry is left untouched.
+	before, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	totalDIDs := before.TotalDIDs
+	_, err = service.RegisterAgent(req2)
+	require.NoError(t, err)
+	after, err := registry.GetRegistry(agentfieldID)
+	require.NoError(t, err)
+	require.Equal(t, totalDIDs, after.TotalDIDs)
+	require.Len(t, after.AgentNodes["agent-existing"].Skills, 1)
+}
+
+func TestDIDService_RegisterAgent_NormalizesComponentIDs(t *testing.T) {
+	service, _, _, _, _ := setupDIDTestEnvironment(t)
+
+	resp1, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+		AgentNodeID: "agent-normalized",
+		Reasoners:   []types.ReasonerDefinition{{ID: "summarizeText"}},
+		Skills:      []types.SkillDefinition{{ID: "web.search"}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp1.Success)
+	require.Contains(t, resp1.IdentityPackage.ReasonerDIDs, "summarizeText", "casing is kept for SDK lookups")
+
+	// Padding is trimmed; the mixed-case component keeps its DID
+	req := &types.DIDRegistrationRequest{
+		AgentNodeID: "agent-normalized",
+		Reasoners:   []types.ReasonerDefinition{{ID: "  summarizeText\t"}},
+		Skills:      []types.SkillDefinition{{ID: " web.search "}},
+	}
+	resp2, err := service.RegisterAgent(req)
+	require.NoError(t, err)
+	require.True(t, resp2.Success)
+	require.Contains(t, resp2.Message, "No changes detected")
+	require.Equal(t, resp1.IdentityPackage.ReasonerDIDs["summarizeText"].DID, resp2.IdentityPackage.ReasonerDIDs["summarizeText"].DID)
+	require.Equal(t, resp1.IdentityPackage.SkillDIDs["web.search"].DID, resp2.IdentityPackage.SkillDIDs["web.search"].DID)
+	require.Equal(t, "  summarizeText\t", req.Reasoners[0].ID, "caller's request is not modified")
+
+	existing, err := service.GetExistingAgentDID("agent-normalized")
+	require.NoError(t, err)
+	require.Equal(t, resp1.IdentityPackage.ReasonerDIDs["summarizeText"].DID, existing.Reasoners["summarizeText"].DID)
+
+	for _, tc := range []struct {
+		reasoners []types.ReasonerDefinition
+		skills    []types.SkillDefinition
+		wantErr   string
+	}{
+		{reasoners: []types.ReasonerDefinition{{ID: "../summarize"}}, wantErr: "path separators"},
+		{skills: []types.SkillDefinition{{ID: `web\search`}}, wantErr: "path separators"},
+		{reasoners: []types.ReasonerDefinition{{ID: "sum marize"}}, wantErr: "whitespace"},
+		{skills: []types.SkillDefinition{{ID: "search!"}}, wantErr: "may only contain"},
+		{reasoners: []types.ReasonerDefinition{{ID: "plan"}, {ID: "Plan "}}, wantErr: "differ only in case"},
+	} {
+		resp, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+			AgentNodeID: "agent-invalid-ids",
+			Reasoners:   tc.reasoners,
+			Skills:      tc.skills,
+		})
+		require.NoError(t, err)
+		require.False(t, resp.Success)
+		require.Contains(t, resp.Error, tc.wantErr)
+	}
+	_, err = service.GetExistingAgentDID("agent-invalid-ids")
+	require.Error(t, err, "rejected requests register nothing")
+}
+
+func TestDIDService_RegisterAgent_ExistingAgent_AddSkill(t *testing.T) {
+	service, _, _, _, _ := setupDIDTestEnvironment(t)
+
+	resp1, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+		AgentNodeID: "agent-grow",
+		Reasoners:   []types.ReasonerDefinition{{ID: "reasoner1"}},
+		Skills:      []types.SkillDefinition{{ID: "skill1"}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp1.Success)
+
+	resp2, err := service.RegisterAgent(&types.DIDRegistrationRequest{
+		AgentNodeID: "agent-grow",
+		Reasoners:   []types.ReasonerDefinition{{ID: "reasoner1"}},
+		Skills:      []types.SkillDefinition{{ID: "skill1"}, {ID: "skill2"}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp2.Success)
+
+	require.NotNil(t, resp2.Diff)
+	require.True(t, resp2.Diff.RequiresUpdate)
+	require.Equal(t, []string{"skill2"}, resp2.Diff.NewSkillIDs)
+	require.Empty(t, resp2.Diff.NewReasonerIDs)
+	require.Empty(t, resp2.Diff.RemovedReasonerIDs)
+	require.Empty(t, resp2.Diff.RemovedSkillIDs)
+
+	// Only the new skill gets a DID, issued with its private key.
+	require.Equal(t, resp1.IdentityPackage.AgentDID.DID, resp2.IdentityPackage.AgentDID.DID)
+	require.Empty(t, resp2.IdentityPackage.ReasonerDIDs)
+	require.Len(t, resp2.IdentityPackage.SkillDIDs, 1)
+	require.NotEmpty(t, resp2.IdentityPackage.SkillDIDs["skill2"].PrivateKeyJWK)
+
+	existing, err := service.GetExistingAgentDID("agent-grow")
+	require.NoError(t, err)
+	require.Equal(t, resp1.IdentityPackage.SkillDIDs["skill1"].DID, existing.Skills["skill1"].DID)
+	require.Equal(t, resp2.IdentityPackage.SkillDIDs["skill2"].DID, existing.Skills["skill2"].DID)
+}
+
+func TestDIDService_RegisterAgent_StoresTenant(t *testing.T) {
+	service, _, provider, ctx, _ := setupDIDTestEnvironment(t)
+
+	req := &types.DIDRegistrationRequest{
+		AgentNodeID: "agent-acme",
+		Reasoners:   []types.ReasonerDefinition{{ID: "reasoner1"}},
+		TenantID:    " acme ",
+	}
+	resp, err := service.RegisterAgent(req)
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	acme, err := provider.ListAgentDIDsForTenant(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, acme, 1)
+	require.Equal(t, resp.IdentityPackage.AgentDID.DID, acme[0].DID)
+	defaults, err := provider.ListAgentDIDsForTenant(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, defaults)
+
+	// The tenant survives a reload from storage
+	reloaded := NewDIDRegistryWithStorage(provider)
+	require.NoError(t, reloaded.Initialize())
+	registry, err := reloaded.GetRegistry("agentfield-test")
+	require.NoError(t, err)
+	require.Equal(t, "acme", registry.AgentNodes["agent-acme"].TenantID)
+
+	// Another tenant cannot re-register the same agent node
+	resp, err = service.RegisterAgent(&types.DIDRegistrationRequest{
+		AgentNodeID: "agent-acme",
+		Reasoners:   []types.ReasonerDefinition{{ID: "reasoner1"}, {ID: "reasoner2"}},
+		TenantID:    "globex",
+	})
+	require.NoError(t, err)
+	require.False(t, resp.Success)
+	require.Contains(t, resp.Error, "another tenant")
+
+	resp, err = service.RegisterAgent(req)
+	require.NoError(t, err)
+	require.True(t, resp.Success)
 }
 
 func TestDIDService_PartialRegisterAgent_NewComponents(t *testing.T) {
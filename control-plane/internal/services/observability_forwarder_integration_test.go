@@ -6,11 +6,11 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -42,7 +42,7 @@ func setupIntegrationTest(t *testing.T) (*storage.LocalStorage, context.Context)
 
 	ls := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(err.Error(), "no such module: fts5") {
+		if errors.Is(err, storage.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping integration test")
 		}
 		t.Fatalf("initialize storage: %v", err)
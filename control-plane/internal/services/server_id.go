@@ -0,0 +1,31 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// GenerateAgentFieldServerID creates a deterministic af server ID based on the agentfield home directory.
+// This ensures each agentfield instance has a unique ID while being deterministic for the same installation.
+// A non-empty salt is mixed into the hash so installs sharing a home path get distinct IDs; an empty salt
+// yields the same ID as before salts existed.
+func GenerateAgentFieldServerID(agentfieldHome, salt string) string {
+	// Use the absolute path of agentfield home to generate a deterministic ID
+	absPath, err := filepath.Abs(agentfieldHome)
+	if err != nil {
+		// Fallback to the original path if absolute path fails
+		absPath = agentfieldHome
+	}
+
+	// Create a hash of the agentfield home path to generate a unique but deterministic ID
+	seed := absPath
+	if salt != "" {
+		seed += "\x00" + salt
+	}
+	hash := sha256.Sum256([]byte(seed))
+
+	// Use first 16 characters of the hex hash as the af server ID
+	// This provides uniqueness while keeping the ID manageable
+	return hex.EncodeToString(hash[:])[:16]
+}
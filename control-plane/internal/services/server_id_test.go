@@ -0,0 +1,31 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAgentFieldServerIDDeterministic(t *testing.T) {
+	dir1 := filepath.Join("/tmp", "agentfield-test-1")
+	dir2 := filepath.Join("/tmp", "agentfield-test-2")
+
+	require.Equal(t, GenerateAgentFieldServerID(dir1, ""), GenerateAgentFieldServerID(dir1, ""), "expected deterministic ID for same path")
+	require.NotEqual(t, GenerateAgentFieldServerID(dir1, ""), GenerateAgentFieldServerID(dir2, ""), "expected different IDs for different paths")
+}
+
+func TestGenerateAgentFieldServerIDSalt(t *testing.T) {
+	dir := filepath.Join("/tmp", "agentfield-test-1")
+
+	// An unset salt keeps the path-only ID of existing installs
+	sum := sha256.Sum256([]byte(dir))
+	require.Equal(t, hex.EncodeToString(sum[:])[:16], GenerateAgentFieldServerID(dir, ""))
+
+	idA := GenerateAgentFieldServerID(dir, "container-a")
+	require.NotEqual(t, idA, GenerateAgentFieldServerID(dir, "container-b"), "expected different IDs for different salts on the same path")
+	require.NotEqual(t, idA, GenerateAgentFieldServerID(dir, ""), "expected salted ID to differ from the unsalted ID")
+	require.Equal(t, idA, GenerateAgentFieldServerID(dir, "container-a"), "expected deterministic ID for same path and salt")
+}
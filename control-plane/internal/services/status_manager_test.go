@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -69,7 +68,7 @@ func setupStatusManagerStorage(t *testing.T) (storage.StorageProvider, context.C
 
 	provider := storage.NewLocalStorage(storage.LocalStorageConfig{})
 	if err := provider.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, storage.ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping status manager test")
 		}
 		require.NoError(t, err)
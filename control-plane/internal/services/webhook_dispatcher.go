@@ -449,7 +449,8 @@ func (d *webhookDispatcher) computeBackoff(attempt int) time.Duration {
 
 func determineWebhookEvent(status string) string {
 	switch types.NormalizeExecutionStatus(status) {
-	case string(types.ExecutionStatusSucceeded):
+	case string(types.ExecutionStatusSucceeded), string(types.ExecutionStatusSucceededWithWarnings):
+		// Warnings are reported through the payload status, not the event.
 		return types.WebhookEventExecutionCompleted
 	default:
 		return types.WebhookEventExecutionFailed
@@ -583,6 +583,7 @@ func TestWebhookDispatcher_DetermineWebhookEvent(t *testing.T) {
 		expected string
 	}{
 		{"succeeded", "execution.completed"},
+		{"succeeded_with_warnings", "execution.completed"},
 		{"failed", "execution.failed"},
 		{"running", "execution.failed"},   // Non-succeeded defaults to failed
 		{"pending", "execution.failed"},   // Non-succeeded defaults to failed
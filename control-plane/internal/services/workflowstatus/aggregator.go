@@ -29,14 +29,16 @@ var (
 		string(types.ExecutionStatusRunning),
 		string(types.ExecutionStatusQueued),
 		string(types.ExecutionStatusPending),
+		string(types.ExecutionStatusSucceededWithWarnings),
 		string(types.ExecutionStatusSucceeded),
 	}
 
 	terminalStatuses = map[string]struct{}{
-		string(types.ExecutionStatusSucceeded): {},
-		string(types.ExecutionStatusFailed):    {},
-		string(types.ExecutionStatusTimeout):   {},
-		string(types.ExecutionStatusCancelled): {},
+		string(types.ExecutionStatusSucceeded):             {},
+		string(types.ExecutionStatusSucceededWithWarnings): {},
+		string(types.ExecutionStatusFailed):                {},
+		string(types.ExecutionStatusTimeout):               {},
+		string(types.ExecutionStatusCancelled):             {},
 	}
 
 	activeStepStatuses = map[string]struct{}{
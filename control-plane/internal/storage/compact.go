@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// Compact deletes component DID rows whose agent DID no longer exists and
+// then reclaims free space: VACUUM rebuilds the SQLite file, while PostgreSQL
+// vacuums the DID tables so their dead tuples can be reused. Compact only
+// does the work; scheduling it is left to the operator.
+func (ls *LocalStorage) Compact(ctx context.Context) (*types.CompactionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during compact: %w", err)
+	}
+
+	start := time.Now()
+	result := &types.CompactionResult{}
+
+	var sizeBefore int64
+	if ls.mode != "postgres" {
+		size, err := ls.sqliteFileSize(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sizeBefore = size
+	}
+
+	res, err := ls.db.ExecContext(ctx, `
+		DELETE FROM component_dids
+		WHERE agent_did NOT IN (SELECT did FROM agent_dids)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned component DIDs: %w", err)
+	}
+	if deleted, err := res.RowsAffected(); err == nil {
+		result.OrphanedComponentDIDs = int(deleted)
+	}
+
+	// VACUUM cannot run inside a transaction in either database.
+	vacuum := "VACUUM"
+	if ls.mode == "postgres" {
+		vacuum = "VACUUM component_dids, agent_dids"
+	}
+	if _, err := ls.db.ExecContext(ctx, vacuum); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	if ls.mode != "postgres" {
+		sizeAfter, err := ls.sqliteFileSize(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if sizeBefore > sizeAfter {
+			result.FreedSpaceBytes = sizeBefore - sizeAfter
+		}
+	}
+
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// sqliteFileSize returns the size of the main SQLite database in bytes.
+func (ls *LocalStorage) sqliteFileSize(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+	if err := ls.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := ls.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactRemovesOrphanedComponentDIDs(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, ls.StoreAgentFieldServerDID(ctx, "agentfield-1", "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, ls.StoreAgentDIDWithComponents(ctx, "agent-kept", "did:agent:kept", "agentfield-1", "", "{}", 0, []ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:kept", ComponentType: "reasoner", ComponentName: "plan", PublicKeyJWK: "{}", DerivationIndex: 1},
+	}))
+	require.NoError(t, ls.StoreAgentDIDWithComponents(ctx, "agent-gone", "did:agent:gone", "agentfield-1", "", "{}", 1, []ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:gone", ComponentType: "reasoner", ComponentName: "plan", PublicKeyJWK: "{}", DerivationIndex: 2},
+		{ComponentDID: "did:skill:gone", ComponentType: "skill", ComponentName: "search", PublicKeyJWK: "{}", DerivationIndex: 3},
+	}))
+
+	// Delete the agent but leave its components behind
+	_, err := ls.db.ExecContext(ctx, "DELETE FROM agent_dids WHERE did = ?", "did:agent:gone")
+	require.NoError(t, err)
+
+	result, err := ls.Compact(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.OrphanedComponentDIDs)
+	require.GreaterOrEqual(t, result.FreedSpaceBytes, int64(0))
+
+	var remaining int
+	require.NoError(t, ls.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM component_dids WHERE agent_did = ?", "did:agent:gone").Scan(&remaining))
+	require.Zero(t, remaining)
+
+	kept, err := ls.ListComponentDIDs(ctx, "did:agent:kept")
+	require.NoError(t, err)
+	require.Len(t, kept, 1)
+
+	// Nothing is left to reclaim on a second pass
+	result, err = ls.Compact(ctx)
+	require.NoError(t, err)
+	require.Zero(t, result.OrphanedComponentDIDs)
+}
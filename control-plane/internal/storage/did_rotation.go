@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// RotateAgentFieldServerRoot replaces an af server's root DID and master seed
+// and rewrites every re-derived agent and component DID in one transaction.
+// Each retired DID is recorded in rotated_dids with its former public key so
+// it stays resolvable. There is no inverse operation; undoing a rotation
+// requires restoring a backup.
+func (ls *LocalStorage) RotateAgentFieldServerRoot(ctx context.Context, agentfieldServerID, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during rotate af server root: %w", err)
+	}
+	if strings.TrimSpace(agentfieldServerID) == "" {
+		return &ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+	if newRootDID == "" {
+		return &ValidationError{
+			Field:   "root_did",
+			Value:   newRootDID,
+			Reason:  "root DID cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+	if len(newMasterSeed) == 0 {
+		return &ValidationError{
+			Field:   "master_seed",
+			Value:   "<encrypted>",
+			Reason:  "master seed cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+
+	tx, err := ls.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackTx(tx, "RotateAgentFieldServerRoot")
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE did_registry SET root_did = ?, master_seed_encrypted = ?, last_key_rotation = ?
+		WHERE agentfield_server_id = ?`,
+		newRootDID, newMasterSeed, rotatedAt, agentfieldServerID)
+	if err != nil {
+		err = fmt.Errorf("failed to update af server root: %w", err)
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("failed to read rotated af server rows: %w", err)
+		return err
+	}
+	if affected == 0 {
+		err = fmt.Errorf("af server DID for %s not found", agentfieldServerID)
+		return err
+	}
+
+	for _, rotation := range rotations {
+		switch rotation.ComponentType {
+		case "agentfield_server":
+			// The root row was updated above.
+		case "agent":
+			if _, err = tx.ExecContext(ctx, `
+				UPDATE agent_dids SET did = ?, public_key_jwk = ?, updated_at = ?
+				WHERE did = ? AND agentfield_server_id = ?`,
+				rotation.NewDID, rotation.NewPublicKeyJWK, rotatedAt, rotation.OldDID, agentfieldServerID); err != nil {
+				err = fmt.Errorf("failed to rotate agent DID %s: %w", rotation.OldDID, err)
+				return err
+			}
+			if _, err = tx.ExecContext(ctx, `
+				UPDATE component_dids SET agent_did = ? WHERE agent_did = ?`,
+				rotation.NewDID, rotation.OldDID); err != nil {
+				err = fmt.Errorf("failed to re-parent components of agent DID %s: %w", rotation.OldDID, err)
+				return err
+			}
+		case "reasoner", "skill":
+			if _, err = tx.ExecContext(ctx, `
+				UPDATE component_dids SET did = ?, public_key_jwk = ?, updated_at = ?
+				WHERE did = ?`,
+				rotation.NewDID, rotation.NewPublicKeyJWK, rotatedAt, rotation.OldDID); err != nil {
+				err = fmt.Errorf("failed to rotate component DID %s: %w", rotation.OldDID, err)
+				return err
+			}
+		default:
+			err = &ValidationError{
+				Field:   "component_type",
+				Value:   rotation.ComponentType,
+				Reason:  "unknown DID component type",
+				Context: "RotateAgentFieldServerRoot",
+			}
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO rotated_dids (
+				did, replaced_by, agentfield_server_id, component_type, public_key_jwk, derivation_path, rotated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			rotation.OldDID, rotation.NewDID, agentfieldServerID, rotation.ComponentType,
+			rotation.OldPublicKeyJWK, rotation.DerivationPath, rotatedAt); err != nil {
+			err = fmt.Errorf("failed to record rotated DID %s: %w", rotation.OldDID, err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit af server root rotation: %w", err)
+		return err
+	}
+	return nil
+}
+
+// GetRotatedDID returns the rotation record for a retired DID, or (nil, nil)
+// if the DID was never rotated.
+func (ls *LocalStorage) GetRotatedDID(ctx context.Context, did string) (*types.RotatedDIDInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get rotated DID: %w", err)
+	}
+
+	info := &types.RotatedDIDInfo{}
+	var publicKeyJWK string
+	err := ls.db.QueryRowContext(ctx, `
+		SELECT did, replaced_by, agentfield_server_id, component_type, public_key_jwk, derivation_path, rotated_at
+		FROM rotated_dids
+		WHERE did = ?`, did).Scan(&info.DID, &info.ReplacedBy, &info.AgentFieldServerID,
+		&info.ComponentType, &publicKeyJWK, &info.DerivationPath, &info.RotatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get rotated DID: %w", err)
+	}
+	info.PublicKeyJWK = json.RawMessage(publicKeyJWK)
+	return info, nil
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// UpdateAgentDIDStatus changes the status of an agent DID and appends the
+// transition to agent_did_status_history in the same transaction.
+func (ls *LocalStorage) UpdateAgentDIDStatus(ctx context.Context, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent DID status: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return &ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "UpdateAgentDIDStatus",
+		}
+	}
+
+	tx, err := ls.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackTx(tx, "UpdateAgentDIDStatus")
+		}
+	}()
+
+	if err = updateAgentDIDStatusTx(ctx, tx, agentfieldServerID, agentNodeID, status, reason, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit agent DID status update: %w", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateAgentDIDStatuses changes the status of several agent DIDs on one af
+// server in a single transaction, recording each transition in the status
+// history. Either every agent is updated or none are.
+func (ls *LocalStorage) UpdateAgentDIDStatuses(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent DID statuses: %w", err)
+	}
+	for _, agentNodeID := range agentNodeIDs {
+		if strings.TrimSpace(agentNodeID) == "" {
+			return &ValidationError{
+				Field:   "agent_node_id",
+				Value:   agentNodeID,
+				Reason:  "agent node ID cannot be empty",
+				Context: "UpdateAgentDIDStatuses",
+			}
+		}
+	}
+	if len(agentNodeIDs) == 0 {
+		return nil
+	}
+
+	tx, err := ls.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackTx(tx, "UpdateAgentDIDStatuses")
+		}
+	}()
+
+	now := time.Now().UTC()
+	for _, agentNodeID := range agentNodeIDs {
+		if err = updateAgentDIDStatusTx(ctx, tx, agentfieldServerID, agentNodeID, status, reason, now); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit agent DID status updates: %w", err)
+		return err
+	}
+	return nil
+}
+
+// updateAgentDIDStatusTx updates one agent DID's status and appends the
+// transition to its history within tx.
+func updateAgentDIDStatusTx(ctx context.Context, tx DBTX, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string, now time.Time) error {
+	var previous string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM agent_dids
+		WHERE agent_node_id = ? AND agentfield_server_id = ?`,
+		agentNodeID, agentfieldServerID).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("agent DID for %s not found", agentNodeID)
+		}
+		return fmt.Errorf("failed to read agent DID status: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE agent_dids SET status = ?, updated_at = ?
+		WHERE agent_node_id = ? AND agentfield_server_id = ?`,
+		string(status), now, agentNodeID, agentfieldServerID); err != nil {
+		return fmt.Errorf("failed to update agent DID status: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO agent_did_status_history (
+			agent_node_id, agentfield_server_id, previous_status, new_status, reason, changed_at
+		) VALUES (?, ?, ?, ?, ?, ?)`,
+		agentNodeID, agentfieldServerID, previous, string(status), reason, now); err != nil {
+		return fmt.Errorf("failed to record agent DID status history: %w", err)
+	}
+	return nil
+}
+
+// SetAgentDIDRateLimit stores the rate-limit policy of an agent DID. A nil
+// policy clears it.
+func (ls *LocalStorage) SetAgentDIDRateLimit(ctx context.Context, agentfieldServerID, agentNodeID string, policy *types.RateLimitPolicy) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during set agent DID rate limit: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return &ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "SetAgentDIDRateLimit",
+		}
+	}
+
+	var rateLimit sql.NullString
+	if policy != nil {
+		encoded, err := json.Marshal(policy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal rate limit policy: %w", err)
+		}
+		rateLimit = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	result, err := ls.db.ExecContext(ctx, `
+		UPDATE agent_dids SET rate_limit = ?, updated_at = ?
+		WHERE agent_node_id = ? AND agentfield_server_id = ?`,
+		rateLimit, time.Now().UTC(), agentNodeID, agentfieldServerID)
+	if err != nil {
+		return fmt.Errorf("failed to update agent DID rate limit: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update agent DID rate limit: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("agent DID for %s not found", agentNodeID)
+	}
+	return nil
+}
+
+// parseRateLimitPolicy decodes the rate_limit column. NULL and empty values
+// mean no policy.
+func parseRateLimitPolicy(raw sql.NullString) (*types.RateLimitPolicy, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	policy := &types.RateLimitPolicy{}
+	if err := json.Unmarshal([]byte(raw.String), policy); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit JSON: %w", err)
+	}
+	return policy, nil
+}
+
+// GetAgentStatusHistory returns every recorded status transition for an agent, oldest first.
+func (ls *LocalStorage) GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get agent status history: %w", err)
+	}
+
+	rows, err := ls.db.QueryContext(ctx, `
+		SELECT id, agent_node_id, agentfield_server_id, previous_status, new_status, reason, changed_at
+		FROM agent_did_status_history
+		WHERE agent_node_id = ?
+		ORDER BY changed_at ASC, id ASC`, agentNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*types.AgentDIDStatusChange{}
+	for rows.Next() {
+		change := &types.AgentDIDStatusChange{}
+		if err := rows.Scan(&change.ID, &change.AgentNodeID, &change.AgentFieldServerID,
+			&change.PreviousStatus, &change.NewStatus, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan agent status history: %w", err)
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate agent status history: %w", err)
+	}
+	return history, nil
+}
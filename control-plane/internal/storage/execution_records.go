@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,11 +16,28 @@ import (
 // maxNodesForDepthCalc caps the number of executions for which we compute DAG depth to avoid heavy queries.
 const maxNodesForDepthCalc = 1000
 
+// maxUpsertAttempts bounds how often UpsertExecution re-checks a status that
+// changed between validation and write.
+const maxUpsertAttempts = 3
+
 // CreateExecutionRecord inserts a new execution row using the simplified schema.
+// Replayed reports for an existing execution_id are merged via UpsertExecution.
 func (ls *LocalStorage) CreateExecutionRecord(ctx context.Context, exec *types.Execution) error {
+	return ls.UpsertExecution(ctx, exec)
+}
+
+// UpsertExecution inserts an execution row or, when a row with the same
+// execution_id already exists, updates its status and, when provided, its
+// completion time, duration, result and error so retried reports converge on
+// one row. A status change the execution state machine does not allow is
+// rejected with an *InvalidExecutionStateTransitionError and nothing is
+// written. With SerializeRunWrites, upserts for the same run are applied one
+// at a time.
+func (ls *LocalStorage) UpsertExecution(ctx context.Context, exec *types.Execution) error {
 	if exec == nil {
 		return fmt.Errorf("nil execution payload")
 	}
+	defer ls.lockRun(exec.RunID)()
 
 	db := ls.requireSQLDB()
 
@@ -29,18 +47,30 @@ func (ls *LocalStorage) CreateExecutionRecord(ctx context.Context, exec *types.E
 	}
 	exec.CreatedAt = now
 	exec.UpdatedAt = now
+	exec.TenantID = types.NormalizeTenantID(exec.TenantID)
 
+	// tenant_id is left out of the conflict update: a replayed report can
+	// never move an execution to another tenant. The update only applies while
+	// the row still has the status the transition was validated against.
 	insert := `
 		INSERT INTO executions (
-			execution_id, run_id, parent_execution_id,
+			execution_id, run_id, parent_execution_id, tenant_id,
 			agent_node_id, reasoner_id, node_id,
 			status, input_payload, result_payload, error_message,
 			input_uri, result_uri,
 			session_id, actor_id,
-			started_at, completed_at, duration_ms,
+			enqueued_at, started_at, completed_at, duration_ms,
 			notes,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(execution_id) DO UPDATE SET
+			status = excluded.status,
+			completed_at = COALESCE(excluded.completed_at, executions.completed_at),
+			duration_ms = COALESCE(excluded.duration_ms, executions.duration_ms),
+			result_payload = COALESCE(excluded.result_payload, executions.result_payload),
+			error_message = COALESCE(excluded.error_message, executions.error_message),
+			updated_at = excluded.updated_at
+		WHERE executions.status = ?`
 
 	// Serialize notes to JSON
 	var notesJSON []byte
@@ -52,12 +82,11 @@ func (ls *LocalStorage) CreateExecutionRecord(ctx context.Context, exec *types.E
 		}
 	}
 
-	_, err := db.ExecContext(
-		ctx,
-		insert,
+	args := []interface{}{
 		exec.ExecutionID,
 		exec.RunID,
 		exec.ParentExecutionID,
+		exec.TenantID,
 		exec.AgentNodeID,
 		exec.ReasonerID,
 		exec.NodeID,
@@ -69,29 +98,56 @@ func (ls *LocalStorage) CreateExecutionRecord(ctx context.Context, exec *types.E
 		exec.ResultURI,
 		exec.SessionID,
 		exec.ActorID,
+		exec.EnqueuedAt,
 		exec.StartedAt,
 		exec.CompletedAt,
 		exec.DurationMS,
 		notesJSON,
 		exec.CreatedAt,
 		exec.UpdatedAt,
-	)
-	if err != nil {
-		return fmt.Errorf("insert execution: %w", err)
 	}
 
-	return nil
+	// Validate against the stored status, then write only if it is unchanged.
+	// A concurrent writer that got in between leaves nothing written and the
+	// transition is checked again against its status.
+	for attempt := 1; ; attempt++ {
+		var current sql.NullString
+		err := db.QueryRowContext(ctx, `SELECT status FROM executions WHERE execution_id = ?`, exec.ExecutionID).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("check existing execution: %w", err)
+		}
+		if current.Valid {
+			if err := ValidateExecutionRecordTransition(exec.ExecutionID, current.String, exec.Status); err != nil {
+				return err
+			}
+		}
+
+		result, err := db.ExecContext(ctx, insert, append(args, current)...)
+		if err != nil {
+			return fmt.Errorf("upsert execution: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("upsert execution: %w", err)
+		}
+		if affected > 0 {
+			return nil
+		}
+		if attempt == maxUpsertAttempts {
+			return fmt.Errorf("upsert execution %s: status changed concurrently", exec.ExecutionID)
+		}
+	}
 }
 
 // GetExecutionRecord fetches a single execution row by execution_id.
 func (ls *LocalStorage) GetExecutionRecord(ctx context.Context, executionID string) (*types.Execution, error) {
 	query := `
-		SELECT execution_id, run_id, parent_execution_id,
+		SELECT execution_id, run_id, parent_execution_id, tenant_id,
 		       agent_node_id, reasoner_id, node_id,
 		       status, input_payload, result_payload, error_message,
 		       input_uri, result_uri,
 		       session_id, actor_id,
-		       started_at, completed_at, duration_ms,
+		       enqueued_at, started_at, completed_at, duration_ms,
 		       notes,
 		       created_at, updated_at
 		FROM executions
@@ -123,12 +179,12 @@ func (ls *LocalStorage) UpdateExecutionRecord(ctx context.Context, executionID s
 	defer rollbackTx(tx, "UpdateExecutionRecord:"+executionID)
 
 	row := tx.QueryRowContext(ctx, `
-		SELECT execution_id, run_id, parent_execution_id,
+		SELECT execution_id, run_id, parent_execution_id, tenant_id,
 		       agent_node_id, reasoner_id, node_id,
 		       status, input_payload, result_payload, error_message,
 		       input_uri, result_uri,
 		       session_id, actor_id,
-		       started_at, completed_at, duration_ms,
+		       enqueued_at, started_at, completed_at, duration_ms,
 		       notes,
 		       created_at, updated_at
 		FROM executions
@@ -176,6 +232,7 @@ func (ls *LocalStorage) UpdateExecutionRecord(ctx context.Context, executionID s
 			result_uri = ?,
 			session_id = ?,
 			actor_id = ?,
+			enqueued_at = ?,
 			started_at = ?,
 			completed_at = ?,
 			duration_ms = ?,
@@ -199,6 +256,7 @@ func (ls *LocalStorage) UpdateExecutionRecord(ctx context.Context, executionID s
 		updated.ResultURI,
 		updated.SessionID,
 		updated.ActorID,
+		updated.EnqueuedAt,
 		updated.StartedAt,
 		updated.CompletedAt,
 		updated.DurationMS,
@@ -225,6 +283,10 @@ func (ls *LocalStorage) QueryExecutionRecords(ctx context.Context, filter types.
 		args  []interface{}
 	)
 
+	if filter.TenantID != nil {
+		where = append(where, "tenant_id = ?")
+		args = append(args, types.NormalizeTenantID(*filter.TenantID))
+	}
 	if filter.ExecutionID != nil {
 		where = append(where, "execution_id = ?")
 		args = append(args, *filter.ExecutionID)
@@ -265,15 +327,19 @@ func (ls *LocalStorage) QueryExecutionRecords(ctx context.Context, filter types.
 		where = append(where, "started_at <= ?")
 		args = append(args, filter.EndTime.UTC())
 	}
+	if filter.UpdatedSince != nil {
+		where = append(where, "updated_at >= ?")
+		args = append(args, filter.UpdatedSince.UTC())
+	}
 
 	queryBuilder := strings.Builder{}
 	queryBuilder.WriteString(`
-		SELECT execution_id, run_id, parent_execution_id,
+		SELECT execution_id, run_id, parent_execution_id, tenant_id,
 		       agent_node_id, reasoner_id, node_id,
 		       status, input_payload, result_payload, error_message,
 		       input_uri, result_uri,
 		       session_id, actor_id,
-		       started_at, completed_at, duration_ms,
+		       enqueued_at, started_at, completed_at, duration_ms,
 		       notes,
 		       created_at, updated_at
 		FROM executions`)
@@ -338,6 +404,200 @@ func (ls *LocalStorage) QueryExecutionRecords(ctx context.Context, filter types.
 	return executions, nil
 }
 
+// defaultExecutionSearchLimit caps SearchExecutions when no limit is given.
+const defaultExecutionSearchLimit = 50
+
+// executionSearchColumns selects an executions row aliased as e, in the
+// order scanExecution expects.
+const executionSearchColumns = `
+		SELECT e.execution_id, e.run_id, e.parent_execution_id, e.tenant_id,
+		       e.agent_node_id, e.reasoner_id, e.node_id,
+		       e.status, e.input_payload, e.result_payload, e.error_message,
+		       e.input_uri, e.result_uri,
+		       e.session_id, e.actor_id,
+		       e.enqueued_at, e.started_at, e.completed_at, e.duration_ms,
+		       e.notes,
+		       e.created_at, e.updated_at`
+
+// SearchExecutions returns up to limit executions whose reasoner ID, status or
+// error message match query, best matches first. SQLite ranks FTS5 matches by
+// bm25; PostgreSQL, or SQLite without FTS5, falls back to a case-insensitive
+// LIKE ranked by how many fields match. A non-nil tenantID restricts the
+// results to that tenant. A non-positive limit means
+// defaultExecutionSearchLimit.
+func (ls *LocalStorage) SearchExecutions(ctx context.Context, query string, tenantID *string, limit int) ([]*types.Execution, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during search executions: %w", err)
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &ValidationError{
+			Field:   "query",
+			Value:   query,
+			Reason:  "search query cannot be empty",
+			Context: "SearchExecutions",
+		}
+	}
+	if limit <= 0 {
+		limit = defaultExecutionSearchLimit
+	}
+
+	tenantClause := ""
+	var tenantArgs []interface{}
+	if tenantID != nil {
+		tenantClause = " AND e.tenant_id = ?"
+		tenantArgs = append(tenantArgs, types.NormalizeTenantID(*tenantID))
+	}
+
+	if ls.mode != "postgres" {
+		if match := sanitizeFTS5Query(query); match != "" {
+			args := append(append([]interface{}{match}, tenantArgs...), limit)
+			executions, err := ls.queryExecutionSearch(ctx, executionSearchColumns+`
+		FROM executions_fts
+		JOIN executions e ON e.id = executions_fts.rowid
+		WHERE executions_fts MATCH ?`+tenantClause+`
+		ORDER BY bm25(executions_fts), e.started_at DESC
+		LIMIT ?`, args...)
+			if err == nil || !errors.Is(wrapFTS5Error(err), ErrFTS5Unavailable) {
+				return executions, err
+			}
+		}
+	}
+
+	pattern := "%" + strings.ToLower(query) + "%"
+	args := append([]interface{}{pattern, pattern, pattern}, tenantArgs...)
+	args = append(args, pattern, pattern, pattern, limit)
+	return ls.queryExecutionSearch(ctx, executionSearchColumns+`
+		FROM executions e
+		WHERE (LOWER(e.reasoner_id) LIKE ? OR LOWER(e.status) LIKE ? OR LOWER(COALESCE(e.error_message, '')) LIKE ?)`+tenantClause+`
+		ORDER BY (CASE WHEN LOWER(e.reasoner_id) LIKE ? THEN 1 ELSE 0 END
+		        + CASE WHEN LOWER(e.status) LIKE ? THEN 1 ELSE 0 END
+		        + CASE WHEN LOWER(COALESCE(e.error_message, '')) LIKE ? THEN 1 ELSE 0 END) DESC,
+		         e.started_at DESC
+		LIMIT ?`, args...)
+}
+
+func (ls *LocalStorage) queryExecutionSearch(ctx context.Context, query string, args ...interface{}) ([]*types.Execution, error) {
+	rows, err := ls.requireSQLDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search executions: %w", err)
+	}
+	defer rows.Close()
+
+	executions := []*types.Execution{}
+	for rows.Next() {
+		exec, err := scanExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, exec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate executions: %w", err)
+	}
+
+	ls.populateWebhookRegistration(ctx, executions)
+
+	return executions, nil
+}
+
+// executionSubscriptionPollInterval is how often SubscribeExecutions checks
+// for changed executions.
+var executionSubscriptionPollInterval = 500 * time.Millisecond
+
+// executionSubscriptionLookback is how far behind the newest updated_at seen
+// each poll reaches back, so a write that commits slightly after a newer one
+// is still picked up.
+const executionSubscriptionLookback = 2 * time.Second
+
+// SubscribeExecutions streams the executions of runID as they are inserted or
+// updated. It first sends every execution already in the run, then polls for
+// rows whose updated_at has moved, in updated_at order. The same database
+// polling is used for SQLite and PostgreSQL.
+//
+// Delivery is at-least-once: an execution is sent again whenever its
+// updated_at changes, and may be repeated even when it has not, so consumers
+// should treat each value as the latest state of that execution ID. A write
+// that commits more than executionSubscriptionLookback after its updated_at
+// was taken can be missed. Sends block while the channel is full rather than
+// dropping updates.
+//
+// A non-nil tenantID only streams that tenant's executions of the run.
+//
+// The channel is closed once ctx is done. Poll errors are logged and retried
+// on the next tick.
+func (ls *LocalStorage) SubscribeExecutions(ctx context.Context, runID string, tenantID *string) (<-chan *types.Execution, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during subscribe to executions: %w", err)
+	}
+	runID = strings.TrimSpace(runID)
+	if runID == "" {
+		return nil, &ValidationError{
+			Field:   "run_id",
+			Value:   runID,
+			Reason:  "run ID cannot be empty",
+			Context: "SubscribeExecutions",
+		}
+	}
+
+	out := make(chan *types.Execution, 100)
+	go ls.pollExecutionChanges(ctx, runID, tenantID, out)
+	return out, nil
+}
+
+func (ls *LocalStorage) pollExecutionChanges(ctx context.Context, runID string, tenantID *string, out chan<- *types.Execution) {
+	defer close(out)
+
+	ticker := time.NewTicker(executionSubscriptionPollInterval)
+	defer ticker.Stop()
+
+	// seen holds the last updated_at sent per execution still inside the
+	// lookback window; older entries cannot be returned again unchanged.
+	seen := make(map[string]time.Time)
+	var newest time.Time
+	for {
+		filter := types.ExecutionFilter{RunID: &runID, TenantID: tenantID, SortBy: "updated_at"}
+		if !newest.IsZero() {
+			since := newest.Add(-executionSubscriptionLookback)
+			filter.UpdatedSince = &since
+		}
+		executions, err := ls.QueryExecutionRecords(ctx, filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Logger.Warn().Err(err).Str("run_id", runID).Msg("failed to poll execution changes")
+		}
+
+		for _, exec := range executions {
+			if last, ok := seen[exec.ExecutionID]; ok && last.Equal(exec.UpdatedAt) {
+				continue
+			}
+			select {
+			case out <- exec:
+			case <-ctx.Done():
+				return
+			}
+			seen[exec.ExecutionID] = exec.UpdatedAt
+			if exec.UpdatedAt.After(newest) {
+				newest = exec.UpdatedAt
+			}
+		}
+		cutoff := newest.Add(-executionSubscriptionLookback)
+		for id, updatedAt := range seen {
+			if updatedAt.Before(cutoff) {
+				delete(seen, id)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // QueryRunSummaries returns aggregated statistics for workflow runs without fetching all execution records.
 // The implementation uses a single GROUP BY query plus a lightweight COUNT for total runs to stay fast even
 // when page_size is large.
@@ -352,6 +612,10 @@ func (ls *LocalStorage) QueryRunSummaries(ctx context.Context, filter types.Exec
 		where = append(where, "run_id = ?")
 		args = append(args, *filter.RunID)
 	}
+	if filter.TenantID != nil {
+		where = append(where, "tenant_id = ?")
+		args = append(args, types.NormalizeTenantID(*filter.TenantID))
+	}
 	if filter.Status != nil {
 		where = append(where, "status = ?")
 		args = append(args, *filter.Status)
@@ -571,6 +835,10 @@ func (ls *LocalStorage) QueryRunSummaries(ctx context.Context, filter types.Exec
 		for i, runID := range runIDsForDepth {
 			depthArgs[i] = runID
 		}
+		if filter.TenantID != nil {
+			depthQuery += " AND tenant_id = ?"
+			depthArgs = append(depthArgs, types.NormalizeTenantID(*filter.TenantID))
+		}
 
 		depthRows, err := db.QueryContext(ctx, depthQuery, depthArgs...)
 		if err != nil {
@@ -1036,6 +1304,7 @@ func scanExecution(scanner interface {
 		inputPayload                 []byte
 		resultPayload                []byte
 		errorMessage                 sql.NullString
+		enqueuedAt                   sql.NullTime
 		completedAt                  sql.NullTime
 		durationMS                   sql.NullInt64
 		notesJSON                    []byte
@@ -1045,6 +1314,7 @@ func scanExecution(scanner interface {
 		&exec.ExecutionID,
 		&exec.RunID,
 		&parentExecutionID,
+		&exec.TenantID,
 		&exec.AgentNodeID,
 		&exec.ReasonerID,
 		&exec.NodeID,
@@ -1056,6 +1326,7 @@ func scanExecution(scanner interface {
 		&resultURI,
 		&sessionID,
 		&actorID,
+		&enqueuedAt,
 		&exec.StartedAt,
 		&completedAt,
 		&durationMS,
@@ -1092,6 +1363,10 @@ func scanExecution(scanner interface {
 	if resultURI.Valid {
 		exec.ResultURI = &resultURI.String
 	}
+	if enqueuedAt.Valid {
+		t := enqueuedAt.Time
+		exec.EnqueuedAt = &t
+	}
 	if completedAt.Valid {
 		t := completedAt.Time
 		exec.CompletedAt = &t
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -61,3 +62,345 @@ func TestQueryRunSummariesParsesTextTimestamps(t *testing.T) {
 func pointerTime(t time.Time) *time.Time {
 	return &t
 }
+
+func TestUpsertExecutionUpdatesExistingRow(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	started := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	exec := &types.Execution{
+		ExecutionID: "exec-replayed",
+		RunID:       "run-replayed",
+		AgentNodeID: "agent-1",
+		ReasonerID:  "reasoner.a",
+		NodeID:      "node-a",
+		Status:      string(types.ExecutionStatusRunning),
+		StartedAt:   started,
+	}
+	require.NoError(t, ls.UpsertExecution(ctx, exec))
+
+	completed := started.Add(2 * time.Second)
+	duration := int64(2000)
+	replay := &types.Execution{
+		ExecutionID: "exec-replayed",
+		RunID:       "run-replayed",
+		AgentNodeID: "agent-1",
+		ReasonerID:  "reasoner.a",
+		NodeID:      "node-a",
+		Status:      string(types.ExecutionStatusSucceeded),
+		StartedAt:   started,
+		CompletedAt: &completed,
+		DurationMS:  &duration,
+	}
+	require.NoError(t, ls.UpsertExecution(ctx, replay))
+	// The legacy create path converges on the same row as well.
+	require.NoError(t, ls.CreateExecutionRecord(ctx, replay))
+
+	runID := "run-replayed"
+	results, err := ls.QueryExecutionRecords(ctx, types.ExecutionFilter{RunID: &runID})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	stored := results[0]
+	require.Equal(t, string(types.ExecutionStatusSucceeded), stored.Status)
+	require.NotNil(t, stored.CompletedAt)
+	require.True(t, completed.Equal(*stored.CompletedAt))
+	require.NotNil(t, stored.DurationMS)
+	require.Equal(t, duration, *stored.DurationMS)
+}
+
+func TestUpsertExecutionValidatesStatusTransitions(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	started := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	completed := started.Add(2 * time.Second)
+	duration := int64(2000)
+	exec := &types.Execution{
+		ExecutionID: "exec-done",
+		RunID:       "run-done",
+		AgentNodeID: "agent-1",
+		ReasonerID:  "reasoner.a",
+		NodeID:      "node-a",
+		Status:      string(types.ExecutionStatusSucceeded),
+		StartedAt:   started,
+		CompletedAt: &completed,
+		DurationMS:  &duration,
+	}
+	require.NoError(t, ls.UpsertExecution(ctx, exec))
+
+	// A stale report cannot move a finished execution back to running.
+	stale := &types.Execution{
+		ExecutionID: "exec-done",
+		RunID:       "run-done",
+		AgentNodeID: "agent-1",
+		ReasonerID:  "reasoner.a",
+		NodeID:      "node-a",
+		Status:      string(types.ExecutionStatusRunning),
+		StartedAt:   started,
+	}
+	err := ls.UpsertExecution(ctx, stale)
+	var stateErr *InvalidExecutionStateTransitionError
+	require.ErrorAs(t, err, &stateErr)
+	require.Equal(t, "exec-done", stateErr.ExecutionID)
+
+	// A replay of the final status without timings keeps the recorded ones.
+	stale.Status = string(types.ExecutionStatusSucceeded)
+	require.NoError(t, ls.UpsertExecution(ctx, stale))
+
+	stored, err := ls.GetExecutionRecord(ctx, "exec-done")
+	require.NoError(t, err)
+	require.Equal(t, string(types.ExecutionStatusSucceeded), stored.Status)
+	require.NotNil(t, stored.CompletedAt)
+	require.True(t, completed.Equal(*stored.CompletedAt))
+	require.NotNil(t, stored.DurationMS)
+	require.Equal(t, duration, *stored.DurationMS)
+}
+
+func TestExecutionRecordPersistsEnqueuedAt(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	enqueued := time.Date(2024, 3, 4, 9, 59, 58, 0, time.UTC)
+	exec := &types.Execution{
+		ExecutionID: "exec-queued",
+		RunID:       "run-queued",
+		AgentNodeID: "agent-1",
+		ReasonerID:  "reasoner.a",
+		NodeID:      "node-a",
+		Status:      string(types.ExecutionStatusQueued),
+		EnqueuedAt:  &enqueued,
+		StartedAt:   enqueued.Add(2 * time.Second),
+	}
+	require.NoError(t, ls.CreateExecutionRecord(ctx, exec))
+
+	stored, err := ls.GetExecutionRecord(ctx, "exec-queued")
+	require.NoError(t, err)
+	require.NotNil(t, stored.EnqueuedAt)
+	require.True(t, enqueued.Equal(*stored.EnqueuedAt))
+}
+
+func TestSearchExecutions(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	base := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	deadline := "context deadline exceeded: timeout after 30s"
+	executions := []*types.Execution{
+		{ExecutionID: "exec-reasoner", ReasonerID: "fetch_timeout", Status: string(types.ExecutionStatusTimeout), StartedAt: base},
+		{ExecutionID: "exec-status", ReasonerID: "plan", Status: string(types.ExecutionStatusTimeout), StartedAt: base.Add(time.Minute)},
+		{ExecutionID: "exec-error", ReasonerID: "search", Status: string(types.ExecutionStatusFailed), ErrorMessage: &deadline, StartedAt: base.Add(2 * time.Minute)},
+		{ExecutionID: "exec-unrelated", ReasonerID: "summarize", Status: string(types.ExecutionStatusSucceeded), StartedAt: base.Add(3 * time.Minute)},
+	}
+	for _, exec := range executions {
+		exec.RunID = "run-search"
+		exec.AgentNodeID = "agent-1"
+		exec.NodeID = "agent-1"
+		require.NoError(t, ls.CreateExecutionRecord(ctx, exec))
+	}
+
+	ids := func(results []*types.Execution) []string {
+		out := make([]string, 0, len(results))
+		for _, exec := range results {
+			out = append(out, exec.ExecutionID)
+		}
+		return out
+	}
+
+	results, err := ls.SearchExecutions(ctx, "timeout", nil, 0)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"exec-reasoner", "exec-status", "exec-error"}, ids(results))
+
+	results, err = ls.SearchExecutions(ctx, "timeout", nil, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// Updates reach the index through the triggers
+	_, err = ls.UpdateExecutionRecord(ctx, "exec-unrelated", func(exec *types.Execution) (*types.Execution, error) {
+		message := "upstream timeout"
+		exec.ErrorMessage = &message
+		return exec, nil
+	})
+	require.NoError(t, err)
+	results, err = ls.SearchExecutions(ctx, "timeout", nil, 0)
+	require.NoError(t, err)
+	require.Contains(t, ids(results), "exec-unrelated")
+
+	// Operator input never reaches FTS5 as syntax
+	_, err = ls.SearchExecutions(ctx, `timeout" OR (`, nil, 0)
+	require.NoError(t, err)
+
+	_, err = ls.SearchExecutions(ctx, "  ", nil, 0)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	// The LIKE fallback used without FTS5 ranks rows matching more fields first
+	ls.mode = "postgres"
+	t.Cleanup(func() { ls.mode = "local" })
+	results, err = ls.SearchExecutions(ctx, "TIMEOUT", nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"exec-reasoner", "exec-unrelated", "exec-error", "exec-status"}, ids(results))
+}
+
+func TestQueryExecutionRecordsIsolatesTenants(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	base := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	for _, exec := range []*types.Execution{
+		{ExecutionID: "exec-default", StartedAt: base},
+		{ExecutionID: "exec-acme", TenantID: "acme", StartedAt: base.Add(time.Second)},
+		{ExecutionID: "exec-globex", TenantID: "globex", StartedAt: base.Add(2 * time.Second)},
+	} {
+		exec.RunID = "run-shared"
+		exec.AgentNodeID = "agent-1"
+		exec.NodeID = "agent-1"
+		exec.ReasonerID = "reasoner"
+		exec.Status = string(types.ExecutionStatusSucceeded)
+		require.NoError(t, ls.CreateExecutionRecord(ctx, exec))
+	}
+
+	stored, err := ls.GetExecutionRecord(ctx, "exec-default")
+	require.NoError(t, err)
+	require.Equal(t, types.DefaultTenantID, stored.TenantID)
+
+	runID := "run-shared"
+	all, err := ls.QueryExecutionRecords(ctx, types.ExecutionFilter{RunID: &runID})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	for tenant, want := range map[string]string{
+		"acme":                "exec-acme",
+		"":                    "exec-default",
+		types.DefaultTenantID: "exec-default",
+	} {
+		tenant := tenant
+		results, err := ls.QueryExecutionRecords(ctx, types.ExecutionFilter{RunID: &runID, TenantID: &tenant})
+		require.NoError(t, err)
+		require.Len(t, results, 1, "tenant %q", tenant)
+		require.Equal(t, want, results[0].ExecutionID)
+	}
+
+	missing := "initech"
+	results, err := ls.QueryExecutionRecords(ctx, types.ExecutionFilter{RunID: &runID, TenantID: &missing})
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestExecutionReadsIsolateTenants(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	interval := executionSubscriptionPollInterval
+	executionSubscriptionPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { executionSubscriptionPollInterval = interval })
+
+	base := time.Date(2024, 6, 2, 8, 0, 0, 0, time.UTC)
+	for _, exec := range []*types.Execution{
+		{ExecutionID: "exec-acme", TenantID: "acme", StartedAt: base},
+		{ExecutionID: "exec-globex", TenantID: "globex", StartedAt: base.Add(time.Second)},
+	} {
+		exec.RunID = "run-shared"
+		exec.AgentNodeID = "agent-1"
+		exec.NodeID = "agent-1"
+		exec.ReasonerID = "fetch_timeout"
+		exec.Status = string(types.ExecutionStatusTimeout)
+		require.NoError(t, ls.CreateExecutionRecord(ctx, exec))
+	}
+	acme := "acme"
+
+	for _, mode := range []string{"local", "postgres"} {
+		ls.mode = mode
+		results, err := ls.SearchExecutions(ctx, "timeout", &acme, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1, mode)
+		require.Equal(t, "exec-acme", results[0].ExecutionID, mode)
+	}
+	ls.mode = "local"
+
+	summaries, total, err := ls.QueryRunSummaries(ctx, types.ExecutionFilter{TenantID: &acme})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Len(t, summaries, 1)
+	require.Equal(t, 1, summaries[0].TotalExecutions)
+	require.Equal(t, "exec-acme", *summaries[0].RootExecutionID)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch, err := ls.SubscribeExecutions(subCtx, "run-shared", &acme)
+	require.NoError(t, err)
+	select {
+	case exec := <-ch:
+		require.Equal(t, "exec-acme", exec.ExecutionID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for execution")
+	}
+	select {
+	case exec := <-ch:
+		t.Fatalf("received execution %s from another tenant", exec.ExecutionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeExecutions(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	interval := executionSubscriptionPollInterval
+	executionSubscriptionPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { executionSubscriptionPollInterval = interval })
+
+	create := func(id, runID string) {
+		require.NoError(t, ls.CreateExecutionRecord(ctx, &types.Execution{
+			ExecutionID: id,
+			RunID:       runID,
+			AgentNodeID: "agent-1",
+			ReasonerID:  "reasoner",
+			NodeID:      "agent-1",
+			Status:      string(types.ExecutionStatusRunning),
+			StartedAt:   time.Now().UTC(),
+		}))
+	}
+	next := func(ch <-chan *types.Execution) *types.Execution {
+		t.Helper()
+		select {
+		case exec, ok := <-ch:
+			require.True(t, ok, "subscription closed early")
+			return exec
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for execution")
+			return nil
+		}
+	}
+
+	create("exec-existing", "run-live")
+	create("exec-other-run", "run-other")
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch, err := ls.SubscribeExecutions(subCtx, "run-live", nil)
+	require.NoError(t, err)
+
+	// Existing executions are sent first
+	require.Equal(t, "exec-existing", next(ch).ExecutionID)
+
+	create("exec-new", "run-live")
+	require.Equal(t, "exec-new", next(ch).ExecutionID)
+
+	_, err = ls.UpdateExecutionRecord(ctx, "exec-existing", func(exec *types.Execution) (*types.Execution, error) {
+		exec.Status = string(types.ExecutionStatusSucceeded)
+		return exec, nil
+	})
+	require.NoError(t, err)
+	updated := next(ch)
+	require.Equal(t, "exec-existing", updated.ExecutionID)
+	require.Equal(t, string(types.ExecutionStatusSucceeded), updated.Status)
+
+	// Cancelling unsubscribes and closes the channel
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	}, 2*time.Second, 5*time.Millisecond)
+
+	_, err = ls.SubscribeExecutions(ctx, " ", nil)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
@@ -18,19 +19,32 @@ func (e *InvalidExecutionStateTransitionError) Error() string {
 		e.ExecutionID, e.CurrentState, e.NewState, e.Reason)
 }
 
+// ValidateExecutionRecordTransition reports whether the execution record
+// executionID may move from currentStatus to newStatus. A rejected transition
+// is returned as an *InvalidExecutionStateTransitionError naming the execution.
+func ValidateExecutionRecordTransition(executionID, currentStatus, newStatus string) error {
+	err := validateExecutionStateTransition(currentStatus, newStatus)
+	var stateErr *InvalidExecutionStateTransitionError
+	if errors.As(err, &stateErr) {
+		stateErr.ExecutionID = executionID
+	}
+	return err
+}
+
 func validateExecutionStateTransition(currentStatus, newStatus string) error {
 	currentStatus = types.NormalizeExecutionStatus(currentStatus)
 	newStatus = types.NormalizeExecutionStatus(newStatus)
 
 	validTransitions := map[string][]string{
-		string(types.ExecutionStatusUnknown):   {string(types.ExecutionStatusPending)},
-		string(types.ExecutionStatusPending):   {string(types.ExecutionStatusQueued), string(types.ExecutionStatusRunning), string(types.ExecutionStatusCancelled)},
-		string(types.ExecutionStatusQueued):    {string(types.ExecutionStatusRunning), string(types.ExecutionStatusCancelled)},
-		string(types.ExecutionStatusRunning):   {string(types.ExecutionStatusSucceeded), string(types.ExecutionStatusFailed), string(types.ExecutionStatusCancelled), string(types.ExecutionStatusTimeout)},
-		string(types.ExecutionStatusSucceeded): {},
-		string(types.ExecutionStatusFailed):    {},
-		string(types.ExecutionStatusCancelled): {},
-		string(types.ExecutionStatusTimeout):   {},
+		string(types.ExecutionStatusUnknown):               {string(types.ExecutionStatusPending)},
+		string(types.ExecutionStatusPending):               {string(types.ExecutionStatusQueued), string(types.ExecutionStatusRunning), string(types.ExecutionStatusCancelled)},
+		string(types.ExecutionStatusQueued):                {string(types.ExecutionStatusRunning), string(types.ExecutionStatusCancelled)},
+		string(types.ExecutionStatusRunning):               {string(types.ExecutionStatusSucceeded), string(types.ExecutionStatusSucceededWithWarnings), string(types.ExecutionStatusFailed), string(types.ExecutionStatusCancelled), string(types.ExecutionStatusTimeout)},
+		string(types.ExecutionStatusSucceeded):             {},
+		string(types.ExecutionStatusSucceededWithWarnings): {},
+		string(types.ExecutionStatusFailed):                {},
+		string(types.ExecutionStatusCancelled):             {},
+		string(types.ExecutionStatusTimeout):               {},
 	}
 
 	allowedStates, exists := validTransitions[currentStatus]
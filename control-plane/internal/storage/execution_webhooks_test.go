@@ -2,9 +2,9 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -29,7 +29,7 @@ func setupTestStorage(t *testing.T) (StorageProvider, context.Context) {
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping test")
 		}
 		require.NoError(t, err)
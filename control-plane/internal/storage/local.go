@@ -39,6 +39,29 @@ func (e *DuplicateDIDError) Error() string {
 	return fmt.Sprintf("duplicate %s DID detected: %s already exists", e.Type, e.DID)
 }
 
+// MalformedAgentDID identifies an agent DID row whose stored JSON could not
+// be decoded.
+type MalformedAgentDID struct {
+	AgentNodeID string
+	DID         string
+	Err         error
+}
+
+// MalformedAgentDIDsError is returned by ListAgentDIDs and
+// ListAgentDIDsForTenant together with the rows that did decode, so one
+// corrupt row does not hide every other agent.
+type MalformedAgentDIDsError struct {
+	Rows []MalformedAgentDID
+}
+
+func (e *MalformedAgentDIDsError) Error() string {
+	ids := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		ids[i] = row.AgentNodeID
+	}
+	return fmt.Sprintf("skipped %d malformed agent DID rows: %s", len(e.Rows), strings.Join(ids, ", "))
+}
+
 // ForeignKeyConstraintError represents a foreign key constraint violation
 type ForeignKeyConstraintError struct {
 	Table           string
@@ -53,6 +76,19 @@ func (e *ForeignKeyConstraintError) Error() string {
 		e.Table, e.Column, e.ReferencedTable, e.ReferencedValue, e.Operation)
 }
 
+// ErrFTS5Unavailable is returned (wrapped) by Initialize when the SQLite
+// driver was built without the FTS5 extension, e.g. without -tags sqlite_fts5.
+var ErrFTS5Unavailable = errors.New("sqlite3 compiled without FTS5 support")
+
+// wrapFTS5Error marks errors caused by a missing FTS5 module so callers can
+// detect them with errors.Is(err, ErrFTS5Unavailable).
+func wrapFTS5Error(err error) error {
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		return fmt.Errorf("%w: %w", ErrFTS5Unavailable, err)
+	}
+	return err
+}
+
 // ValidationError represents a pre-storage validation failure
 type ValidationError struct {
 	Field   string
@@ -446,6 +482,7 @@ type LocalStorage struct {
 	vectorStore               vectorStore
 	eventBus                  *events.ExecutionEventBus // Event bus for real-time updates
 	workflowExecutionEventBus *events.EventBus[*types.WorkflowExecutionEvent]
+	runLocks                  *keyedMutex // per-run execution write locks; nil unless SerializeRunWrites
 }
 
 // NewLocalStorage creates a new instance of LocalStorage.
@@ -490,10 +527,13 @@ func (ls *LocalStorage) Initialize(ctx context.Context, config StorageConfig) er
 	ls.postgresConfig = config.Postgres
 	ls.vectorConfig = config.Vector.normalized()
 	ls.vectorMetric = parseDistanceMetric(ls.vectorConfig.Distance)
+	if config.SerializeRunWrites {
+		ls.runLocks = newKeyedMutex()
+	}
 
 	switch mode {
 	case "local":
-		return ls.initializeSQLite(ctx)
+		return wrapFTS5Error(ls.initializeSQLite(ctx))
 	case "postgres":
 		return ls.initializePostgres(ctx)
 	default:
@@ -878,6 +918,10 @@ func (ls *LocalStorage) createSchema(ctx context.Context) error {
 		return err
 	}
 
+	if err := ls.setupExecutionRecordFTS(); err != nil {
+		return err
+	}
+
 	if err := ls.ensureSQLiteIndexes(); err != nil {
 		return err
 	}
@@ -1110,6 +1154,56 @@ func (ls *LocalStorage) setupWorkflowExecutionFTS() error {
 	return nil
 }
 
+// setupExecutionRecordFTS indexes execution reasoner IDs, statuses and error
+// messages for SearchExecutions.
+func (ls *LocalStorage) setupExecutionRecordFTS() error {
+	createFTSTable := `
+        CREATE VIRTUAL TABLE IF NOT EXISTS executions_fts USING fts5(
+                reasoner_id,
+                status,
+                error_message
+        );`
+
+	if _, err := ls.db.Exec(createFTSTable); err != nil {
+		return fmt.Errorf("failed to create executions FTS5 virtual table: %w", err)
+	}
+
+	createFTSTriggers := []string{
+		`CREATE TRIGGER IF NOT EXISTS executions_fts_insert AFTER INSERT ON executions BEGIN
+                        INSERT INTO executions_fts(rowid, reasoner_id, status, error_message)
+                        VALUES (new.id, new.reasoner_id, new.status, new.error_message);
+                END;`,
+		`CREATE TRIGGER IF NOT EXISTS executions_fts_update AFTER UPDATE ON executions BEGIN
+                        UPDATE executions_fts SET
+                                reasoner_id = new.reasoner_id,
+                                status = new.status,
+                                error_message = new.error_message
+                        WHERE rowid = new.id;
+                END;`,
+		`CREATE TRIGGER IF NOT EXISTS executions_fts_delete AFTER DELETE ON executions BEGIN
+                        DELETE FROM executions_fts WHERE rowid = old.id;
+                END;`,
+	}
+
+	for _, triggerSQL := range createFTSTriggers {
+		if _, err := ls.db.Exec(triggerSQL); err != nil {
+			return fmt.Errorf("failed to create executions FTS5 trigger: %w", err)
+		}
+	}
+
+	populateFTS := `
+        INSERT INTO executions_fts(rowid, reasoner_id, status, error_message)
+        SELECT id, reasoner_id, status, error_message
+        FROM executions
+        WHERE NOT EXISTS (SELECT 1 FROM executions_fts WHERE rowid = executions.id);`
+
+	if _, err := ls.db.Exec(populateFTS); err != nil {
+		return fmt.Errorf("failed to populate executions FTS5 table: %w", err)
+	}
+
+	return nil
+}
+
 func (ls *LocalStorage) ensureSQLiteIndexes() error {
 	indexStatements := []string{
 		"CREATE UNIQUE INDEX IF NOT EXISTS idx_agent_config_agent_package ON agent_configurations(agent_id, package_id)",
@@ -1502,112 +1596,8 @@ func (ls *LocalStorage) runMigrations() error {
 		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
 
-	// Define all migrations with their SQL content
-	migrations := []struct {
-		version     string
-		description string
-		sql         string
-	}{
-		{
-			version:     "007",
-			description: "Add parent_execution_id column",
-			sql:         `ALTER TABLE workflow_executions ADD COLUMN parent_execution_id TEXT;`,
-		},
-		{
-			version:     "008",
-			description: "Create FTS5 search table",
-			sql: `
-				-- Check if FTS table exists before creating
-				CREATE VIRTUAL TABLE IF NOT EXISTS workflow_executions_fts USING fts5(
-					execution_id,
-					workflow_id,
-					agent_node_id,
-					session_id,
-					workflow_name
-				);
-
-				-- Drop existing triggers if they exist to avoid conflicts
-				DROP TRIGGER IF EXISTS workflow_executions_fts_insert;
-				DROP TRIGGER IF EXISTS workflow_executions_fts_update;
-				DROP TRIGGER IF EXISTS workflow_executions_fts_delete;
-
-				-- Create triggers
-				CREATE TRIGGER workflow_executions_fts_insert AFTER INSERT ON workflow_executions BEGIN
-					INSERT INTO workflow_executions_fts(rowid, execution_id, workflow_id, agent_node_id, session_id, workflow_name)
-					VALUES (new.id, new.execution_id, new.workflow_id, new.agent_node_id, new.session_id, new.workflow_name);
-				END;
-
-				CREATE TRIGGER workflow_executions_fts_update AFTER UPDATE ON workflow_executions BEGIN
-					UPDATE workflow_executions_fts SET
-						execution_id = new.execution_id,
-						workflow_id = new.workflow_id,
-						agent_node_id = new.agent_node_id,
-						session_id = new.session_id,
-						workflow_name = new.workflow_name
-					WHERE rowid = new.id;
-				END;
-
-				CREATE TRIGGER workflow_executions_fts_delete AFTER DELETE ON workflow_executions BEGIN
-					DELETE FROM workflow_executions_fts WHERE rowid = old.id;
-				END;
-
-				-- Populate FTS table with existing data (ignore duplicates)
-				INSERT OR IGNORE INTO workflow_executions_fts(rowid, execution_id, workflow_id, agent_node_id, session_id, workflow_name)
-				SELECT id, execution_id, workflow_id, agent_node_id, session_id, workflow_name
-				FROM workflow_executions
-				WHERE NOT EXISTS (SELECT 1 FROM workflow_executions_fts WHERE rowid = workflow_executions.id);`,
-		},
-		{
-			version:     "009",
-			description: "Add notes column to workflow_executions",
-			sql:         `ALTER TABLE workflow_executions ADD COLUMN notes TEXT DEFAULT '[]';`,
-		},
-		{
-			version:     "010",
-			description: "Add composite indexes for workflow execution filtering performance",
-			sql: `
-				-- Composite index for session + status + time queries
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_session_status_time ON workflow_executions(session_id, status, started_at);
-
-				-- Composite index for actor + status + time queries
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_actor_status_time ON workflow_executions(actor_id, status, started_at);
-
-				-- Composite index for agent + status + time queries
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_agent_status_time ON workflow_executions(agent_node_id, status, started_at);
-
-				-- Composite index for status + time queries
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_status_time ON workflow_executions(status, started_at);
-
-				-- Composite index for session + time queries (without status filter)
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_session_time ON workflow_executions(session_id, started_at);
-
-				-- Composite index for actor + time queries (without status filter)
-				CREATE INDEX IF NOT EXISTS idx_workflow_executions_actor_time ON workflow_executions(actor_id, started_at);`,
-		},
-		{
-			version:     "011",
-			description: "Add storage URI column to execution_vcs",
-			sql:         `ALTER TABLE execution_vcs ADD COLUMN storage_uri TEXT DEFAULT '';`,
-		},
-		{
-			version:     "012",
-			description: "Add document size column to execution_vcs",
-			sql:         `ALTER TABLE execution_vcs ADD COLUMN document_size_bytes INTEGER DEFAULT 0;`,
-		},
-		{
-			version:     "013",
-			description: "Add storage URI column to workflow_vcs",
-			sql:         `ALTER TABLE workflow_vcs ADD COLUMN storage_uri TEXT DEFAULT '';`,
-		},
-		{
-			version:     "014",
-			description: "Add document size column to workflow_vcs",
-			sql:         `ALTER TABLE workflow_vcs ADD COLUMN document_size_bytes INTEGER DEFAULT 0;`,
-		},
-	}
-
 	// Apply each migration if not already applied
-	for _, migration := range migrations {
+	for _, migration := range sqliteMigrations {
 		// Check if migration has already been applied
 		var count int
 		checkQuery := `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`
@@ -1625,7 +1615,7 @@ func (ls *LocalStorage) runMigrations() error {
 		log.Printf("Applying migration %s: %s", migration.version, migration.description)
 
 		// Execute the migration SQL
-		_, err = ls.db.Exec(migration.sql)
+		_, err = ls.db.Exec(migration.up)
 		if err != nil {
 			// For ALTER TABLE operations, check if column already exists
 			if strings.Contains(err.Error(), "duplicate column name") {
@@ -5801,8 +5791,9 @@ func (ls *LocalStorage) StoreAgentFieldServerDID(ctx context.Context, agentfield
 	return nil
 }
 
-// StoreAgentDIDWithComponents stores an agent DID along with its component DIDs in a single transaction
-func (ls *LocalStorage) StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int, components []ComponentDIDRequest) error {
+// StoreAgentDIDWithComponents stores an agent DID along with its component DIDs in a single transaction.
+// An empty tenantID stores the DID in types.DefaultTenantID.
+func (ls *LocalStorage) StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int, components []ComponentDIDRequest) error {
 	// Check context cancellation early
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("context cancelled during store agent DID with components: %w", err)
@@ -5828,11 +5819,11 @@ func (ls *LocalStorage) StoreAgentDIDWithComponents(ctx context.Context, agentID
 	err = ls.retryOnConstraintFailure(ctx, func() error {
 		query := `
 			INSERT INTO agent_dids (
-				agent_node_id, did, agentfield_server_id, public_key_jwk, derivation_path, registered_at, status
-			) VALUES (?, ?, ?, ?, ?, ?, ?)`
+				agent_node_id, did, agentfield_server_id, tenant_id, public_key_jwk, derivation_path, registered_at, status
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
 		derivationPath := fmt.Sprintf("m/44'/0'/0'/%d", derivationIndex)
-		_, execErr := tx.ExecContext(ctx, query, agentID, agentDID, agentfieldServerDID, publicKeyJWK, derivationPath, time.Now(), "active")
+		_, execErr := tx.ExecContext(ctx, query, agentID, agentDID, agentfieldServerDID, types.NormalizeTenantID(tenantID), publicKeyJWK, derivationPath, time.Now(), "active")
 		if execErr != nil {
 			if strings.Contains(execErr.Error(), "UNIQUE constraint failed") || strings.Contains(execErr.Error(), "agent_dids") {
 				return &DuplicateDIDError{
@@ -5967,6 +5958,55 @@ func (ls *LocalStorage) ListAgentFieldServerDIDs(ctx context.Context) ([]*types.
 	return infos, nil
 }
 
+// DeleteAgentFieldServerDID removes an af server DID. Servers that still have
+// agent DIDs are refused, since those would lose their root. Deleting a
+// server that does not exist is not an error.
+func (ls *LocalStorage) DeleteAgentFieldServerDID(ctx context.Context, agentfieldServerID string) (err error) {
+	// Check context cancellation early
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during delete af server DID: %w", err)
+	}
+	if agentfieldServerID == "" {
+		return &ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "DeleteAgentFieldServerDID",
+		}
+	}
+
+	tx, err := ls.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackTx(tx, "DeleteAgentFieldServerDID")
+		}
+	}()
+
+	var agents int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM agent_dids WHERE agentfield_server_id = ?`, agentfieldServerID).Scan(&agents); err != nil {
+		err = fmt.Errorf("failed to count agent DIDs: %w", err)
+		return err
+	}
+	if agents > 0 {
+		err = fmt.Errorf("af server %s still has %d agent DIDs", agentfieldServerID, agents)
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM did_registry WHERE agentfield_server_id = ?`, agentfieldServerID); err != nil {
+		err = fmt.Errorf("failed to delete af server DID: %w", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		return err
+	}
+	return nil
+}
+
 // DID Registry operations
 func (ls *LocalStorage) StoreDID(ctx context.Context, did string, didDocument, publicKey, privateKeyRef, derivationPath string) error {
 	// Check context cancellation early
@@ -6160,7 +6200,10 @@ func (ls *LocalStorage) retryOnConstraintFailure(ctx context.Context, operation
 }
 
 // Agent DID operations
-func (ls *LocalStorage) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int) error {
+
+// StoreAgentDID stores an agent DID in tenantID; an empty tenantID means
+// types.DefaultTenantID.
+func (ls *LocalStorage) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int) error {
 	// Check context cancellation early
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("context cancelled during store agent DID: %w", err)
@@ -6213,11 +6256,11 @@ func (ls *LocalStorage) StoreAgentDID(ctx context.Context, agentID, agentDID, ag
 		// INSERT-only query - no ON CONFLICT clause for security
 		query := `
 			INSERT INTO agent_dids (
-				agent_node_id, did, agentfield_server_id, public_key_jwk, derivation_path, registered_at, status
-			) VALUES (?, ?, ?, ?, ?, ?, ?)`
+				agent_node_id, did, agentfield_server_id, tenant_id, public_key_jwk, derivation_path, registered_at, status
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
 		derivationPath := fmt.Sprintf("m/44'/0'/0'/%d", derivationIndex)
-		_, execErr := tx.ExecContext(ctx, query, agentID, agentDID, agentfieldServerDID, publicKeyJWK, derivationPath, time.Now(), "active")
+		_, execErr := tx.ExecContext(ctx, query, agentID, agentDID, agentfieldServerDID, types.NormalizeTenantID(tenantID), publicKeyJWK, derivationPath, time.Now(), "active")
 		if execErr != nil {
 			// Check if this is a unique constraint violation (duplicate agent DID)
 			if strings.Contains(execErr.Error(), "UNIQUE constraint failed") || strings.Contains(execErr.Error(), "agent_dids") {
@@ -6262,16 +6305,17 @@ func (ls *LocalStorage) GetAgentDID(ctx context.Context, agentID string) (*types
 	}
 
 	query := `
-		SELECT agent_node_id, did, agentfield_server_id, public_key_jwk, derivation_path,
-		       reasoners, skills, status, registered_at
+		SELECT agent_node_id, did, agentfield_server_id, tenant_id, public_key_jwk, derivation_path,
+		       reasoners, skills, status, registered_at, rate_limit
 		FROM agent_dids WHERE agent_node_id = ?`
 
 	row := ls.db.QueryRowContext(ctx, query, agentID)
 	info := &types.AgentDIDInfo{}
 
 	var reasonersJSON, skillsJSON, publicKeyJWK string
-	err := row.Scan(&info.AgentNodeID, &info.DID, &info.AgentFieldServerID, &publicKeyJWK,
-		&info.DerivationPath, &reasonersJSON, &skillsJSON, &info.Status, &info.RegisteredAt)
+	var rateLimitJSON sql.NullString
+	err := row.Scan(&info.AgentNodeID, &info.DID, &info.AgentFieldServerID, &info.TenantID, &publicKeyJWK,
+		&info.DerivationPath, &reasonersJSON, &skillsJSON, &info.Status, &info.RegisteredAt, &rateLimitJSON)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("agent DID for %s not found", agentID)
@@ -6297,27 +6341,53 @@ func (ls *LocalStorage) GetAgentDID(ctx context.Context, agentID string) (*types
 		info.Skills = make(map[string]types.SkillDIDInfo)
 	}
 
+	if info.RateLimit, err = parseRateLimitPolicy(rateLimitJSON); err != nil {
+		return nil, err
+	}
+
 	return info, nil
 }
 
+// ListAgentDIDs lists agent DIDs across every tenant. Rows whose reasoners or
+// skills JSON is corrupt are left out and reported in a
+// *MalformedAgentDIDsError returned with the remaining rows.
 func (ls *LocalStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error) {
+	return ls.listAgentDIDs(ctx, nil)
+}
+
+// ListAgentDIDsForTenant lists the agent DIDs owned by tenantID. An empty
+// tenantID means types.DefaultTenantID. Corrupt rows are reported as in
+// ListAgentDIDs.
+func (ls *LocalStorage) ListAgentDIDsForTenant(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error) {
+	tenantID = types.NormalizeTenantID(tenantID)
+	return ls.listAgentDIDs(ctx, &tenantID)
+}
+
+func (ls *LocalStorage) listAgentDIDs(ctx context.Context, tenantID *string) ([]*types.AgentDIDInfo, error) {
 	// Check context cancellation early
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("context cancelled during list agent DIDs: %w", err)
 	}
 
 	query := `
-		SELECT agent_node_id, did, agentfield_server_id, public_key_jwk, derivation_path,
-		       reasoners, skills, status, registered_at
-		FROM agent_dids ORDER BY registered_at DESC`
+		SELECT agent_node_id, did, agentfield_server_id, tenant_id, public_key_jwk, derivation_path,
+		       reasoners, skills, status, registered_at, rate_limit
+		FROM agent_dids`
+	var args []interface{}
+	if tenantID != nil {
+		query += ` WHERE tenant_id = ?`
+		args = append(args, *tenantID)
+	}
+	query += ` ORDER BY registered_at DESC`
 
-	rows, err := ls.db.QueryContext(ctx, query)
+	rows, err := ls.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agent DIDs: %w", err)
 	}
 	defer rows.Close()
 
 	var infos []*types.AgentDIDInfo
+	var malformed []MalformedAgentDID
 	for rows.Next() {
 		// Check context cancellation during iteration
 		if err := ctx.Err(); err != nil {
@@ -6326,8 +6396,9 @@ func (ls *LocalStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInf
 
 		info := &types.AgentDIDInfo{}
 		var reasonersJSON, skillsJSON, publicKeyJWK string
-		err := rows.Scan(&info.AgentNodeID, &info.DID, &info.AgentFieldServerID, &publicKeyJWK,
-			&info.DerivationPath, &reasonersJSON, &skillsJSON, &info.Status, &info.RegisteredAt)
+		var rateLimitJSON sql.NullString
+		err := rows.Scan(&info.AgentNodeID, &info.DID, &info.AgentFieldServerID, &info.TenantID, &publicKeyJWK,
+			&info.DerivationPath, &reasonersJSON, &skillsJSON, &info.Status, &info.RegisteredAt, &rateLimitJSON)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan agent DID: %w", err)
 		}
@@ -6336,7 +6407,8 @@ func (ls *LocalStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInf
 		// Parse JSON fields
 		if reasonersJSON != "" {
 			if err := json.Unmarshal([]byte(reasonersJSON), &info.Reasoners); err != nil {
-				return nil, fmt.Errorf("failed to parse reasoners JSON: %w", err)
+				malformed = append(malformed, MalformedAgentDID{AgentNodeID: info.AgentNodeID, DID: info.DID, Err: fmt.Errorf("failed to parse reasoners JSON: %w", err)})
+				continue
 			}
 		} else {
 			info.Reasoners = make(map[string]types.ReasonerDIDInfo)
@@ -6344,14 +6416,26 @@ func (ls *LocalStorage) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInf
 
 		if skillsJSON != "" {
 			if err := json.Unmarshal([]byte(skillsJSON), &info.Skills); err != nil {
-				return nil, fmt.Errorf("failed to parse skills JSON: %w", err)
+				malformed = append(malformed, MalformedAgentDID{AgentNodeID: info.AgentNodeID, DID: info.DID, Err: fmt.Errorf("failed to parse skills JSON: %w", err)})
+				continue
 			}
 		} else {
 			info.Skills = make(map[string]types.SkillDIDInfo)
 		}
 
+		if info.RateLimit, err = parseRateLimitPolicy(rateLimitJSON); err != nil {
+			malformed = append(malformed, MalformedAgentDID{AgentNodeID: info.AgentNodeID, DID: info.DID, Err: err})
+			continue
+		}
+
 		infos = append(infos, info)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate agent DIDs: %w", err)
+	}
+	if len(malformed) > 0 {
+		return infos, &MalformedAgentDIDsError{Rows: malformed}
+	}
 	return infos, nil
 }
 
@@ -6575,6 +6659,104 @@ func (ls *LocalStorage) ListComponentDIDs(ctx context.Context, agentDID string)
 	return infos, nil
 }
 
+// ListAgentComponentDIDs returns the reasoner and skill DIDs stored for an agent
+// node, with their public keys and derivation indices, ordered by component type
+// and function name.
+func (ls *LocalStorage) ListAgentComponentDIDs(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error) {
+	// Check context cancellation early
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list agent component DIDs: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return nil, &ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "ListAgentComponentDIDs",
+		}
+	}
+
+	query := `
+		SELECT c.did, c.agent_did, c.component_type, c.function_name,
+			   c.public_key_jwk, c.derivation_path, c.created_at
+		FROM component_dids c
+		JOIN agent_dids a ON a.did = c.agent_did
+		WHERE a.agent_node_id = ?
+		ORDER BY c.component_type, c.function_name`
+
+	rows, err := ls.db.QueryContext(ctx, query, agentNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent component DIDs: %w", err)
+	}
+	defer rows.Close()
+
+	components := []*types.ComponentDID{}
+	for rows.Next() {
+		component := &types.ComponentDID{}
+		var publicKeyJWK string
+		var createdAt sql.NullTime
+
+		if err := rows.Scan(&component.DID, &component.AgentDID, &component.ComponentType,
+			&component.FunctionName, &publicKeyJWK, &component.DerivationPath, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan agent component DID: %w", err)
+		}
+
+		component.PublicKeyJWK = json.RawMessage(publicKeyJWK)
+		if createdAt.Valid {
+			component.CreatedAt = createdAt.Time
+		}
+		if parts := strings.Split(component.DerivationPath, "/"); len(parts) > 0 {
+			if index, parseErr := strconv.Atoi(strings.Trim(parts[len(parts)-1], "'")); parseErr == nil {
+				component.DerivationIndex = index
+			}
+		}
+
+		components = append(components, component)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate agent component DIDs: %w", err)
+	}
+
+	return components, nil
+}
+
+// CountComponentDIDsByType returns the number of component DIDs per component
+// type for all agents registered under an af server, grouped in a single query.
+func (ls *LocalStorage) CountComponentDIDsByType(ctx context.Context, agentfieldServerID string) (map[string]int, error) {
+	// Check context cancellation early
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during count component DIDs: %w", err)
+	}
+
+	query := `
+		SELECT c.component_type, COUNT(*)
+		FROM component_dids c
+		JOIN agent_dids a ON a.did = c.agent_did
+		WHERE a.agentfield_server_id = ?
+		GROUP BY c.component_type`
+
+	rows, err := ls.db.QueryContext(ctx, query, agentfieldServerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count component DIDs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var componentType string
+		var count int
+		if err := rows.Scan(&componentType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan component DID count: %w", err)
+		}
+		counts[componentType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate component DID counts: %w", err)
+	}
+
+	return counts, nil
+}
+
 // Execution VC operations
 func (ls *LocalStorage) StoreExecutionVC(ctx context.Context, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status string, vcDocument []byte, signature string, storageURI string, documentSizeBytes int64) error {
 	// Check context cancellation early
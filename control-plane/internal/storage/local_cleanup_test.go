@@ -4,8 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -75,7 +75,7 @@ func TestLocalStorageCleanupWorkflowByRunID(t *testing.T) {
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(err.Error(), "fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping cleanup test")
 		}
 		t.Fatalf("initialize local storage: %v", err)
@@ -261,7 +261,7 @@ func TestLocalStorageCleanupOldExecutions(t *testing.T) {
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(err.Error(), "fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping old execution cleanup test")
 		}
 		t.Fatalf("initialize local storage: %v", err)
@@ -2,8 +2,8 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -27,7 +27,7 @@ func setupLocalStorage(t *testing.T) (*LocalStorage, context.Context) {
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping query workflow tests")
 		}
 		require.NoError(t, err)
@@ -193,6 +193,43 @@ func TestQueryWorkflowDAGReturnsHierarchy(t *testing.T) {
 	require.Equal(t, "child-exec", dagExecutions[1].ExecutionID)
 }
 
+func TestListAgentComponentDIDs(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, ls.StoreAgentFieldServerDID(ctx, "agentfield-1", "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, ls.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", "agentfield-1", "", "{}", 0, []ComponentDIDRequest{
+		{ComponentDID: "did:skill:1", ComponentType: "skill", ComponentName: "summarize", PublicKeyJWK: `{"kty":"OKP","x":"skill"}`, DerivationIndex: 2},
+		{ComponentDID: "did:reasoner:1", ComponentType: "reasoner", ComponentName: "plan", PublicKeyJWK: `{"kty":"OKP","x":"reasoner"}`, DerivationIndex: 1},
+	}))
+	require.NoError(t, ls.StoreAgentDIDWithComponents(ctx, "agent-2", "did:agent:2", "agentfield-1", "", "{}", 1, []ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:2", ComponentType: "reasoner", ComponentName: "other", PublicKeyJWK: "{}", DerivationIndex: 3},
+	}))
+
+	components, err := ls.ListAgentComponentDIDs(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+
+	require.Equal(t, "did:reasoner:1", components[0].DID)
+	require.Equal(t, "did:agent:1", components[0].AgentDID)
+	require.Equal(t, "reasoner", components[0].ComponentType)
+	require.Equal(t, "plan", components[0].FunctionName)
+	require.Equal(t, 1, components[0].DerivationIndex)
+	require.JSONEq(t, `{"kty":"OKP","x":"reasoner"}`, string(components[0].PublicKeyJWK))
+
+	require.Equal(t, "did:skill:1", components[1].DID)
+	require.Equal(t, "skill", components[1].ComponentType)
+	require.Equal(t, 2, components[1].DerivationIndex)
+
+	components, err = ls.ListAgentComponentDIDs(ctx, "missing-agent")
+	require.NoError(t, err)
+	require.Empty(t, components)
+
+	_, err = ls.ListAgentComponentDIDs(ctx, " ")
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+}
+
 func TestSanitizeFTS5Query(t *testing.T) {
 	sanitized := sanitizeFTS5Query("\"Alpha\" AND (Beta*) OR NOT Gamma")
 	require.Equal(t, "\"Alpha Beta Gamma\"", sanitized)
@@ -212,3 +249,53 @@ func TestValidateExecutionStateTransition(t *testing.T) {
 	require.Equal(t, string(types.ExecutionStatusRunning), transitionErr.CurrentState)
 	require.Equal(t, string(types.ExecutionStatusPending), transitionErr.NewState)
 }
+
+func TestListAgentDIDsForTenant(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, ls.StoreAgentFieldServerDID(ctx, "agentfield-1", "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, ls.StoreAgentDID(ctx, "agent-1", "did:agent:1", "agentfield-1", "", "{}", 0))
+	require.NoError(t, ls.StoreAgentDID(ctx, "agent-2", "did:agent:2", "agentfield-1", "", "{}", 1))
+	_, err := ls.db.ExecContext(ctx, "UPDATE agent_dids SET tenant_id = ? WHERE agent_node_id = ?", "acme", "agent-2")
+	require.NoError(t, err)
+
+	all, err := ls.ListAgentDIDs(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	defaults, err := ls.ListAgentDIDsForTenant(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, defaults, 1)
+	require.Equal(t, "agent-1", defaults[0].AgentNodeID)
+	require.Equal(t, types.DefaultTenantID, defaults[0].TenantID)
+
+	acme, err := ls.ListAgentDIDsForTenant(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, acme, 1)
+	require.Equal(t, "agent-2", acme[0].AgentNodeID)
+
+	other, err := ls.ListAgentDIDsForTenant(ctx, "globex")
+	require.NoError(t, err)
+	require.Empty(t, other)
+}
+
+func TestListAgentDIDsSkipsMalformedRows(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, ls.StoreAgentFieldServerDID(ctx, "agentfield-1", "did:agentfield:root", []byte("seed"), now, now))
+	require.NoError(t, ls.StoreAgentDID(ctx, "agent-good", "did:agent:good", "agentfield-1", "", "{}", 0))
+	require.NoError(t, ls.StoreAgentDID(ctx, "agent-bad", "did:agent:bad", "agentfield-1", "", "{}", 1))
+	_, err := ls.db.ExecContext(ctx, "UPDATE agent_dids SET reasoners = ? WHERE agent_node_id = ?", "{not json", "agent-bad")
+	require.NoError(t, err)
+
+	infos, err := ls.ListAgentDIDs(ctx)
+	var malformed *MalformedAgentDIDsError
+	require.ErrorAs(t, err, &malformed)
+	require.Len(t, malformed.Rows, 1)
+	require.Equal(t, "agent-bad", malformed.Rows[0].AgentNodeID)
+	require.Equal(t, "did:agent:bad", malformed.Rows[0].DID)
+	require.Len(t, infos, 1)
+	require.Equal(t, "agent-good", infos[0].AgentNodeID)
+}
@@ -2,8 +2,8 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -24,7 +24,7 @@ func TestLocalStorageStoreWorkflowExecutionPersistsLifecycleFields(t *testing.T)
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(err.Error(), "no such module: fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping local storage persistence test")
 		}
 		t.Fatalf("initialize local storage: %v", err)
@@ -95,3 +95,22 @@ func TestLocalStorageStoreWorkflowExecutionPersistsLifecycleFields(t *testing.T)
 		t.Fatalf("expected pending_children 0, got %d", stored.PendingChildren)
 	}
 }
+
+func TestWrapFTS5Error(t *testing.T) {
+	missing := errors.New("failed to create FTS5 virtual table: no such module: fts5")
+	wrapped := wrapFTS5Error(missing)
+	if !errors.Is(wrapped, ErrFTS5Unavailable) {
+		t.Fatalf("expected ErrFTS5Unavailable, got %v", wrapped)
+	}
+	if !errors.Is(wrapped, missing) {
+		t.Fatalf("expected original error to be preserved, got %v", wrapped)
+	}
+
+	other := errors.New("database is locked")
+	if err := wrapFTS5Error(other); errors.Is(err, ErrFTS5Unavailable) || err != other {
+		t.Fatalf("expected unrelated error to pass through unchanged, got %v", err)
+	}
+	if wrapFTS5Error(nil) != nil {
+		t.Fatal("expected nil to pass through")
+	}
+}
@@ -37,6 +37,8 @@ func (ls *LocalStorage) autoMigrateSchema(ctx context.Context) error {
 		&SessionModel{},
 		&DIDRegistryModel{},
 		&AgentDIDModel{},
+		&AgentDIDStatusHistoryModel{},
+		&RotatedDIDModel{},
 		&ComponentDIDModel{},
 		&ExecutionVCModel{},
 		&WorkflowVCModel{},
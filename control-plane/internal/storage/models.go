@@ -7,6 +7,7 @@ type ExecutionRecordModel struct {
 	ExecutionID       string     `gorm:"column:execution_id;not null;uniqueIndex"`
 	RunID             string     `gorm:"column:run_id;not null;index"`
 	ParentExecutionID *string    `gorm:"column:parent_execution_id;index"`
+	TenantID          string     `gorm:"column:tenant_id;not null;default:'default';index"`
 	AgentNodeID       string     `gorm:"column:agent_node_id;not null;index"`
 	ReasonerID        string     `gorm:"column:reasoner_id;not null;index"`
 	NodeID            string     `gorm:"column:node_id;not null;index"`
@@ -18,6 +19,7 @@ type ExecutionRecordModel struct {
 	ResultURI         *string    `gorm:"column:result_uri"`
 	SessionID         *string    `gorm:"column:session_id;index"`
 	ActorID           *string    `gorm:"column:actor_id;index"`
+	EnqueuedAt        *time.Time `gorm:"column:enqueued_at"`
 	StartedAt         time.Time  `gorm:"column:started_at;not null;index"`
 	CompletedAt       *time.Time `gorm:"column:completed_at"`
 	DurationMS        *int64     `gorm:"column:duration_ms"`
@@ -276,11 +278,13 @@ type AgentDIDModel struct {
 	DID                string    `gorm:"column:did;primaryKey"`
 	AgentNodeID        string    `gorm:"column:agent_node_id;not null;index"`
 	AgentFieldServerID string    `gorm:"column:agentfield_server_id;not null;index"`
+	TenantID           string    `gorm:"column:tenant_id;not null;default:'default';index"`
 	PublicKeyJWK       string    `gorm:"column:public_key_jwk;not null"`
 	DerivationPath     string    `gorm:"column:derivation_path;not null"`
 	Reasoners          string    `gorm:"column:reasoners;default:'{}'"`
 	Skills             string    `gorm:"column:skills;default:'{}'"`
 	Status             string    `gorm:"column:status;not null;default:'active'"`
+	RateLimit          *string   `gorm:"column:rate_limit"`
 	RegisteredAt       time.Time `gorm:"column:registered_at;autoCreateTime"`
 	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt          time.Time `gorm:"column:updated_at;autoUpdateTime"`
@@ -288,6 +292,32 @@ type AgentDIDModel struct {
 
 func (AgentDIDModel) TableName() string { return "agent_dids" }
 
+// AgentDIDStatusHistoryModel is an append-only log of agent DID status transitions.
+type AgentDIDStatusHistoryModel struct {
+	ID                 int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	AgentNodeID        string    `gorm:"column:agent_node_id;not null;index"`
+	AgentFieldServerID string    `gorm:"column:agentfield_server_id;not null;index"`
+	PreviousStatus     string    `gorm:"column:previous_status;not null"`
+	NewStatus          string    `gorm:"column:new_status;not null"`
+	Reason             string    `gorm:"column:reason;not null;default:''"`
+	ChangedAt          time.Time `gorm:"column:changed_at;not null;index"`
+}
+
+func (AgentDIDStatusHistoryModel) TableName() string { return "agent_did_status_history" }
+
+// RotatedDIDModel keeps DIDs retired by a root key rotation resolvable.
+type RotatedDIDModel struct {
+	DID                string    `gorm:"column:did;primaryKey"`
+	ReplacedBy         string    `gorm:"column:replaced_by;not null;index"`
+	AgentFieldServerID string    `gorm:"column:agentfield_server_id;not null;index"`
+	ComponentType      string    `gorm:"column:component_type;not null"`
+	PublicKeyJWK       string    `gorm:"column:public_key_jwk;not null"`
+	DerivationPath     string    `gorm:"column:derivation_path;not null"`
+	RotatedAt          time.Time `gorm:"column:rotated_at;not null"`
+}
+
+func (RotatedDIDModel) TableName() string { return "rotated_dids" }
+
 type ComponentDIDModel struct {
 	DID            string    `gorm:"column:did;primaryKey"`
 	AgentDID       string    `gorm:"column:agent_did;not null;index"`
@@ -0,0 +1,535 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/events"
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// ErrStorageUnavailable is the sentinel wrapped by every error NoopStorage returns.
+var ErrStorageUnavailable = errors.New("storage unavailable")
+
+// StorageUnavailableError reports which operation was attempted while no
+// storage backend was available. It matches ErrStorageUnavailable via errors.Is.
+type StorageUnavailableError struct {
+	Operation string
+}
+
+func (e *StorageUnavailableError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Operation, ErrStorageUnavailable)
+}
+
+func (e *StorageUnavailableError) Unwrap() error {
+	return ErrStorageUnavailable
+}
+
+func unavailable(operation string) error {
+	return &StorageUnavailableError{Operation: operation}
+}
+
+// NoopStorage is a StorageProvider used when the configured backend could not
+// be initialized. Every operation fails with a StorageUnavailableError, so
+// callers get a clear error instead of dereferencing a nil provider. The event
+// buses are real but never receive events.
+type NoopStorage struct {
+	eventBus                  *events.ExecutionEventBus
+	workflowExecutionEventBus *events.EventBus[*types.WorkflowExecutionEvent]
+}
+
+var _ StorageProvider = (*NoopStorage)(nil)
+
+// NewNoopStorage creates a NoopStorage.
+func NewNoopStorage() *NoopStorage {
+	return &NoopStorage{
+		eventBus:                  events.NewExecutionEventBus(),
+		workflowExecutionEventBus: events.NewEventBus[*types.WorkflowExecutionEvent](),
+	}
+}
+
+func (s *NoopStorage) Initialize(context.Context, StorageConfig) error {
+	return unavailable("Initialize")
+}
+
+func (s *NoopStorage) Close(context.Context) error {
+	return unavailable("Close")
+}
+
+func (s *NoopStorage) HealthCheck(context.Context) error {
+	return unavailable("HealthCheck")
+}
+
+func (s *NoopStorage) StoreExecution(context.Context, *types.AgentExecution) error {
+	return unavailable("StoreExecution")
+}
+
+func (s *NoopStorage) GetExecution(context.Context, int64) (*types.AgentExecution, error) {
+	return nil, unavailable("GetExecution")
+}
+
+func (s *NoopStorage) QueryExecutions(context.Context, types.ExecutionFilters) ([]*types.AgentExecution, error) {
+	return nil, unavailable("QueryExecutions")
+}
+
+func (s *NoopStorage) StoreWorkflowExecution(context.Context, *types.WorkflowExecution) error {
+	return unavailable("StoreWorkflowExecution")
+}
+
+func (s *NoopStorage) GetWorkflowExecution(context.Context, string) (*types.WorkflowExecution, error) {
+	return nil, unavailable("GetWorkflowExecution")
+}
+
+func (s *NoopStorage) QueryWorkflowExecutions(context.Context, types.WorkflowExecutionFilters) ([]*types.WorkflowExecution, error) {
+	return nil, unavailable("QueryWorkflowExecutions")
+}
+
+func (s *NoopStorage) UpdateWorkflowExecution(context.Context, string, func(execution *types.WorkflowExecution) (*types.WorkflowExecution, error)) error {
+	return unavailable("UpdateWorkflowExecution")
+}
+
+func (s *NoopStorage) CreateExecutionRecord(context.Context, *types.Execution) error {
+	return unavailable("CreateExecutionRecord")
+}
+
+func (s *NoopStorage) UpsertExecution(context.Context, *types.Execution) error {
+	return unavailable("UpsertExecution")
+}
+
+func (s *NoopStorage) GetExecutionRecord(context.Context, string) (*types.Execution, error) {
+	return nil, unavailable("GetExecutionRecord")
+}
+
+func (s *NoopStorage) UpdateExecutionRecord(context.Context, string, func(*types.Execution) (*types.Execution, error)) (*types.Execution, error) {
+	return nil, unavailable("UpdateExecutionRecord")
+}
+
+func (s *NoopStorage) QueryExecutionRecords(context.Context, types.ExecutionFilter) ([]*types.Execution, error) {
+	return nil, unavailable("QueryExecutionRecords")
+}
+
+func (s *NoopStorage) SearchExecutions(context.Context, string, *string, int) ([]*types.Execution, error) {
+	return nil, unavailable("SearchExecutions")
+}
+
+func (s *NoopStorage) SubscribeExecutions(context.Context, string, *string) (<-chan *types.Execution, error) {
+	return nil, unavailable("SubscribeExecutions")
+}
+
+func (s *NoopStorage) QueryRunSummaries(context.Context, types.ExecutionFilter) ([]*RunSummaryAggregation, int, error) {
+	return nil, 0, unavailable("QueryRunSummaries")
+}
+
+func (s *NoopStorage) RegisterExecutionWebhook(context.Context, *types.ExecutionWebhook) error {
+	return unavailable("RegisterExecutionWebhook")
+}
+
+func (s *NoopStorage) GetExecutionWebhook(context.Context, string) (*types.ExecutionWebhook, error) {
+	return nil, unavailable("GetExecutionWebhook")
+}
+
+func (s *NoopStorage) ListDueExecutionWebhooks(context.Context, int) ([]*types.ExecutionWebhook, error) {
+	return nil, unavailable("ListDueExecutionWebhooks")
+}
+
+func (s *NoopStorage) TryMarkExecutionWebhookInFlight(context.Context, string, time.Time) (bool, error) {
+	return false, unavailable("TryMarkExecutionWebhookInFlight")
+}
+
+func (s *NoopStorage) UpdateExecutionWebhookState(context.Context, string, types.ExecutionWebhookStateUpdate) error {
+	return unavailable("UpdateExecutionWebhookState")
+}
+
+func (s *NoopStorage) HasExecutionWebhook(context.Context, string) (bool, error) {
+	return false, unavailable("HasExecutionWebhook")
+}
+
+func (s *NoopStorage) ListExecutionWebhooksRegistered(context.Context, []string) (map[string]bool, error) {
+	return nil, unavailable("ListExecutionWebhooksRegistered")
+}
+
+func (s *NoopStorage) StoreExecutionWebhookEvent(context.Context, *types.ExecutionWebhookEvent) error {
+	return unavailable("StoreExecutionWebhookEvent")
+}
+
+func (s *NoopStorage) ListExecutionWebhookEvents(context.Context, string) ([]*types.ExecutionWebhookEvent, error) {
+	return nil, unavailable("ListExecutionWebhookEvents")
+}
+
+func (s *NoopStorage) ListExecutionWebhookEventsBatch(context.Context, []string) (map[string][]*types.ExecutionWebhookEvent, error) {
+	return nil, unavailable("ListExecutionWebhookEventsBatch")
+}
+
+func (s *NoopStorage) StoreWorkflowExecutionEvent(context.Context, *types.WorkflowExecutionEvent) error {
+	return unavailable("StoreWorkflowExecutionEvent")
+}
+
+func (s *NoopStorage) ListWorkflowExecutionEvents(context.Context, string, *int64, int) ([]*types.WorkflowExecutionEvent, error) {
+	return nil, unavailable("ListWorkflowExecutionEvents")
+}
+
+func (s *NoopStorage) CleanupOldExecutions(context.Context, time.Duration, int) (int, error) {
+	return 0, unavailable("CleanupOldExecutions")
+}
+
+func (s *NoopStorage) MarkStaleExecutions(context.Context, time.Duration, int) (int, error) {
+	return 0, unavailable("MarkStaleExecutions")
+}
+
+func (s *NoopStorage) CleanupWorkflow(context.Context, string, bool) (*types.WorkflowCleanupResult, error) {
+	return nil, unavailable("CleanupWorkflow")
+}
+
+func (s *NoopStorage) Compact(context.Context) (*types.CompactionResult, error) {
+	return nil, unavailable("Compact")
+}
+
+func (s *NoopStorage) QueryWorkflowDAG(context.Context, string) ([]*types.WorkflowExecution, error) {
+	return nil, unavailable("QueryWorkflowDAG")
+}
+
+func (s *NoopStorage) CreateOrUpdateWorkflow(context.Context, *types.Workflow) error {
+	return unavailable("CreateOrUpdateWorkflow")
+}
+
+func (s *NoopStorage) GetWorkflow(context.Context, string) (*types.Workflow, error) {
+	return nil, unavailable("GetWorkflow")
+}
+
+func (s *NoopStorage) QueryWorkflows(context.Context, types.WorkflowFilters) ([]*types.Workflow, error) {
+	return nil, unavailable("QueryWorkflows")
+}
+
+func (s *NoopStorage) CreateOrUpdateSession(context.Context, *types.Session) error {
+	return unavailable("CreateOrUpdateSession")
+}
+
+func (s *NoopStorage) GetSession(context.Context, string) (*types.Session, error) {
+	return nil, unavailable("GetSession")
+}
+
+func (s *NoopStorage) QuerySessions(context.Context, types.SessionFilters) ([]*types.Session, error) {
+	return nil, unavailable("QuerySessions")
+}
+
+func (s *NoopStorage) SetMemory(context.Context, *types.Memory) error {
+	return unavailable("SetMemory")
+}
+
+func (s *NoopStorage) GetMemory(context.Context, string, string, string) (*types.Memory, error) {
+	return nil, unavailable("GetMemory")
+}
+
+func (s *NoopStorage) DeleteMemory(context.Context, string, string, string) error {
+	return unavailable("DeleteMemory")
+}
+
+func (s *NoopStorage) ListMemory(context.Context, string, string) ([]*types.Memory, error) {
+	return nil, unavailable("ListMemory")
+}
+
+func (s *NoopStorage) SetVector(context.Context, *types.VectorRecord) error {
+	return unavailable("SetVector")
+}
+
+func (s *NoopStorage) GetVector(context.Context, string, string, string) (*types.VectorRecord, error) {
+	return nil, unavailable("GetVector")
+}
+
+func (s *NoopStorage) DeleteVector(context.Context, string, string, string) error {
+	return unavailable("DeleteVector")
+}
+
+func (s *NoopStorage) DeleteVectorsByPrefix(context.Context, string, string, string) (int, error) {
+	return 0, unavailable("DeleteVectorsByPrefix")
+}
+
+func (s *NoopStorage) SimilaritySearch(context.Context, string, string, []float32, int, map[string]interface{}) ([]*types.VectorSearchResult, error) {
+	return nil, unavailable("SimilaritySearch")
+}
+
+func (s *NoopStorage) StoreEvent(context.Context, *types.MemoryChangeEvent) error {
+	return unavailable("StoreEvent")
+}
+
+func (s *NoopStorage) GetEventHistory(context.Context, types.EventFilter) ([]*types.MemoryChangeEvent, error) {
+	return nil, unavailable("GetEventHistory")
+}
+
+func (s *NoopStorage) AcquireLock(context.Context, string, time.Duration) (*types.DistributedLock, error) {
+	return nil, unavailable("AcquireLock")
+}
+
+func (s *NoopStorage) ReleaseLock(context.Context, string) error {
+	return unavailable("ReleaseLock")
+}
+
+func (s *NoopStorage) RenewLock(context.Context, string) (*types.DistributedLock, error) {
+	return nil, unavailable("RenewLock")
+}
+
+func (s *NoopStorage) GetLockStatus(context.Context, string) (*types.DistributedLock, error) {
+	return nil, unavailable("GetLockStatus")
+}
+
+func (s *NoopStorage) RegisterAgent(context.Context, *types.AgentNode) error {
+	return unavailable("RegisterAgent")
+}
+
+func (s *NoopStorage) GetAgent(context.Context, string) (*types.AgentNode, error) {
+	return nil, unavailable("GetAgent")
+}
+
+func (s *NoopStorage) ListAgents(context.Context, types.AgentFilters) ([]*types.AgentNode, error) {
+	return nil, unavailable("ListAgents")
+}
+
+func (s *NoopStorage) UpdateAgentHealth(context.Context, string, types.HealthStatus) error {
+	return unavailable("UpdateAgentHealth")
+}
+
+func (s *NoopStorage) UpdateAgentHealthAtomic(context.Context, string, types.HealthStatus, *time.Time) error {
+	return unavailable("UpdateAgentHealthAtomic")
+}
+
+func (s *NoopStorage) UpdateAgentHeartbeat(context.Context, string, time.Time) error {
+	return unavailable("UpdateAgentHeartbeat")
+}
+
+func (s *NoopStorage) UpdateAgentLifecycleStatus(context.Context, string, types.AgentLifecycleStatus) error {
+	return unavailable("UpdateAgentLifecycleStatus")
+}
+
+func (s *NoopStorage) SetConfig(context.Context, string, interface{}) error {
+	return unavailable("SetConfig")
+}
+
+func (s *NoopStorage) GetConfig(context.Context, string) (interface{}, error) {
+	return nil, unavailable("GetConfig")
+}
+
+func (s *NoopStorage) GetReasonerPerformanceMetrics(context.Context, string) (*types.ReasonerPerformanceMetrics, error) {
+	return nil, unavailable("GetReasonerPerformanceMetrics")
+}
+
+func (s *NoopStorage) GetReasonerExecutionHistory(context.Context, string, int, int) (*types.ReasonerExecutionHistory, error) {
+	return nil, unavailable("GetReasonerExecutionHistory")
+}
+
+func (s *NoopStorage) StoreAgentConfiguration(context.Context, *types.AgentConfiguration) error {
+	return unavailable("StoreAgentConfiguration")
+}
+
+func (s *NoopStorage) GetAgentConfiguration(context.Context, string, string) (*types.AgentConfiguration, error) {
+	return nil, unavailable("GetAgentConfiguration")
+}
+
+func (s *NoopStorage) QueryAgentConfigurations(context.Context, types.ConfigurationFilters) ([]*types.AgentConfiguration, error) {
+	return nil, unavailable("QueryAgentConfigurations")
+}
+
+func (s *NoopStorage) UpdateAgentConfiguration(context.Context, *types.AgentConfiguration) error {
+	return unavailable("UpdateAgentConfiguration")
+}
+
+func (s *NoopStorage) DeleteAgentConfiguration(context.Context, string, string) error {
+	return unavailable("DeleteAgentConfiguration")
+}
+
+func (s *NoopStorage) ValidateAgentConfiguration(context.Context, string, string, map[string]interface{}) (*types.ConfigurationValidationResult, error) {
+	return nil, unavailable("ValidateAgentConfiguration")
+}
+
+func (s *NoopStorage) StoreAgentPackage(context.Context, *types.AgentPackage) error {
+	return unavailable("StoreAgentPackage")
+}
+
+func (s *NoopStorage) GetAgentPackage(context.Context, string) (*types.AgentPackage, error) {
+	return nil, unavailable("GetAgentPackage")
+}
+
+func (s *NoopStorage) QueryAgentPackages(context.Context, types.PackageFilters) ([]*types.AgentPackage, error) {
+	return nil, unavailable("QueryAgentPackages")
+}
+
+func (s *NoopStorage) UpdateAgentPackage(context.Context, *types.AgentPackage) error {
+	return unavailable("UpdateAgentPackage")
+}
+
+func (s *NoopStorage) DeleteAgentPackage(context.Context, string) error {
+	return unavailable("DeleteAgentPackage")
+}
+
+func (s *NoopStorage) SubscribeToMemoryChanges(context.Context, string, string) (<-chan types.MemoryChangeEvent, error) {
+	return nil, unavailable("SubscribeToMemoryChanges")
+}
+
+func (s *NoopStorage) PublishMemoryChange(context.Context, types.MemoryChangeEvent) error {
+	return unavailable("PublishMemoryChange")
+}
+
+func (s *NoopStorage) GetExecutionEventBus() *events.ExecutionEventBus {
+	return s.eventBus
+}
+
+func (s *NoopStorage) GetWorkflowExecutionEventBus() *events.EventBus[*types.WorkflowExecutionEvent] {
+	return s.workflowExecutionEventBus
+}
+
+func (s *NoopStorage) StoreDID(context.Context, string, string, string, string, string) error {
+	return unavailable("StoreDID")
+}
+
+func (s *NoopStorage) GetDID(context.Context, string) (*types.DIDRegistryEntry, error) {
+	return nil, unavailable("GetDID")
+}
+
+func (s *NoopStorage) ListDIDs(context.Context) ([]*types.DIDRegistryEntry, error) {
+	return nil, unavailable("ListDIDs")
+}
+
+func (s *NoopStorage) StoreAgentFieldServerDID(context.Context, string, string, []byte, time.Time, time.Time) error {
+	return unavailable("StoreAgentFieldServerDID")
+}
+
+func (s *NoopStorage) GetAgentFieldServerDID(context.Context, string) (*types.AgentFieldServerDIDInfo, error) {
+	return nil, unavailable("GetAgentFieldServerDID")
+}
+
+func (s *NoopStorage) ListAgentFieldServerDIDs(context.Context) ([]*types.AgentFieldServerDIDInfo, error) {
+	return nil, unavailable("ListAgentFieldServerDIDs")
+}
+
+func (s *NoopStorage) DeleteAgentFieldServerDID(context.Context, string) error {
+	return unavailable("DeleteAgentFieldServerDID")
+}
+
+func (s *NoopStorage) StoreAgentDID(context.Context, string, string, string, string, string, int) error {
+	return unavailable("StoreAgentDID")
+}
+
+func (s *NoopStorage) GetAgentDID(context.Context, string) (*types.AgentDIDInfo, error) {
+	return nil, unavailable("GetAgentDID")
+}
+
+func (s *NoopStorage) ListAgentDIDs(context.Context) ([]*types.AgentDIDInfo, error) {
+	return nil, unavailable("ListAgentDIDs")
+}
+
+func (s *NoopStorage) ListAgentDIDsForTenant(context.Context, string) ([]*types.AgentDIDInfo, error) {
+	return nil, unavailable("ListAgentDIDsForTenant")
+}
+
+func (s *NoopStorage) UpdateAgentDIDStatus(context.Context, string, string, types.AgentDIDStatus, string) error {
+	return unavailable("UpdateAgentDIDStatus")
+}
+
+func (s *NoopStorage) UpdateAgentDIDStatuses(context.Context, string, []string, types.AgentDIDStatus, string) error {
+	return unavailable("UpdateAgentDIDStatuses")
+}
+
+func (s *NoopStorage) SetAgentDIDRateLimit(context.Context, string, string, *types.RateLimitPolicy) error {
+	return unavailable("SetAgentDIDRateLimit")
+}
+
+func (s *NoopStorage) GetAgentStatusHistory(context.Context, string) ([]*types.AgentDIDStatusChange, error) {
+	return nil, unavailable("GetAgentStatusHistory")
+}
+
+func (s *NoopStorage) RotateAgentFieldServerRoot(context.Context, string, string, []byte, time.Time, []types.DIDKeyRotation) error {
+	return unavailable("RotateAgentFieldServerRoot")
+}
+
+func (s *NoopStorage) GetRotatedDID(context.Context, string) (*types.RotatedDIDInfo, error) {
+	return nil, unavailable("GetRotatedDID")
+}
+
+func (s *NoopStorage) StoreComponentDID(context.Context, string, string, string, string, string, int) error {
+	return unavailable("StoreComponentDID")
+}
+
+func (s *NoopStorage) GetComponentDID(context.Context, string) (*types.ComponentDIDInfo, error) {
+	return nil, unavailable("GetComponentDID")
+}
+
+func (s *NoopStorage) ListComponentDIDs(context.Context, string) ([]*types.ComponentDIDInfo, error) {
+	return nil, unavailable("ListComponentDIDs")
+}
+
+func (s *NoopStorage) ListAgentComponentDIDs(context.Context, string) ([]*types.ComponentDID, error) {
+	return nil, unavailable("ListAgentComponentDIDs")
+}
+
+func (s *NoopStorage) CountComponentDIDsByType(context.Context, string) (map[string]int, error) {
+	return nil, unavailable("CountComponentDIDsByType")
+}
+
+func (s *NoopStorage) StoreAgentDIDWithComponents(context.Context, string, string, string, string, string, int, []ComponentDIDRequest) error {
+	return unavailable("StoreAgentDIDWithComponents")
+}
+
+func (s *NoopStorage) StoreExecutionVC(context.Context, string, string, string, string, string, string, string, string, string, string, []byte, string, string, int64) error {
+	return unavailable("StoreExecutionVC")
+}
+
+func (s *NoopStorage) GetExecutionVC(context.Context, string) (*types.ExecutionVCInfo, error) {
+	return nil, unavailable("GetExecutionVC")
+}
+
+func (s *NoopStorage) ListExecutionVCs(context.Context, types.VCFilters) ([]*types.ExecutionVCInfo, error) {
+	return nil, unavailable("ListExecutionVCs")
+}
+
+func (s *NoopStorage) ListWorkflowVCStatusSummaries(context.Context, []string) ([]*types.WorkflowVCStatusAggregation, error) {
+	return nil, unavailable("ListWorkflowVCStatusSummaries")
+}
+
+func (s *NoopStorage) CountExecutionVCs(context.Context, types.VCFilters) (int, error) {
+	return 0, unavailable("CountExecutionVCs")
+}
+
+func (s *NoopStorage) StoreWorkflowVC(context.Context, string, string, string, []string, string, *time.Time, *time.Time, int, int, string, int64) error {
+	return unavailable("StoreWorkflowVC")
+}
+
+func (s *NoopStorage) GetWorkflowVC(context.Context, string) (*types.WorkflowVCInfo, error) {
+	return nil, unavailable("GetWorkflowVC")
+}
+
+func (s *NoopStorage) ListWorkflowVCs(context.Context, string) ([]*types.WorkflowVCInfo, error) {
+	return nil, unavailable("ListWorkflowVCs")
+}
+
+func (s *NoopStorage) GetObservabilityWebhook(context.Context) (*types.ObservabilityWebhookConfig, error) {
+	return nil, unavailable("GetObservabilityWebhook")
+}
+
+func (s *NoopStorage) SetObservabilityWebhook(context.Context, *types.ObservabilityWebhookConfig) error {
+	return unavailable("SetObservabilityWebhook")
+}
+
+func (s *NoopStorage) DeleteObservabilityWebhook(context.Context) error {
+	return unavailable("DeleteObservabilityWebhook")
+}
+
+func (s *NoopStorage) AddToDeadLetterQueue(context.Context, *types.ObservabilityEvent, string, int) error {
+	return unavailable("AddToDeadLetterQueue")
+}
+
+func (s *NoopStorage) GetDeadLetterQueueCount(context.Context) (int64, error) {
+	return 0, unavailable("GetDeadLetterQueueCount")
+}
+
+func (s *NoopStorage) GetDeadLetterQueue(context.Context, int, int) ([]types.ObservabilityDeadLetterEntry, error) {
+	return nil, unavailable("GetDeadLetterQueue")
+}
+
+func (s *NoopStorage) DeleteFromDeadLetterQueue(context.Context, []int64) error {
+	return unavailable("DeleteFromDeadLetterQueue")
+}
+
+func (s *NoopStorage) ClearDeadLetterQueue(context.Context) error {
+	return unavailable("ClearDeadLetterQueue")
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoopStorageReturnsUnavailable(t *testing.T) {
+	noop := NewNoopStorage()
+	value := reflect.ValueOf(noop)
+	providerType := reflect.TypeOf((*StorageProvider)(nil)).Elem()
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	ctxType := reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	for i := 0; i < providerType.NumMethod(); i++ {
+		method := providerType.Method(i)
+		t.Run(method.Name, func(t *testing.T) {
+			fn := value.MethodByName(method.Name)
+			args := make([]reflect.Value, fn.Type().NumIn())
+			for j := range args {
+				argType := fn.Type().In(j)
+				if argType == ctxType {
+					args[j] = reflect.ValueOf(context.Background())
+					continue
+				}
+				args[j] = reflect.Zero(argType)
+			}
+
+			results := fn.Call(args)
+			last := results[len(results)-1]
+			if last.Type() != errorType {
+				// Event bus accessors return usable buses instead of errors.
+				require.False(t, last.IsNil(), "%s returned nil", method.Name)
+				return
+			}
+
+			err, _ := last.Interface().(error)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrStorageUnavailable), "%s returned %v", method.Name, err)
+
+			var unavailableErr *StorageUnavailableError
+			require.True(t, errors.As(err, &unavailableErr))
+			require.Equal(t, method.Name, unavailableErr.Operation)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -28,7 +29,7 @@ func setupObservabilityTestStorage(t *testing.T) (*LocalStorage, context.Context
 
 	ls := NewLocalStorage(LocalStorageConfig{})
 	if err := ls.Initialize(ctx, cfg); err != nil {
-		if strings.Contains(err.Error(), "no such module: fts5") {
+		if errors.Is(err, ErrFTS5Unavailable) {
 			t.Skip("sqlite3 compiled without FTS5; skipping test")
 		}
 		t.Fatalf("initialize local storage: %v", err)
@@ -0,0 +1,64 @@
+package storage
+
+import "sync"
+
+// keyedMutex hands out one mutex per key so writes that share a key are
+// serialized while writes for different keys run in parallel.
+//
+// An entry exists only while at least one goroutine holds or waits for its
+// key, and is deleted by the last unlock. Memory therefore grows with the
+// number of runs being written at the same moment (a map entry and a mutex,
+// roughly 100 bytes each), not with the number of runs ever written.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedMutexEntry)}
+}
+
+// Lock blocks until key is free and returns the function that releases it.
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	entry, ok := k.locks[key]
+	if !ok {
+		entry = &keyedMutexEntry{}
+		k.locks[key] = entry
+	}
+	entry.refs++
+	k.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+
+		k.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// len reports how many keys currently have a holder or waiter.
+func (k *keyedMutex) len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
+
+// lockRun serializes execution writes for runID when SerializeRunWrites is
+// enabled. It returns a no-op unlock otherwise.
+func (ls *LocalStorage) lockRun(runID string) (unlock func()) {
+	if ls.runLocks == nil || runID == "" {
+		return func() {}
+	}
+	return ls.runLocks.Lock(runID)
+}
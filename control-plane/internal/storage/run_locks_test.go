@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedMutexSerializesPerKey(t *testing.T) {
+	locks := newKeyedMutex()
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.Lock("run-1")
+			defer unlock()
+			n := atomic.AddInt32(&active, 1)
+			for {
+				seen := atomic.LoadInt32(&maxActive)
+				if n <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), maxActive)
+
+	// Entries are dropped once nobody holds or waits on them
+	require.Equal(t, 0, locks.len())
+}
+
+func TestKeyedMutexAllowsOtherKeys(t *testing.T) {
+	locks := newKeyedMutex()
+	unlock := locks.Lock("run-1")
+	defer unlock()
+
+	acquired := make(chan struct{})
+	go func() {
+		locks.Lock("run-2")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a different run was blocked by run-1")
+	}
+	require.Equal(t, 1, locks.len())
+}
+
+func TestUpsertExecutionSerializedByRun(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+	ls.runLocks = newKeyedMutex()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- ls.CreateExecutionRecord(ctx, &types.Execution{
+				ExecutionID: fmt.Sprintf("exec-%d", i),
+				RunID:       fmt.Sprintf("run-%d", i%2),
+				AgentNodeID: "agent-1",
+				ReasonerID:  "reasoner",
+				NodeID:      "agent-1",
+				Status:      string(types.ExecutionStatusRunning),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	all, err := ls.QueryExecutionRecords(ctx, types.ExecutionFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 20)
+	require.Equal(t, 0, ls.runLocks.len())
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// schemaMigration is a numbered SQLite schema change. up is applied once by
+// runMigrations and recorded in schema_migrations; down reverts it for
+// MigrateDown. Postgres schema changes live in the goose files under
+// control-plane/migrations instead.
+type schemaMigration struct {
+	version     string
+	description string
+	up          string
+	down        string
+}
+
+// sqliteMigrations lists every numbered SQLite migration in ascending order.
+// Append new entries with the next version number; never renumber or edit
+// an entry that has shipped.
+var sqliteMigrations = []schemaMigration{
+	{
+		version:     "007",
+		description: "Add parent_execution_id column",
+		up:          `ALTER TABLE workflow_executions ADD COLUMN parent_execution_id TEXT;`,
+		down: `
+			DROP INDEX IF EXISTS idx_workflow_executions_parent_execution_id;
+			ALTER TABLE workflow_executions DROP COLUMN parent_execution_id;`,
+	},
+	{
+		version:     "008",
+		description: "Create FTS5 search table",
+		up: `
+			-- Check if FTS table exists before creating
+			CREATE VIRTUAL TABLE IF NOT EXISTS workflow_executions_fts USING fts5(
+				execution_id,
+				workflow_id,
+				agent_node_id,
+				session_id,
+				workflow_name
+			);
+
+			-- Drop existing triggers if they exist to avoid conflicts
+			DROP TRIGGER IF EXISTS workflow_executions_fts_insert;
+			DROP TRIGGER IF EXISTS workflow_executions_fts_update;
+			DROP TRIGGER IF EXISTS workflow_executions_fts_delete;
+
+			-- Create triggers
+			CREATE TRIGGER workflow_executions_fts_insert AFTER INSERT ON workflow_executions BEGIN
+				INSERT INTO workflow_executions_fts(rowid, execution_id, workflow_id, agent_node_id, session_id, workflow_name)
+				VALUES (new.id, new.execution_id, new.workflow_id, new.agent_node_id, new.session_id, new.workflow_name);
+			END;
+
+			CREATE TRIGGER workflow_executions_fts_update AFTER UPDATE ON workflow_executions BEGIN
+				UPDATE workflow_executions_fts SET
+					execution_id = new.execution_id,
+					workflow_id = new.workflow_id,
+					agent_node_id = new.agent_node_id,
+					session_id = new.session_id,
+					workflow_name = new.workflow_name
+				WHERE rowid = new.id;
+			END;
+
+			CREATE TRIGGER workflow_executions_fts_delete AFTER DELETE ON workflow_executions BEGIN
+				DELETE FROM workflow_executions_fts WHERE rowid = old.id;
+			END;
+
+			-- Populate FTS table with existing data (ignore duplicates)
+			INSERT OR IGNORE INTO workflow_executions_fts(rowid, execution_id, workflow_id, agent_node_id, session_id, workflow_name)
+			SELECT id, execution_id, workflow_id, agent_node_id, session_id, workflow_name
+			FROM workflow_executions
+			WHERE NOT EXISTS (SELECT 1 FROM workflow_executions_fts WHERE rowid = workflow_executions.id);`,
+		down: `
+			DROP TRIGGER IF EXISTS workflow_executions_fts_insert;
+			DROP TRIGGER IF EXISTS workflow_executions_fts_update;
+			DROP TRIGGER IF EXISTS workflow_executions_fts_delete;
+			DROP TABLE IF EXISTS workflow_executions_fts;`,
+	},
+	{
+		version:     "009",
+		description: "Add notes column to workflow_executions",
+		up:          `ALTER TABLE workflow_executions ADD COLUMN notes TEXT DEFAULT '[]';`,
+		down:        `ALTER TABLE workflow_executions DROP COLUMN notes;`,
+	},
+	{
+		version:     "010",
+		description: "Add composite indexes for workflow execution filtering performance",
+		up: `
+			-- Composite index for session + status + time queries
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_session_status_time ON workflow_executions(session_id, status, started_at);
+
+			-- Composite index for actor + status + time queries
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_actor_status_time ON workflow_executions(actor_id, status, started_at);
+
+			-- Composite index for agent + status + time queries
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_agent_status_time ON workflow_executions(agent_node_id, status, started_at);
+
+			-- Composite index for status + time queries
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_status_time ON workflow_executions(status, started_at);
+
+			-- Composite index for session + time queries (without status filter)
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_session_time ON workflow_executions(session_id, started_at);
+
+			-- Composite index for actor + time queries (without status filter)
+			CREATE INDEX IF NOT EXISTS idx_workflow_executions_actor_time ON workflow_executions(actor_id, started_at);`,
+		down: `
+			DROP INDEX IF EXISTS idx_workflow_executions_session_status_time;
+			DROP INDEX IF EXISTS idx_workflow_executions_actor_status_time;
+			DROP INDEX IF EXISTS idx_workflow_executions_agent_status_time;
+			DROP INDEX IF EXISTS idx_workflow_executions_status_time;
+			DROP INDEX IF EXISTS idx_workflow_executions_session_time;
+			DROP INDEX IF EXISTS idx_workflow_executions_actor_time;`,
+	},
+	{
+		version:     "011",
+		description: "Add storage URI column to execution_vcs",
+		up:          `ALTER TABLE execution_vcs ADD COLUMN storage_uri TEXT DEFAULT '';`,
+		down:        `ALTER TABLE execution_vcs DROP COLUMN storage_uri;`,
+	},
+	{
+		version:     "012",
+		description: "Add document size column to execution_vcs",
+		up:          `ALTER TABLE execution_vcs ADD COLUMN document_size_bytes INTEGER DEFAULT 0;`,
+		down:        `ALTER TABLE execution_vcs DROP COLUMN document_size_bytes;`,
+	},
+	{
+		version:     "013",
+		description: "Add storage URI column to workflow_vcs",
+		up:          `ALTER TABLE workflow_vcs ADD COLUMN storage_uri TEXT DEFAULT '';`,
+		down:        `ALTER TABLE workflow_vcs DROP COLUMN storage_uri;`,
+	},
+	{
+		version:     "014",
+		description: "Add document size column to workflow_vcs",
+		up:          `ALTER TABLE workflow_vcs ADD COLUMN document_size_bytes INTEGER DEFAULT 0;`,
+		down:        `ALTER TABLE workflow_vcs DROP COLUMN document_size_bytes;`,
+	},
+}
+
+// MigrationVersion returns the highest numbered migration recorded in
+// schema_migrations, or 0 when none have been applied.
+func (ls *LocalStorage) MigrationVersion(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("context cancelled during migration version lookup: %w", err)
+	}
+
+	versions, err := ls.appliedMigrationVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[len(versions)-1], nil
+}
+
+// MigrateDown reverts applied SQLite migrations newer than targetVersion, newest
+// first. Each step runs its down SQL and removes its schema_migrations row in a
+// single transaction, so a failed step leaves the database at the previous version.
+func (ls *LocalStorage) MigrateDown(ctx context.Context, targetVersion int) error {
+	if ls.mode == "postgres" {
+		return fmt.Errorf("postgres schema is managed by goose migrations; use goose down instead")
+	}
+
+	versions, err := ls.appliedMigrationVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[int]schemaMigration, len(sqliteMigrations))
+	for _, migration := range sqliteMigrations {
+		version, err := strconv.Atoi(migration.version)
+		if err != nil {
+			return fmt.Errorf("invalid migration version %q: %w", migration.version, err)
+		}
+		byVersion[version] = migration
+	}
+
+	for i := len(versions) - 1; i >= 0 && versions[i] > targetVersion; i-- {
+		migration, ok := byVersion[versions[i]]
+		if !ok || migration.down == "" {
+			return fmt.Errorf("migration %03d cannot be reverted", versions[i])
+		}
+		if err := ls.revertMigration(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ls *LocalStorage) revertMigration(ctx context.Context, migration schemaMigration) (err error) {
+	tx, err := ls.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackTx(tx, "revertMigration")
+		}
+	}()
+
+	log.Printf("Reverting migration %s: %s", migration.version, migration.description)
+
+	if _, err = tx.ExecContext(ctx, migration.down); err != nil {
+		err = fmt.Errorf("failed to revert migration %s: %w", migration.version, err)
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, migration.version); err != nil {
+		err = fmt.Errorf("failed to unrecord migration %s: %w", migration.version, err)
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit revert of migration %s: %w", migration.version, err)
+		return err
+	}
+
+	log.Printf("Successfully reverted migration %s", migration.version)
+	return nil
+}
+
+// appliedMigrationVersions returns the numeric versions recorded in
+// schema_migrations in ascending order. Non-numeric versions are ignored.
+func (ls *LocalStorage) appliedMigrationVersions(ctx context.Context) ([]int, error) {
+	rows, err := ls.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		version, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate schema_migrations: %w", err)
+	}
+
+	sort.Ints(versions)
+	return versions, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationVersionAfterInitialize(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	version, err := ls.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 14, version)
+
+	// Re-running migrations is idempotent.
+	require.NoError(t, ls.runMigrations())
+	version, err = ls.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 14, version)
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	ls, ctx := setupLocalStorage(t)
+
+	require.NoError(t, ls.MigrateDown(ctx, 12))
+	version, err := ls.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 12, version)
+	require.False(t, tableHasColumn(t, ls, "workflow_vcs", "storage_uri"))
+	require.True(t, tableHasColumn(t, ls, "execution_vcs", "document_size_bytes"))
+
+	// Every shipped migration can be reverted.
+	require.NoError(t, ls.MigrateDown(ctx, 0))
+	version, err = ls.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+	require.False(t, tableHasColumn(t, ls, "workflow_executions", "notes"))
+
+	require.NoError(t, ls.runMigrations())
+	version, err = ls.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 14, version)
+	require.True(t, tableHasColumn(t, ls, "workflow_vcs", "storage_uri"))
+	require.True(t, tableHasColumn(t, ls, "workflow_executions", "notes"))
+}
+
+func tableHasColumn(t *testing.T, ls *LocalStorage, table, column string) bool {
+	t.Helper()
+
+	rows, err := ls.db.Query("PRAGMA table_info(" + table + ")")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue interface{}
+			pk        int
+		)
+		require.NoError(t, rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk))
+		if name == column {
+			return true
+		}
+	}
+	require.NoError(t, rows.Err())
+	return false
+}
@@ -44,9 +44,12 @@ type StorageProvider interface {
 	QueryWorkflowExecutions(ctx context.Context, filters types.WorkflowExecutionFilters) ([]*types.WorkflowExecution, error)
 	UpdateWorkflowExecution(ctx context.Context, executionID string, updateFunc func(execution *types.WorkflowExecution) (*types.WorkflowExecution, error)) error
 	CreateExecutionRecord(ctx context.Context, execution *types.Execution) error
+	UpsertExecution(ctx context.Context, execution *types.Execution) error
 	GetExecutionRecord(ctx context.Context, executionID string) (*types.Execution, error)
 	UpdateExecutionRecord(ctx context.Context, executionID string, update func(*types.Execution) (*types.Execution, error)) (*types.Execution, error)
 	QueryExecutionRecords(ctx context.Context, filter types.ExecutionFilter) ([]*types.Execution, error)
+	SearchExecutions(ctx context.Context, query string, tenantID *string, limit int) ([]*types.Execution, error)
+	SubscribeExecutions(ctx context.Context, runID string, tenantID *string) (<-chan *types.Execution, error)
 	QueryRunSummaries(ctx context.Context, filter types.ExecutionFilter) ([]*RunSummaryAggregation, int, error)
 	RegisterExecutionWebhook(ctx context.Context, webhook *types.ExecutionWebhook) error
 	GetExecutionWebhook(ctx context.Context, executionID string) (*types.ExecutionWebhook, error)
@@ -68,6 +71,9 @@ type StorageProvider interface {
 	// Workflow cleanup operations - deletes all data related to a workflow ID
 	CleanupWorkflow(ctx context.Context, workflowID string, dryRun bool) (*types.WorkflowCleanupResult, error)
 
+	// Compact removes orphaned DID rows and reclaims free space
+	Compact(ctx context.Context) (*types.CompactionResult, error)
+
 	// DAG operations - optimized single-query DAG building
 	QueryWorkflowDAG(ctx context.Context, rootWorkflowID string) ([]*types.WorkflowExecution, error)
 
@@ -151,19 +157,29 @@ type StorageProvider interface {
 	StoreAgentFieldServerDID(ctx context.Context, agentfieldServerID, rootDID string, masterSeed []byte, createdAt, lastKeyRotation time.Time) error
 	GetAgentFieldServerDID(ctx context.Context, agentfieldServerID string) (*types.AgentFieldServerDIDInfo, error)
 	ListAgentFieldServerDIDs(ctx context.Context) ([]*types.AgentFieldServerDIDInfo, error)
+	DeleteAgentFieldServerDID(ctx context.Context, agentfieldServerID string) error
 
 	// Agent DID operations
-	StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int) error
+	StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int) error
 	GetAgentDID(ctx context.Context, agentID string) (*types.AgentDIDInfo, error)
 	ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error)
+	ListAgentDIDsForTenant(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error)
+	UpdateAgentDIDStatus(ctx context.Context, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) error
+	UpdateAgentDIDStatuses(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) error
+	SetAgentDIDRateLimit(ctx context.Context, agentfieldServerID, agentNodeID string, policy *types.RateLimitPolicy) error
+	GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error)
+	RotateAgentFieldServerRoot(ctx context.Context, agentfieldServerID, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) error
+	GetRotatedDID(ctx context.Context, did string) (*types.RotatedDIDInfo, error)
 
 	// Component DID operations
 	StoreComponentDID(ctx context.Context, componentID, componentDID, agentDID, componentType, componentName string, derivationIndex int) error
 	GetComponentDID(ctx context.Context, componentID string) (*types.ComponentDIDInfo, error)
 	ListComponentDIDs(ctx context.Context, agentDID string) ([]*types.ComponentDIDInfo, error)
+	ListAgentComponentDIDs(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error)
+	CountComponentDIDsByType(ctx context.Context, agentfieldServerID string) (map[string]int, error)
 
 	// Multi-step DID operations with transaction safety
-	StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, publicKeyJWK string, derivationIndex int, components []ComponentDIDRequest) error
+	StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int, components []ComponentDIDRequest) error
 
 	// Execution VC operations
 	StoreExecutionVC(ctx context.Context, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status string, vcDocument []byte, signature string, storageURI string, documentSizeBytes int64) error
@@ -223,6 +239,11 @@ type StorageConfig struct {
 	Local    LocalStorageConfig    `yaml:"local" mapstructure:"local"`
 	Postgres PostgresStorageConfig `yaml:"postgres" mapstructure:"postgres"`
 	Vector   VectorStoreConfig     `yaml:"vector" mapstructure:"vector"`
+	// SerializeRunWrites orders execution writes within a run, so a burst of
+	// inserts for one run lands one at a time while other runs proceed in
+	// parallel. It costs throughput on hot runs and keeps one lock entry per
+	// run with a write in flight.
+	SerializeRunWrites bool `yaml:"serialize_run_writes" mapstructure:"serialize_run_writes"`
 }
 
 // PostgresStorageConfig holds configuration for the PostgreSQL storage provider.
@@ -2,8 +2,8 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -34,7 +34,7 @@ func TestStorageParity_CreateExecutionRecord(t *testing.T) {
 				}
 				ls := NewLocalStorage(LocalStorageConfig{})
 				if err := ls.Initialize(ctx, cfg); err != nil {
-					if strings.Contains(strings.ToLower(err.Error()), "fts5") {
+					if errors.Is(err, ErrFTS5Unavailable) {
 						t.Skip("sqlite3 compiled without FTS5")
 					}
 					require.NoError(t, err)
@@ -0,0 +1,919 @@
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+type agentDIDRecord struct {
+	info types.AgentDIDInfo
+	seq  int64
+}
+
+type componentDIDRecord struct {
+	component types.ComponentDID
+	seq       int64
+}
+
+func derivationPath(index int) string {
+	return fmt.Sprintf("m/44'/0'/0'/%d", index)
+}
+
+func (m *Mock) StoreAgentFieldServerDID(ctx context.Context, agentfieldServerID, rootDID string, masterSeed []byte, createdAt, lastKeyRotation time.Time) error {
+	if m.StoreAgentFieldServerDIDFunc != nil {
+		return m.StoreAgentFieldServerDIDFunc(ctx, agentfieldServerID, rootDID, masterSeed, createdAt, lastKeyRotation)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during store af server DID: %w", err)
+	}
+	if agentfieldServerID == "" {
+		return &storage.ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "StoreAgentFieldServerDID",
+		}
+	}
+	if rootDID == "" {
+		return &storage.ValidationError{
+			Field:   "root_did",
+			Value:   rootDID,
+			Reason:  "root DID cannot be empty",
+			Context: "StoreAgentFieldServerDID",
+		}
+	}
+	if len(masterSeed) == 0 {
+		return &storage.ValidationError{
+			Field:   "master_seed",
+			Value:   "<encrypted>",
+			Reason:  "master seed cannot be empty",
+			Context: "StoreAgentFieldServerDID",
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servers[agentfieldServerID] = &types.AgentFieldServerDIDInfo{
+		AgentFieldServerID: agentfieldServerID,
+		RootDID:            rootDID,
+		MasterSeed:         append([]byte(nil), masterSeed...),
+		CreatedAt:          createdAt,
+		LastKeyRotation:    lastKeyRotation,
+	}
+	return nil
+}
+
+// GetAgentFieldServerDID returns the af server DID, or (nil, nil) if it does not exist.
+func (m *Mock) GetAgentFieldServerDID(ctx context.Context, agentfieldServerID string) (*types.AgentFieldServerDIDInfo, error) {
+	if m.GetAgentFieldServerDIDFunc != nil {
+		return m.GetAgentFieldServerDIDFunc(ctx, agentfieldServerID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get af server DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.servers[agentfieldServerID]
+	if !ok {
+		return nil, nil
+	}
+	return cloneServerDID(info), nil
+}
+
+// ListAgentFieldServerDIDs returns every af server DID, newest first.
+func (m *Mock) ListAgentFieldServerDIDs(ctx context.Context) ([]*types.AgentFieldServerDIDInfo, error) {
+	if m.ListAgentFieldServerDIDsFunc != nil {
+		return m.ListAgentFieldServerDIDsFunc(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list af server DIDs: %w", err)
+	}
+
+	m.mu.Lock()
+	var infos []*types.AgentFieldServerDIDInfo
+	for _, info := range m.servers {
+		infos = append(infos, cloneServerDID(info))
+	}
+	m.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].CreatedAt.After(infos[j].CreatedAt)
+		}
+		return infos[i].AgentFieldServerID < infos[j].AgentFieldServerID
+	})
+	return infos, nil
+}
+
+// DeleteAgentFieldServerDID removes an af server DID unless agent DIDs still
+// belong to it. Deleting a missing server is not an error.
+func (m *Mock) DeleteAgentFieldServerDID(ctx context.Context, agentfieldServerID string) error {
+	if m.DeleteAgentFieldServerDIDFunc != nil {
+		return m.DeleteAgentFieldServerDIDFunc(ctx, agentfieldServerID)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during delete af server DID: %w", err)
+	}
+	if agentfieldServerID == "" {
+		return &storage.ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "DeleteAgentFieldServerDID",
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	agents := 0
+	for _, record := range m.agentDIDs {
+		if record.info.AgentFieldServerID == agentfieldServerID {
+			agents++
+		}
+	}
+	if agents > 0 {
+		return fmt.Errorf("af server %s still has %d agent DIDs", agentfieldServerID, agents)
+	}
+	delete(m.servers, agentfieldServerID)
+	return nil
+}
+
+func (m *Mock) StoreAgentDID(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int) error {
+	if m.StoreAgentDIDFunc != nil {
+		return m.StoreAgentDIDFunc(ctx, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK, derivationIndex)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during store agent DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.validateServerExists(agentfieldServerDID); err != nil {
+		return fmt.Errorf("pre-storage validation failed: %w", err)
+	}
+	if agentID == "" {
+		return &storage.ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentID,
+			Reason:  "agent ID cannot be empty",
+			Context: "StoreAgentDID",
+		}
+	}
+	if agentDID == "" {
+		return &storage.ValidationError{
+			Field:   "did",
+			Value:   agentDID,
+			Reason:  "agent DID cannot be empty",
+			Context: "StoreAgentDID",
+		}
+	}
+	if publicKeyJWK == "" {
+		return &storage.ValidationError{
+			Field:   "public_key_jwk",
+			Value:   publicKeyJWK,
+			Reason:  "public key JWK cannot be empty",
+			Context: "StoreAgentDID",
+		}
+	}
+	if _, exists := m.agentDIDs[agentDID]; exists {
+		return &storage.DuplicateDIDError{
+			DID:  fmt.Sprintf("agent:%s@%s", agentID, agentfieldServerDID),
+			Type: "agent",
+		}
+	}
+
+	m.insertAgentDID(agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK, derivationIndex)
+	return nil
+}
+
+// StoreAgentDIDWithComponents stores an agent DID and its component DIDs. All
+// of them are checked before anything is stored, so a duplicate leaves the
+// mock unchanged, as the rolled back transaction does in LocalStorage.
+func (m *Mock) StoreAgentDIDWithComponents(ctx context.Context, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK string, derivationIndex int, components []storage.ComponentDIDRequest) error {
+	if m.StoreAgentDIDWithComponentsFunc != nil {
+		return m.StoreAgentDIDWithComponentsFunc(ctx, agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK, derivationIndex, components)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during store agent DID with components: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.validateServerExists(agentfieldServerDID); err != nil {
+		return fmt.Errorf("pre-storage validation failed: %w", err)
+	}
+	if _, exists := m.agentDIDs[agentDID]; exists {
+		return &storage.DuplicateDIDError{
+			DID:  fmt.Sprintf("agent:%s@%s", agentID, agentfieldServerDID),
+			Type: "agent",
+		}
+	}
+	seen := make(map[string]bool, len(components))
+	for _, component := range components {
+		if _, exists := m.components[component.ComponentDID]; exists || seen[component.ComponentDID] {
+			return &storage.DuplicateDIDError{
+				DID:  fmt.Sprintf("component:%s/%s@%s", component.ComponentType, component.ComponentName, agentDID),
+				Type: "component",
+			}
+		}
+		seen[component.ComponentDID] = true
+	}
+
+	m.insertAgentDID(agentID, agentDID, agentfieldServerDID, tenantID, publicKeyJWK, derivationIndex)
+	for _, component := range components {
+		m.insertComponentDID(component.ComponentDID, agentDID, component.ComponentType, component.ComponentName, component.PublicKeyJWK, component.DerivationIndex)
+	}
+	return nil
+}
+
+func (m *Mock) validateServerExists(agentfieldServerID string) error {
+	if agentfieldServerID == "" {
+		return &storage.ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "pre-storage validation",
+		}
+	}
+	if _, ok := m.servers[agentfieldServerID]; !ok {
+		return &storage.ForeignKeyConstraintError{
+			Table:           "agent_dids",
+			Column:          "agentfield_server_id",
+			ReferencedTable: "did_registry",
+			ReferencedValue: agentfieldServerID,
+			Operation:       "INSERT",
+		}
+	}
+	return nil
+}
+
+func (m *Mock) insertAgentDID(agentID, agentDID, agentfieldServerID, tenantID, publicKeyJWK string, derivationIndex int) {
+	m.agentDIDs[agentDID] = &agentDIDRecord{
+		info: types.AgentDIDInfo{
+			DID:                agentDID,
+			AgentNodeID:        agentID,
+			AgentFieldServerID: agentfieldServerID,
+			TenantID:           types.NormalizeTenantID(tenantID),
+			PublicKeyJWK:       []byte(publicKeyJWK),
+			DerivationPath:     derivationPath(derivationIndex),
+			Reasoners:          map[string]types.ReasonerDIDInfo{},
+			Skills:             map[string]types.SkillDIDInfo{},
+			Status:             types.AgentDIDStatusActive,
+			RegisteredAt:       time.Now(),
+		},
+		seq: m.next(),
+	}
+}
+
+// GetAgentDID returns the agent DID stored for agentID. When an agent has
+// several DIDs the first one stored wins.
+func (m *Mock) GetAgentDID(ctx context.Context, agentID string) (*types.AgentDIDInfo, error) {
+	if m.GetAgentDIDFunc != nil {
+		return m.GetAgentDIDFunc(ctx, agentID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get agent DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found *agentDIDRecord
+	for _, record := range m.agentDIDs {
+		if record.info.AgentNodeID == agentID && (found == nil || record.seq < found.seq) {
+			found = record
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("agent DID for %s not found", agentID)
+	}
+	return cloneAgentDID(&found.info), nil
+}
+
+// ListAgentDIDs returns agent DIDs across every tenant, most recently registered first.
+func (m *Mock) ListAgentDIDs(ctx context.Context) ([]*types.AgentDIDInfo, error) {
+	if m.ListAgentDIDsFunc != nil {
+		return m.ListAgentDIDsFunc(ctx)
+	}
+	return m.listAgentDIDs(ctx, nil)
+}
+
+// ListAgentDIDsForTenant returns the agent DIDs owned by tenantID. An empty
+// tenantID means types.DefaultTenantID.
+func (m *Mock) ListAgentDIDsForTenant(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error) {
+	if m.ListAgentDIDsForTenantFunc != nil {
+		return m.ListAgentDIDsForTenantFunc(ctx, tenantID)
+	}
+	tenantID = types.NormalizeTenantID(tenantID)
+	return m.listAgentDIDs(ctx, &tenantID)
+}
+
+func (m *Mock) listAgentDIDs(ctx context.Context, tenantID *string) ([]*types.AgentDIDInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list agent DIDs: %w", err)
+	}
+
+	m.mu.Lock()
+	var records []*agentDIDRecord
+	for _, record := range m.agentDIDs {
+		if tenantID == nil || record.info.TenantID == *tenantID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !a.info.RegisteredAt.Equal(b.info.RegisteredAt) {
+			return a.info.RegisteredAt.After(b.info.RegisteredAt)
+		}
+		return a.seq > b.seq
+	})
+	var infos []*types.AgentDIDInfo
+	for _, record := range records {
+		infos = append(infos, cloneAgentDID(&record.info))
+	}
+	m.mu.Unlock()
+	return infos, nil
+}
+
+// UpdateAgentDIDStatus changes the status of an agent DID and records the transition.
+func (m *Mock) UpdateAgentDIDStatus(ctx context.Context, agentfieldServerID, agentNodeID string, status types.AgentDIDStatus, reason string) error {
+	if m.UpdateAgentDIDStatusFunc != nil {
+		return m.UpdateAgentDIDStatusFunc(ctx, agentfieldServerID, agentNodeID, status, reason)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent DID status: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return &storage.ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "UpdateAgentDIDStatus",
+		}
+	}
+	return m.UpdateAgentDIDStatuses(ctx, agentfieldServerID, []string{agentNodeID}, status, reason)
+}
+
+// UpdateAgentDIDStatuses changes the status of several agent DIDs on one af
+// server. If any agent is missing, none are updated.
+func (m *Mock) UpdateAgentDIDStatuses(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) error {
+	if m.UpdateAgentDIDStatusesFunc != nil {
+		return m.UpdateAgentDIDStatusesFunc(ctx, agentfieldServerID, agentNodeIDs, status, reason)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent DID statuses: %w", err)
+	}
+	for _, agentNodeID := range agentNodeIDs {
+		if strings.TrimSpace(agentNodeID) == "" {
+			return &storage.ValidationError{
+				Field:   "agent_node_id",
+				Value:   agentNodeID,
+				Reason:  "agent node ID cannot be empty",
+				Context: "UpdateAgentDIDStatuses",
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, agentNodeID := range agentNodeIDs {
+		if m.serverAgentDIDs(agentfieldServerID, agentNodeID) == nil {
+			return fmt.Errorf("agent DID for %s not found", agentNodeID)
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, agentNodeID := range agentNodeIDs {
+		records := m.serverAgentDIDs(agentfieldServerID, agentNodeID)
+		previous := records[0].info.Status
+		for _, record := range records {
+			record.info.Status = status
+		}
+		m.history = append(m.history, &types.AgentDIDStatusChange{
+			ID:                 m.next(),
+			AgentNodeID:        agentNodeID,
+			AgentFieldServerID: agentfieldServerID,
+			PreviousStatus:     previous,
+			NewStatus:          status,
+			Reason:             reason,
+			ChangedAt:          now,
+		})
+	}
+	return nil
+}
+
+// SetAgentDIDRateLimit stores the rate-limit policy of an agent DID. A nil
+// policy clears it.
+func (m *Mock) SetAgentDIDRateLimit(ctx context.Context, agentfieldServerID, agentNodeID string, policy *types.RateLimitPolicy) error {
+	if m.SetAgentDIDRateLimitFunc != nil {
+		return m.SetAgentDIDRateLimitFunc(ctx, agentfieldServerID, agentNodeID, policy)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during set agent DID rate limit: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return &storage.ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "SetAgentDIDRateLimit",
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := m.serverAgentDIDs(agentfieldServerID, agentNodeID)
+	if len(records) == 0 {
+		return fmt.Errorf("agent DID for %s not found", agentNodeID)
+	}
+	for _, record := range records {
+		record.info.RateLimit = cloneRateLimit(policy)
+	}
+	return nil
+}
+
+// serverAgentDIDs returns the DIDs of an agent on one af server, oldest first.
+func (m *Mock) serverAgentDIDs(agentfieldServerID, agentNodeID string) []*agentDIDRecord {
+	var records []*agentDIDRecord
+	for _, record := range m.agentDIDs {
+		if record.info.AgentNodeID == agentNodeID && record.info.AgentFieldServerID == agentfieldServerID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].seq < records[j].seq })
+	return records
+}
+
+// GetAgentStatusHistory returns every recorded status transition for an agent, oldest first.
+func (m *Mock) GetAgentStatusHistory(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error) {
+	if m.GetAgentStatusHistoryFunc != nil {
+		return m.GetAgentStatusHistoryFunc(ctx, agentNodeID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get agent status history: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := []*types.AgentDIDStatusChange{}
+	for _, change := range m.history {
+		if change.AgentNodeID == agentNodeID {
+			copied := *change
+			history = append(history, &copied)
+		}
+	}
+	return history, nil
+}
+
+// RotateAgentFieldServerRoot replaces an af server's root DID and master seed
+// and rewrites every re-derived agent and component DID. Retired DIDs stay
+// resolvable through GetRotatedDID. Nothing changes if any rotation is invalid.
+func (m *Mock) RotateAgentFieldServerRoot(ctx context.Context, agentfieldServerID, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) error {
+	if m.RotateAgentFieldServerRootFunc != nil {
+		return m.RotateAgentFieldServerRootFunc(ctx, agentfieldServerID, newRootDID, newMasterSeed, rotatedAt, rotations)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during rotate af server root: %w", err)
+	}
+	if strings.TrimSpace(agentfieldServerID) == "" {
+		return &storage.ValidationError{
+			Field:   "agentfield_server_id",
+			Value:   agentfieldServerID,
+			Reason:  "af server ID cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+	if newRootDID == "" {
+		return &storage.ValidationError{
+			Field:   "root_did",
+			Value:   newRootDID,
+			Reason:  "root DID cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+	if len(newMasterSeed) == 0 {
+		return &storage.ValidationError{
+			Field:   "master_seed",
+			Value:   "<encrypted>",
+			Reason:  "master seed cannot be empty",
+			Context: "RotateAgentFieldServerRoot",
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	server, ok := m.servers[agentfieldServerID]
+	if !ok {
+		return fmt.Errorf("af server DID for %s not found", agentfieldServerID)
+	}
+	for _, rotation := range rotations {
+		switch rotation.ComponentType {
+		case "agentfield_server", "agent", "reasoner", "skill":
+		default:
+			return &storage.ValidationError{
+				Field:   "component_type",
+				Value:   rotation.ComponentType,
+				Reason:  "unknown DID component type",
+				Context: "RotateAgentFieldServerRoot",
+			}
+		}
+	}
+
+	server.RootDID = newRootDID
+	server.MasterSeed = append([]byte(nil), newMasterSeed...)
+	server.LastKeyRotation = rotatedAt
+
+	for _, rotation := range rotations {
+		switch rotation.ComponentType {
+		case "agent":
+			if record, ok := m.agentDIDs[rotation.OldDID]; ok && record.info.AgentFieldServerID == agentfieldServerID {
+				delete(m.agentDIDs, rotation.OldDID)
+				record.info.DID = rotation.NewDID
+				record.info.PublicKeyJWK = []byte(rotation.NewPublicKeyJWK)
+				m.agentDIDs[rotation.NewDID] = record
+			}
+			for _, component := range m.components {
+				if component.component.AgentDID == rotation.OldDID {
+					component.component.AgentDID = rotation.NewDID
+				}
+			}
+		case "reasoner", "skill":
+			if record, ok := m.components[rotation.OldDID]; ok {
+				delete(m.components, rotation.OldDID)
+				record.component.DID = rotation.NewDID
+				record.component.PublicKeyJWK = []byte(rotation.NewPublicKeyJWK)
+				m.components[rotation.NewDID] = record
+			}
+		}
+
+		m.rotated[rotation.OldDID] = &types.RotatedDIDInfo{
+			DID:                rotation.OldDID,
+			ReplacedBy:         rotation.NewDID,
+			AgentFieldServerID: agentfieldServerID,
+			ComponentType:      rotation.ComponentType,
+			PublicKeyJWK:       []byte(rotation.OldPublicKeyJWK),
+			DerivationPath:     rotation.DerivationPath,
+			RotatedAt:          rotatedAt,
+		}
+	}
+	return nil
+}
+
+// GetRotatedDID returns the rotation record for a retired DID, or (nil, nil)
+// if the DID was never rotated.
+func (m *Mock) GetRotatedDID(ctx context.Context, did string) (*types.RotatedDIDInfo, error) {
+	if m.GetRotatedDIDFunc != nil {
+		return m.GetRotatedDIDFunc(ctx, did)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get rotated DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.rotated[did]
+	if !ok {
+		return nil, nil
+	}
+	copied := *info
+	copied.PublicKeyJWK = append([]byte(nil), info.PublicKeyJWK...)
+	return &copied, nil
+}
+
+// StoreComponentDID stores a reasoner or skill DID for an existing agent DID.
+// Like LocalStorage it has no public key parameter and stores an empty one.
+func (m *Mock) StoreComponentDID(ctx context.Context, componentID, componentDID, agentDID, componentType, componentName string, derivationIndex int) error {
+	if m.StoreComponentDIDFunc != nil {
+		return m.StoreComponentDIDFunc(ctx, componentID, componentDID, agentDID, componentType, componentName, derivationIndex)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during store component DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.validateAgentDIDExists(agentDID); err != nil {
+		return fmt.Errorf("pre-storage validation failed: %w", err)
+	}
+	if componentDID == "" {
+		return &storage.ValidationError{
+			Field:   "component_did",
+			Value:   componentDID,
+			Reason:  "component DID cannot be empty",
+			Context: "StoreComponentDID",
+		}
+	}
+	if componentType == "" {
+		return &storage.ValidationError{
+			Field:   "component_type",
+			Value:   componentType,
+			Reason:  "component type cannot be empty",
+			Context: "StoreComponentDID",
+		}
+	}
+	if componentName == "" {
+		return &storage.ValidationError{
+			Field:   "component_name",
+			Value:   componentName,
+			Reason:  "component name cannot be empty",
+			Context: "StoreComponentDID",
+		}
+	}
+	if componentType != "reasoner" && componentType != "skill" {
+		return &storage.ValidationError{
+			Field:   "component_type",
+			Value:   componentType,
+			Reason:  "component type must be 'reasoner' or 'skill'",
+			Context: "StoreComponentDID",
+		}
+	}
+	if _, exists := m.components[componentDID]; exists {
+		return &storage.DuplicateDIDError{
+			DID:  fmt.Sprintf("component:%s/%s@%s", componentType, componentName, agentDID),
+			Type: "component",
+		}
+	}
+
+	m.insertComponentDID(componentDID, agentDID, componentType, componentName, "", derivationIndex)
+	return nil
+}
+
+func (m *Mock) validateAgentDIDExists(agentDID string) error {
+	if agentDID == "" {
+		return &storage.ValidationError{
+			Field:   "agent_did",
+			Value:   agentDID,
+			Reason:  "agent DID cannot be empty",
+			Context: "pre-storage validation",
+		}
+	}
+	if _, ok := m.agentDIDs[agentDID]; !ok {
+		return &storage.ForeignKeyConstraintError{
+			Table:           "component_dids",
+			Column:          "agent_did",
+			ReferencedTable: "agent_dids",
+			ReferencedValue: agentDID,
+			Operation:       "INSERT",
+		}
+	}
+	return nil
+}
+
+func (m *Mock) insertComponentDID(componentDID, agentDID, componentType, componentName, publicKeyJWK string, derivationIndex int) {
+	m.components[componentDID] = &componentDIDRecord{
+		component: types.ComponentDID{
+			DID:             componentDID,
+			AgentDID:        agentDID,
+			ComponentType:   componentType,
+			FunctionName:    componentName,
+			PublicKeyJWK:    []byte(publicKeyJWK),
+			DerivationPath:  derivationPath(derivationIndex),
+			DerivationIndex: derivationIndex,
+			CreatedAt:       time.Now(),
+		},
+		seq: m.next(),
+	}
+}
+
+// GetComponentDID looks a component up by function name, which LocalStorage
+// uses as the component ID. When several agents share a function name the
+// first one stored wins.
+func (m *Mock) GetComponentDID(ctx context.Context, componentID string) (*types.ComponentDIDInfo, error) {
+	if m.GetComponentDIDFunc != nil {
+		return m.GetComponentDIDFunc(ctx, componentID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get component DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found *componentDIDRecord
+	for _, record := range m.components {
+		if record.component.FunctionName == componentID && (found == nil || record.seq < found.seq) {
+			found = record
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("component DID for %s not found", componentID)
+	}
+	return componentInfo(&found.component), nil
+}
+
+// ListComponentDIDs returns the component DIDs of agentDID, or of every agent
+// when agentDID is empty, newest first.
+func (m *Mock) ListComponentDIDs(ctx context.Context, agentDID string) ([]*types.ComponentDIDInfo, error) {
+	if m.ListComponentDIDsFunc != nil {
+		return m.ListComponentDIDsFunc(ctx, agentDID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list component DIDs: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var records []*componentDIDRecord
+	for _, record := range m.components {
+		if agentDID == "" || record.component.AgentDID == agentDID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !a.component.CreatedAt.Equal(b.component.CreatedAt) {
+			return a.component.CreatedAt.After(b.component.CreatedAt)
+		}
+		return a.seq > b.seq
+	})
+	var infos []*types.ComponentDIDInfo
+	for _, record := range records {
+		infos = append(infos, componentInfo(&record.component))
+	}
+	return infos, nil
+}
+
+// ListAgentComponentDIDs returns the reasoner and skill DIDs stored for an
+// agent node, ordered by component type and function name.
+func (m *Mock) ListAgentComponentDIDs(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error) {
+	if m.ListAgentComponentDIDsFunc != nil {
+		return m.ListAgentComponentDIDsFunc(ctx, agentNodeID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list agent component DIDs: %w", err)
+	}
+	if strings.TrimSpace(agentNodeID) == "" {
+		return nil, &storage.ValidationError{
+			Field:   "agent_node_id",
+			Value:   agentNodeID,
+			Reason:  "agent node ID cannot be empty",
+			Context: "ListAgentComponentDIDs",
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	components := []*types.ComponentDID{}
+	for _, record := range m.components {
+		agent, ok := m.agentDIDs[record.component.AgentDID]
+		if !ok || agent.info.AgentNodeID != agentNodeID {
+			continue
+		}
+		copied := record.component
+		copied.PublicKeyJWK = append([]byte(nil), record.component.PublicKeyJWK...)
+		components = append(components, &copied)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].ComponentType != components[j].ComponentType {
+			return components[i].ComponentType < components[j].ComponentType
+		}
+		return components[i].FunctionName < components[j].FunctionName
+	})
+	return components, nil
+}
+
+// CountComponentDIDsByType returns the number of component DIDs per component
+// type for all agents registered under an af server.
+func (m *Mock) CountComponentDIDsByType(ctx context.Context, agentfieldServerID string) (map[string]int, error) {
+	if m.CountComponentDIDsByTypeFunc != nil {
+		return m.CountComponentDIDsByTypeFunc(ctx, agentfieldServerID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during count component DIDs: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[string]int)
+	for _, record := range m.components {
+		agent, ok := m.agentDIDs[record.component.AgentDID]
+		if ok && agent.info.AgentFieldServerID == agentfieldServerID {
+			counts[record.component.ComponentType]++
+		}
+	}
+	return counts, nil
+}
+
+// StoreDID adds a DID registry entry. Entries are insert-only.
+func (m *Mock) StoreDID(ctx context.Context, did string, didDocument, publicKey, privateKeyRef, derivationPath string) error {
+	if m.StoreDIDFunc != nil {
+		return m.StoreDIDFunc(ctx, did, didDocument, publicKey, privateKeyRef, derivationPath)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during store DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.registry[did]; exists {
+		return &storage.DuplicateDIDError{DID: did, Type: "registry"}
+	}
+	now := time.Now()
+	m.registry[did] = &types.DIDRegistryEntry{
+		DID:            did,
+		DIDDocument:    didDocument,
+		PublicKey:      publicKey,
+		PrivateKeyRef:  privateKeyRef,
+		DerivationPath: derivationPath,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Status:         "active",
+	}
+	return nil
+}
+
+func (m *Mock) GetDID(ctx context.Context, did string) (*types.DIDRegistryEntry, error) {
+	if m.GetDIDFunc != nil {
+		return m.GetDIDFunc(ctx, did)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get DID: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.registry[did]
+	if !ok {
+		return nil, fmt.Errorf("DID %s not found", did)
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// ListDIDs returns every DID registry entry, newest first.
+func (m *Mock) ListDIDs(ctx context.Context) ([]*types.DIDRegistryEntry, error) {
+	if m.ListDIDsFunc != nil {
+		return m.ListDIDsFunc(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list DIDs: %w", err)
+	}
+
+	m.mu.Lock()
+	var entries []*types.DIDRegistryEntry
+	for _, entry := range m.registry {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].DID < entries[j].DID
+	})
+	return entries, nil
+}
+
+func cloneServerDID(info *types.AgentFieldServerDIDInfo) *types.AgentFieldServerDIDInfo {
+	copied := *info
+	copied.MasterSeed = append([]byte(nil), info.MasterSeed...)
+	return &copied
+}
+
+func cloneAgentDID(info *types.AgentDIDInfo) *types.AgentDIDInfo {
+	copied := *info
+	copied.PublicKeyJWK = append([]byte(nil), info.PublicKeyJWK...)
+	copied.Reasoners = make(map[string]types.ReasonerDIDInfo, len(info.Reasoners))
+	for name, reasoner := range info.Reasoners {
+		copied.Reasoners[name] = reasoner
+	}
+	copied.Skills = make(map[string]types.SkillDIDInfo, len(info.Skills))
+	for name, skill := range info.Skills {
+		copied.Skills[name] = skill
+	}
+	copied.RateLimit = cloneRateLimit(info.RateLimit)
+	return &copied
+}
+
+func cloneRateLimit(policy *types.RateLimitPolicy) *types.RateLimitPolicy {
+	if policy == nil {
+		return nil
+	}
+	copied := *policy
+	return &copied
+}
+
+func componentInfo(component *types.ComponentDID) *types.ComponentDIDInfo {
+	return &types.ComponentDIDInfo{
+		ComponentID:     component.FunctionName,
+		ComponentDID:    component.DID,
+		AgentDID:        component.AgentDID,
+		ComponentType:   component.ComponentType,
+		ComponentName:   component.FunctionName,
+		DerivationIndex: component.DerivationIndex,
+		CreatedAt:       component.CreatedAt,
+	}
+}
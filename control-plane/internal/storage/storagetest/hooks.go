@@ -0,0 +1,722 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/events"
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// Hooks overrides individual StorageProvider operations of a Mock. A non-nil
+// <Operation>Func is called instead of the operation, whether the Mock models
+// it or would fail it with storage.ErrStorageUnavailable, so a test can stub
+// the few operations it needs without writing its own provider.
+//
+// Hooks are set before the Mock is shared and are called without the Mock's
+// lock held, so they may call back into the Mock.
+type Hooks struct {
+	InitializeFunc                      func(ctx context.Context, config storage.StorageConfig) error
+	CloseFunc                           func(ctx context.Context) error
+	HealthCheckFunc                     func(ctx context.Context) error
+	StoreExecutionFunc                  func(ctx context.Context, execution *types.AgentExecution) error
+	GetExecutionFunc                    func(ctx context.Context, id int64) (*types.AgentExecution, error)
+	QueryExecutionsFunc                 func(ctx context.Context, filters types.ExecutionFilters) ([]*types.AgentExecution, error)
+	StoreWorkflowExecutionFunc          func(ctx context.Context, execution *types.WorkflowExecution) error
+	GetWorkflowExecutionFunc            func(ctx context.Context, executionID string) (*types.WorkflowExecution, error)
+	QueryWorkflowExecutionsFunc         func(ctx context.Context, filters types.WorkflowExecutionFilters) ([]*types.WorkflowExecution, error)
+	UpdateWorkflowExecutionFunc         func(ctx context.Context, executionID string, updateFunc func(execution *types.WorkflowExecution) (*types.WorkflowExecution, error)) error
+	CreateExecutionRecordFunc           func(ctx context.Context, execution *types.Execution) error
+	UpsertExecutionFunc                 func(ctx context.Context, execution *types.Execution) error
+	GetExecutionRecordFunc              func(ctx context.Context, executionID string) (*types.Execution, error)
+	UpdateExecutionRecordFunc           func(ctx context.Context, executionID string, update func(*types.Execution) (*types.Execution, error)) (*types.Execution, error)
+	QueryExecutionRecordsFunc           func(ctx context.Context, filter types.ExecutionFilter) ([]*types.Execution, error)
+	SearchExecutionsFunc                func(ctx context.Context, query string, tenantID *string, limit int) ([]*types.Execution, error)
+	SubscribeExecutionsFunc             func(ctx context.Context, runID string, tenantID *string) (<-chan *types.Execution, error)
+	QueryRunSummariesFunc               func(ctx context.Context, filter types.ExecutionFilter) ([]*storage.RunSummaryAggregation, int, error)
+	RegisterExecutionWebhookFunc        func(ctx context.Context, webhook *types.ExecutionWebhook) error
+	GetExecutionWebhookFunc             func(ctx context.Context, executionID string) (*types.ExecutionWebhook, error)
+	ListDueExecutionWebhooksFunc        func(ctx context.Context, limit int) ([]*types.ExecutionWebhook, error)
+	TryMarkExecutionWebhookInFlightFunc func(ctx context.Context, executionID string, now time.Time) (bool, error)
+	UpdateExecutionWebhookStateFunc     func(ctx context.Context, executionID string, update types.ExecutionWebhookStateUpdate) error
+	HasExecutionWebhookFunc             func(ctx context.Context, executionID string) (bool, error)
+	ListExecutionWebhooksRegisteredFunc func(ctx context.Context, executionIDs []string) (map[string]bool, error)
+	StoreExecutionWebhookEventFunc      func(ctx context.Context, event *types.ExecutionWebhookEvent) error
+	ListExecutionWebhookEventsFunc      func(ctx context.Context, executionID string) ([]*types.ExecutionWebhookEvent, error)
+	ListExecutionWebhookEventsBatchFunc func(ctx context.Context, executionIDs []string) (map[string][]*types.ExecutionWebhookEvent, error)
+	StoreWorkflowExecutionEventFunc     func(ctx context.Context, event *types.WorkflowExecutionEvent) error
+	ListWorkflowExecutionEventsFunc     func(ctx context.Context, executionID string, afterSeq *int64, limit int) ([]*types.WorkflowExecutionEvent, error)
+	CleanupOldExecutionsFunc            func(ctx context.Context, retentionPeriod time.Duration, batchSize int) (int, error)
+	MarkStaleExecutionsFunc             func(ctx context.Context, staleAfter time.Duration, limit int) (int, error)
+	CleanupWorkflowFunc                 func(ctx context.Context, workflowID string, dryRun bool) (*types.WorkflowCleanupResult, error)
+	CompactFunc                         func(ctx context.Context) (*types.CompactionResult, error)
+	QueryWorkflowDAGFunc                func(ctx context.Context, rootWorkflowID string) ([]*types.WorkflowExecution, error)
+	CreateOrUpdateWorkflowFunc          func(ctx context.Context, workflow *types.Workflow) error
+	GetWorkflowFunc                     func(ctx context.Context, workflowID string) (*types.Workflow, error)
+	QueryWorkflowsFunc                  func(ctx context.Context, filters types.WorkflowFilters) ([]*types.Workflow, error)
+	CreateOrUpdateSessionFunc           func(ctx context.Context, session *types.Session) error
+	GetSessionFunc                      func(ctx context.Context, sessionID string) (*types.Session, error)
+	QuerySessionsFunc                   func(ctx context.Context, filters types.SessionFilters) ([]*types.Session, error)
+	SetMemoryFunc                       func(ctx context.Context, memory *types.Memory) error
+	GetMemoryFunc                       func(ctx context.Context, scope string, scopeID string, key string) (*types.Memory, error)
+	DeleteMemoryFunc                    func(ctx context.Context, scope string, scopeID string, key string) error
+	ListMemoryFunc                      func(ctx context.Context, scope string, scopeID string) ([]*types.Memory, error)
+	SetVectorFunc                       func(ctx context.Context, record *types.VectorRecord) error
+	GetVectorFunc                       func(ctx context.Context, scope string, scopeID string, key string) (*types.VectorRecord, error)
+	DeleteVectorFunc                    func(ctx context.Context, scope string, scopeID string, key string) error
+	DeleteVectorsByPrefixFunc           func(ctx context.Context, scope string, scopeID string, prefix string) (int, error)
+	SimilaritySearchFunc                func(ctx context.Context, scope string, scopeID string, queryEmbedding []float32, topK int, filters map[string]interface{}) ([]*types.VectorSearchResult, error)
+	StoreEventFunc                      func(ctx context.Context, event *types.MemoryChangeEvent) error
+	GetEventHistoryFunc                 func(ctx context.Context, filter types.EventFilter) ([]*types.MemoryChangeEvent, error)
+	AcquireLockFunc                     func(ctx context.Context, key string, timeout time.Duration) (*types.DistributedLock, error)
+	ReleaseLockFunc                     func(ctx context.Context, lockID string) error
+	RenewLockFunc                       func(ctx context.Context, lockID string) (*types.DistributedLock, error)
+	GetLockStatusFunc                   func(ctx context.Context, key string) (*types.DistributedLock, error)
+	RegisterAgentFunc                   func(ctx context.Context, agent *types.AgentNode) error
+	GetAgentFunc                        func(ctx context.Context, id string) (*types.AgentNode, error)
+	ListAgentsFunc                      func(ctx context.Context, filters types.AgentFilters) ([]*types.AgentNode, error)
+	UpdateAgentHealthFunc               func(ctx context.Context, id string, status types.HealthStatus) error
+	UpdateAgentHealthAtomicFunc         func(ctx context.Context, id string, status types.HealthStatus, expectedLastHeartbeat *time.Time) error
+	UpdateAgentHeartbeatFunc            func(ctx context.Context, id string, heartbeatTime time.Time) error
+	UpdateAgentLifecycleStatusFunc      func(ctx context.Context, id string, status types.AgentLifecycleStatus) error
+	SetConfigFunc                       func(ctx context.Context, key string, value interface{}) error
+	GetConfigFunc                       func(ctx context.Context, key string) (interface{}, error)
+	GetReasonerPerformanceMetricsFunc   func(ctx context.Context, reasonerID string) (*types.ReasonerPerformanceMetrics, error)
+	GetReasonerExecutionHistoryFunc     func(ctx context.Context, reasonerID string, page int, limit int) (*types.ReasonerExecutionHistory, error)
+	StoreAgentConfigurationFunc         func(ctx context.Context, config *types.AgentConfiguration) error
+	GetAgentConfigurationFunc           func(ctx context.Context, agentID string, packageID string) (*types.AgentConfiguration, error)
+	QueryAgentConfigurationsFunc        func(ctx context.Context, filters types.ConfigurationFilters) ([]*types.AgentConfiguration, error)
+	UpdateAgentConfigurationFunc        func(ctx context.Context, config *types.AgentConfiguration) error
+	DeleteAgentConfigurationFunc        func(ctx context.Context, agentID string, packageID string) error
+	ValidateAgentConfigurationFunc      func(ctx context.Context, agentID string, packageID string, config map[string]interface{}) (*types.ConfigurationValidationResult, error)
+	StoreAgentPackageFunc               func(ctx context.Context, pkg *types.AgentPackage) error
+	GetAgentPackageFunc                 func(ctx context.Context, packageID string) (*types.AgentPackage, error)
+	QueryAgentPackagesFunc              func(ctx context.Context, filters types.PackageFilters) ([]*types.AgentPackage, error)
+	UpdateAgentPackageFunc              func(ctx context.Context, pkg *types.AgentPackage) error
+	DeleteAgentPackageFunc              func(ctx context.Context, packageID string) error
+	SubscribeToMemoryChangesFunc        func(ctx context.Context, scope string, scopeID string) (<-chan types.MemoryChangeEvent, error)
+	PublishMemoryChangeFunc             func(ctx context.Context, event types.MemoryChangeEvent) error
+	GetExecutionEventBusFunc            func() *events.ExecutionEventBus
+	GetWorkflowExecutionEventBusFunc    func() *events.EventBus[*types.WorkflowExecutionEvent]
+	StoreDIDFunc                        func(ctx context.Context, did string, didDocument string, publicKey string, privateKeyRef string, derivationPath string) error
+	GetDIDFunc                          func(ctx context.Context, did string) (*types.DIDRegistryEntry, error)
+	ListDIDsFunc                        func(ctx context.Context) ([]*types.DIDRegistryEntry, error)
+	StoreAgentFieldServerDIDFunc        func(ctx context.Context, agentfieldServerID string, rootDID string, masterSeed []byte, createdAt time.Time, lastKeyRotation time.Time) error
+	GetAgentFieldServerDIDFunc          func(ctx context.Context, agentfieldServerID string) (*types.AgentFieldServerDIDInfo, error)
+	ListAgentFieldServerDIDsFunc        func(ctx context.Context) ([]*types.AgentFieldServerDIDInfo, error)
+	DeleteAgentFieldServerDIDFunc       func(ctx context.Context, agentfieldServerID string) error
+	StoreAgentDIDFunc                   func(ctx context.Context, agentID string, agentDID string, agentfieldServerDID string, tenantID string, publicKeyJWK string, derivationIndex int) error
+	GetAgentDIDFunc                     func(ctx context.Context, agentID string) (*types.AgentDIDInfo, error)
+	ListAgentDIDsFunc                   func(ctx context.Context) ([]*types.AgentDIDInfo, error)
+	ListAgentDIDsForTenantFunc          func(ctx context.Context, tenantID string) ([]*types.AgentDIDInfo, error)
+	UpdateAgentDIDStatusFunc            func(ctx context.Context, agentfieldServerID string, agentNodeID string, status types.AgentDIDStatus, reason string) error
+	UpdateAgentDIDStatusesFunc          func(ctx context.Context, agentfieldServerID string, agentNodeIDs []string, status types.AgentDIDStatus, reason string) error
+	SetAgentDIDRateLimitFunc            func(ctx context.Context, agentfieldServerID string, agentNodeID string, policy *types.RateLimitPolicy) error
+	GetAgentStatusHistoryFunc           func(ctx context.Context, agentNodeID string) ([]*types.AgentDIDStatusChange, error)
+	RotateAgentFieldServerRootFunc      func(ctx context.Context, agentfieldServerID string, newRootDID string, newMasterSeed []byte, rotatedAt time.Time, rotations []types.DIDKeyRotation) error
+	GetRotatedDIDFunc                   func(ctx context.Context, did string) (*types.RotatedDIDInfo, error)
+	StoreComponentDIDFunc               func(ctx context.Context, componentID string, componentDID string, agentDID string, componentType string, componentName string, derivationIndex int) error
+	GetComponentDIDFunc                 func(ctx context.Context, componentID string) (*types.ComponentDIDInfo, error)
+	ListComponentDIDsFunc               func(ctx context.Context, agentDID string) ([]*types.ComponentDIDInfo, error)
+	ListAgentComponentDIDsFunc          func(ctx context.Context, agentNodeID string) ([]*types.ComponentDID, error)
+	CountComponentDIDsByTypeFunc        func(ctx context.Context, agentfieldServerID string) (map[string]int, error)
+	StoreAgentDIDWithComponentsFunc     func(ctx context.Context, agentID string, agentDID string, agentfieldServerDID string, tenantID string, publicKeyJWK string, derivationIndex int, components []storage.ComponentDIDRequest) error
+	StoreExecutionVCFunc                func(ctx context.Context, vcID string, executionID string, workflowID string, sessionID string, issuerDID string, targetDID string, callerDID string, inputHash string, outputHash string, status string, vcDocument []byte, signature string, storageURI string, documentSizeBytes int64) error
+	GetExecutionVCFunc                  func(ctx context.Context, vcID string) (*types.ExecutionVCInfo, error)
+	ListExecutionVCsFunc                func(ctx context.Context, filters types.VCFilters) ([]*types.ExecutionVCInfo, error)
+	ListWorkflowVCStatusSummariesFunc   func(ctx context.Context, workflowIDs []string) ([]*types.WorkflowVCStatusAggregation, error)
+	CountExecutionVCsFunc               func(ctx context.Context, filters types.VCFilters) (int, error)
+	StoreWorkflowVCFunc                 func(ctx context.Context, workflowVCID string, workflowID string, sessionID string, componentVCIDs []string, status string, startTime *time.Time, endTime *time.Time, totalSteps int, completedSteps int, storageURI string, documentSizeBytes int64) error
+	GetWorkflowVCFunc                   func(ctx context.Context, workflowVCID string) (*types.WorkflowVCInfo, error)
+	ListWorkflowVCsFunc                 func(ctx context.Context, workflowID string) ([]*types.WorkflowVCInfo, error)
+	GetObservabilityWebhookFunc         func(ctx context.Context) (*types.ObservabilityWebhookConfig, error)
+	SetObservabilityWebhookFunc         func(ctx context.Context, config *types.ObservabilityWebhookConfig) error
+	DeleteObservabilityWebhookFunc      func(ctx context.Context) error
+	AddToDeadLetterQueueFunc            func(ctx context.Context, event *types.ObservabilityEvent, errorMessage string, retryCount int) error
+	GetDeadLetterQueueCountFunc         func(ctx context.Context) (int64, error)
+	GetDeadLetterQueueFunc              func(ctx context.Context, limit int, offset int) ([]types.ObservabilityDeadLetterEntry, error)
+	DeleteFromDeadLetterQueueFunc       func(ctx context.Context, ids []int64) error
+	ClearDeadLetterQueueFunc            func(ctx context.Context) error
+}
+
+func (m *Mock) StoreExecution(ctx context.Context, execution *types.AgentExecution) error {
+	if m.StoreExecutionFunc != nil {
+		return m.StoreExecutionFunc(ctx, execution)
+	}
+	return m.NoopStorage.StoreExecution(ctx, execution)
+}
+
+func (m *Mock) GetExecution(ctx context.Context, id int64) (*types.AgentExecution, error) {
+	if m.GetExecutionFunc != nil {
+		return m.GetExecutionFunc(ctx, id)
+	}
+	return m.NoopStorage.GetExecution(ctx, id)
+}
+
+func (m *Mock) QueryExecutions(ctx context.Context, filters types.ExecutionFilters) ([]*types.AgentExecution, error) {
+	if m.QueryExecutionsFunc != nil {
+		return m.QueryExecutionsFunc(ctx, filters)
+	}
+	return m.NoopStorage.QueryExecutions(ctx, filters)
+}
+
+func (m *Mock) StoreWorkflowExecution(ctx context.Context, execution *types.WorkflowExecution) error {
+	if m.StoreWorkflowExecutionFunc != nil {
+		return m.StoreWorkflowExecutionFunc(ctx, execution)
+	}
+	return m.NoopStorage.StoreWorkflowExecution(ctx, execution)
+}
+
+func (m *Mock) GetWorkflowExecution(ctx context.Context, executionID string) (*types.WorkflowExecution, error) {
+	if m.GetWorkflowExecutionFunc != nil {
+		return m.GetWorkflowExecutionFunc(ctx, executionID)
+	}
+	return m.NoopStorage.GetWorkflowExecution(ctx, executionID)
+}
+
+func (m *Mock) QueryWorkflowExecutions(ctx context.Context, filters types.WorkflowExecutionFilters) ([]*types.WorkflowExecution, error) {
+	if m.QueryWorkflowExecutionsFunc != nil {
+		return m.QueryWorkflowExecutionsFunc(ctx, filters)
+	}
+	return m.NoopStorage.QueryWorkflowExecutions(ctx, filters)
+}
+
+func (m *Mock) UpdateWorkflowExecution(ctx context.Context, executionID string, updateFunc func(execution *types.WorkflowExecution) (*types.WorkflowExecution, error)) error {
+	if m.UpdateWorkflowExecutionFunc != nil {
+		return m.UpdateWorkflowExecutionFunc(ctx, executionID, updateFunc)
+	}
+	return m.NoopStorage.UpdateWorkflowExecution(ctx, executionID, updateFunc)
+}
+
+func (m *Mock) SearchExecutions(ctx context.Context, query string, tenantID *string, limit int) ([]*types.Execution, error) {
+	if m.SearchExecutionsFunc != nil {
+		return m.SearchExecutionsFunc(ctx, query, tenantID, limit)
+	}
+	return m.NoopStorage.SearchExecutions(ctx, query, tenantID, limit)
+}
+
+func (m *Mock) SubscribeExecutions(ctx context.Context, runID string, tenantID *string) (<-chan *types.Execution, error) {
+	if m.SubscribeExecutionsFunc != nil {
+		return m.SubscribeExecutionsFunc(ctx, runID, tenantID)
+	}
+	return m.NoopStorage.SubscribeExecutions(ctx, runID, tenantID)
+}
+
+func (m *Mock) QueryRunSummaries(ctx context.Context, filter types.ExecutionFilter) ([]*storage.RunSummaryAggregation, int, error) {
+	if m.QueryRunSummariesFunc != nil {
+		return m.QueryRunSummariesFunc(ctx, filter)
+	}
+	return m.NoopStorage.QueryRunSummaries(ctx, filter)
+}
+
+func (m *Mock) RegisterExecutionWebhook(ctx context.Context, webhook *types.ExecutionWebhook) error {
+	if m.RegisterExecutionWebhookFunc != nil {
+		return m.RegisterExecutionWebhookFunc(ctx, webhook)
+	}
+	return m.NoopStorage.RegisterExecutionWebhook(ctx, webhook)
+}
+
+func (m *Mock) GetExecutionWebhook(ctx context.Context, executionID string) (*types.ExecutionWebhook, error) {
+	if m.GetExecutionWebhookFunc != nil {
+		return m.GetExecutionWebhookFunc(ctx, executionID)
+	}
+	return m.NoopStorage.GetExecutionWebhook(ctx, executionID)
+}
+
+func (m *Mock) ListDueExecutionWebhooks(ctx context.Context, limit int) ([]*types.ExecutionWebhook, error) {
+	if m.ListDueExecutionWebhooksFunc != nil {
+		return m.ListDueExecutionWebhooksFunc(ctx, limit)
+	}
+	return m.NoopStorage.ListDueExecutionWebhooks(ctx, limit)
+}
+
+func (m *Mock) TryMarkExecutionWebhookInFlight(ctx context.Context, executionID string, now time.Time) (bool, error) {
+	if m.TryMarkExecutionWebhookInFlightFunc != nil {
+		return m.TryMarkExecutionWebhookInFlightFunc(ctx, executionID, now)
+	}
+	return m.NoopStorage.TryMarkExecutionWebhookInFlight(ctx, executionID, now)
+}
+
+func (m *Mock) UpdateExecutionWebhookState(ctx context.Context, executionID string, update types.ExecutionWebhookStateUpdate) error {
+	if m.UpdateExecutionWebhookStateFunc != nil {
+		return m.UpdateExecutionWebhookStateFunc(ctx, executionID, update)
+	}
+	return m.NoopStorage.UpdateExecutionWebhookState(ctx, executionID, update)
+}
+
+func (m *Mock) HasExecutionWebhook(ctx context.Context, executionID string) (bool, error) {
+	if m.HasExecutionWebhookFunc != nil {
+		return m.HasExecutionWebhookFunc(ctx, executionID)
+	}
+	return m.NoopStorage.HasExecutionWebhook(ctx, executionID)
+}
+
+func (m *Mock) ListExecutionWebhooksRegistered(ctx context.Context, executionIDs []string) (map[string]bool, error) {
+	if m.ListExecutionWebhooksRegisteredFunc != nil {
+		return m.ListExecutionWebhooksRegisteredFunc(ctx, executionIDs)
+	}
+	return m.NoopStorage.ListExecutionWebhooksRegistered(ctx, executionIDs)
+}
+
+func (m *Mock) StoreExecutionWebhookEvent(ctx context.Context, event *types.ExecutionWebhookEvent) error {
+	if m.StoreExecutionWebhookEventFunc != nil {
+		return m.StoreExecutionWebhookEventFunc(ctx, event)
+	}
+	return m.NoopStorage.StoreExecutionWebhookEvent(ctx, event)
+}
+
+func (m *Mock) ListExecutionWebhookEvents(ctx context.Context, executionID string) ([]*types.ExecutionWebhookEvent, error) {
+	if m.ListExecutionWebhookEventsFunc != nil {
+		return m.ListExecutionWebhookEventsFunc(ctx, executionID)
+	}
+	return m.NoopStorage.ListExecutionWebhookEvents(ctx, executionID)
+}
+
+func (m *Mock) ListExecutionWebhookEventsBatch(ctx context.Context, executionIDs []string) (map[string][]*types.ExecutionWebhookEvent, error) {
+	if m.ListExecutionWebhookEventsBatchFunc != nil {
+		return m.ListExecutionWebhookEventsBatchFunc(ctx, executionIDs)
+	}
+	return m.NoopStorage.ListExecutionWebhookEventsBatch(ctx, executionIDs)
+}
+
+func (m *Mock) StoreWorkflowExecutionEvent(ctx context.Context, event *types.WorkflowExecutionEvent) error {
+	if m.StoreWorkflowExecutionEventFunc != nil {
+		return m.StoreWorkflowExecutionEventFunc(ctx, event)
+	}
+	return m.NoopStorage.StoreWorkflowExecutionEvent(ctx, event)
+}
+
+func (m *Mock) ListWorkflowExecutionEvents(ctx context.Context, executionID string, afterSeq *int64, limit int) ([]*types.WorkflowExecutionEvent, error) {
+	if m.ListWorkflowExecutionEventsFunc != nil {
+		return m.ListWorkflowExecutionEventsFunc(ctx, executionID, afterSeq, limit)
+	}
+	return m.NoopStorage.ListWorkflowExecutionEvents(ctx, executionID, afterSeq, limit)
+}
+
+func (m *Mock) CleanupOldExecutions(ctx context.Context, retentionPeriod time.Duration, batchSize int) (int, error) {
+	if m.CleanupOldExecutionsFunc != nil {
+		return m.CleanupOldExecutionsFunc(ctx, retentionPeriod, batchSize)
+	}
+	return m.NoopStorage.CleanupOldExecutions(ctx, retentionPeriod, batchSize)
+}
+
+func (m *Mock) MarkStaleExecutions(ctx context.Context, staleAfter time.Duration, limit int) (int, error) {
+	if m.MarkStaleExecutionsFunc != nil {
+		return m.MarkStaleExecutionsFunc(ctx, staleAfter, limit)
+	}
+	return m.NoopStorage.MarkStaleExecutions(ctx, staleAfter, limit)
+}
+
+func (m *Mock) CleanupWorkflow(ctx context.Context, workflowID string, dryRun bool) (*types.WorkflowCleanupResult, error) {
+	if m.CleanupWorkflowFunc != nil {
+		return m.CleanupWorkflowFunc(ctx, workflowID, dryRun)
+	}
+	return m.NoopStorage.CleanupWorkflow(ctx, workflowID, dryRun)
+}
+
+func (m *Mock) Compact(ctx context.Context) (*types.CompactionResult, error) {
+	if m.CompactFunc != nil {
+		return m.CompactFunc(ctx)
+	}
+	return m.NoopStorage.Compact(ctx)
+}
+
+func (m *Mock) QueryWorkflowDAG(ctx context.Context, rootWorkflowID string) ([]*types.WorkflowExecution, error) {
+	if m.QueryWorkflowDAGFunc != nil {
+		return m.QueryWorkflowDAGFunc(ctx, rootWorkflowID)
+	}
+	return m.NoopStorage.QueryWorkflowDAG(ctx, rootWorkflowID)
+}
+
+func (m *Mock) CreateOrUpdateWorkflow(ctx context.Context, workflow *types.Workflow) error {
+	if m.CreateOrUpdateWorkflowFunc != nil {
+		return m.CreateOrUpdateWorkflowFunc(ctx, workflow)
+	}
+	return m.NoopStorage.CreateOrUpdateWorkflow(ctx, workflow)
+}
+
+func (m *Mock) GetWorkflow(ctx context.Context, workflowID string) (*types.Workflow, error) {
+	if m.GetWorkflowFunc != nil {
+		return m.GetWorkflowFunc(ctx, workflowID)
+	}
+	return m.NoopStorage.GetWorkflow(ctx, workflowID)
+}
+
+func (m *Mock) QueryWorkflows(ctx context.Context, filters types.WorkflowFilters) ([]*types.Workflow, error) {
+	if m.QueryWorkflowsFunc != nil {
+		return m.QueryWorkflowsFunc(ctx, filters)
+	}
+	return m.NoopStorage.QueryWorkflows(ctx, filters)
+}
+
+func (m *Mock) CreateOrUpdateSession(ctx context.Context, session *types.Session) error {
+	if m.CreateOrUpdateSessionFunc != nil {
+		return m.CreateOrUpdateSessionFunc(ctx, session)
+	}
+	return m.NoopStorage.CreateOrUpdateSession(ctx, session)
+}
+
+func (m *Mock) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
+	if m.GetSessionFunc != nil {
+		return m.GetSessionFunc(ctx, sessionID)
+	}
+	return m.NoopStorage.GetSession(ctx, sessionID)
+}
+
+func (m *Mock) QuerySessions(ctx context.Context, filters types.SessionFilters) ([]*types.Session, error) {
+	if m.QuerySessionsFunc != nil {
+		return m.QuerySessionsFunc(ctx, filters)
+	}
+	return m.NoopStorage.QuerySessions(ctx, filters)
+}
+
+func (m *Mock) SetMemory(ctx context.Context, memory *types.Memory) error {
+	if m.SetMemoryFunc != nil {
+		return m.SetMemoryFunc(ctx, memory)
+	}
+	return m.NoopStorage.SetMemory(ctx, memory)
+}
+
+func (m *Mock) GetMemory(ctx context.Context, scope string, scopeID string, key string) (*types.Memory, error) {
+	if m.GetMemoryFunc != nil {
+		return m.GetMemoryFunc(ctx, scope, scopeID, key)
+	}
+	return m.NoopStorage.GetMemory(ctx, scope, scopeID, key)
+}
+
+func (m *Mock) DeleteMemory(ctx context.Context, scope string, scopeID string, key string) error {
+	if m.DeleteMemoryFunc != nil {
+		return m.DeleteMemoryFunc(ctx, scope, scopeID, key)
+	}
+	return m.NoopStorage.DeleteMemory(ctx, scope, scopeID, key)
+}
+
+func (m *Mock) ListMemory(ctx context.Context, scope string, scopeID string) ([]*types.Memory, error) {
+	if m.ListMemoryFunc != nil {
+		return m.ListMemoryFunc(ctx, scope, scopeID)
+	}
+	return m.NoopStorage.ListMemory(ctx, scope, scopeID)
+}
+
+func (m *Mock) SetVector(ctx context.Context, record *types.VectorRecord) error {
+	if m.SetVectorFunc != nil {
+		return m.SetVectorFunc(ctx, record)
+	}
+	return m.NoopStorage.SetVector(ctx, record)
+}
+
+func (m *Mock) GetVector(ctx context.Context, scope string, scopeID string, key string) (*types.VectorRecord, error) {
+	if m.GetVectorFunc != nil {
+		return m.GetVectorFunc(ctx, scope, scopeID, key)
+	}
+	return m.NoopStorage.GetVector(ctx, scope, scopeID, key)
+}
+
+func (m *Mock) DeleteVector(ctx context.Context, scope string, scopeID string, key string) error {
+	if m.DeleteVectorFunc != nil {
+		return m.DeleteVectorFunc(ctx, scope, scopeID, key)
+	}
+	return m.NoopStorage.DeleteVector(ctx, scope, scopeID, key)
+}
+
+func (m *Mock) DeleteVectorsByPrefix(ctx context.Context, scope string, scopeID string, prefix string) (int, error) {
+	if m.DeleteVectorsByPrefixFunc != nil {
+		return m.DeleteVectorsByPrefixFunc(ctx, scope, scopeID, prefix)
+	}
+	return m.NoopStorage.DeleteVectorsByPrefix(ctx, scope, scopeID, prefix)
+}
+
+func (m *Mock) SimilaritySearch(ctx context.Context, scope string, scopeID string, queryEmbedding []float32, topK int, filters map[string]interface{}) ([]*types.VectorSearchResult, error) {
+	if m.SimilaritySearchFunc != nil {
+		return m.SimilaritySearchFunc(ctx, scope, scopeID, queryEmbedding, topK, filters)
+	}
+	return m.NoopStorage.SimilaritySearch(ctx, scope, scopeID, queryEmbedding, topK, filters)
+}
+
+func (m *Mock) StoreEvent(ctx context.Context, event *types.MemoryChangeEvent) error {
+	if m.StoreEventFunc != nil {
+		return m.StoreEventFunc(ctx, event)
+	}
+	return m.NoopStorage.StoreEvent(ctx, event)
+}
+
+func (m *Mock) GetEventHistory(ctx context.Context, filter types.EventFilter) ([]*types.MemoryChangeEvent, error) {
+	if m.GetEventHistoryFunc != nil {
+		return m.GetEventHistoryFunc(ctx, filter)
+	}
+	return m.NoopStorage.GetEventHistory(ctx, filter)
+}
+
+func (m *Mock) AcquireLock(ctx context.Context, key string, timeout time.Duration) (*types.DistributedLock, error) {
+	if m.AcquireLockFunc != nil {
+		return m.AcquireLockFunc(ctx, key, timeout)
+	}
+	return m.NoopStorage.AcquireLock(ctx, key, timeout)
+}
+
+func (m *Mock) ReleaseLock(ctx context.Context, lockID string) error {
+	if m.ReleaseLockFunc != nil {
+		return m.ReleaseLockFunc(ctx, lockID)
+	}
+	return m.NoopStorage.ReleaseLock(ctx, lockID)
+}
+
+func (m *Mock) RenewLock(ctx context.Context, lockID string) (*types.DistributedLock, error) {
+	if m.RenewLockFunc != nil {
+		return m.RenewLockFunc(ctx, lockID)
+	}
+	return m.NoopStorage.RenewLock(ctx, lockID)
+}
+
+func (m *Mock) GetLockStatus(ctx context.Context, key string) (*types.DistributedLock, error) {
+	if m.GetLockStatusFunc != nil {
+		return m.GetLockStatusFunc(ctx, key)
+	}
+	return m.NoopStorage.GetLockStatus(ctx, key)
+}
+
+func (m *Mock) SetConfig(ctx context.Context, key string, value interface{}) error {
+	if m.SetConfigFunc != nil {
+		return m.SetConfigFunc(ctx, key, value)
+	}
+	return m.NoopStorage.SetConfig(ctx, key, value)
+}
+
+func (m *Mock) GetConfig(ctx context.Context, key string) (interface{}, error) {
+	if m.GetConfigFunc != nil {
+		return m.GetConfigFunc(ctx, key)
+	}
+	return m.NoopStorage.GetConfig(ctx, key)
+}
+
+func (m *Mock) GetReasonerPerformanceMetrics(ctx context.Context, reasonerID string) (*types.ReasonerPerformanceMetrics, error) {
+	if m.GetReasonerPerformanceMetricsFunc != nil {
+		return m.GetReasonerPerformanceMetricsFunc(ctx, reasonerID)
+	}
+	return m.NoopStorage.GetReasonerPerformanceMetrics(ctx, reasonerID)
+}
+
+func (m *Mock) GetReasonerExecutionHistory(ctx context.Context, reasonerID string, page int, limit int) (*types.ReasonerExecutionHistory, error) {
+	if m.GetReasonerExecutionHistoryFunc != nil {
+		return m.GetReasonerExecutionHistoryFunc(ctx, reasonerID, page, limit)
+	}
+	return m.NoopStorage.GetReasonerExecutionHistory(ctx, reasonerID, page, limit)
+}
+
+func (m *Mock) StoreAgentConfiguration(ctx context.Context, config *types.AgentConfiguration) error {
+	if m.StoreAgentConfigurationFunc != nil {
+		return m.StoreAgentConfigurationFunc(ctx, config)
+	}
+	return m.NoopStorage.StoreAgentConfiguration(ctx, config)
+}
+
+func (m *Mock) GetAgentConfiguration(ctx context.Context, agentID string, packageID string) (*types.AgentConfiguration, error) {
+	if m.GetAgentConfigurationFunc != nil {
+		return m.GetAgentConfigurationFunc(ctx, agentID, packageID)
+	}
+	return m.NoopStorage.GetAgentConfiguration(ctx, agentID, packageID)
+}
+
+func (m *Mock) QueryAgentConfigurations(ctx context.Context, filters types.ConfigurationFilters) ([]*types.AgentConfiguration, error) {
+	if m.QueryAgentConfigurationsFunc != nil {
+		return m.QueryAgentConfigurationsFunc(ctx, filters)
+	}
+	return m.NoopStorage.QueryAgentConfigurations(ctx, filters)
+}
+
+func (m *Mock) UpdateAgentConfiguration(ctx context.Context, config *types.AgentConfiguration) error {
+	if m.UpdateAgentConfigurationFunc != nil {
+		return m.UpdateAgentConfigurationFunc(ctx, config)
+	}
+	return m.NoopStorage.UpdateAgentConfiguration(ctx, config)
+}
+
+func (m *Mock) DeleteAgentConfiguration(ctx context.Context, agentID string, packageID string) error {
+	if m.DeleteAgentConfigurationFunc != nil {
+		return m.DeleteAgentConfigurationFunc(ctx, agentID, packageID)
+	}
+	return m.NoopStorage.DeleteAgentConfiguration(ctx, agentID, packageID)
+}
+
+func (m *Mock) ValidateAgentConfiguration(ctx context.Context, agentID string, packageID string, config map[string]interface{}) (*types.ConfigurationValidationResult, error) {
+	if m.ValidateAgentConfigurationFunc != nil {
+		return m.ValidateAgentConfigurationFunc(ctx, agentID, packageID, config)
+	}
+	return m.NoopStorage.ValidateAgentConfiguration(ctx, agentID, packageID, config)
+}
+
+func (m *Mock) StoreAgentPackage(ctx context.Context, pkg *types.AgentPackage) error {
+	if m.StoreAgentPackageFunc != nil {
+		return m.StoreAgentPackageFunc(ctx, pkg)
+	}
+	return m.NoopStorage.StoreAgentPackage(ctx, pkg)
+}
+
+func (m *Mock) GetAgentPackage(ctx context.Context, packageID string) (*types.AgentPackage, error) {
+	if m.GetAgentPackageFunc != nil {
+		return m.GetAgentPackageFunc(ctx, packageID)
+	}
+	return m.NoopStorage.GetAgentPackage(ctx, packageID)
+}
+
+func (m *Mock) QueryAgentPackages(ctx context.Context, filters types.PackageFilters) ([]*types.AgentPackage, error) {
+	if m.QueryAgentPackagesFunc != nil {
+		return m.QueryAgentPackagesFunc(ctx, filters)
+	}
+	return m.NoopStorage.QueryAgentPackages(ctx, filters)
+}
+
+func (m *Mock) UpdateAgentPackage(ctx context.Context, pkg *types.AgentPackage) error {
+	if m.UpdateAgentPackageFunc != nil {
+		return m.UpdateAgentPackageFunc(ctx, pkg)
+	}
+	return m.NoopStorage.UpdateAgentPackage(ctx, pkg)
+}
+
+func (m *Mock) DeleteAgentPackage(ctx context.Context, packageID string) error {
+	if m.DeleteAgentPackageFunc != nil {
+		return m.DeleteAgentPackageFunc(ctx, packageID)
+	}
+	return m.NoopStorage.DeleteAgentPackage(ctx, packageID)
+}
+
+func (m *Mock) SubscribeToMemoryChanges(ctx context.Context, scope string, scopeID string) (<-chan types.MemoryChangeEvent, error) {
+	if m.SubscribeToMemoryChangesFunc != nil {
+		return m.SubscribeToMemoryChangesFunc(ctx, scope, scopeID)
+	}
+	return m.NoopStorage.SubscribeToMemoryChanges(ctx, scope, scopeID)
+}
+
+func (m *Mock) PublishMemoryChange(ctx context.Context, event types.MemoryChangeEvent) error {
+	if m.PublishMemoryChangeFunc != nil {
+		return m.PublishMemoryChangeFunc(ctx, event)
+	}
+	return m.NoopStorage.PublishMemoryChange(ctx, event)
+}
+
+func (m *Mock) GetExecutionEventBus() *events.ExecutionEventBus {
+	if m.GetExecutionEventBusFunc != nil {
+		return m.GetExecutionEventBusFunc()
+	}
+	return m.NoopStorage.GetExecutionEventBus()
+}
+
+func (m *Mock) GetWorkflowExecutionEventBus() *events.EventBus[*types.WorkflowExecutionEvent] {
+	if m.GetWorkflowExecutionEventBusFunc != nil {
+		return m.GetWorkflowExecutionEventBusFunc()
+	}
+	return m.NoopStorage.GetWorkflowExecutionEventBus()
+}
+
+func (m *Mock) StoreExecutionVC(ctx context.Context, vcID string, executionID string, workflowID string, sessionID string, issuerDID string, targetDID string, callerDID string, inputHash string, outputHash string, status string, vcDocument []byte, signature string, storageURI string, documentSizeBytes int64) error {
+	if m.StoreExecutionVCFunc != nil {
+		return m.StoreExecutionVCFunc(ctx, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status, vcDocument, signature, storageURI, documentSizeBytes)
+	}
+	return m.NoopStorage.StoreExecutionVC(ctx, vcID, executionID, workflowID, sessionID, issuerDID, targetDID, callerDID, inputHash, outputHash, status, vcDocument, signature, storageURI, documentSizeBytes)
+}
+
+func (m *Mock) GetExecutionVC(ctx context.Context, vcID string) (*types.ExecutionVCInfo, error) {
+	if m.GetExecutionVCFunc != nil {
+		return m.GetExecutionVCFunc(ctx, vcID)
+	}
+	return m.NoopStorage.GetExecutionVC(ctx, vcID)
+}
+
+func (m *Mock) ListExecutionVCs(ctx context.Context, filters types.VCFilters) ([]*types.ExecutionVCInfo, error) {
+	if m.ListExecutionVCsFunc != nil {
+		return m.ListExecutionVCsFunc(ctx, filters)
+	}
+	return m.NoopStorage.ListExecutionVCs(ctx, filters)
+}
+
+func (m *Mock) ListWorkflowVCStatusSummaries(ctx context.Context, workflowIDs []string) ([]*types.WorkflowVCStatusAggregation, error) {
+	if m.ListWorkflowVCStatusSummariesFunc != nil {
+		return m.ListWorkflowVCStatusSummariesFunc(ctx, workflowIDs)
+	}
+	return m.NoopStorage.ListWorkflowVCStatusSummaries(ctx, workflowIDs)
+}
+
+func (m *Mock) CountExecutionVCs(ctx context.Context, filters types.VCFilters) (int, error) {
+	if m.CountExecutionVCsFunc != nil {
+		return m.CountExecutionVCsFunc(ctx, filters)
+	}
+	return m.NoopStorage.CountExecutionVCs(ctx, filters)
+}
+
+func (m *Mock) StoreWorkflowVC(ctx context.Context, workflowVCID string, workflowID string, sessionID string, componentVCIDs []string, status string, startTime *time.Time, endTime *time.Time, totalSteps int, completedSteps int, storageURI string, documentSizeBytes int64) error {
+	if m.StoreWorkflowVCFunc != nil {
+		return m.StoreWorkflowVCFunc(ctx, workflowVCID, workflowID, sessionID, componentVCIDs, status, startTime, endTime, totalSteps, completedSteps, storageURI, documentSizeBytes)
+	}
+	return m.NoopStorage.StoreWorkflowVC(ctx, workflowVCID, workflowID, sessionID, componentVCIDs, status, startTime, endTime, totalSteps, completedSteps, storageURI, documentSizeBytes)
+}
+
+func (m *Mock) GetWorkflowVC(ctx context.Context, workflowVCID string) (*types.WorkflowVCInfo, error) {
+	if m.GetWorkflowVCFunc != nil {
+		return m.GetWorkflowVCFunc(ctx, workflowVCID)
+	}
+	return m.NoopStorage.GetWorkflowVC(ctx, workflowVCID)
+}
+
+func (m *Mock) ListWorkflowVCs(ctx context.Context, workflowID string) ([]*types.WorkflowVCInfo, error) {
+	if m.ListWorkflowVCsFunc != nil {
+		return m.ListWorkflowVCsFunc(ctx, workflowID)
+	}
+	return m.NoopStorage.ListWorkflowVCs(ctx, workflowID)
+}
+
+func (m *Mock) GetObservabilityWebhook(ctx context.Context) (*types.ObservabilityWebhookConfig, error) {
+	if m.GetObservabilityWebhookFunc != nil {
+		return m.GetObservabilityWebhookFunc(ctx)
+	}
+	return m.NoopStorage.GetObservabilityWebhook(ctx)
+}
+
+func (m *Mock) SetObservabilityWebhook(ctx context.Context, config *types.ObservabilityWebhookConfig) error {
+	if m.SetObservabilityWebhookFunc != nil {
+		return m.SetObservabilityWebhookFunc(ctx, config)
+	}
+	return m.NoopStorage.SetObservabilityWebhook(ctx, config)
+}
+
+func (m *Mock) DeleteObservabilityWebhook(ctx context.Context) error {
+	if m.DeleteObservabilityWebhookFunc != nil {
+		return m.DeleteObservabilityWebhookFunc(ctx)
+	}
+	return m.NoopStorage.DeleteObservabilityWebhook(ctx)
+}
+
+func (m *Mock) AddToDeadLetterQueue(ctx context.Context, event *types.ObservabilityEvent, errorMessage string, retryCount int) error {
+	if m.AddToDeadLetterQueueFunc != nil {
+		return m.AddToDeadLetterQueueFunc(ctx, event, errorMessage, retryCount)
+	}
+	return m.NoopStorage.AddToDeadLetterQueue(ctx, event, errorMessage, retryCount)
+}
+
+func (m *Mock) GetDeadLetterQueueCount(ctx context.Context) (int64, error) {
+	if m.GetDeadLetterQueueCountFunc != nil {
+		return m.GetDeadLetterQueueCountFunc(ctx)
+	}
+	return m.NoopStorage.GetDeadLetterQueueCount(ctx)
+}
+
+func (m *Mock) GetDeadLetterQueue(ctx context.Context, limit int, offset int) ([]types.ObservabilityDeadLetterEntry, error) {
+	if m.GetDeadLetterQueueFunc != nil {
+		return m.GetDeadLetterQueueFunc(ctx, limit, offset)
+	}
+	return m.NoopStorage.GetDeadLetterQueue(ctx, limit, offset)
+}
+
+func (m *Mock) DeleteFromDeadLetterQueue(ctx context.Context, ids []int64) error {
+	if m.DeleteFromDeadLetterQueueFunc != nil {
+		return m.DeleteFromDeadLetterQueueFunc(ctx, ids)
+	}
+	return m.NoopStorage.DeleteFromDeadLetterQueue(ctx, ids)
+}
+
+func (m *Mock) ClearDeadLetterQueue(ctx context.Context) error {
+	if m.ClearDeadLetterQueueFunc != nil {
+		return m.ClearDeadLetterQueueFunc(ctx)
+	}
+	return m.NoopStorage.ClearDeadLetterQueue(ctx)
+}
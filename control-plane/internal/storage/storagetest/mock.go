@@ -0,0 +1,494 @@
+// Package storagetest provides an in-memory storage.StorageProvider for
+// handler and service tests that should not depend on CGO or SQLite.
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+)
+
+// Mock is an in-memory StorageProvider backed by maps guarded by a mutex. It
+// implements execution records, agent nodes and the DID, component DID and
+// status history operations with the same validation, ordering and error
+// types as LocalStorage. Every other operation falls through to the embedded
+// NoopStorage and fails with storage.ErrStorageUnavailable, so a test that
+// relies on an operation the mock does not model fails loudly instead of
+// passing against a silent stub. Hooks override any operation.
+//
+// Values are copied on the way in and out, so callers cannot change stored
+// state by mutating a value they passed or received.
+type Mock struct {
+	*storage.NoopStorage
+	Hooks
+
+	mu         sync.Mutex
+	seq        int64
+	executions map[string]*types.Execution
+	agents     map[string]*types.AgentNode
+	servers    map[string]*types.AgentFieldServerDIDInfo
+	agentDIDs  map[string]*agentDIDRecord
+	components map[string]*componentDIDRecord
+	registry   map[string]*types.DIDRegistryEntry
+	rotated    map[string]*types.RotatedDIDInfo
+	history    []*types.AgentDIDStatusChange
+}
+
+var _ storage.StorageProvider = (*Mock)(nil)
+
+// NewMock creates an empty Mock.
+func NewMock() *Mock {
+	return &Mock{
+		NoopStorage: storage.NewNoopStorage(),
+		executions:  make(map[string]*types.Execution),
+		agents:      make(map[string]*types.AgentNode),
+		servers:     make(map[string]*types.AgentFieldServerDIDInfo),
+		agentDIDs:   make(map[string]*agentDIDRecord),
+		components:  make(map[string]*componentDIDRecord),
+		registry:    make(map[string]*types.DIDRegistryEntry),
+		rotated:     make(map[string]*types.RotatedDIDInfo),
+	}
+}
+
+func (m *Mock) Initialize(ctx context.Context, config storage.StorageConfig) error {
+	if m.InitializeFunc != nil {
+		return m.InitializeFunc(ctx, config)
+	}
+	return nil
+}
+
+func (m *Mock) Close(ctx context.Context) error {
+	if m.CloseFunc != nil {
+		return m.CloseFunc(ctx)
+	}
+	return nil
+}
+
+func (m *Mock) HealthCheck(ctx context.Context) error {
+	if m.HealthCheckFunc != nil {
+		return m.HealthCheckFunc(ctx)
+	}
+	return ctx.Err()
+}
+
+// next returns a strictly increasing sequence number. Records created within
+// the same clock tick keep their insertion order when sorted by time.
+func (m *Mock) next() int64 {
+	m.seq++
+	return m.seq
+}
+
+// CreateExecutionRecord stores exec, merging it into an existing record with
+// the same execution ID as UpsertExecution does.
+func (m *Mock) CreateExecutionRecord(ctx context.Context, exec *types.Execution) error {
+	if m.CreateExecutionRecordFunc != nil {
+		return m.CreateExecutionRecordFunc(ctx, exec)
+	}
+	return m.UpsertExecution(ctx, exec)
+}
+
+// UpsertExecution inserts exec or, when the execution ID already exists,
+// updates its status and, when set, completion time, duration, result and
+// error. Status changes are validated as LocalStorage does. The tenant of an
+// existing record never changes.
+func (m *Mock) UpsertExecution(ctx context.Context, exec *types.Execution) error {
+	if m.UpsertExecutionFunc != nil {
+		return m.UpsertExecutionFunc(ctx, exec)
+	}
+	if exec == nil {
+		return fmt.Errorf("nil execution payload")
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("upsert execution: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if exec.StartedAt.IsZero() {
+		exec.StartedAt = now
+	}
+	exec.CreatedAt = now
+	exec.UpdatedAt = now
+	exec.TenantID = types.NormalizeTenantID(exec.TenantID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.executions[exec.ExecutionID]
+	if !ok {
+		m.executions[exec.ExecutionID] = cloneExecution(exec)
+		return nil
+	}
+	if err := storage.ValidateExecutionRecordTransition(exec.ExecutionID, existing.Status, exec.Status); err != nil {
+		return err
+	}
+	existing.Status = exec.Status
+	if exec.CompletedAt != nil {
+		existing.CompletedAt = cloneTime(exec.CompletedAt)
+	}
+	if exec.DurationMS != nil {
+		existing.DurationMS = cloneInt64(exec.DurationMS)
+	}
+	if exec.ResultPayload != nil {
+		existing.ResultPayload = cloneRaw(exec.ResultPayload)
+	}
+	if exec.ErrorMessage != nil {
+		existing.ErrorMessage = cloneString(exec.ErrorMessage)
+	}
+	existing.UpdatedAt = now
+	return nil
+}
+
+// GetExecutionRecord returns the execution, or (nil, nil) if it does not exist.
+func (m *Mock) GetExecutionRecord(ctx context.Context, executionID string) (*types.Execution, error) {
+	if m.GetExecutionRecordFunc != nil {
+		return m.GetExecutionRecordFunc(ctx, executionID)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exec, ok := m.executions[executionID]
+	if !ok {
+		return nil, nil
+	}
+	return cloneExecution(exec), nil
+}
+
+// UpdateExecutionRecord passes a copy of the execution, or nil if it does not
+// exist, to updater and stores what it returns. A nil result leaves the
+// record unchanged.
+func (m *Mock) UpdateExecutionRecord(ctx context.Context, executionID string, updater func(*types.Execution) (*types.Execution, error)) (*types.Execution, error) {
+	if m.UpdateExecutionRecordFunc != nil {
+		return m.UpdateExecutionRecordFunc(ctx, executionID, updater)
+	}
+	if updater == nil {
+		return nil, fmt.Errorf("nil updater")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current *types.Execution
+	if exec, ok := m.executions[executionID]; ok {
+		current = cloneExecution(exec)
+	}
+	updated, err := updater(current)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return current, nil
+	}
+	if current == nil {
+		// The SQL update matches no row, so nothing is stored.
+		return updated, nil
+	}
+
+	updated.UpdatedAt = time.Now().UTC()
+	stored := cloneExecution(updated)
+	stored.ExecutionID = executionID
+	stored.TenantID = current.TenantID
+	stored.CreatedAt = current.CreatedAt
+	m.executions[executionID] = stored
+	return updated, nil
+}
+
+// QueryExecutionRecords returns the executions matching every set field of
+// filter, ordered by filter.SortBy (started_at by default, ascending unless
+// SortDescending) and paged by Limit and Offset.
+func (m *Mock) QueryExecutionRecords(ctx context.Context, filter types.ExecutionFilter) ([]*types.Execution, error) {
+	if m.QueryExecutionRecordsFunc != nil {
+		return m.QueryExecutionRecordsFunc(ctx, filter)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("query executions: %w", err)
+	}
+
+	m.mu.Lock()
+	var executions []*types.Execution
+	for _, exec := range m.executions {
+		if matchesExecutionFilter(exec, filter) {
+			executions = append(executions, cloneExecution(exec))
+		}
+	}
+	m.mu.Unlock()
+
+	less := executionLess(filter.SortBy)
+	sort.SliceStable(executions, func(i, j int) bool {
+		if filter.SortDescending {
+			return less(executions[j], executions[i])
+		}
+		return less(executions[i], executions[j])
+	})
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(executions) {
+			return nil, nil
+		}
+		executions = executions[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(executions) {
+		executions = executions[:filter.Limit]
+	}
+	return executions, nil
+}
+
+func matchesExecutionFilter(exec *types.Execution, filter types.ExecutionFilter) bool {
+	if filter.TenantID != nil && exec.TenantID != types.NormalizeTenantID(*filter.TenantID) {
+		return false
+	}
+	if !matchesString(exec.ExecutionID, filter.ExecutionID) ||
+		!matchesString(exec.RunID, filter.RunID) ||
+		!matchesOptional(exec.ParentExecutionID, filter.ParentExecutionID) ||
+		!matchesString(exec.AgentNodeID, filter.AgentNodeID) ||
+		!matchesString(exec.ReasonerID, filter.ReasonerID) ||
+		!matchesString(exec.Status, filter.Status) ||
+		!matchesOptional(exec.SessionID, filter.SessionID) ||
+		!matchesOptional(exec.ActorID, filter.ActorID) {
+		return false
+	}
+	if filter.StartTime != nil && exec.StartedAt.Before(filter.StartTime.UTC()) {
+		return false
+	}
+	if filter.EndTime != nil && exec.StartedAt.After(filter.EndTime.UTC()) {
+		return false
+	}
+	if filter.UpdatedSince != nil && exec.UpdatedAt.Before(filter.UpdatedSince.UTC()) {
+		return false
+	}
+	return true
+}
+
+func matchesString(value string, want *string) bool {
+	return want == nil || value == *want
+}
+
+// matchesOptional mirrors SQL equality: a NULL column never matches.
+func matchesOptional(value, want *string) bool {
+	return want == nil || (value != nil && *value == *want)
+}
+
+// executionLess orders executions by the same columns QueryExecutionRecords
+// accepts in LocalStorage. Unset durations sort first, as NULLs do in SQLite.
+func executionLess(sortBy string) func(a, b *types.Execution) bool {
+	switch sortBy {
+	case "status":
+		return func(a, b *types.Execution) bool { return a.Status < b.Status }
+	case "duration_ms":
+		return func(a, b *types.Execution) bool {
+			if a.DurationMS == nil || b.DurationMS == nil {
+				return a.DurationMS == nil && b.DurationMS != nil
+			}
+			return *a.DurationMS < *b.DurationMS
+		}
+	case "agent_node_id":
+		return func(a, b *types.Execution) bool { return a.AgentNodeID < b.AgentNodeID }
+	case "reasoner_id":
+		return func(a, b *types.Execution) bool { return a.ReasonerID < b.ReasonerID }
+	case "execution_id":
+		return func(a, b *types.Execution) bool { return a.ExecutionID < b.ExecutionID }
+	case "run_id":
+		return func(a, b *types.Execution) bool { return a.RunID < b.RunID }
+	case "created_at":
+		return func(a, b *types.Execution) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "updated_at":
+		return func(a, b *types.Execution) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		return func(a, b *types.Execution) bool { return a.StartedAt.Before(b.StartedAt) }
+	}
+}
+
+// RegisterAgent stores agent, replacing any node with the same ID. The
+// original registration time is kept on re-registration.
+func (m *Mock) RegisterAgent(ctx context.Context, agent *types.AgentNode) error {
+	if m.RegisterAgentFunc != nil {
+		return m.RegisterAgentFunc(ctx, agent)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during register agent: %w", err)
+	}
+	if strings.TrimSpace(agent.DeploymentType) == "" {
+		agent.DeploymentType = "long_running"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *agent
+	if existing, ok := m.agents[agent.ID]; ok {
+		stored.RegisteredAt = existing.RegisteredAt
+	}
+	m.agents[agent.ID] = &stored
+	return nil
+}
+
+func (m *Mock) GetAgent(ctx context.Context, id string) (*types.AgentNode, error) {
+	if m.GetAgentFunc != nil {
+		return m.GetAgentFunc(ctx, id)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during get agent: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	agent, ok := m.agents[id]
+	if !ok {
+		return nil, fmt.Errorf("agent node with ID '%s' not found", id)
+	}
+	copied := *agent
+	return &copied, nil
+}
+
+// ListAgents returns the agents matching filters, most recently registered first.
+func (m *Mock) ListAgents(ctx context.Context, filters types.AgentFilters) ([]*types.AgentNode, error) {
+	if m.ListAgentsFunc != nil {
+		return m.ListAgentsFunc(ctx, filters)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled during list agents: %w", err)
+	}
+
+	m.mu.Lock()
+	agents := []*types.AgentNode{}
+	for _, agent := range m.agents {
+		if filters.HealthStatus != nil && agent.HealthStatus != *filters.HealthStatus {
+			continue
+		}
+		if filters.TeamID != nil && agent.TeamID != *filters.TeamID {
+			continue
+		}
+		copied := *agent
+		agents = append(agents, &copied)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(agents, func(i, j int) bool {
+		if !agents[i].RegisteredAt.Equal(agents[j].RegisteredAt) {
+			return agents[i].RegisteredAt.After(agents[j].RegisteredAt)
+		}
+		return agents[i].ID < agents[j].ID
+	})
+	return agents, nil
+}
+
+// UpdateAgentHealth sets the health status of an agent. Like the SQL update,
+// it does nothing when the agent does not exist.
+func (m *Mock) UpdateAgentHealth(ctx context.Context, id string, status types.HealthStatus) error {
+	if m.UpdateAgentHealthFunc != nil {
+		return m.UpdateAgentHealthFunc(ctx, id, status)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent health: %w", err)
+	}
+	m.updateAgent(id, func(agent *types.AgentNode) { agent.HealthStatus = status })
+	return nil
+}
+
+// UpdateAgentHealthAtomic sets the health status of an agent only if its last
+// heartbeat still equals expectedLastHeartbeat, when given.
+func (m *Mock) UpdateAgentHealthAtomic(ctx context.Context, id string, status types.HealthStatus, expectedLastHeartbeat *time.Time) error {
+	if m.UpdateAgentHealthAtomicFunc != nil {
+		return m.UpdateAgentHealthAtomicFunc(ctx, id, status, expectedLastHeartbeat)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent health atomic: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	agent, ok := m.agents[id]
+	if expectedLastHeartbeat != nil {
+		if !ok || !agent.LastHeartbeat.Equal(*expectedLastHeartbeat) {
+			return fmt.Errorf("no rows updated for agent ID '%s' - possible concurrent modification or node not found", id)
+		}
+	} else if !ok {
+		return fmt.Errorf("agent node with ID '%s' not found", id)
+	}
+	agent.HealthStatus = status
+	return nil
+}
+
+func (m *Mock) UpdateAgentHeartbeat(ctx context.Context, id string, heartbeatTime time.Time) error {
+	if m.UpdateAgentHeartbeatFunc != nil {
+		return m.UpdateAgentHeartbeatFunc(ctx, id, heartbeatTime)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent heartbeat: %w", err)
+	}
+	m.updateAgent(id, func(agent *types.AgentNode) { agent.LastHeartbeat = heartbeatTime.UTC() })
+	return nil
+}
+
+func (m *Mock) UpdateAgentLifecycleStatus(ctx context.Context, id string, status types.AgentLifecycleStatus) error {
+	if m.UpdateAgentLifecycleStatusFunc != nil {
+		return m.UpdateAgentLifecycleStatusFunc(ctx, id, status)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context cancelled during update agent lifecycle status: %w", err)
+	}
+	m.updateAgent(id, func(agent *types.AgentNode) { agent.LifecycleStatus = status })
+	return nil
+}
+
+func (m *Mock) updateAgent(id string, update func(*types.AgentNode)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if agent, ok := m.agents[id]; ok {
+		update(agent)
+	}
+}
+
+func cloneExecution(exec *types.Execution) *types.Execution {
+	copied := *exec
+	copied.ParentExecutionID = cloneString(exec.ParentExecutionID)
+	copied.InputPayload = cloneRaw(exec.InputPayload)
+	copied.ResultPayload = cloneRaw(exec.ResultPayload)
+	copied.ErrorMessage = cloneString(exec.ErrorMessage)
+	copied.InputURI = cloneString(exec.InputURI)
+	copied.ResultURI = cloneString(exec.ResultURI)
+	copied.EnqueuedAt = cloneTime(exec.EnqueuedAt)
+	copied.CompletedAt = cloneTime(exec.CompletedAt)
+	copied.DurationMS = cloneInt64(exec.DurationMS)
+	copied.SessionID = cloneString(exec.SessionID)
+	copied.ActorID = cloneString(exec.ActorID)
+	if exec.Notes != nil {
+		copied.Notes = append([]types.ExecutionNote(nil), exec.Notes...)
+	}
+	return &copied
+}
+
+func cloneRaw(raw json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return nil
+	}
+	return append(json.RawMessage(nil), raw...)
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func cloneInt64(n *int64) *int64 {
+	if n == nil {
+		return nil
+	}
+	v := *n
+	return &v
+}
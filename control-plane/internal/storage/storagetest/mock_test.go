@@ -0,0 +1,244 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Agent-Field/agentfield/control-plane/internal/storage"
+	"github.com/Agent-Field/agentfield/control-plane/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newServerWithAgent(t *testing.T) (*Mock, context.Context) {
+	t.Helper()
+	ctx := context.Background()
+	m := NewMock()
+	now := time.Now().UTC()
+	require.NoError(t, m.StoreAgentFieldServerDID(ctx, "af-1", "did:af:root", []byte("seed"), now, now))
+	require.NoError(t, m.StoreAgentDIDWithComponents(ctx, "agent-1", "did:agent:1", "af-1", "", `{"kty":"OKP"}`, 0, []storage.ComponentDIDRequest{
+		{ComponentDID: "did:skill:b", ComponentType: "skill", ComponentName: "search", PublicKeyJWK: `{"k":"s"}`, DerivationIndex: 3},
+		{ComponentDID: "did:reasoner:b", ComponentType: "reasoner", ComponentName: "summarize", PublicKeyJWK: `{"k":"r2"}`, DerivationIndex: 2},
+		{ComponentDID: "did:reasoner:a", ComponentType: "reasoner", ComponentName: "plan", PublicKeyJWK: `{"k":"r1"}`, DerivationIndex: 1},
+	}))
+	return m, ctx
+}
+
+func TestMock_ComponentStorageAndListing(t *testing.T) {
+	m, ctx := newServerWithAgent(t)
+
+	components, err := m.ListAgentComponentDIDs(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, components, 3)
+	var order []string
+	for _, component := range components {
+		order = append(order, component.ComponentType+"/"+component.FunctionName)
+	}
+	require.Equal(t, []string{"reasoner/plan", "reasoner/summarize", "skill/search"}, order)
+	require.JSONEq(t, `{"k":"r1"}`, string(components[0].PublicKeyJWK))
+	require.Equal(t, "m/44'/0'/0'/1", components[0].DerivationPath)
+	require.Equal(t, 1, components[0].DerivationIndex)
+
+	// Newest first; components stored together keep insertion order reversed.
+	infos, err := m.ListComponentDIDs(ctx, "did:agent:1")
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	require.Equal(t, "plan", infos[0].ComponentID)
+	require.Equal(t, "search", infos[2].ComponentName)
+
+	require.NoError(t, m.StoreComponentDID(ctx, "fetch", "did:skill:c", "did:agent:1", "skill", "fetch", 4))
+	info, err := m.GetComponentDID(ctx, "fetch")
+	require.NoError(t, err)
+	require.Equal(t, "did:skill:c", info.ComponentDID)
+	require.Equal(t, 4, info.DerivationIndex)
+
+	all, err := m.ListComponentDIDs(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	require.Equal(t, "fetch", all[0].ComponentName)
+
+	counts, err := m.CountComponentDIDsByType(ctx, "af-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"reasoner": 2, "skill": 2}, counts)
+
+	empty, err := m.ListAgentComponentDIDs(ctx, "agent-unknown")
+	require.NoError(t, err)
+	require.NotNil(t, empty)
+	require.Empty(t, empty)
+
+	_, err = m.GetComponentDID(ctx, "missing")
+	require.EqualError(t, err, "component DID for missing not found")
+
+	// Lists are copies.
+	components[0].PublicKeyJWK[2] = 'X'
+	components, err = m.ListAgentComponentDIDs(ctx, "agent-1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"k":"r1"}`, string(components[0].PublicKeyJWK))
+}
+
+func TestMock_ComponentValidation(t *testing.T) {
+	m, ctx := newServerWithAgent(t)
+
+	var validationErr *storage.ValidationError
+	require.ErrorAs(t, m.StoreComponentDID(ctx, "x", "did:x", "did:agent:1", "tool", "x", 0), &validationErr)
+	require.Equal(t, "component_type", validationErr.Field)
+	require.ErrorAs(t, m.StoreComponentDID(ctx, "x", "did:x", "did:agent:1", "skill", "", 0), &validationErr)
+	require.Equal(t, "component_name", validationErr.Field)
+
+	var fkErr *storage.ForeignKeyConstraintError
+	require.ErrorAs(t, m.StoreComponentDID(ctx, "x", "did:x", "did:agent:missing", "skill", "x", 0), &fkErr)
+	require.Equal(t, "agent_dids", fkErr.ReferencedTable)
+
+	var dupErr *storage.DuplicateDIDError
+	require.ErrorAs(t, m.StoreComponentDID(ctx, "plan", "did:reasoner:a", "did:agent:1", "reasoner", "plan", 1), &dupErr)
+	require.Equal(t, "component", dupErr.Type)
+
+	// A duplicate component rejects the whole batch.
+	err := m.StoreAgentDIDWithComponents(ctx, "agent-2", "did:agent:2", "af-1", "", "{}", 1, []storage.ComponentDIDRequest{
+		{ComponentDID: "did:reasoner:new", ComponentType: "reasoner", ComponentName: "new", PublicKeyJWK: "{}"},
+		{ComponentDID: "did:reasoner:a", ComponentType: "reasoner", ComponentName: "plan", PublicKeyJWK: "{}"},
+	})
+	require.ErrorAs(t, err, &dupErr)
+	_, err = m.GetAgentDID(ctx, "agent-2")
+	require.Error(t, err)
+	_, err = m.GetComponentDID(ctx, "new")
+	require.Error(t, err)
+
+	err = m.StoreAgentDIDWithComponents(ctx, "agent-3", "did:agent:3", "af-missing", "", "{}", 1, nil)
+	require.ErrorAs(t, err, &fkErr)
+	require.Equal(t, "did_registry", fkErr.ReferencedTable)
+}
+
+func TestMock_AgentDIDs(t *testing.T) {
+	m, ctx := newServerWithAgent(t)
+
+	var dupErr *storage.DuplicateDIDError
+	require.ErrorAs(t, m.StoreAgentDID(ctx, "agent-1", "did:agent:1", "af-1", "", "{}", 0), &dupErr)
+	require.Equal(t, "agent:agent-1@af-1", dupErr.DID)
+	require.NoError(t, m.StoreAgentDID(ctx, "agent-2", "did:agent:2", "af-1", "", "{}", 1))
+
+	info, err := m.GetAgentDID(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Equal(t, types.AgentDIDStatusActive, info.Status)
+	require.Equal(t, types.DefaultTenantID, info.TenantID)
+	require.NotNil(t, info.Reasoners)
+	require.NotNil(t, info.Skills)
+
+	listed, err := m.ListAgentDIDsForTenant(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	require.Equal(t, "agent-2", listed[0].AgentNodeID)
+	other, err := m.ListAgentDIDsForTenant(ctx, "globex")
+	require.NoError(t, err)
+	require.Empty(t, other)
+
+	require.NoError(t, m.UpdateAgentDIDStatus(ctx, "af-1", "agent-1", types.AgentDIDStatusInactive, "paused"))
+	require.Error(t, m.UpdateAgentDIDStatuses(ctx, "af-1", []string{"agent-2", "missing"}, types.AgentDIDStatusRevoked, ""))
+	info, err = m.GetAgentDID(ctx, "agent-2")
+	require.NoError(t, err)
+	require.Equal(t, types.AgentDIDStatusActive, info.Status)
+
+	history, err := m.GetAgentStatusHistory(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, types.AgentDIDStatusActive, history[0].PreviousStatus)
+	require.Equal(t, types.AgentDIDStatusInactive, history[0].NewStatus)
+	require.Equal(t, "paused", history[0].Reason)
+}
+
+func TestMock_ExecutionRecords(t *testing.T) {
+	ctx := context.Background()
+	m := NewMock()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"exec-b", "exec-a", "exec-c"} {
+		require.NoError(t, m.CreateExecutionRecord(ctx, &types.Execution{
+			ExecutionID: id, RunID: "run-1", Status: "running", StartedAt: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	result := []byte(`"done"`)
+	require.NoError(t, m.UpsertExecution(ctx, &types.Execution{ExecutionID: "exec-a", RunID: "run-1", Status: "succeeded", ResultPayload: result, TenantID: "globex"}))
+
+	exec, err := m.GetExecutionRecord(ctx, "exec-a")
+	require.NoError(t, err)
+	require.Equal(t, "succeeded", exec.Status)
+	require.Equal(t, types.DefaultTenantID, exec.TenantID)
+	require.Equal(t, base.Add(time.Second), exec.StartedAt)
+	require.JSONEq(t, `"done"`, string(exec.ResultPayload))
+
+	// Finished executions cannot be reported as running again.
+	err = m.UpsertExecution(ctx, &types.Execution{ExecutionID: "exec-a", RunID: "run-1", Status: "running"})
+	var stateErr *storage.InvalidExecutionStateTransitionError
+	require.ErrorAs(t, err, &stateErr)
+
+	missing, err := m.GetExecutionRecord(ctx, "exec-missing")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+
+	executions, err := m.QueryExecutionRecords(ctx, types.ExecutionFilter{RunID: &exec.RunID, SortDescending: true, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	require.Equal(t, "exec-c", executions[0].ExecutionID)
+	require.Equal(t, "exec-a", executions[1].ExecutionID)
+
+	status := "running"
+	executions, err = m.QueryExecutionRecords(ctx, types.ExecutionFilter{Status: &status, SortBy: "execution_id"})
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	require.Equal(t, "exec-b", executions[0].ExecutionID)
+
+	updated, err := m.UpdateExecutionRecord(ctx, "exec-b", func(current *types.Execution) (*types.Execution, error) {
+		current.Status = "failed"
+		return current, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "failed", updated.Status)
+	exec, err = m.GetExecutionRecord(ctx, "exec-b")
+	require.NoError(t, err)
+	require.Equal(t, "failed", exec.Status)
+
+	boom := errors.New("boom")
+	_, err = m.UpdateExecutionRecord(ctx, "exec-c", func(*types.Execution) (*types.Execution, error) { return nil, boom })
+	require.ErrorIs(t, err, boom)
+}
+
+func TestMock_UnmodeledOperationsAreUnavailable(t *testing.T) {
+	_, err := NewMock().GetWorkflowVC(context.Background(), "vc-1")
+	require.ErrorIs(t, err, storage.ErrStorageUnavailable)
+}
+
+func TestMock_HooksOverrideEveryOperation(t *testing.T) {
+	provider := reflect.TypeOf((*storage.StorageProvider)(nil)).Elem()
+	for i := 0; i < provider.NumMethod(); i++ {
+		method := provider.Method(i)
+		t.Run(method.Name, func(t *testing.T) {
+			m := NewMock()
+			hook := reflect.ValueOf(&m.Hooks).Elem().FieldByName(method.Name + "Func")
+			require.True(t, hook.IsValid(), "Hooks has no %sFunc", method.Name)
+			require.Equal(t, method.Type, hook.Type())
+
+			called := false
+			hook.Set(reflect.MakeFunc(method.Type, func([]reflect.Value) []reflect.Value {
+				called = true
+				results := make([]reflect.Value, method.Type.NumOut())
+				for j := range results {
+					results[j] = reflect.Zero(method.Type.Out(j))
+				}
+				return results
+			}))
+
+			numArgs := method.Type.NumIn()
+			if method.Type.IsVariadic() {
+				numArgs--
+			}
+			args := make([]reflect.Value, numArgs)
+			for j := range args {
+				args[j] = reflect.Zero(method.Type.In(j))
+			}
+			reflect.ValueOf(m).MethodByName(method.Name).Call(args)
+			require.True(t, called, "%s did not call its hook", method.Name)
+		})
+	}
+}
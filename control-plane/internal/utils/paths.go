@@ -1,13 +1,22 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // DataDirectories holds all the standardized paths for AgentField data storage
 type DataDirectories struct {
+	// Environment is the AGENTFIELD_ENV namespace, or "" for the flat layout.
+	Environment string
+	// EnvironmentDir is the root of the data, logs, config and other
+	// per-environment directories. It equals AgentFieldHome when no
+	// environment is set.
+	EnvironmentDir string
+
 	AgentFieldHome   string
 	DataDir          string
 	DatabaseDir      string
@@ -23,9 +32,22 @@ type DataDirectories struct {
 	PayloadsDir      string
 }
 
+// environmentsDirName is the subdirectory of the AgentField home that holds
+// one directory per named environment.
+const environmentsDirName = "envs"
+
 // GetAgentFieldDataDirectories returns the standardized data directories for AgentField
 // It respects environment variables and provides sensible defaults
 func GetAgentFieldDataDirectories() (*DataDirectories, error) {
+	return GetAgentFieldDataDirectoriesForEnv(os.Getenv("AGENTFIELD_ENV"))
+}
+
+// GetAgentFieldDataDirectoriesForEnv returns the data directories for the
+// named environment (e.g. "dev", "staging"). Each environment gets its own
+// tree under <home>/envs/<env>, so environments on one machine share no
+// database, keys, logs or config; installed packages stay in the home
+// directory. An empty env returns the flat layout.
+func GetAgentFieldDataDirectoriesForEnv(env string) (*DataDirectories, error) {
 	// Determine AgentField home directory
 	agentfieldHome := os.Getenv("AGENTFIELD_HOME")
 	if agentfieldHome == "" {
@@ -36,36 +58,87 @@ func GetAgentFieldDataDirectories() (*DataDirectories, error) {
 		agentfieldHome = filepath.Join(homeDir, ".agentfield")
 	}
 
+	root := agentfieldHome
+	env = strings.TrimSpace(env)
+	if env != "" {
+		if err := validateEnvironmentName(env); err != nil {
+			return nil, err
+		}
+		root = filepath.Join(agentfieldHome, environmentsDirName, env)
+	}
+
 	// Create the data directories structure
 	dirs := &DataDirectories{
+		Environment:      env,
+		EnvironmentDir:   root,
 		AgentFieldHome:   agentfieldHome,
-		DataDir:          filepath.Join(agentfieldHome, "data"),
-		DatabaseDir:      filepath.Join(agentfieldHome, "data"),
-		KeysDir:          filepath.Join(agentfieldHome, "data", "keys"),
-		DIDRegistriesDir: filepath.Join(agentfieldHome, "data", "did_registries"),
-		VCsDir:           filepath.Join(agentfieldHome, "data", "vcs"),
-		VCsExecutionsDir: filepath.Join(agentfieldHome, "data", "vcs", "executions"),
-		VCsWorkflowsDir:  filepath.Join(agentfieldHome, "data", "vcs", "workflows"),
-		AgentsDir:        filepath.Join(agentfieldHome, "agents"),
-		LogsDir:          filepath.Join(agentfieldHome, "logs"),
-		ConfigDir:        filepath.Join(agentfieldHome, "config"),
-		TempDir:          filepath.Join(agentfieldHome, "temp"),
-		PayloadsDir:      filepath.Join(agentfieldHome, "data", "payloads"),
+		DataDir:          filepath.Join(root, "data"),
+		DatabaseDir:      filepath.Join(root, "data"),
+		KeysDir:          filepath.Join(root, "data", "keys"),
+		DIDRegistriesDir: filepath.Join(root, "data", "did_registries"),
+		VCsDir:           filepath.Join(root, "data", "vcs"),
+		VCsExecutionsDir: filepath.Join(root, "data", "vcs", "executions"),
+		VCsWorkflowsDir:  filepath.Join(root, "data", "vcs", "workflows"),
+		AgentsDir:        filepath.Join(root, "agents"),
+		LogsDir:          filepath.Join(root, "logs"),
+		ConfigDir:        filepath.Join(root, "config"),
+		TempDir:          filepath.Join(root, "temp"),
+		PayloadsDir:      filepath.Join(root, "data", "payloads"),
 	}
 
 	return dirs, nil
 }
 
+// validateEnvironmentName rejects environment names that are not a single
+// plain path segment, so one environment cannot resolve into another's tree.
+func validateEnvironmentName(env string) error {
+	if len(env) > 64 {
+		return fmt.Errorf("environment name %q is longer than 64 characters", env)
+	}
+	for i, r := range env {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case (r == '-' || r == '_') && i > 0:
+		default:
+			return fmt.Errorf("environment name %q must contain only letters, digits, '-' and '_', and start with a letter or digit", env)
+		}
+	}
+	return nil
+}
+
 // EnsureDataDirectories creates all necessary AgentField data directories
 func EnsureDataDirectories() (*DataDirectories, error) {
+	dirs, _, err := EnsureDataDirectoriesReport()
+	return dirs, err
+}
+
+// EnsureDataDirectoriesReport creates all necessary AgentField data directories
+// and reports, keyed by directory path, whether each one was created by this
+// call (true) or already existed (false).
+func EnsureDataDirectoriesReport() (*DataDirectories, map[string]bool, error) {
 	dirs, err := GetAgentFieldDataDirectories()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ensureDirectories(dirs)
+}
+
+// EnsureDataDirectoriesForEnv creates the data directories for the named
+// environment; see GetAgentFieldDataDirectoriesForEnv.
+func EnsureDataDirectoriesForEnv(env string) (*DataDirectories, error) {
+	dirs, err := GetAgentFieldDataDirectoriesForEnv(env)
 	if err != nil {
 		return nil, err
 	}
+	dirs, _, err = ensureDirectories(dirs)
+	return dirs, err
+}
 
+func ensureDirectories(dirs *DataDirectories) (*DataDirectories, map[string]bool, error) {
 	// Create all directories with appropriate permissions
 	directoriesToCreate := []string{
 		dirs.AgentFieldHome,
+		dirs.EnvironmentDir,
 		dirs.DataDir,
 		dirs.DatabaseDir,
 		dirs.KeysDir,
@@ -80,9 +153,20 @@ func EnsureDataDirectories() (*DataDirectories, error) {
 		dirs.PayloadsDir,
 	}
 
+	created := make(map[string]bool, len(directoriesToCreate))
 	for _, dir := range directoriesToCreate {
+		// MkdirAll does not report whether it created anything, so stat first
+		_, statErr := os.Stat(dir)
+		if statErr != nil && !os.IsNotExist(statErr) {
+			return nil, nil, statErr
+		}
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		// Some entries share a path (DataDir and DatabaseDir, and
+		// EnvironmentDir without an environment); keep the first observation
+		if _, seen := created[dir]; !seen {
+			created[dir] = os.IsNotExist(statErr)
 		}
 	}
 
@@ -94,11 +178,95 @@ func EnsureDataDirectories() (*DataDirectories, error) {
 
 	for _, dir := range sensitiveDirectories {
 		if err := os.Chmod(dir, 0700); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	return dirs, nil
+	return dirs, created, nil
+}
+
+// PermissionIssue describes a file or directory under a sensitive data
+// directory whose permissions are looser than Want.
+type PermissionIssue struct {
+	Path  string
+	IsDir bool
+	Mode  os.FileMode
+	Want  os.FileMode
+}
+
+// Maximum permissions for entries under KeysDir and DIDRegistriesDir.
+const (
+	sensitiveDirPerm  os.FileMode = 0700
+	sensitiveFilePerm os.FileMode = 0600
+)
+
+// AuditPermissions reports directories and files under KeysDir and
+// DIDRegistriesDir that are more permissive than 0700 and 0600 respectively.
+// Missing directories are not an issue. Symlinks are skipped, and nothing is
+// reported on Windows, where Unix permission bits do not apply.
+func AuditPermissions() ([]PermissionIssue, error) {
+	dirs, err := GetAgentFieldDataDirectories()
+	if err != nil {
+		return nil, err
+	}
+	return auditPermissions(dirs)
+}
+
+// FixPermissions tightens every entry reported by AuditPermissions by
+// clearing the bits outside its allowed mode, and returns the issues it fixed.
+func FixPermissions() ([]PermissionIssue, error) {
+	dirs, err := GetAgentFieldDataDirectories()
+	if err != nil {
+		return nil, err
+	}
+	issues, err := auditPermissions(dirs)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if err := os.Chmod(issue.Path, issue.Mode&issue.Want); err != nil {
+			return nil, fmt.Errorf("failed to tighten permissions on %s: %w", issue.Path, err)
+		}
+	}
+	return issues, nil
+}
+
+func auditPermissions(dirs *DataDirectories) ([]PermissionIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+
+	var issues []PermissionIssue
+	for _, root := range []string{dirs.KeysDir, dirs.DIDRegistriesDir} {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.Type()&os.ModeSymlink != 0 {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			want := sensitiveFilePerm
+			if d.IsDir() {
+				want = sensitiveDirPerm
+			}
+			if mode := info.Mode().Perm(); mode&^want != 0 {
+				issues = append(issues, PermissionIssue{Path: path, IsDir: d.IsDir(), Mode: mode, Want: want})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to audit permissions under %s: %w", root, err)
+		}
+	}
+	return issues, nil
 }
 
 // GetDatabasePath returns the path to the main AgentField database
@@ -134,7 +302,7 @@ func GetConfigPath(filename string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dirs.ConfigDir, filename), nil
+	return joinWithinDir(dirs.ConfigDir, filename)
 }
 
 // GetLogPath returns the path to a log file
@@ -143,7 +311,7 @@ func GetLogPath(filename string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dirs.LogsDir, filename), nil
+	return joinWithinDir(dirs.LogsDir, filename)
 }
 
 // GetTempPath returns the path to a temporary file
@@ -152,7 +320,32 @@ func GetTempPath(filename string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dirs.TempDir, filename), nil
+	return joinWithinDir(dirs.TempDir, filename)
+}
+
+// joinWithinDir joins filename onto dir and rejects results that escape dir.
+// Filenames may originate from package manifests, so traversal sequences such
+// as "../../etc/passwd" or absolute paths must not resolve outside the parent.
+func joinWithinDir(dir, filename string) (string, error) {
+	if filename == "" {
+		return "", fmt.Errorf("filename must not be empty")
+	}
+	if filepath.IsAbs(filename) {
+		return "", fmt.Errorf("filename %q must be relative to %s", filename, dir)
+	}
+
+	base := filepath.Clean(dir)
+	joined := filepath.Join(base, filename)
+
+	rel, err := filepath.Rel(base, joined)
+	if err != nil {
+		return "", fmt.Errorf("resolve %q within %s: %w", filename, dir, err)
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("filename %q escapes directory %s", filename, dir)
+	}
+
+	return joined, nil
 }
 
 // GetPlatformSpecificPaths returns platform-specific paths if needed
@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPathHelpersRejectTraversal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("AGENTFIELD_HOME", home)
+
+	helpers := map[string]func(string) (string, error){
+		"config": GetConfigPath,
+		"logs":   GetLogPath,
+		"temp":   GetTempPath,
+	}
+
+	malicious := []string{
+		"../../etc/passwd",
+		"..",
+		"../config.yaml",
+		"nested/../../escape.txt",
+		"/etc/passwd",
+		"",
+		".",
+	}
+
+	for name, helper := range helpers {
+		for _, filename := range malicious {
+			if path, err := helper(filename); err == nil {
+				t.Errorf("%s helper accepted %q and returned %q", name, filename, path)
+			}
+		}
+	}
+}
+
+func TestPathHelpersAllowNestedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("AGENTFIELD_HOME", home)
+
+	tests := []struct {
+		name     string
+		helper   func(string) (string, error)
+		filename string
+		expected string
+	}{
+		{"config", GetConfigPath, "agentfield.yaml", filepath.Join(home, "config", "agentfield.yaml")},
+		{"logs", GetLogPath, "agents/run.log", filepath.Join(home, "logs", "agents", "run.log")},
+		{"temp", GetTempPath, "a/../b.tmp", filepath.Join(home, "temp", "b.tmp")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.helper(tt.filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEnsureDataDirectoriesReport(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "agentfield")
+	t.Setenv("AGENTFIELD_HOME", home)
+
+	dirs, created, err := EnsureDataDirectoriesReport()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) == 0 {
+		t.Fatal("expected created report to list directories")
+	}
+	for dir, wasCreated := range created {
+		if !wasCreated {
+			t.Errorf("expected %s to be created on first run", dir)
+		}
+	}
+
+	// A second run finds everything in place.
+	_, created, err = EnsureDataDirectoriesReport()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for dir, wasCreated := range created {
+		if wasCreated {
+			t.Errorf("expected %s to already exist", dir)
+		}
+	}
+
+	// Only a removed directory is reported as created.
+	if err := os.Remove(dirs.TempDir); err != nil {
+		t.Fatalf("remove temp dir: %v", err)
+	}
+	_, created, err = EnsureDataDirectoriesReport()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !created[dirs.TempDir] {
+		t.Errorf("expected %s to be reported as created", dirs.TempDir)
+	}
+	if created[dirs.ConfigDir] {
+		t.Errorf("expected %s to be reported as existing", dirs.ConfigDir)
+	}
+}
+
+func TestDataDirectoriesEnvironmentNamespace(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("AGENTFIELD_HOME", home)
+
+	t.Setenv("AGENTFIELD_ENV", "")
+	flat, err := GetDatabasePath()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(home, "data", "agentfield.db"); flat != want {
+		t.Fatalf("expected flat layout %q, got %q", want, flat)
+	}
+
+	t.Setenv("AGENTFIELD_ENV", "staging")
+	dirs, err := EnsureDataDirectories()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	envRoot := filepath.Join(home, "envs", "staging")
+	if dirs.Environment != "staging" || dirs.EnvironmentDir != envRoot || dirs.AgentFieldHome != home {
+		t.Fatalf("unexpected environment dirs: %+v", dirs)
+	}
+	if _, err := os.Stat(dirs.KeysDir); err != nil {
+		t.Fatalf("expected keys dir to be created: %v", err)
+	}
+
+	dbPath, err := GetDatabasePath()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kvPath, err := GetKVStorePath()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logPath, err := GetLogPath("server.log")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{dbPath, kvPath, logPath} {
+		if rel, err := filepath.Rel(envRoot, path); err != nil || strings.HasPrefix(rel, "..") {
+			t.Errorf("expected %q to live under %q", path, envRoot)
+		}
+	}
+
+	prod, err := GetAgentFieldDataDirectoriesForEnv("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prod.DatabaseDir == dirs.DatabaseDir {
+		t.Fatalf("expected environments to use separate database dirs, both got %q", prod.DatabaseDir)
+	}
+}
+
+func TestDataDirectoriesRejectInvalidEnvironment(t *testing.T) {
+	t.Setenv("AGENTFIELD_HOME", t.TempDir())
+
+	for _, env := range []string{"..", "../prod", "a/b", "-dev", "dev env"} {
+		if dirs, err := GetAgentFieldDataDirectoriesForEnv(env); err == nil {
+			t.Errorf("expected %q to be rejected, got %+v", env, dirs)
+		}
+	}
+}
+
+func TestAuditAndFixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits do not apply on Windows")
+	}
+	home := filepath.Join(t.TempDir(), "agentfield")
+	t.Setenv("AGENTFIELD_HOME", home)
+
+	// Nothing exists yet, so there is nothing to report.
+	issues, err := AuditPermissions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues before directories exist, got %v", issues)
+	}
+
+	dirs, err := EnsureDataDirectories()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tightKey := filepath.Join(dirs.KeysDir, "tight.key")
+	looseKey := filepath.Join(dirs.KeysDir, "loose.key")
+	looseDir := filepath.Join(dirs.DIDRegistriesDir, "nested")
+	looseRegistry := filepath.Join(looseDir, "registry.json")
+	for path, mode := range map[string]os.FileMode{tightKey: 0400, looseKey: 0644} {
+		if err := os.WriteFile(path, []byte("secret"), 0600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("chmod %s: %v", path, err)
+		}
+	}
+	if err := os.Mkdir(looseDir, 0700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(looseRegistry, []byte("{}"), 0600); err != nil {
+		t.Fatalf("write registry: %v", err)
+	}
+	for path, mode := range map[string]os.FileMode{looseDir: 0755, looseRegistry: 0660} {
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("chmod %s: %v", path, err)
+		}
+	}
+
+	issues, err = AuditPermissions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reported := make(map[string]PermissionIssue, len(issues))
+	for _, issue := range issues {
+		reported[issue.Path] = issue
+	}
+	if len(reported) != 3 {
+		t.Fatalf("expected 3 issues, got %v", issues)
+	}
+	if issue := reported[looseKey]; issue.Mode != 0644 || issue.Want != 0600 || issue.IsDir {
+		t.Errorf("unexpected issue for loose key: %+v", issue)
+	}
+	if issue := reported[looseDir]; issue.Mode != 0755 || issue.Want != 0700 || !issue.IsDir {
+		t.Errorf("unexpected issue for loose dir: %+v", issue)
+	}
+	if _, ok := reported[tightKey]; ok {
+		t.Errorf("did not expect %s to be reported", tightKey)
+	}
+
+	fixed, err := FixPermissions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fixed) != 3 {
+		t.Fatalf("expected 3 fixed issues, got %v", fixed)
+	}
+	for path, want := range map[string]os.FileMode{looseKey: 0600, looseDir: 0700, looseRegistry: 0600, tightKey: 0400} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", path, err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("expected %s to be %o, got %o", path, want, info.Mode().Perm())
+		}
+	}
+
+	issues, err = AuditPermissions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues after fix, got %v", issues)
+	}
+}
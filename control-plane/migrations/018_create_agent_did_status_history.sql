@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS agent_did_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    agent_node_id TEXT NOT NULL,
+    agentfield_server_id TEXT NOT NULL,
+    previous_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for reading an agent's history in order
+CREATE INDEX IF NOT EXISTS idx_agent_did_status_history_agent ON agent_did_status_history(agent_node_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_agent_did_status_history_agent;
+DROP TABLE IF EXISTS agent_did_status_history;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Time an execution was placed on the async queue; NULL for synchronous executions
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS enqueued_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE executions DROP COLUMN IF EXISTS enqueued_at;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rotated_dids (
+    did TEXT PRIMARY KEY,
+    replaced_by TEXT NOT NULL,
+    agentfield_server_id TEXT NOT NULL,
+    component_type TEXT NOT NULL,
+    public_key_jwk TEXT NOT NULL,
+    derivation_path TEXT NOT NULL,
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Indexes for following a rotation chain and listing a server's retired DIDs
+CREATE INDEX IF NOT EXISTS idx_rotated_dids_replaced_by ON rotated_dids(replaced_by);
+CREATE INDEX IF NOT EXISTS idx_rotated_dids_agentfield_server ON rotated_dids(agentfield_server_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rotated_dids_agentfield_server;
+DROP INDEX IF EXISTS idx_rotated_dids_replaced_by;
+DROP TABLE IF EXISTS rotated_dids;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tenant that owns each execution and agent DID; existing rows belong to the
+-- implicit single-tenant "default"
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE agent_dids ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_executions_tenant_id ON executions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_agent_dids_tenant_id ON agent_dids(tenant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_agent_dids_tenant_id;
+DROP INDEX IF EXISTS idx_executions_tenant_id;
+ALTER TABLE agent_dids DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE executions DROP COLUMN IF EXISTS tenant_id;
+-- +goose StatementEnd
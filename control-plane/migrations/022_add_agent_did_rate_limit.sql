@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- JSON-encoded per-agent rate-limit policy; NULL when the agent has none
+ALTER TABLE agent_dids ADD COLUMN IF NOT EXISTS rate_limit TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE agent_dids DROP COLUMN IF EXISTS rate_limit;
+-- +goose StatementEnd
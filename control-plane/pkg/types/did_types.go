@@ -21,12 +21,21 @@ type AgentDIDInfo struct {
 	DID                string                     `json:"did" db:"did"`
 	AgentNodeID        string                     `json:"agent_node_id" db:"agent_node_id"`
 	AgentFieldServerID string                     `json:"agentfield_server_id" db:"agentfield_server_id"`
+	TenantID           string                     `json:"tenant_id,omitempty" db:"tenant_id"`
 	PublicKeyJWK       json.RawMessage            `json:"public_key_jwk" db:"public_key_jwk"`
 	DerivationPath     string                     `json:"derivation_path" db:"derivation_path"`
 	Reasoners          map[string]ReasonerDIDInfo `json:"reasoners" db:"reasoners"`
 	Skills             map[string]SkillDIDInfo    `json:"skills" db:"skills"`
 	Status             AgentDIDStatus             `json:"status" db:"status"`
 	RegisteredAt       time.Time                  `json:"registered_at" db:"registered_at"`
+	RateLimit          *RateLimitPolicy           `json:"rate_limit,omitempty" db:"rate_limit"`
+}
+
+// RateLimitPolicy is a per-agent request budget. The DID registry only
+// stores it; enforcement happens in the callers that read it back.
+type RateLimitPolicy struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
 }
 
 // ReasonerDIDInfo represents DID information for a reasoner.
@@ -60,6 +69,40 @@ const (
 	AgentDIDStatusRevoked  AgentDIDStatus = "revoked"
 )
 
+// AgentDIDStatusChange records a single transition of an agent DID's status.
+type AgentDIDStatusChange struct {
+	ID                 int64          `json:"id" db:"id"`
+	AgentNodeID        string         `json:"agent_node_id" db:"agent_node_id"`
+	AgentFieldServerID string         `json:"agentfield_server_id" db:"agentfield_server_id"`
+	PreviousStatus     AgentDIDStatus `json:"previous_status" db:"previous_status"`
+	NewStatus          AgentDIDStatus `json:"new_status" db:"new_status"`
+	Reason             string         `json:"reason,omitempty" db:"reason"`
+	ChangedAt          time.Time      `json:"changed_at" db:"changed_at"`
+}
+
+// DIDKeyRotation describes how one DID was re-derived during an af server root
+// key rotation. ComponentType is "agentfield_server", "agent", "reasoner", or "skill".
+type DIDKeyRotation struct {
+	OldDID          string `json:"old_did"`
+	NewDID          string `json:"new_did"`
+	ComponentType   string `json:"component_type"`
+	OldPublicKeyJWK string `json:"old_public_key_jwk"`
+	NewPublicKeyJWK string `json:"new_public_key_jwk"`
+	DerivationPath  string `json:"derivation_path"`
+}
+
+// RotatedDIDInfo is a DID retired by a root key rotation, kept so that it
+// still resolves to its former public key.
+type RotatedDIDInfo struct {
+	DID                string          `json:"did" db:"did"`
+	ReplacedBy         string          `json:"replaced_by" db:"replaced_by"`
+	AgentFieldServerID string          `json:"agentfield_server_id" db:"agentfield_server_id"`
+	ComponentType      string          `json:"component_type" db:"component_type"`
+	PublicKeyJWK       json.RawMessage `json:"public_key_jwk" db:"public_key_jwk"`
+	DerivationPath     string          `json:"derivation_path" db:"derivation_path"`
+	RotatedAt          time.Time       `json:"rotated_at" db:"rotated_at"`
+}
+
 // ExecutionVC represents a verifiable credential for an execution.
 type ExecutionVC struct {
 	VCID         string          `json:"vc_id" db:"vc_id"`
@@ -118,6 +161,14 @@ type DIDIdentity struct {
 	DerivationPath string `json:"derivation_path"`
 	ComponentType  string `json:"component_type"`
 	FunctionName   string `json:"function_name,omitempty"`
+	// RotatedTo is set when the DID was retired by a root key rotation and
+	// names its replacement. Rotated identities carry no private key.
+	RotatedTo string `json:"rotated_to,omitempty"`
+	// Revoked is set when the DID belongs to a revoked agent. The public key
+	// is still returned so verifiers can decide how to treat old signatures.
+	Revoked bool `json:"revoked,omitempty"`
+	// RateLimit is the rate-limit policy of the owning agent, if one is set.
+	RateLimit *RateLimitPolicy `json:"rate_limit,omitempty"`
 }
 
 // ExecutionContext represents the context for DID-enabled execution.
@@ -254,14 +305,21 @@ type DIDRegistrationRequest struct {
 	AgentNodeID string               `json:"agent_node_id"`
 	Reasoners   []ReasonerDefinition `json:"reasoners"`
 	Skills      []SkillDefinition    `json:"skills"`
+	// TenantID is the tenant the agent registers in. Handlers set it from
+	// the authenticated request, never from the body; empty means
+	// DefaultTenantID.
+	TenantID string `json:"-"`
 }
 
 // DIDRegistrationResponse represents the response to a DID registration request.
+// For a re-registration of a known agent node, IdentityPackage holds only the
+// DIDs generated by this call and Diff reports the component changes applied.
 type DIDRegistrationResponse struct {
-	Success         bool               `json:"success"`
-	IdentityPackage DIDIdentityPackage `json:"identity_package"`
-	Message         string             `json:"message,omitempty"`
-	Error           string             `json:"error,omitempty"`
+	Success         bool                        `json:"success"`
+	IdentityPackage DIDIdentityPackage          `json:"identity_package"`
+	Diff            *DifferentialAnalysisResult `json:"diff,omitempty"`
+	Message         string                      `json:"message,omitempty"`
+	Error           string                      `json:"error,omitempty"`
 }
 
 // VCVerificationRequest represents a request to verify a VC.
@@ -358,6 +416,19 @@ type ComponentDIDInfo struct {
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
 
+// ComponentDID is a flat view of a stored reasoner or skill DID, including its
+// public key, for comparing persisted components against a package manifest.
+type ComponentDID struct {
+	DID             string          `json:"did" db:"did"`
+	AgentDID        string          `json:"agent_did" db:"agent_did"`
+	ComponentType   string          `json:"component_type" db:"component_type"`
+	FunctionName    string          `json:"function_name" db:"function_name"`
+	PublicKeyJWK    json.RawMessage `json:"public_key_jwk" db:"public_key_jwk"`
+	DerivationPath  string          `json:"derivation_path" db:"derivation_path"`
+	DerivationIndex int             `json:"derivation_index" db:"derivation_index"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
 // ExecutionVCInfo represents information about an execution VC stored in database.
 type ExecutionVCInfo struct {
 	VCID         string    `json:"vc_id" db:"vc_id"`
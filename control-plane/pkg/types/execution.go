@@ -2,9 +2,27 @@ package types
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
+// DefaultTenantID is the implicit tenant of single-tenant deployments and of
+// every record written before tenants existed.
+const DefaultTenantID = "default"
+
+// TenantIDHeader selects the tenant of an API request made with the global API
+// key, or with auth disabled. A tenant API key always acts for its own tenant.
+const TenantIDHeader = "X-Tenant-ID"
+
+// NormalizeTenantID trims tenantID and maps an empty value to DefaultTenantID.
+func NormalizeTenantID(tenantID string) string {
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return DefaultTenantID
+	}
+	return tenantID
+}
+
 // Execution captures a single agent invocation. A workflow run is represented by a
 // shared RunID across multiple executions. ParentExecutionID creates the DAG edges.
 type Execution struct {
@@ -12,6 +30,7 @@ type Execution struct {
 	ExecutionID       string  `json:"execution_id" db:"execution_id"`
 	RunID             string  `json:"run_id" db:"run_id"`
 	ParentExecutionID *string `json:"parent_execution_id,omitempty" db:"parent_execution_id"`
+	TenantID          string  `json:"tenant_id,omitempty" db:"tenant_id"`
 
 	// Agent metadata
 	AgentNodeID string `json:"agent_node_id" db:"agent_node_id"`
@@ -27,6 +46,7 @@ type Execution struct {
 
 	// Lifecycle
 	Status      string     `json:"status" db:"status"`
+	EnqueuedAt  *time.Time `json:"enqueued_at,omitempty" db:"enqueued_at"`
 	StartedAt   time.Time  `json:"started_at" db:"started_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
 	DurationMS  *int64     `json:"duration_ms,omitempty" db:"duration_ms"`
@@ -48,6 +68,8 @@ type Execution struct {
 
 // ExecutionFilter describes supported filters when querying executions.
 type ExecutionFilter struct {
+	// TenantID scopes the query to one tenant; nil matches every tenant.
+	TenantID          *string
 	RunID             *string
 	ExecutionID       *string
 	ParentExecutionID *string
@@ -60,6 +82,7 @@ type ExecutionFilter struct {
 	Offset            int
 	StartTime         *time.Time
 	EndTime           *time.Time
+	UpdatedSince      *time.Time
 	SortBy            string
 	SortDescending    bool
 }
@@ -11,20 +11,24 @@ const (
 	ExecutionStatusQueued    ExecutionStatus = "queued"
 	ExecutionStatusRunning   ExecutionStatus = "running"
 	ExecutionStatusSucceeded ExecutionStatus = "succeeded"
-	ExecutionStatusFailed    ExecutionStatus = "failed"
-	ExecutionStatusCancelled ExecutionStatus = "cancelled"
-	ExecutionStatusTimeout   ExecutionStatus = "timeout"
+	// ExecutionStatusSucceededWithWarnings marks an execution that completed
+	// but reported non-fatal issues.
+	ExecutionStatusSucceededWithWarnings ExecutionStatus = "succeeded_with_warnings"
+	ExecutionStatusFailed                ExecutionStatus = "failed"
+	ExecutionStatusCancelled             ExecutionStatus = "cancelled"
+	ExecutionStatusTimeout               ExecutionStatus = "timeout"
 )
 
 var canonicalExecutionStatuses = map[ExecutionStatus]struct{}{
-	ExecutionStatusUnknown:   {},
-	ExecutionStatusPending:   {},
-	ExecutionStatusQueued:    {},
-	ExecutionStatusRunning:   {},
-	ExecutionStatusSucceeded: {},
-	ExecutionStatusFailed:    {},
-	ExecutionStatusCancelled: {},
-	ExecutionStatusTimeout:   {},
+	ExecutionStatusUnknown:               {},
+	ExecutionStatusPending:               {},
+	ExecutionStatusQueued:                {},
+	ExecutionStatusRunning:               {},
+	ExecutionStatusSucceeded:             {},
+	ExecutionStatusSucceededWithWarnings: {},
+	ExecutionStatusFailed:                {},
+	ExecutionStatusCancelled:             {},
+	ExecutionStatusTimeout:               {},
 }
 
 var executionStatusAliases = map[string]ExecutionStatus{
@@ -65,7 +69,7 @@ func NormalizeExecutionStatus(status string) string {
 // IsTerminalExecutionStatus reports whether the provided status string represents a terminal execution state.
 func IsTerminalExecutionStatus(status string) bool {
 	switch NormalizeExecutionStatus(status) {
-	case string(ExecutionStatusSucceeded), string(ExecutionStatusFailed), string(ExecutionStatusCancelled), string(ExecutionStatusTimeout):
+	case string(ExecutionStatusSucceeded), string(ExecutionStatusSucceededWithWarnings), string(ExecutionStatusFailed), string(ExecutionStatusCancelled), string(ExecutionStatusTimeout):
 		return true
 	default:
 		return false
@@ -4,16 +4,17 @@ import "testing"
 
 func TestNormalizeExecutionStatus(t *testing.T) {
 	cases := map[string]string{
-		"":              string(ExecutionStatusUnknown),
-		"  ":            string(ExecutionStatusUnknown),
-		"Completed":     string(ExecutionStatusSucceeded),
-		"success":       string(ExecutionStatusSucceeded),
-		"FAILED":        string(ExecutionStatusFailed),
-		"canceled":      string(ExecutionStatusCancelled),
-		"TIMED_OUT":     string(ExecutionStatusTimeout),
-		"waiting":       string(ExecutionStatusQueued),
-		"processing":    string(ExecutionStatusRunning),
-		"custom-status": string(ExecutionStatusUnknown),
+		"":                        string(ExecutionStatusUnknown),
+		"  ":                      string(ExecutionStatusUnknown),
+		"Completed":               string(ExecutionStatusSucceeded),
+		"success":                 string(ExecutionStatusSucceeded),
+		"FAILED":                  string(ExecutionStatusFailed),
+		"canceled":                string(ExecutionStatusCancelled),
+		"TIMED_OUT":               string(ExecutionStatusTimeout),
+		"Succeeded_With_Warnings": string(ExecutionStatusSucceededWithWarnings),
+		"waiting":                 string(ExecutionStatusQueued),
+		"processing":              string(ExecutionStatusRunning),
+		"custom-status":           string(ExecutionStatusUnknown),
 	}
 
 	for input, expected := range cases {
@@ -24,7 +25,7 @@ func TestNormalizeExecutionStatus(t *testing.T) {
 }
 
 func TestIsTerminalExecutionStatus(t *testing.T) {
-	terminals := []string{"succeeded", "failed", "cancelled", "timeout", "completed"}
+	terminals := []string{"succeeded", "failed", "cancelled", "timeout", "completed", "succeeded_with_warnings"}
 	nonTerminals := []string{"pending", "queued", "running", "processing"}
 
 	for _, status := range terminals {
@@ -960,6 +960,17 @@ type WorkflowSummaryData struct {
 	CurrentTask     *string   `json:"current_task" db:"current_task"`
 }
 
+// CompactionResult reports what a storage compaction reclaimed.
+type CompactionResult struct {
+	// OrphanedComponentDIDs counts component DID rows removed because their
+	// agent DID no longer exists.
+	OrphanedComponentDIDs int `json:"orphaned_component_dids"`
+	// FreedSpaceBytes is the shrinkage of the SQLite file; PostgreSQL reuses
+	// space in place and reports 0.
+	FreedSpaceBytes int64 `json:"freed_space_bytes"`
+	DurationMS      int64 `json:"duration_ms"`
+}
+
 // WorkflowCleanupResult represents the result of a workflow cleanup operation
 type WorkflowCleanupResult struct {
 	WorkflowID      string         `json:"workflow_id"`
@@ -172,6 +172,10 @@ type Config struct {
 	// *ai.Config to enable AI-related APIs.
 	AIConfig *ai.Config
 
+	// AIObservers are notified around every AI call made through the
+	// agent's AI client. Optional; ignored if AIConfig is nil.
+	AIObservers []ai.Observer
+
 	// CLIConfig controls CLI-specific behaviour and help text.
 	// Optional. If nil, CLI behavior uses sensible defaults.
 	CLIConfig *CLIConfig
@@ -253,7 +257,11 @@ func New(cfg Config) (*Agent, error) {
 	var aiClient *ai.Client
 	var err error
 	if cfg.AIConfig != nil {
-		aiClient, err = ai.NewClient(cfg.AIConfig)
+		var aiOpts []ai.ClientOption
+		for _, o := range cfg.AIObservers {
+			aiOpts = append(aiOpts, ai.WithObserver(o))
+		}
+		aiClient, err = ai.NewClient(cfg.AIConfig, aiOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("initialize AI client: %w", err)
 		}
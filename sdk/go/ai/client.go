@@ -14,10 +14,17 @@ import (
 type Client struct {
 	config     *Config
 	httpClient *http.Client
+	observers  []Observer
+
+	// schemaValidator is nil unless WithSchemaValidationRetry is set.
+	schemaValidator SchemaValidator
+	schemaRetries   int
 }
 
 // NewClient creates a new AI client with the given configuration.
-func NewClient(config *Config) (*Client, error) {
+// Optional ClientOptions customize the HTTP transport (e.g. TLS trust) and
+// register observers.
+func NewClient(config *Config, opts ...ClientOption) (*Client, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
@@ -26,12 +33,24 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	return &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
-	}, nil
+	options, err := applyClientOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client option: %w", err)
+	}
+
+	client := &Client{
+		config:     config,
+		httpClient: newHTTPClient(config, options),
+		observers:  options.observers,
+	}
+	if options.validateSchema {
+		client.schemaValidator = options.schemaValidator
+		if client.schemaValidator == nil {
+			client.schemaValidator = ValidateJSONSchema
+		}
+		client.schemaRetries = options.schemaRetries
+	}
+	return client, nil
 }
 
 // Complete makes a chat completion request.
@@ -82,6 +101,15 @@ func (c *Client) CompleteWithMessages(ctx context.Context, messages []Message, o
 }
 
 func (c *Client) doRequest(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	return c.completeValidated(ctx, req)
+}
+
+// send performs the HTTP round trip for a validated request.
+func (c *Client) send(ctx context.Context, req *Request) (*Response, error) {
 	// Marshal request
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -114,6 +142,7 @@ func (c *Client) doRequest(ctx context.Context, req *Request) (*Response, error)
 			httpReq.Header.Set("X-Title", c.config.SiteName)
 		}
 	}
+	setRequestHeaders(httpReq, req)
 
 	// Execute request
 	httpResp, err := c.httpClient.Do(httpReq)
@@ -142,10 +171,25 @@ func (c *Client) doRequest(ctx context.Context, req *Request) (*Response, error)
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
+	if response.SchemaVersion == "" && req.ResponseFormat != nil && req.ResponseFormat.JSONSchema != nil {
+		response.SchemaVersion = req.ResponseFormat.JSONSchema.Version
+	}
 
 	return &response, nil
 }
 
+// setRequestHeaders applies the request's own headers after the client's, so
+// they can replace provider headers such as X-Title. Validate has already
+// rejected the reserved ones.
+func setRequestHeaders(httpReq *http.Request, req *Request) {
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if req.IdempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+}
+
 // StreamComplete makes a streaming chat completion request.
 // Returns a channel of response chunks.
 func (c *Client) StreamComplete(ctx context.Context, prompt string, opts ...Option) (<-chan StreamChunk, <-chan error) {
@@ -181,10 +225,24 @@ func (c *Client) StreamComplete(ctx context.Context, prompt string, opts ...Opti
 			}
 		}
 
+		if err := req.Validate(); err != nil {
+			errCh <- fmt.Errorf("invalid request: %w", err)
+			return
+		}
+
+		// Errors past this point are reported to observers as well.
+		var streamErr error
+		c.notifyRequest(req)
+		defer func() { c.notifyResponse(nil, streamErr) }()
+		fail := func(err error) {
+			streamErr = err
+			errCh <- err
+		}
+
 		// Marshal request
 		body, err := json.Marshal(req)
 		if err != nil {
-			errCh <- fmt.Errorf("marshal request: %w", err)
+			fail(fmt.Errorf("marshal request: %w", err))
 			return
 		}
 
@@ -194,7 +252,7 @@ func (c *Client) StreamComplete(ctx context.Context, prompt string, opts ...Opti
 		// Create HTTP request
 		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 		if err != nil {
-			errCh <- fmt.Errorf("create request: %w", err)
+			fail(fmt.Errorf("create request: %w", err))
 			return
 		}
 
@@ -216,11 +274,12 @@ func (c *Client) StreamComplete(ctx context.Context, prompt string, opts ...Opti
 				httpReq.Header.Set("X-Title", c.config.SiteName)
 			}
 		}
+		setRequestHeaders(httpReq, req)
 
 		// Execute request
 		httpResp, err := c.httpClient.Do(httpReq)
 		if err != nil {
-			errCh <- fmt.Errorf("execute request: %w", err)
+			fail(fmt.Errorf("execute request: %w", err))
 			return
 		}
 		defer httpResp.Body.Close()
@@ -228,85 +287,114 @@ func (c *Client) StreamComplete(ctx context.Context, prompt string, opts ...Opti
 		// Check for errors
 		if httpResp.StatusCode >= 400 {
 			respBody, _ := io.ReadAll(httpResp.Body)
-			errCh <- fmt.Errorf("API error (%d): %s", httpResp.StatusCode, string(respBody))
+			fail(fmt.Errorf("API error (%d): %s", httpResp.StatusCode, string(respBody)))
 			return
 		}
 
 		// Parse SSE stream
 		decoder := NewSSEDecoder(httpResp.Body)
+		stopper := newToolStopper(req.StopTools)
 		for {
 			chunk, err := decoder.Decode()
 			if err != nil {
 				if err != io.EOF {
-					errCh <- fmt.Errorf("decode stream: %w", err)
+					fail(fmt.Errorf("decode stream: %w", err))
 				}
 				return
 			}
+			if stopper.startsOtherCall(chunk) {
+				return
+			}
 
 			select {
 			case <-ctx.Done():
-				errCh <- ctx.Err()
+				fail(ctx.Err())
 				return
 			case chunkCh <- chunk:
 			}
+			if stopper.observe(chunk) {
+				return
+			}
 		}
 	}()
 
 	return chunkCh, errCh
 }
 
-// SSEDecoder decodes Server-Sent Events from a stream.
-type SSEDecoder struct {
-	reader      io.Reader
-	accumulated []byte
-	buf         []byte
+// toolStopper ends a stream once a call to one of the StopTools is complete:
+// when a choice finishes, or when the model starts another tool call, whose
+// first chunk is then not delivered.
+type toolStopper struct {
+	names  map[string]bool
+	active int // index of the matching tool call, or -1
 }
 
-// NewSSEDecoder creates a new SSE decoder.
-func NewSSEDecoder(r io.Reader) *SSEDecoder {
-	return &SSEDecoder{
-		reader: r,
-		buf:    make([]byte, 8192),
+func newToolStopper(names []string) *toolStopper {
+	if len(names) == 0 {
+		return nil
+	}
+	s := &toolStopper{names: make(map[string]bool, len(names)), active: -1}
+	for _, name := range names {
+		s.names[name] = true
 	}
+	return s
 }
 
-// Decode reads and decodes the next SSE chunk.
-func (d *SSEDecoder) Decode() (StreamChunk, error) {
-	for {
-		// First check if we already have a complete message in accumulated buffer
-		data := string(d.accumulated)
-		if idx := strings.Index(data, "\n\n"); idx >= 0 {
-			message := data[:idx]
-			d.accumulated = []byte(data[idx+2:])
-
-			// Parse SSE message
-			if strings.HasPrefix(message, "data: ") {
-				jsonData := strings.TrimPrefix(message, "data: ")
-				jsonData = strings.TrimSpace(jsonData)
-
-				// Check for stream end
-				if jsonData == "[DONE]" {
-					return StreamChunk{}, io.EOF
-				}
-
-				var chunk StreamChunk
-				if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
-					continue // Skip malformed chunks
-				}
-
-				return chunk, nil
+// startsOtherCall reports whether chunk begins a tool call after a matching one.
+func (s *toolStopper) startsOtherCall(chunk StreamChunk) bool {
+	if s == nil || s.active < 0 {
+		return false
+	}
+	for _, choice := range chunk.Choices {
+		for _, call := range choice.Delta.ToolCalls {
+			if call.Index != s.active {
+				return true
 			}
-			continue // Non-data message, try next
 		}
+	}
+	return false
+}
 
-		// Need more data
-		n, err := d.reader.Read(d.buf)
-		if err != nil {
-			return StreamChunk{}, err
+// observe records matching tool calls in chunk and reports whether the stream
+// should end after it.
+func (s *toolStopper) observe(chunk StreamChunk) bool {
+	if s == nil {
+		return false
+	}
+	finished := false
+	for _, choice := range chunk.Choices {
+		for _, call := range choice.Delta.ToolCalls {
+			if s.names[call.Function.Name] {
+				s.active = call.Index
+			}
 		}
+		if choice.FinishReason != nil {
+			finished = true
+		}
+	}
+	return finished && s.active >= 0
+}
 
-		d.accumulated = append(d.accumulated, d.buf[:n]...)
+// SSEDecoder decodes Server-Sent Events from a stream. Unlike StreamReader it
+// skips chunks it cannot decode.
+type SSEDecoder struct {
+	reader *StreamReader
+}
+
+// NewSSEDecoder creates a new SSE decoder.
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	reader := NewStreamReader(r)
+	reader.skipMalformed = true
+	return &SSEDecoder{reader: reader}
+}
+
+// Decode reads and decodes the next SSE chunk.
+func (d *SSEDecoder) Decode() (StreamChunk, error) {
+	chunk, err := d.reader.Next()
+	if err != nil {
+		return StreamChunk{}, err
 	}
+	return *chunk, nil
 }
 
 // Convenience functions for common patterns
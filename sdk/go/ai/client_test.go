@@ -2,12 +2,19 @@ package ai
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -164,6 +171,80 @@ func TestComplete_WithAPIKeyOverride(t *testing.T) {
 	assert.NotNil(t, resp)
 }
 
+func TestComplete_WithHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "pool-a", r.Header.Get("X-Model-Pool"))
+		assert.Equal(t, "Bearer default-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: "ok"}}}}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{APIKey: "default-key", BaseURL: server.URL, Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	_, err = client.Complete(context.Background(), "Hello", WithHeader("X-Model-Pool", "pool-a"))
+	assert.NoError(t, err)
+
+	_, err = client.Complete(context.Background(), "Hello", WithHeader("Authorization", "Bearer other"))
+	assert.Error(t, err)
+}
+
+func TestComplete_WithIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: "ok"}}}}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	// Each attempt of one logical call carries the same key.
+	for i := 0; i < 2; i++ {
+		_, err = client.Complete(context.Background(), "Hello", WithIdempotencyKey("call-1"), WithHeader("Idempotency-Key", "ignored"))
+		require.NoError(t, err)
+	}
+	_, err = client.Complete(context.Background(), "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"call-1", "call-1", ""}, keys)
+
+	_, err = client.Complete(context.Background(), "Hello", WithIdempotencyKey(" "))
+	assert.Error(t, err)
+}
+
+func TestComplete_SchemaVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{
+			SchemaVersion: r.Header.Get("X-Echo-Schema-Version"),
+			Choices:       []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: "{}"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	resp, err := client.Complete(context.Background(), "Hello", WithSchema(`{"type":"object"}`), WithSchemaVersion("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", resp.SchemaVersion)
+
+	// A version echoed by the gateway wins, exposing a stale schema.
+	resp, err = client.Complete(context.Background(), "Hello", WithSchema(`{"type":"object"}`), WithSchemaVersion("v2"),
+		WithHeader("X-Echo-Schema-Version", "v1"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", resp.SchemaVersion)
+
+	resp, err = client.Complete(context.Background(), "Hello")
+	require.NoError(t, err)
+	assert.Empty(t, resp.SchemaVersion)
+}
+
 func TestComplete_WithOptions(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req Request
@@ -481,6 +562,64 @@ func TestStreamComplete(t *testing.T) {
 	}
 }
 
+func TestStreamComplete_WithStopAfterTool(t *testing.T) {
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.NotContains(t, body, "stop_tools")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		chunks := []string{
+			`data: {"id":"c","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"search","arguments":""}}]}}]}`,
+			`data: {"id":"c","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{}"}}]}}]}`,
+			`data: {"id":"c","choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"finish","arguments":"{\"answer\""}}]}}]}`,
+			`data: {"id":"c","choices":[{"delta":{"tool_calls":[{"index":1,"function":{"arguments":":42}"}}]}}]}`,
+			`data: {"id":"c","choices":[{"delta":{"tool_calls":[{"index":2,"id":"call_3","type":"function","function":{"name":"search","arguments":""}}]}}]}`,
+			`data: [DONE]`,
+		}
+		for _, chunk := range chunks {
+			w.Write([]byte(chunk + "\n\n"))
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	chunks, errs := client.StreamComplete(context.Background(), "Hello", WithTool("finish", "Return the answer", nil), WithStopAfterTool("finish"))
+
+	var arguments string
+	var received int
+	for chunk := range chunks {
+		received++
+		for _, call := range chunk.Choices[0].Delta.ToolCalls {
+			if call.Index == 1 {
+				arguments += call.Function.Arguments
+			}
+		}
+	}
+	require.NoError(t, <-errs)
+	assert.Equal(t, 4, received)
+	assert.Equal(t, `{"answer":42}`, arguments)
+}
+
+func TestWithStopAfterTool_RejectsEmptyName(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithStopAfterTool("finish")(req))
+	require.NoError(t, WithStopAfterTool("handoff")(req))
+	assert.Equal(t, []string{"finish", "handoff"}, req.StopTools)
+	assert.Error(t, WithStopAfterTool(" ")(req))
+}
+
 func TestStreamComplete_ErrorHandling(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -583,6 +722,65 @@ func TestSSEDecoder(t *testing.T) {
 	}
 }
 
+func TestStreamReader(t *testing.T) {
+	stream := ": keep-alive\r\n" +
+		"\r\n" +
+		"event: message\r\n" +
+		"id: 1\r\n" +
+		"data: {\"id\":\"c1\",\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\r\n" +
+		"\r\n" +
+		"data:{\"id\":\"c1\",\"choices\":[{\"delta\":\n" +
+		"data: {\"content\":\"lo\"}}]}\n" +
+		"\n" +
+		"data: {\"id\":\"c1\",\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n" +
+		"\n" +
+		"data: [DONE]\n" +
+		"\n" +
+		"data: {\"id\":\"after-done\"}\n\n"
+
+	// One byte per read exercises events split across reads.
+	reader := NewStreamReader(iotest.OneByteReader(strings.NewReader(stream)))
+	var text strings.Builder
+	var finish []string
+	for {
+		chunk, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		text.WriteString(chunk.Text())
+		finish = append(finish, chunk.FinishReason())
+	}
+	assert.Equal(t, "Hello", text.String())
+	assert.Equal(t, []string{"", "", "stop"}, finish)
+
+	_, err := reader.Next()
+	assert.Equal(t, io.EOF, err, "the reader stays at EOF after [DONE]")
+}
+
+func TestStreamReader_EndsWithoutDone(t *testing.T) {
+	reader := NewStreamReader(strings.NewReader("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}"))
+	chunk, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", chunk.Text())
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewStreamReader(strings.NewReader("")).Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamReader_MalformedChunk(t *testing.T) {
+	reader := NewStreamReader(strings.NewReader("data: not json\n\ndata: {}\n\n"))
+	_, err := reader.Next()
+	assert.ErrorContains(t, err, "decode stream chunk")
+
+	chunk, err := reader.Next()
+	require.NoError(t, err)
+	assert.Empty(t, chunk.Text())
+	assert.Empty(t, chunk.FinishReason())
+}
+
 func TestSimpleAI(t *testing.T) {
 	// This test requires a valid config, so we'll skip it in unit tests
 	// or mock the environment
@@ -593,3 +791,352 @@ func TestStructuredAI(t *testing.T) {
 	// This test requires a valid config, so we'll skip it in unit tests
 	t.Skip("Requires actual API key or extensive mocking")
 }
+
+func TestNewClientTLSOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Response{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: "ok"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	config := &Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Model:   "gpt-4o",
+		Timeout: 5 * time.Second,
+	}
+
+	t.Run("untrusted certificate is rejected by default", func(t *testing.T) {
+		client, err := NewClient(config)
+		require.NoError(t, err)
+
+		_, err = client.Complete(context.Background(), "Hello")
+		assert.Error(t, err)
+	})
+
+	t.Run("CA certificate is trusted", func(t *testing.T) {
+		caPath := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caPath, certPEM, 0o600))
+
+		client, err := NewClient(config, WithCACert(caPath))
+		require.NoError(t, err)
+
+		resp, err := client.Complete(context.Background(), "Hello")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Text())
+	})
+
+	t.Run("insecure skip verify", func(t *testing.T) {
+		client, err := NewClient(config, WithInsecureSkipVerify(true))
+		require.NoError(t, err)
+
+		resp, err := client.Complete(context.Background(), "Hello")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Text())
+	})
+
+	t.Run("custom TLS config", func(t *testing.T) {
+		pool := x509.NewCertPool()
+		pool.AddCert(server.Certificate())
+
+		client, err := NewClient(config, WithTLSConfig(&tls.Config{RootCAs: pool}))
+		require.NoError(t, err)
+
+		_, err = client.Complete(context.Background(), "Hello")
+		require.NoError(t, err)
+	})
+
+	t.Run("CA certificate does not modify caller pool", func(t *testing.T) {
+		caPath := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caPath, certPEM, 0o600))
+
+		callerPool := x509.NewCertPool()
+		callerCfg := &tls.Config{RootCAs: callerPool}
+
+		client, err := NewClient(config, WithTLSConfig(callerCfg), WithCACert(caPath))
+		require.NoError(t, err)
+
+		_, err = client.Complete(context.Background(), "Hello")
+		require.NoError(t, err)
+		assert.Same(t, callerPool, callerCfg.RootCAs)
+		assert.True(t, callerPool.Equal(x509.NewCertPool()), "caller pool must stay empty")
+	})
+
+	t.Run("invalid CA file", func(t *testing.T) {
+		caPath := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(caPath, []byte("not a certificate"), 0o600))
+
+		client, err := NewClient(config, WithCACert(caPath))
+		assert.Error(t, err)
+		assert.Nil(t, client)
+
+		_, err = NewClient(config, WithCACert(filepath.Join(t.TempDir(), "missing.pem")))
+		assert.Error(t, err)
+	})
+}
+
+func TestComplete_RejectsInvalidRequest(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "o1", MaxTokens: 1024})
+	require.NoError(t, err)
+
+	_, err = client.Complete(context.Background(), "Hello", WithMaxCompletionTokens(2048), WithMaxTokens(512))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid request")
+	assert.False(t, called, "invalid requests must not reach the server")
+}
+
+type recordingObserver struct {
+	mu        sync.Mutex
+	requests  []*Request
+	responses []*Response
+	errs      []error
+}
+
+func (o *recordingObserver) OnRequest(req *Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests = append(o.requests, req)
+}
+
+func (o *recordingObserver) OnResponse(resp *Response, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.responses = append(o.responses, resp)
+	o.errs = append(o.errs, err)
+}
+
+func TestClientObserver(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("boom"))
+			return
+		}
+		json.NewEncoder(w).Encode(Response{
+			Model:   "gpt-4o",
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: "hi"}}}}},
+			Usage:   &Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		})
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"}, WithObserver(obs))
+	require.NoError(t, err)
+
+	resp, err := client.Complete(context.Background(), "Hello", WithModel("gpt-4o-mini"))
+	require.NoError(t, err)
+	assert.Greater(t, resp.Latency, time.Duration(0))
+
+	require.Len(t, obs.requests, 1)
+	assert.Equal(t, "gpt-4o-mini", obs.requests[0].Model)
+	assert.Same(t, resp, obs.responses[0])
+	assert.NoError(t, obs.errs[0])
+	assert.Equal(t, 5, obs.responses[0].Usage.TotalTokens)
+
+	fail = true
+	_, err = client.Complete(context.Background(), "Hello")
+	require.Error(t, err)
+	require.Len(t, obs.requests, 2)
+	assert.Nil(t, obs.responses[1])
+	assert.Equal(t, err, obs.errs[1])
+
+	// Requests rejected by validation are never sent, so are not observed.
+	_, err = client.Complete(context.Background(), "Hello", WithMaxTokens(-1))
+	require.Error(t, err)
+	assert.Len(t, obs.requests, 2)
+}
+
+func TestStreamCompleteObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"id\":\"1\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"}, WithObserver(obs))
+	require.NoError(t, err)
+
+	chunks, errs := client.StreamComplete(context.Background(), "Hello")
+	for range chunks {
+	}
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	require.Len(t, obs.requests, 1)
+	assert.True(t, obs.requests[0].Stream)
+	require.Len(t, obs.responses, 1)
+	assert.Nil(t, obs.responses[0])
+	assert.NoError(t, obs.errs[0])
+}
+
+func TestWithObserverRejectsNil(t *testing.T) {
+	_, err := NewClient(&Config{APIKey: "test-key", BaseURL: "https://api.openai.com/v1", Model: "gpt-4o"}, WithObserver(nil))
+	assert.Error(t, err)
+}
+
+func TestComplete_SchemaValidationRetry(t *testing.T) {
+	replies := []string{`{"name": 7}`, `{"name": "ada"}`}
+	var requests []Request
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		text := replies[len(replies)-1]
+		if len(requests) <= len(replies) {
+			text = replies[len(requests)-1]
+		}
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: text}}}}}})
+	}))
+	defer server.Close()
+
+	schema := `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`
+	obs := &recordingObserver{}
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"},
+		WithSchemaValidationRetry(2), WithObserver(obs))
+	require.NoError(t, err)
+
+	resp, err := client.Complete(context.Background(), "Who?", WithSchema(schema), WithIdempotencyKey("call-1"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "ada"}`, resp.Text())
+	require.Len(t, requests, 2)
+	assert.Len(t, obs.requests, 2)
+	assert.Equal(t, []string{"call-1", "call-1"}, keys)
+
+	retry := requests[1].Messages
+	require.Len(t, retry, 3)
+	assert.Equal(t, "assistant", retry[1].Role)
+	assert.Equal(t, `{"name": 7}`, retry[1].Content[0].Text)
+	assert.Equal(t, "user", retry[2].Role)
+	assert.Contains(t, retry[2].Content[0].Text, "$.name: expected string, got number")
+
+	// Retries run out: the last response is returned with the error.
+	replies = []string{`not json`}
+	requests = nil
+	keys = nil
+	resp, err = client.Complete(context.Background(), "Who?", WithSchema(schema), WithIdempotencyKey("call-2"))
+	require.NotNil(t, resp)
+	assert.Equal(t, "not json", resp.Text())
+	var verr *SchemaValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, 3, verr.Attempts)
+	assert.Len(t, requests, 3)
+	assert.Len(t, requests[2].Messages, 5)
+	assert.Equal(t, []string{"call-2", "call-2", "call-2"}, keys)
+
+	// Requests without a schema are not validated.
+	requests = nil
+	_, err = client.Complete(context.Background(), "Who?")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+}
+
+func TestComplete_SchemaValidationRetryWithPrefill(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		text := `"n": "x"}`
+		if len(requests) > 1 {
+			text = `"n": 1}`
+		}
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: text}}}}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o"}, WithSchemaValidationRetry(1))
+	require.NoError(t, err)
+
+	_, err = client.Complete(context.Background(), "Count",
+		WithSchema(`{"type":"object","properties":{"n":{"type":"integer"}}}`), WithAssistantPrefix("{"))
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+
+	// The prefill is validated with the continuation and stays last on re-asks.
+	retry := requests[1].Messages
+	require.Len(t, retry, 4)
+	assert.Equal(t, `{"n": "x"}`, retry[1].Content[0].Text)
+	assert.Equal(t, "user", retry[2].Role)
+	assert.Equal(t, "assistant", retry[3].Role)
+	assert.Equal(t, "{", retry[3].Content[0].Text)
+}
+
+func TestSchemaValidationOptions(t *testing.T) {
+	config := &Config{APIKey: "test-key", BaseURL: "https://api.openai.com/v1", Model: "gpt-4o"}
+	_, err := NewClient(config, WithSchemaValidationRetry(-1))
+	assert.Error(t, err)
+	_, err = NewClient(config, WithSchemaValidator(nil))
+	assert.Error(t, err)
+
+	var calls int
+	client, err := NewClient(config, WithSchemaValidator(func(json.RawMessage, []byte) error {
+		calls++
+		return nil
+	}), WithSchemaValidationRetry(0))
+	require.NoError(t, err)
+	require.NotNil(t, client.schemaValidator)
+	assert.NoError(t, client.schemaValidator(nil, nil))
+	assert.Equal(t, 1, calls)
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": ["integer", "null"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"level": {"enum": ["low", "high"]},
+			"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`)
+
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{data: `{"name": "ada", "age": 36, "tags": ["x"], "level": "low", "id": 3}`},
+		{data: `{"name": "ada", "age": null, "id": "a"}`},
+		{data: `{"name": "ada", "age": 36.0}`},
+		{data: `{"age": 36}`, wantErr: `$: missing required property "name"`},
+		{data: `{"name": "ada", "age": 3.5}`, wantErr: "$.age: expected integer or null, got number"},
+		{data: `{"name": "ada", "tags": ["x", 1]}`, wantErr: "$.tags[1]: expected string, got number"},
+		{data: `{"name": "ada", "level": "mid"}`, wantErr: "$.level: value is not one of the allowed values"},
+		{data: `{"name": "ada", "id": true}`, wantErr: "$.id: expected string, got boolean"},
+		{data: `{"name": "ada", "extra": 1}`, wantErr: `$: unexpected property "extra"`},
+		{data: `[]`, wantErr: "$: expected object, got array"},
+		{data: `{"name": "ada"} {}`, wantErr: "response is not valid JSON"},
+		{data: "```json\n{}\n```", wantErr: "response is not valid JSON"},
+	}
+	for _, tt := range tests {
+		err := ValidateJSONSchema(schema, []byte(tt.data))
+		if tt.wantErr == "" {
+			assert.NoError(t, err, tt.data)
+		} else if assert.Error(t, err, tt.data) {
+			assert.Contains(t, err.Error(), tt.wantErr, tt.data)
+		}
+	}
+
+	assert.ErrorContains(t, ValidateJSONSchema(json.RawMessage(`{`), []byte(`{}`)), "invalid schema")
+}
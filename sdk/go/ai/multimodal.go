@@ -1,6 +1,77 @@
 package ai
 
-import "strings"
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoding for resizing
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxImageBytes caps the size of image data attached from files or bytes,
+// matching the upload limit common to vision providers. Set it to 0 to
+// disable the check.
+var MaxImageBytes = 20 << 20
+
+// checkImageSize rejects image payloads larger than MaxImageBytes.
+func checkImageSize(n int) error {
+	if MaxImageBytes > 0 && n > MaxImageBytes {
+		return fmt.Errorf("image is %d bytes, exceeding the %d byte limit", n, MaxImageBytes)
+	}
+	return nil
+}
+
+// ImageFetchTimeout bounds how long WithImageURLFetched waits for a download,
+// including reading the body.
+var ImageFetchTimeout = 30 * time.Second
+
+// fetchImage downloads an image from url, reading at most MaxImageBytes, and
+// returns it with its MIME type. The type comes from an image/* Content-Type
+// header, or is sniffed from the data when the header is missing or generic.
+func fetchImage(url string) ([]byte, string, error) {
+	client := &http.Client{Timeout: ImageFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("fetch image: unexpected status %s", resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if MaxImageBytes > 0 {
+		body = io.LimitReader(resp.Body, int64(MaxImageBytes)+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch image: %w", err)
+	}
+	if err := checkImageSize(len(data)); err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("fetch image: empty response body")
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("fetch image: response is not an image (detected %q)", mimeType)
+	}
+	return data, mimeType, nil
+}
 
 func detectMIMEType(path string) string {
 	lower := strings.ToLower(path)
@@ -13,7 +84,121 @@ func detectMIMEType(path string) string {
 		return "image/gif"
 	case strings.HasSuffix(lower, ".webp"):
 		return "image/webp"
+	case strings.HasSuffix(lower, ".pdf"):
+		return "application/pdf"
+	case strings.HasSuffix(lower, ".docx"):
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case strings.HasSuffix(lower, ".txt"):
+		return "text/plain"
+	case strings.HasSuffix(lower, ".csv"):
+		return "text/csv"
+	case strings.HasSuffix(lower, ".md"):
+		return "text/markdown"
 	default:
 		return "application/octet-stream"
 	}
 }
+
+// parseImageDataURL validates a base64 image data URL of the form
+// "data:<mime>;base64,<payload>" and returns its MIME type.
+func parseImageDataURL(dataURL string) (string, error) {
+	if !strings.HasPrefix(dataURL, "data:") {
+		return "", fmt.Errorf("invalid data URL: missing data: scheme")
+	}
+
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok {
+		return "", fmt.Errorf("invalid data URL: missing ',' separator")
+	}
+
+	mimeType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", fmt.Errorf("invalid data URL: only base64 encoding is supported")
+	}
+	if !strings.HasPrefix(mimeType, "image/") || len(mimeType) == len("image/") {
+		return "", fmt.Errorf("invalid data URL: unsupported MIME type %q", mimeType)
+	}
+
+	if payload == "" {
+		return "", fmt.Errorf("invalid data URL: empty payload")
+	}
+	if _, err := base64.StdEncoding.DecodeString(payload); err != nil {
+		return "", fmt.Errorf("invalid data URL: %w", err)
+	}
+
+	return mimeType, nil
+}
+
+// resizeImage scales an encoded image so its longest side is at most
+// maxDimension, preserving aspect ratio, and re-encodes it as JPEG when the
+// source is JPEG and PNG otherwise. Images already within the limit are
+// returned unchanged. An image.ErrFormat error means the format can't be decoded.
+func resizeImage(data []byte, maxDimension int) ([]byte, string, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return data, "image/" + format, nil
+	}
+
+	dstWidth, dstHeight := maxDimension, maxDimension
+	if width >= height {
+		dstHeight = max(1, height*maxDimension/width)
+	} else {
+		dstWidth = max(1, width*maxDimension/height)
+	}
+	dst := downscale(src, dstWidth, dstHeight)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90})
+		format = "image/jpeg"
+	} else {
+		err = png.Encode(&buf, dst)
+		format = "image/png"
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("encode resized image: %w", err)
+	}
+	return buf.Bytes(), format, nil
+}
+
+// downscale resizes src to width x height by averaging the source pixels that
+// fall into each destination pixel.
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcWidth/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.RGBA64Model.Convert(src.At(sx, sy)).(color.RGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r / n) >> 8),
+				G: uint8((g / n) >> 8),
+				B: uint8((b / n) >> 8),
+				A: uint8((a / n) >> 8),
+			})
+		}
+	}
+	return dst
+}
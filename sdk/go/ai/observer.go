@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"errors"
+	"time"
+)
+
+// Observer receives a callback before and after every completion call made
+// by a Client. Observers are called synchronously on the calling goroutine
+// (or the streaming goroutine for StreamComplete), so implementations should
+// return quickly and must be safe for concurrent use if the Client is shared.
+//
+// The Request and Response are passed as-is, including message content.
+// Observers that export data elsewhere are responsible for redacting it, and
+// must not modify either value.
+type Observer interface {
+	// OnRequest is called once the request has been built and validated,
+	// immediately before it is sent.
+	OnRequest(req *Request)
+
+	// OnResponse is called when the call finishes. For Complete and
+	// CompleteWithMessages exactly one of resp and err is non-nil. For
+	// StreamComplete resp is always nil; it is called once the stream ends,
+	// with the error that ended it, so both are nil for a clean stream.
+	OnResponse(resp *Response, err error)
+}
+
+// WithObserver registers an Observer that is notified around each
+// completion call. It may be given more than once; observers are called in
+// the order they were registered.
+func WithObserver(o Observer) ClientOption {
+	return func(opts *clientOptions) error {
+		if o == nil {
+			return errors.New("observer cannot be nil")
+		}
+		opts.observers = append(opts.observers, o)
+		return nil
+	}
+}
+
+func (c *Client) notifyRequest(req *Request) {
+	for _, o := range c.observers {
+		o.OnRequest(req)
+	}
+}
+
+func (c *Client) notifyResponse(resp *Response, err error) {
+	for _, o := range c.observers {
+		o.OnResponse(resp, err)
+	}
+}
+
+// observe wraps a completion call with the registered observers and
+// records the call's latency on a successful Response.
+func (c *Client) observe(req *Request, call func() (*Response, error)) (*Response, error) {
+	c.notifyRequest(req)
+	start := time.Now()
+	resp, err := call()
+	if resp != nil {
+		resp.Latency = time.Since(start)
+	}
+	c.notifyResponse(resp, err)
+	return resp, err
+}
@@ -1,11 +1,21 @@
 package ai
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"log"
+	"math"
+	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // Message represents a chat message.
@@ -25,25 +35,306 @@ type Request struct {
 	// Temperature (0.0 to 2.0)
 	Temperature *float64 `json:"temperature,omitempty"`
 
+	// TemperatureClamped reports that WithTemperatureClamped moved an
+	// out-of-range temperature into [MinTemperature, MaxTemperature].
+	TemperatureClamped bool `json:"-"`
+
+	// Nucleus sampling probability mass (0.0 to 1.0)
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// Frequency and presence penalties (-2.0 to 2.0)
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+
+	// Seed requests deterministic sampling where the provider supports it
+	Seed *int `json:"seed,omitempty"`
+
 	// Maximum tokens to generate
 	MaxTokens *int `json:"max_tokens,omitempty"`
 
+	// Maximum completion tokens for reasoning models, which reject max_tokens
+	MaxCompletionTokens *int `json:"max_completion_tokens,omitempty"`
+
+	// MinP and TopK are sampling controls offered by self-hosted gateways such
+	// as vLLM and llama.cpp. OpenAI does not accept them.
+	MinP *float64 `json:"min_p,omitempty"`
+	TopK *int     `json:"top_k,omitempty"`
+
+	// RepetitionPenalty is the multiplicative repetition penalty of local
+	// inference servers (1.0 disables it), distinct from frequency and
+	// presence penalties
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"`
+
+	// GuidedChoice constrains the output to exactly one of the listed strings
+	// on gateways with guided decoding, such as vLLM. Others ignore it.
+	GuidedChoice []string `json:"guided_choice,omitempty"`
+
+	// Echo asks completion-style gateways to include the prompt in the output
+	Echo *bool `json:"echo,omitempty"`
+
+	// RoutingHint is read by gateways that route on the request body, e.g. to
+	// pin a prompt to a GPU pool. Providers that don't know it ignore it.
+	RoutingHint string `json:"routing_hint,omitempty"`
+
+	// CacheKey is sent as prompt_cache_key so gateways can route calls that
+	// share a prompt prefix to the same KV cache. See WithCacheKey.
+	CacheKey string `json:"prompt_cache_key,omitempty"`
+
+	// Truncation lets gateways that support it drop the oldest messages of an
+	// over-long conversation ("auto") instead of failing. See TruncationAuto.
+	Truncation string `json:"truncation,omitempty"`
+
+	// Thinking enables Anthropic extended thinking with a token budget
+	Thinking *ThinkingConfig `json:"thinking,omitempty"`
+
+	// SafetySettings sets per-category safety thresholds on Gemini gateways
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty"`
+
+	// Output modalities, e.g. ["text", "audio"], for models that can speak
+	Modalities []string `json:"modalities,omitempty"`
+
+	// Audio configures spoken output; required when Modalities includes "audio"
+	Audio *AudioOutputConfig `json:"audio,omitempty"`
+
 	// Enable streaming
 	Stream bool `json:"stream,omitempty"`
 
+	// Stream options, e.g. reporting usage on the final chunk
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
 	// Response format for structured outputs
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// Tools the model may call
+	Tools []Tool `json:"tools,omitempty"`
+
+	// ToolChoice controls whether and which tool the model calls. See
+	// WithToolChoice.
+	ToolChoice json.RawMessage `json:"tool_choice,omitempty"`
+
+	// Functions and FunctionCall are the legacy function-calling fields, for
+	// gateways that predate tools. They cannot be combined with Tools.
+	Functions    []FunctionDef   `json:"functions,omitempty"`
+	FunctionCall json.RawMessage `json:"function_call,omitempty"`
+
+	// StrictTools is applied to tools declared by later WithTool options
+	StrictTools bool `json:"-"`
+
+	// StopTools names terminal tools: StreamComplete ends the stream once a
+	// call to one of them has been received. Non-streaming calls ignore it.
+	StopTools []string `json:"-"`
+
+	// ExtraBody holds provider-specific fields merged into the top-level JSON
+	// body at marshal time. Keys must not collide with known request fields.
+	ExtraBody map[string]json.RawMessage `json:"-"`
+
+	// RoleMapping rewrites message roles at marshal time (e.g. "system" to
+	// "developer"). Messages keep their original roles.
+	RoleMapping map[string]string `json:"-"`
+
+	// Headers are extra HTTP headers sent with this request only, e.g.
+	// gateway routing headers. Authorization and Content-Type are reserved.
+	Headers map[string]string `json:"-"`
+
+	// IdempotencyKey is sent as the Idempotency-Key header on every attempt
+	// of this request, so a gateway that honors it won't repeat work when
+	// the request is retried.
+	IdempotencyKey string `json:"-"`
+}
+
+// validatePrefill checks that at most one assistant prefill is present and
+// that it is the text-only last message.
+func (r *Request) validatePrefill() error {
+	for i, msg := range r.Messages {
+		if !msg.Prefill {
+			continue
+		}
+		if i != len(r.Messages)-1 {
+			return fmt.Errorf("assistant prefill must be the last message; only one is allowed")
+		}
+		if msg.Role != "assistant" {
+			return fmt.Errorf("prefill message must have role assistant, got %q", msg.Role)
+		}
+		for _, part := range msg.Content {
+			if part.Type != "text" {
+				return fmt.Errorf("assistant prefill must contain only text, got a %q part", part.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// validRoles lists the message roles accepted by OpenAI-compatible providers.
+var validRoles = map[string]struct{}{
+	"system":    {},
+	"developer": {},
+	"user":      {},
+	"assistant": {},
+	"tool":      {},
+}
+
+// requestFieldNames holds the JSON names of the fields Request serializes itself.
+var requestFieldNames = func() map[string]struct{} {
+	names := make(map[string]struct{})
+	t := reflect.TypeOf(Request{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}()
+
+// MarshalJSON serializes a Request, applying RoleMapping to message roles and
+// appending ExtraBody entries to the top-level object in sorted key order.
+func (r Request) MarshalJSON() ([]byte, error) {
+	if len(r.RoleMapping) > 0 {
+		// r is a copy, but Messages shares its backing array with the caller.
+		messages := make([]Message, len(r.Messages))
+		for i, msg := range r.Messages {
+			if mapped, ok := r.RoleMapping[msg.Role]; ok {
+				msg.Role = mapped
+			}
+			messages[i] = msg
+		}
+		r.Messages = messages
+	}
+
+	type Alias Request
+	data, err := json.Marshal(Alias(r))
+	if err != nil || len(r.ExtraBody) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(r.ExtraBody))
+	for key := range r.ExtraBody {
+		if _, known := requestFieldNames[key]; known {
+			return nil, fmt.Errorf("extra body field %q collides with a known request field", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, key := range keys {
+		value := r.ExtraBody[key]
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("extra body field %q is not valid JSON", key)
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Validate reports request settings that providers are known to reject.
+func (r *Request) Validate() error {
+	if r.MaxTokens != nil && r.MaxCompletionTokens != nil {
+		return fmt.Errorf("max_tokens and max_completion_tokens are mutually exclusive; reasoning models accept only max_completion_tokens")
+	}
+	if r.MaxTokens != nil && *r.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must be non-negative, got %d", *r.MaxTokens)
+	}
+	if r.MaxCompletionTokens != nil && *r.MaxCompletionTokens < 0 {
+		return fmt.Errorf("max_completion_tokens must be non-negative, got %d", *r.MaxCompletionTokens)
+	}
+	if r.TopK != nil && *r.TopK < 0 {
+		return fmt.Errorf("top_k must be non-negative, got %d", *r.TopK)
+	}
+	if r.RepetitionPenalty != nil && !(*r.RepetitionPenalty > 0) {
+		return fmt.Errorf("repetition_penalty must be positive, got %v", *r.RepetitionPenalty)
+	}
+	if r.GuidedChoice != nil {
+		if len(r.GuidedChoice) < 2 {
+			return fmt.Errorf("guided_choice needs at least two choices, got %d", len(r.GuidedChoice))
+		}
+		for i, choice := range r.GuidedChoice {
+			if choice == "" {
+				return fmt.Errorf("guided_choice[%d] is empty", i)
+			}
+		}
+	}
+	if err := validateTruncation(r.Truncation); err != nil {
+		return err
+	}
+	if r.Echo != nil && *r.Echo && r.Stream {
+		return fmt.Errorf("echo cannot be combined with streaming; some backends reject echo on streamed requests")
+	}
+	if r.Thinking != nil {
+		if r.Thinking.BudgetTokens <= 0 {
+			return fmt.Errorf("thinking budget_tokens must be positive, got %d", r.Thinking.BudgetTokens)
+		}
+		if r.MaxTokens != nil && r.Thinking.BudgetTokens >= *r.MaxTokens {
+			return fmt.Errorf("thinking budget_tokens (%d) must be less than max_tokens (%d)", r.Thinking.BudgetTokens, *r.MaxTokens)
+		}
+	}
+	if err := r.ResponseFormat.validate(); err != nil {
+		return err
+	}
+	if err := r.validateFunctions(); err != nil {
+		return err
+	}
+	if err := r.validateToolChoice(); err != nil {
+		return err
+	}
+	if err := r.validatePrefill(); err != nil {
+		return err
+	}
+	for _, modality := range r.Modalities {
+		if modality == "audio" && r.Audio == nil {
+			return fmt.Errorf("the audio modality requires an audio output config; use WithAudioOutput")
+		}
+	}
+	for _, setting := range r.SafetySettings {
+		if err := setting.validate(); err != nil {
+			return err
+		}
+	}
+	for from, to := range r.RoleMapping {
+		if _, ok := validRoles[to]; !ok {
+			return fmt.Errorf("role mapping %q -> %q targets an unknown role", from, to)
+		}
+	}
+	for key := range r.Headers {
+		if err := validateHeaderKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type Message struct {
 	Role    string        `json:"role"`
 	Content []ContentPart `json:"content"`
+
+	// ToolCallID links a "tool" role message to the tool call it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// ToolCalls holds the tool calls of an assistant message. Responses that
+	// only call tools have no Content.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Prefill marks an assistant message added by WithAssistantPrefix. It is
+	// not sent; the provider sees an ordinary trailing assistant message.
+	Prefill bool `json:"-"`
 }
 
 type ContentPart struct {
-	Type     string        `json:"type"` // "text" or "image_url"
+	Type     string        `json:"type"` // "text", "image_url" or "file"
 	Text     string        `json:"text,omitempty"`
 	ImageURL *ImageURLData `json:"image_url,omitempty"`
+	File     *FileData     `json:"file,omitempty"`
 }
 
 // ImageURLData holds the URL and optional detail level for image content parts.
@@ -52,14 +343,23 @@ type ImageURLData struct {
 	Detail string `json:"detail,omitempty"`
 }
 
+// FileData holds an inline document for file content parts.
+type FileData struct {
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data"` // "data:<mime>;base64,<payload>"
+}
+
 // MarshalJSON serializes a Message. If the content is a single text part,
-// it serializes content as a plain string for maximum API compatibility.
+// it serializes content as a plain string for maximum API compatibility;
+// any other content, including tool results carrying images, stays an array.
 func (m Message) MarshalJSON() ([]byte, error) {
 	if len(m.Content) == 1 && m.Content[0].Type == "text" && m.Content[0].ImageURL == nil {
 		return json.Marshal(struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		}{Role: m.Role, Content: m.Content[0].Text})
+			Role       string     `json:"role"`
+			Content    string     `json:"content"`
+			ToolCallID string     `json:"tool_call_id,omitempty"`
+			ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+		}{Role: m.Role, Content: m.Content[0].Text, ToolCallID: m.ToolCallID, ToolCalls: m.ToolCalls})
 	}
 	type Alias Message
 	return json.Marshal((Alias)(m))
@@ -78,6 +378,12 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Tool-call responses send null content, or none at all.
+	if len(aux.Content) == 0 || string(aux.Content) == "null" {
+		m.Content = nil
+		return nil
+	}
+
 	var s string
 	if err := json.Unmarshal(aux.Content, &s); err == nil {
 		m.Content = []ContentPart{{Type: "text", Text: s}}
@@ -94,15 +400,41 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 
 // ResponseFormat specifies the desired output format.
 type ResponseFormat struct {
-	Type       string      `json:"type"` // "json_object" or "json_schema"
+	Type       string      `json:"type"` // "text", "json_object" or "json_schema"
 	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
 }
 
+// validate rejects a format that mixes text mode with a schema, which happens
+// when a request is edited by hand after WithTextMode, WithJSONMode or
+// WithSchema.
+func (f *ResponseFormat) validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.Type {
+	case "text", "json_object":
+		if f.JSONSchema != nil {
+			return fmt.Errorf("response_format %q cannot carry a json_schema; text mode, JSON mode and schemas are mutually exclusive", f.Type)
+		}
+	case "json_schema":
+		if f.JSONSchema == nil {
+			return fmt.Errorf("response_format json_schema requires a schema")
+		}
+	default:
+		return fmt.Errorf("unknown response_format type %q", f.Type)
+	}
+	return nil
+}
+
 // JSONSchema defines the structure for structured outputs.
 type JSONSchema struct {
 	Name   string          `json:"name"`
 	Strict bool            `json:"strict"`
 	Schema json.RawMessage `json:"schema"`
+
+	// Version identifies a revision of the schema, for gateways that echo
+	// it back as the response's schema_version
+	Version string `json:"version,omitempty"`
 }
 
 // Option is a functional option for configuring an AI request.
@@ -123,6 +455,25 @@ func WithSystem(content string) Option {
 	}
 }
 
+// WithAssistantPrefix appends an assistant message holding text as a
+// prefill, which gateways that support it continue rather than answer, e.g.
+// "{" to make a JSON reply start with an object. Apply it after any other
+// message options: Validate requires the prefill to be the last message. The
+// response holds only the continuation, so prepend text before parsing it.
+func WithAssistantPrefix(text string) Option {
+	return func(r *Request) error {
+		if text == "" {
+			return fmt.Errorf("assistant prefix cannot be empty")
+		}
+		r.Messages = append(r.Messages, Message{
+			Role:    "assistant",
+			Content: []ContentPart{{Type: "text", Text: text}},
+			Prefill: true,
+		})
+		return nil
+	}
+}
+
 // WithModel overrides the default model.
 func WithModel(model string) Option {
 	return func(r *Request) error {
@@ -143,179 +494,1174 @@ func WithAPIKey(apiKey string) Option {
 func WithTemperature(temp float64) Option {
 	return func(r *Request) error {
 		r.Temperature = &temp
+		r.TemperatureClamped = false
 		return nil
 	}
 }
 
-// WithMaxTokens sets the maximum tokens.
-func WithMaxTokens(tokens int) Option {
+// Temperature bounds accepted by OpenAI-compatible providers.
+const (
+	MinTemperature = 0.0
+	MaxTemperature = 2.0
+)
+
+// WithTopP sets nucleus sampling: the model samples only from the smallest
+// set of tokens whose probability mass reaches p. p must be within [0, 1].
+func WithTopP(p float64) Option {
 	return func(r *Request) error {
-		r.MaxTokens = &tokens
+		if !(p >= 0 && p <= 1) {
+			return fmt.Errorf("top_p must be between 0 and 1, got %v", p)
+		}
+		r.TopP = &p
 		return nil
 	}
 }
 
-// WithStream enables streaming responses.
-func WithStream() Option {
+// WithSeed sets the sampling seed, so repeated requests with the same seed
+// and parameters return the same output on providers that support it. Pair
+// it with WithTemperature(0) for reproducible evals.
+func WithSeed(seed int) Option {
 	return func(r *Request) error {
-		r.Stream = true
+		r.Seed = &seed
 		return nil
 	}
 }
 
-// WithJSONMode enables JSON object mode (non-strict).
-func WithJSONMode() Option {
+// Penalty bounds accepted by OpenAI-compatible providers for
+// WithFrequencyPenalty and WithPresencePenalty.
+const (
+	MinPenalty = -2.0
+	MaxPenalty = 2.0
+)
+
+// WithFrequencyPenalty sets frequency_penalty, which penalizes tokens in
+// proportion to how often they already appear. p must be within
+// [MinPenalty, MaxPenalty].
+func WithFrequencyPenalty(p float64) Option {
 	return func(r *Request) error {
-		r.ResponseFormat = &ResponseFormat{
-			Type: "json_object",
+		if err := validatePenalty("frequency_penalty", p); err != nil {
+			return err
 		}
+		r.FrequencyPenalty = &p
 		return nil
 	}
 }
 
-// WithSchema enables structured output with a JSON schema.
-// Accepts either a Go struct (will be converted to JSON schema) or json.RawMessage.
-func WithSchema(schema interface{}) Option {
+// WithPresencePenalty sets presence_penalty, which penalizes any token that
+// has already appeared. p must be within [MinPenalty, MaxPenalty].
+func WithPresencePenalty(p float64) Option {
 	return func(r *Request) error {
-		var schemaBytes json.RawMessage
-		var schemaName string
-
-		switch v := schema.(type) {
-		case json.RawMessage:
-			schemaBytes = v
-			schemaName = "response"
-		case []byte:
-			schemaBytes = json.RawMessage(v)
-			schemaName = "response"
-		case string:
-			schemaBytes = json.RawMessage(v)
-			schemaName = "response"
-		default:
-			// Convert Go struct to JSON schema
-			schemaMap, name, err := structToJSONSchema(v)
-			if err != nil {
-				return fmt.Errorf("convert schema: %w", err)
-			}
-			schemaBytes, err = json.Marshal(schemaMap)
-			if err != nil {
-				return fmt.Errorf("marshal schema: %w", err)
-			}
-			schemaName = name
+		if err := validatePenalty("presence_penalty", p); err != nil {
+			return err
 		}
+		r.PresencePenalty = &p
+		return nil
+	}
+}
 
-		r.ResponseFormat = &ResponseFormat{
-			Type: "json_schema",
-			JSONSchema: &JSONSchema{
-				Name:   schemaName,
-				Strict: true,
-				Schema: schemaBytes,
-			},
+func validatePenalty(name string, p float64) error {
+	if !(p >= MinPenalty && p <= MaxPenalty) {
+		return fmt.Errorf("%s must be between %v and %v, got %v", name, MinPenalty, MaxPenalty, p)
+	}
+	return nil
+}
+
+// WithTemperatureClamped sets the temperature, clamping it into
+// [MinTemperature, MaxTemperature] instead of letting the provider reject it.
+// Request.TemperatureClamped records whether the value was adjusted.
+func WithTemperatureClamped(temp float64) Option {
+	return func(r *Request) error {
+		if math.IsNaN(temp) {
+			return fmt.Errorf("temperature must be a number")
 		}
+		clamped := math.Min(math.Max(temp, MinTemperature), MaxTemperature)
+		r.Temperature = &clamped
+		r.TemperatureClamped = clamped != temp
 		return nil
 	}
 }
 
-// Image options
-func WithImageFile(path string) Option {
+// WithMaxTokens sets the maximum tokens.
+func WithMaxTokens(tokens int) Option {
 	return func(r *Request) error {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("read image file: %w", err)
-		}
+		r.MaxTokens = &tokens
+		return nil
+	}
+}
 
-		mimeType := detectMIMEType(path)
-		encoded := base64.StdEncoding.EncodeToString(data)
+// WithMaxCompletionTokens sets max_completion_tokens for reasoning models.
+// It clears max_tokens (which the client pre-fills from Config), since those
+// models reject requests that carry both.
+func WithMaxCompletionTokens(tokens int) Option {
+	return func(r *Request) error {
+		r.MaxCompletionTokens = &tokens
+		r.MaxTokens = nil
+		return nil
+	}
+}
 
-		if len(r.Messages) == 0 {
-			r.Messages = append(r.Messages, Message{
-				Role:    "user",
-				Content: []ContentPart{},
-			})
-		}
+// WithMinP sets min_p sampling for gateways that support it, such as vLLM and
+// llama.cpp.
+func WithMinP(minP float64) Option {
+	return func(r *Request) error {
+		r.MinP = &minP
+		return nil
+	}
+}
 
-		last := &r.Messages[len(r.Messages)-1]
-		last.Content = append(last.Content, ContentPart{
-			Type: "image_url",
-			ImageURL: &ImageURLData{
-				URL: "data:" + mimeType + ";base64," + encoded,
-			},
-		})
+// WithTopK sets top_k sampling for gateways that support it, such as vLLM and
+// llama.cpp.
+func WithTopK(topK int) Option {
+	return func(r *Request) error {
+		r.TopK = &topK
+		return nil
+	}
+}
 
+// WithRepetitionPenalty sets repetition_penalty for self-hosted servers that
+// support it, such as vLLM and llama.cpp. Values above 1.0 discourage
+// repetition; Validate rejects values that are not positive.
+func WithRepetitionPenalty(p float64) Option {
+	return func(r *Request) error {
+		r.RepetitionPenalty = &p
 		return nil
 	}
 }
 
-// WithImageURL attaches an image from a remote URL.
-func WithImageURL(url string) Option {
+// WithGuidedChoice sends guided_choice so gateways with guided decoding,
+// such as vLLM, return exactly one of choices, which is more reliable for
+// classification than asking for it in the prompt. Validate requires at
+// least two non-empty choices.
+func WithGuidedChoice(choices ...string) Option {
 	return func(r *Request) error {
-		if len(r.Messages) == 0 {
-			r.Messages = append(r.Messages, Message{
-				Role:    "user",
-				Content: []ContentPart{},
-			})
-		}
+		r.GuidedChoice = append([]string{}, choices...)
+		return nil
+	}
+}
 
-		last := &r.Messages[len(r.Messages)-1]
-		last.Content = append(last.Content, ContentPart{
-			Type: "image_url",
-			ImageURL: &ImageURLData{
-				URL: url,
-			},
-		})
+// WithEcho asks completion-style gateways to echo the prompt back with the
+// output, e.g. for evaluation pipelines. Validate rejects echo on streaming
+// requests, which some backends don't support.
+func WithEcho(echo bool) Option {
+	return func(r *Request) error {
+		r.Echo = &echo
+		return nil
+	}
+}
 
+// WithRoutingHint sets routing_hint in the request body for gateways that
+// inspect the JSON rather than headers to pick a backend. An empty hint
+// clears an earlier one.
+func WithRoutingHint(hint string) Option {
+	return func(r *Request) error {
+		r.RoutingHint = hint
 		return nil
 	}
 }
 
-// WithImageBytes attaches an image from raw bytes (SDK encodes automatically).
-func WithImageBytes(data []byte, mimeType string) Option {
+// WithCacheKey sets prompt_cache_key in the request body so gateways that
+// keep KV caches can reuse one across calls sharing a system prompt.
+// Providers that don't support it ignore the field. An empty key clears an
+// earlier one.
+//
+// Derive the key from the shared prefix rather than per call, typically a
+// hex SHA-256 of the system prompt (plus the model, if calls sharing a prompt
+// go to different models), so identical prompts map to the same key:
+//
+//	sum := sha256.Sum256([]byte(model + "\x00" + systemPrompt))
+//	ai.WithCacheKey(hex.EncodeToString(sum[:]))
+func WithCacheKey(key string) Option {
 	return func(r *Request) error {
-		if len(data) == 0 {
-			return nil
-		}
+		r.CacheKey = key
+		return nil
+	}
+}
 
-		encoded := base64.StdEncoding.EncodeToString(data)
+// Truncation strategies accepted by WithTruncation.
+const (
+	TruncationAuto     = "auto"
+	TruncationDisabled = "disabled"
+)
 
-		if len(r.Messages) == 0 {
-			r.Messages = append(r.Messages, Message{
-				Role:    "user",
-				Content: []ContentPart{},
-			})
+// validateTruncation reports whether strategy is empty or a known strategy.
+func validateTruncation(strategy string) error {
+	switch strategy {
+	case "", TruncationAuto, TruncationDisabled:
+		return nil
+	default:
+		return fmt.Errorf("invalid truncation %q: must be one of %q, %q", strategy, TruncationAuto, TruncationDisabled)
+	}
+}
+
+// WithTruncation sets the truncation strategy for gateways that can trim long
+// conversations themselves. Providers that don't support it ignore the field.
+func WithTruncation(strategy string) Option {
+	return func(r *Request) error {
+		if err := validateTruncation(strategy); err != nil {
+			return err
 		}
+		r.Truncation = strategy
+		return nil
+	}
+}
 
-		last := &r.Messages[len(r.Messages)-1]
-		last.Content = append(last.Content, ContentPart{
-			Type: "image_url",
-			ImageURL: &ImageURLData{
-				URL: "data:" + mimeType + ";base64," + encoded,
-			},
-		})
+// WithExtraBody adds a provider-specific field to the top-level request body.
+// The value is marshaled to JSON; keys that collide with known request fields
+// are rejected so the escape hatch can't silently override typed options.
+func WithExtraBody(key string, value interface{}) Option {
+	return func(r *Request) error {
+		if key == "" {
+			return fmt.Errorf("extra body key cannot be empty")
+		}
+		if _, known := requestFieldNames[key]; known {
+			return fmt.Errorf("extra body field %q collides with a known request field", key)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal extra body field %q: %w", key, err)
+		}
+		if r.ExtraBody == nil {
+			r.ExtraBody = make(map[string]json.RawMessage)
+		}
+		r.ExtraBody[key] = data
+		return nil
+	}
+}
 
+// WithRoleMapping rewrites message roles when the request is sent, for
+// gateways whose models disagree on role names (e.g. {"system": "developer"}).
+// The mapping is copied and merged with any earlier mapping; Validate rejects
+// targets that are not known roles.
+func WithRoleMapping(mapping map[string]string) Option {
+	return func(r *Request) error {
+		if r.RoleMapping == nil {
+			r.RoleMapping = make(map[string]string, len(mapping))
+		}
+		for from, to := range mapping {
+			r.RoleMapping[from] = to
+		}
 		return nil
 	}
 }
 
-// structToJSONSchema converts a Go struct to a JSON schema.
-// This is a simplified version - you may want to use a library like
-// github.com/invopop/jsonschema for production.
-func structToJSONSchema(v interface{}) (map[string]interface{}, string, error) {
-	t := reflect.TypeOf(v)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+// restrictedHeaders are set by the client from its config and the JSON body,
+// so requests may not override them.
+var restrictedHeaders = map[string]struct{}{
+	"Authorization": {},
+	"Content-Type":  {},
+}
+
+func validateHeaderKey(key string) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("header name cannot be empty")
 	}
-	if t.Kind() != reflect.Struct {
-		return nil, "", fmt.Errorf("schema must be a struct, got %v", t.Kind())
+	if _, restricted := restrictedHeaders[http.CanonicalHeaderKey(key)]; restricted {
+		return fmt.Errorf("header %q is set by the client and cannot be overridden; use WithAPIKey for per-request credentials", key)
 	}
+	return nil
+}
 
-	schemaName := t.Name()
-	if schemaName == "" {
-		schemaName = "response"
+// WithHeader adds an HTTP header to this request, e.g. a gateway routing
+// header such as X-Model-Pool. Keys are canonicalized, so a later call with
+// the same name in different case replaces the earlier value. Authorization
+// and Content-Type are rejected.
+func WithHeader(key, value string) Option {
+	return func(r *Request) error {
+		if err := validateHeaderKey(key); err != nil {
+			return err
+		}
+		if r.Headers == nil {
+			r.Headers = make(map[string]string)
+		}
+		r.Headers[http.CanonicalHeaderKey(key)] = value
+		return nil
 	}
+}
 
-	properties := make(map[string]interface{})
-	required := []string{}
-
-	for i := 0; i < t.NumField(); i++ {
+// WithIdempotencyKey sends key as the Idempotency-Key header. Retrying with
+// the same Request resends the same key. The SDK only plumbs the key; the
+// gateway must honor it for retries to be safe. It takes precedence over an
+// Idempotency-Key set with WithHeader.
+func WithIdempotencyKey(key string) Option {
+	return func(r *Request) error {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("idempotency key cannot be empty")
+		}
+		r.IdempotencyKey = key
+		return nil
+	}
+}
+
+// StreamOptions configures streaming responses.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// WithStreamUsage asks the provider to send token usage, including cached
+// prompt tokens, on the final chunk of a streaming response. Non-streaming
+// responses always carry usage.
+func WithStreamUsage() Option {
+	return func(r *Request) error {
+		r.StreamOptions = &StreamOptions{IncludeUsage: true}
+		return nil
+	}
+}
+
+// ThinkingConfig configures Anthropic extended thinking.
+type ThinkingConfig struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// AudioOutputConfig selects the voice and encoding of audio output.
+type AudioOutputConfig struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
+}
+
+// WithModalities sets the output modalities, e.g. WithModalities("text", "audio").
+// Requesting "audio" also requires WithAudioOutput.
+func WithModalities(modalities ...string) Option {
+	return func(r *Request) error {
+		r.Modalities = append([]string(nil), modalities...)
+		return nil
+	}
+}
+
+// WithAudioOutput sets the voice (e.g. "alloy") and format (e.g. "wav" or
+// "mp3") of audio output.
+func WithAudioOutput(voice, format string) Option {
+	return func(r *Request) error {
+		if voice == "" || format == "" {
+			return fmt.Errorf("audio output needs both a voice and a format")
+		}
+		r.Audio = &AudioOutputConfig{Voice: voice, Format: format}
+		return nil
+	}
+}
+
+// SafetySetting is a Gemini safety threshold for one harm category.
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// validSafetyCategories and validSafetyThresholds list the Gemini enum values.
+// A mistyped category would otherwise leave that category unfiltered.
+var validSafetyCategories = map[string]struct{}{
+	"HARM_CATEGORY_HARASSMENT":        {},
+	"HARM_CATEGORY_HATE_SPEECH":       {},
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT": {},
+	"HARM_CATEGORY_DANGEROUS_CONTENT": {},
+	"HARM_CATEGORY_CIVIC_INTEGRITY":   {},
+}
+
+var validSafetyThresholds = map[string]struct{}{
+	"HARM_BLOCK_THRESHOLD_UNSPECIFIED": {},
+	"BLOCK_LOW_AND_ABOVE":              {},
+	"BLOCK_MEDIUM_AND_ABOVE":           {},
+	"BLOCK_ONLY_HIGH":                  {},
+	"BLOCK_NONE":                       {},
+	"OFF":                              {},
+}
+
+func (s SafetySetting) validate() error {
+	if _, ok := validSafetyCategories[s.Category]; !ok {
+		return fmt.Errorf("unknown safety category %q", s.Category)
+	}
+	if _, ok := validSafetyThresholds[s.Threshold]; !ok {
+		return fmt.Errorf("unknown safety threshold %q for %s", s.Threshold, s.Category)
+	}
+	return nil
+}
+
+// WithSafetySetting appends a Gemini safety threshold for category, such as
+// WithSafetySetting("HARM_CATEGORY_HARASSMENT", "BLOCK_ONLY_HIGH"). Unknown
+// categories and thresholds are rejected.
+func WithSafetySetting(category, threshold string) Option {
+	return func(r *Request) error {
+		setting := SafetySetting{Category: category, Threshold: threshold}
+		if err := setting.validate(); err != nil {
+			return err
+		}
+		r.SafetySettings = append(r.SafetySettings, setting)
+		return nil
+	}
+}
+
+// WithThinking enables extended thinking with the given token budget. The
+// budget must stay below max_tokens, which caps thinking and output together.
+func WithThinking(budgetTokens int) Option {
+	return func(r *Request) error {
+		r.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: budgetTokens}
+		return nil
+	}
+}
+
+// WithStream enables streaming responses.
+func WithStream() Option {
+	return func(r *Request) error {
+		r.Stream = true
+		return nil
+	}
+}
+
+// WithJSONMode enables JSON object mode (non-strict).
+func WithJSONMode() Option {
+	return func(r *Request) error {
+		r.ResponseFormat = &ResponseFormat{
+			Type: "json_object",
+		}
+		return nil
+	}
+}
+
+// WithTextMode requests plain text output, replacing any JSON mode or schema
+// set earlier, so a request built from a structured template can opt out.
+func WithTextMode() Option {
+	return func(r *Request) error {
+		r.ResponseFormat = &ResponseFormat{
+			Type: "text",
+		}
+		return nil
+	}
+}
+
+// WithSchema enables structured output with a JSON schema.
+// Accepts either a Go struct (will be converted to JSON schema) or json.RawMessage.
+// Struct fields tagged `nullable:"true"` are emitted as a union with "null".
+func WithSchema(schema interface{}) Option {
+	return withSchema(schema, schemaOptions{})
+}
+
+// WithSchemaFile reads a JSON schema from path and uses it as WithSchema
+// would use a json.RawMessage. The file must contain valid JSON.
+func WithSchemaFile(path string) Option {
+	return func(r *Request) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read schema file: %w", err)
+		}
+		var probe interface{}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return fmt.Errorf("schema file %s is not valid JSON: %w", path, err)
+		}
+		return WithSchema(json.RawMessage(data))(r)
+	}
+}
+
+// WithNullableSchema behaves like WithSchema but also treats every pointer
+// field of a Go struct as nullable, so the model must return the key but may
+// set it to null.
+func WithNullableSchema(schema interface{}) Option {
+	return withSchema(schema, schemaOptions{nullablePointers: true})
+}
+
+// WithNamedSchema behaves like WithSchema but uses name as the schema name
+// instead of deriving it from the type, which is useful for anonymous structs
+// and map-based schemas. Characters other than letters, digits, '_' and '-'
+// are replaced with '_' and the result is truncated to 64 characters.
+func WithNamedSchema(name string, schema interface{}) Option {
+	return func(r *Request) error {
+		schemaName := sanitizeSchemaName(name)
+		if schemaName == "" {
+			return fmt.Errorf("schema name cannot be empty")
+		}
+		if err := withSchema(schema, schemaOptions{})(r); err != nil {
+			return err
+		}
+		r.ResponseFormat.JSONSchema.Name = schemaName
+		return nil
+	}
+}
+
+// WithSchemaVersion tags the schema set by an earlier WithSchema or
+// WithNamedSchema with a version. Response.SchemaVersion reports the version
+// the output was produced against, so callers can reject responses generated
+// from a stale schema.
+func WithSchemaVersion(version string) Option {
+	return func(r *Request) error {
+		if strings.TrimSpace(version) == "" {
+			return fmt.Errorf("schema version cannot be empty")
+		}
+		if r.ResponseFormat == nil || r.ResponseFormat.JSONSchema == nil {
+			return fmt.Errorf("schema version requires a schema; apply WithSchema first")
+		}
+		r.ResponseFormat.JSONSchema.Version = version
+		return nil
+	}
+}
+
+// maxSchemaNameLength is the longest schema name providers accept.
+const maxSchemaNameLength = 64
+
+// sanitizeSchemaName maps name onto the [a-zA-Z0-9_-] alphabet accepted for
+// json_schema names.
+func sanitizeSchemaName(name string) string {
+	name = strings.TrimSpace(name)
+	var b strings.Builder
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+		if b.Len() >= maxSchemaNameLength {
+			break
+		}
+	}
+	return b.String()
+}
+
+func withSchema(schema interface{}, opts schemaOptions) Option {
+	return func(r *Request) error {
+		schemaBytes, schemaName, err := encodeSchema(schema, opts)
+		if err != nil {
+			return err
+		}
+
+		r.ResponseFormat = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchema{
+				Name:   schemaName,
+				Strict: true,
+				Schema: schemaBytes,
+			},
+		}
+		return nil
+	}
+}
+
+// encodeSchema returns schema as JSON with its name. Raw JSON, strings and
+// maps are used as-is under the name "response"; anything else is converted
+// from a Go struct.
+func encodeSchema(schema interface{}, opts schemaOptions) (json.RawMessage, string, error) {
+	switch v := schema.(type) {
+	case json.RawMessage:
+		return v, "response", nil
+	case []byte:
+		return json.RawMessage(v), "response", nil
+	case string:
+		return json.RawMessage(v), "response", nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", fmt.Errorf("marshal schema: %w", err)
+		}
+		return data, "response", nil
+	default:
+		// Convert Go struct to JSON schema
+		return schemaOf(v, opts)
+	}
+}
+
+// Tool declares a function the model may call.
+type Tool struct {
+	Type     string      `json:"type"`
+	Function FunctionDef `json:"function"`
+}
+
+// FunctionDef describes a callable function and its JSON schema parameters.
+type FunctionDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+
+	// Strict asks providers that support it to guarantee the arguments match
+	// Parameters exactly. Struct-derived schemas already disallow extra
+	// properties and require every field without omitempty.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// WithTool declares a function tool. params may be a Go struct, converted as
+// WithSchema would, or raw JSON schema; nil declares a tool without
+// parameters. The tool is strict if WithStrictTools(true) was applied
+// earlier.
+func WithTool(name, description string, params interface{}) Option {
+	return func(r *Request) error {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("tool name cannot be empty")
+		}
+		def, err := newFunctionDef(name, description, params)
+		if err != nil {
+			return fmt.Errorf("tool %q parameters: %w", name, err)
+		}
+		def.Strict = r.StrictTools
+		r.Tools = append(r.Tools, Tool{Type: "function", Function: def})
+		return nil
+	}
+}
+
+// newFunctionDef builds a function definition, converting params as
+// WithSchema would.
+func newFunctionDef(name, description string, params interface{}) (FunctionDef, error) {
+	def := FunctionDef{Name: name, Description: description}
+	if params != nil {
+		schema, _, err := encodeSchema(params, schemaOptions{})
+		if err != nil {
+			return FunctionDef{}, err
+		}
+		def.Parameters = schema
+	}
+	return def, nil
+}
+
+// FunctionSpec declares a function for WithFunctions. Params is converted as
+// in WithTool.
+type FunctionSpec struct {
+	Name        string
+	Description string
+	Params      interface{}
+}
+
+// WithFunctions declares functions using the legacy functions field, for
+// gateways that don't accept tools. Prefer WithTool where tools are
+// supported; Validate rejects requests that mix the two.
+func WithFunctions(functions ...FunctionSpec) Option {
+	return func(r *Request) error {
+		for _, fn := range functions {
+			if strings.TrimSpace(fn.Name) == "" {
+				return fmt.Errorf("function name cannot be empty")
+			}
+			def, err := newFunctionDef(fn.Name, fn.Description, fn.Params)
+			if err != nil {
+				return fmt.Errorf("function %q parameters: %w", fn.Name, err)
+			}
+			r.Functions = append(r.Functions, def)
+		}
+		return nil
+	}
+}
+
+// WithFunctionCall sets the legacy function_call field: "auto", "none", or
+// the name of a function declared with WithFunctions to force that call.
+func WithFunctionCall(choice string) Option {
+	return func(r *Request) error {
+		var value interface{}
+		switch choice {
+		case "":
+			return fmt.Errorf("function call choice cannot be empty")
+		case "auto", "none":
+			value = choice
+		default:
+			value = map[string]string{"name": choice}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal function call: %w", err)
+		}
+		r.FunctionCall = data
+		return nil
+	}
+}
+
+// validateFunctions checks the legacy function-calling fields.
+func (r *Request) validateFunctions() error {
+	if len(r.Tools) > 0 && (len(r.Functions) > 0 || len(r.FunctionCall) > 0) {
+		return fmt.Errorf("functions and function_call cannot be combined with tools; use WithTool or WithFunctions, not both")
+	}
+	var named struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(r.FunctionCall, &named) != nil || named.Name == "" {
+		return nil
+	}
+	for _, fn := range r.Functions {
+		if fn.Name == named.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("function_call names %q, which is not a declared function", named.Name)
+}
+
+// Tool choices accepted by WithToolChoice besides the name of a tool.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// WithToolChoice sets tool_choice: ToolChoiceAuto lets the model decide,
+// ToolChoiceNone forbids tool calls, ToolChoiceRequired forces some tool
+// call, and the name of a tool declared with WithTool forces a call to it.
+func WithToolChoice(choice string) Option {
+	return func(r *Request) error {
+		var value interface{}
+		switch choice {
+		case "":
+			return fmt.Errorf("tool choice cannot be empty")
+		case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+			value = choice
+		default:
+			value = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": choice},
+			}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal tool choice: %w", err)
+		}
+		r.ToolChoice = data
+		return nil
+	}
+}
+
+// validateToolChoice checks that tool_choice is only sent with tools and
+// that a named choice refers to a declared tool.
+func (r *Request) validateToolChoice() error {
+	if len(r.ToolChoice) == 0 {
+		return nil
+	}
+	if len(r.Tools) == 0 {
+		return fmt.Errorf("tool_choice requires at least one tool; declare tools with WithTool")
+	}
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if json.Unmarshal(r.ToolChoice, &named) != nil || named.Function.Name == "" {
+		return nil
+	}
+	for _, tool := range r.Tools {
+		if tool.Function.Name == named.Function.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("tool_choice names %q, which is not a declared tool", named.Function.Name)
+}
+
+// WithStrictTools sets the strict flag on every tool declared so far and on
+// tools declared by later WithTool options.
+func WithStrictTools(strict bool) Option {
+	return func(r *Request) error {
+		r.StrictTools = strict
+		for i := range r.Tools {
+			r.Tools[i].Function.Strict = strict
+		}
+		return nil
+	}
+}
+
+// WithStopAfterTool makes StreamComplete close its channel once the model has
+// finished streaming a call to the named tool, so an agent loop can act on a
+// terminal tool without waiting for, or paying for, further output. It may be
+// repeated to stop on any of several tools. Non-streaming calls ignore it,
+// since the full response arrives at once.
+func WithStopAfterTool(name string) Option {
+	return func(r *Request) error {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("stop tool name cannot be empty")
+		}
+		r.StopTools = append(r.StopTools, name)
+		return nil
+	}
+}
+
+// WithToolResult appends a tool message answering the tool call identified by toolCallID.
+func WithToolResult(toolCallID string, content string) Option {
+	return func(r *Request) error {
+		if toolCallID == "" {
+			return fmt.Errorf("tool call ID is required")
+		}
+		r.Messages = append(r.Messages, Message{
+			Role:       "tool",
+			Content:    []ContentPart{{Type: "text", Text: content}},
+			ToolCallID: toolCallID,
+		})
+		return nil
+	}
+}
+
+// WithToolResultJSON marshals v to JSON and appends it as a tool message
+// answering the tool call identified by toolCallID.
+func WithToolResultJSON(toolCallID string, v interface{}) Option {
+	return func(r *Request) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshal tool result: %w", err)
+		}
+		return WithToolResult(toolCallID, string(data))(r)
+	}
+}
+
+// WithToolResultImage appends a tool message whose content is an image, for
+// tools that return visual output such as rendered charts. The image is sent
+// inline as a base64 data URL and is subject to MaxImageBytes.
+func WithToolResultImage(toolCallID string, data []byte, mimeType string) Option {
+	return func(r *Request) error {
+		if toolCallID == "" {
+			return fmt.Errorf("tool call ID is required")
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("tool result image is empty")
+		}
+		if !strings.HasPrefix(mimeType, "image/") {
+			return fmt.Errorf("tool result image MIME type must be image/*, got %q", mimeType)
+		}
+		if err := checkImageSize(len(data)); err != nil {
+			return err
+		}
+
+		r.Messages = append(r.Messages, Message{
+			Role: "tool",
+			Content: []ContentPart{{
+				Type: "image_url",
+				ImageURL: &ImageURLData{
+					URL:    "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+					Detail: DefaultImageDetail,
+				},
+			}},
+			ToolCallID: toolCallID,
+		})
+		return nil
+	}
+}
+
+// WithMessagesJSON replaces the request's messages with a conversation
+// previously serialized as a JSON []Message. Content may be either a plain
+// string or an array of parts, so history stored via Message.MarshalJSON
+// round-trips with its image parts intact.
+func WithMessagesJSON(data []byte) Option {
+	return func(r *Request) error {
+		var messages []Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("decode messages JSON: %w", err)
+		}
+		r.Messages = messages
+		return nil
+	}
+}
+
+// Image detail levels accepted by vision models.
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// DefaultImageDetail is applied to every image attached by WithImageFile,
+// WithImageURL, and WithImageBytes. Set it to "" to let the provider choose,
+// or override a single image with WithImageDetail.
+var DefaultImageDetail = ImageDetailAuto
+
+// validateImageDetail reports whether detail is empty or a supported level.
+func validateImageDetail(detail string) error {
+	switch detail {
+	case "", ImageDetailAuto, ImageDetailLow, ImageDetailHigh:
+		return nil
+	default:
+		return fmt.Errorf("invalid image detail %q: must be one of %q, %q, %q", detail, ImageDetailAuto, ImageDetailLow, ImageDetailHigh)
+	}
+}
+
+// appendImagePart adds an image content part to the last message, creating a
+// user message if none exists, with DefaultImageDetail applied.
+func appendImagePart(r *Request, url string) error {
+	if err := validateImageDetail(DefaultImageDetail); err != nil {
+		return err
+	}
+
+	if len(r.Messages) == 0 {
+		r.Messages = append(r.Messages, Message{
+			Role:    "user",
+			Content: []ContentPart{},
+		})
+	}
+
+	last := &r.Messages[len(r.Messages)-1]
+	last.Content = append(last.Content, ContentPart{
+		Type: "image_url",
+		ImageURL: &ImageURLData{
+			URL:    url,
+			Detail: DefaultImageDetail,
+		},
+	})
+
+	return nil
+}
+
+// Image options
+func WithImageFile(path string) Option {
+	return func(r *Request) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read image file: %w", err)
+		}
+		return appendImageFile(r, path, data)
+	}
+}
+
+// WithImageFileCtx is WithImageFile with a context. If ctx is cancelled or
+// its deadline passes before the file has been read, the option returns the
+// context error instead of waiting on the read, which keeps a hung network
+// filesystem from blocking past a handler's deadline. The abandoned read
+// finishes in the background.
+func WithImageFileCtx(ctx context.Context, path string) Option {
+	return func(r *Request) error {
+		data, err := readFileCtx(ctx, path)
+		if err != nil {
+			return err
+		}
+		return appendImageFile(r, path, data)
+	}
+}
+
+// readImageFile reads files for readFileCtx; tests swap it to simulate a hung read.
+var readImageFile = os.ReadFile
+
+// readFileCtx reads path, giving up when ctx is done.
+func readFileCtx(ctx context.Context, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	read := readImageFile
+	go func() {
+		data, err := read(path)
+		done <- result{data, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("read image file: %w", res.err)
+		}
+		return res.data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// appendImageFile attaches image data read from path as a data URL.
+func appendImageFile(r *Request, path string, data []byte) error {
+	if err := checkImageSize(len(data)); err != nil {
+		return err
+	}
+
+	mimeType := detectMIMEType(path)
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	return appendImagePart(r, "data:"+mimeType+";base64,"+encoded)
+}
+
+// WithImageFileResized attaches an image from a local file, scaling it down
+// so its longest side is at most maxDimension pixels. JPEG sources are
+// re-encoded as JPEG and PNG/GIF sources as PNG. Formats that can't be decoded
+// (such as WebP) are attached unchanged and a warning is logged.
+func WithImageFileResized(path string, maxDimension int) Option {
+	return func(r *Request) error {
+		if maxDimension <= 0 {
+			return fmt.Errorf("max dimension must be positive, got %d", maxDimension)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read image file: %w", err)
+		}
+
+		mimeType := detectMIMEType(path)
+		resized, resizedType, err := resizeImage(data, maxDimension)
+		switch {
+		case err == nil:
+			data, mimeType = resized, resizedType
+		case errors.Is(err, image.ErrFormat):
+			log.Printf("[ai] WARNING: cannot resize %s (unsupported format); attaching original", path)
+		default:
+			return fmt.Errorf("resize image: %w", err)
+		}
+
+		if err := checkImageSize(len(data)); err != nil {
+			return err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(data)
+		return appendImagePart(r, "data:"+mimeType+";base64,"+encoded)
+	}
+}
+
+// WithImageURL attaches an image from a remote URL.
+func WithImageURL(url string) Option {
+	return func(r *Request) error {
+		return appendImagePart(r, url)
+	}
+}
+
+// WithImageURLFetched downloads the image at url and attaches it as a data
+// URL, for URLs the provider can't reach itself, such as hosts on a private
+// network. The download is bounded by ImageFetchTimeout and MaxImageBytes.
+func WithImageURLFetched(url string) Option {
+	return func(r *Request) error {
+		data, mimeType, err := fetchImage(url)
+		if err != nil {
+			return err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(data)
+		return appendImagePart(r, "data:"+mimeType+";base64,"+encoded)
+	}
+}
+
+// WithImageBytes attaches an image from raw bytes (SDK encodes automatically).
+func WithImageBytes(data []byte, mimeType string) Option {
+	return func(r *Request) error {
+		if len(data) == 0 {
+			return nil
+		}
+		if err := checkImageSize(len(data)); err != nil {
+			return err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(data)
+
+		return appendImagePart(r, "data:"+mimeType+";base64,"+encoded)
+	}
+}
+
+// WithDataURL attaches an image from an existing base64 data URL
+// ("data:image/png;base64,...") without decoding and re-encoding it.
+func WithDataURL(dataURL string) Option {
+	return func(r *Request) error {
+		if _, err := parseImageDataURL(dataURL); err != nil {
+			return err
+		}
+		return appendImagePart(r, dataURL)
+	}
+}
+
+// WithImageDetail overrides the detail level of the most recently attached image.
+func WithImageDetail(detail string) Option {
+	return func(r *Request) error {
+		if err := validateImageDetail(detail); err != nil {
+			return err
+		}
+		for i := len(r.Messages) - 1; i >= 0; i-- {
+			content := r.Messages[i].Content
+			for j := len(content) - 1; j >= 0; j-- {
+				if content[j].ImageURL != nil {
+					content[j].ImageURL.Detail = detail
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no image to apply detail to")
+	}
+}
+
+// WithDocumentFile attaches a local document, such as a PDF, as a file
+// content part. The MIME type is detected from the file extension.
+func WithDocumentFile(path string) Option {
+	return func(r *Request) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read document file: %w", err)
+		}
+		return appendDocumentPart(r, filepath.Base(path), detectMIMEType(path), data)
+	}
+}
+
+// WithDocumentBytes attaches a document from raw bytes (SDK encodes
+// automatically).
+func WithDocumentBytes(data []byte, mimeType string) Option {
+	return func(r *Request) error {
+		if len(data) == 0 {
+			return nil
+		}
+		if mimeType == "" {
+			return fmt.Errorf("document MIME type cannot be empty")
+		}
+		return appendDocumentPart(r, "", mimeType, data)
+	}
+}
+
+// appendDocumentPart adds a file content part to the last message, creating a
+// user message if none exists.
+func appendDocumentPart(r *Request, filename, mimeType string, data []byte) error {
+	if len(r.Messages) == 0 {
+		r.Messages = append(r.Messages, Message{
+			Role:    "user",
+			Content: []ContentPart{},
+		})
+	}
+
+	last := &r.Messages[len(r.Messages)-1]
+	last.Content = append(last.Content, ContentPart{
+		Type: "file",
+		File: &FileData{
+			Filename: filename,
+			FileData: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		},
+	})
+
+	return nil
+}
+
+// schemaOptions controls how Go structs are converted to JSON schema.
+type schemaOptions struct {
+	// nullablePointers marks pointer fields as nullable without a tag.
+	nullablePointers bool
+}
+
+// SchemaOf converts a Go struct, or a pointer to one, to the JSON schema
+// WithSchema sends for it, along with the schema name derived from the type
+// ("response" for anonymous structs). The output is deterministic, so it can
+// be used to document or validate against the exact schema a request uses.
+func SchemaOf(v interface{}) (schema json.RawMessage, name string, err error) {
+	return schemaOf(v, schemaOptions{})
+}
+
+func schemaOf(v interface{}, opts schemaOptions) (json.RawMessage, string, error) {
+	schemaMap, name, err := structToJSONSchemaWithOptions(v, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("convert schema: %w", err)
+	}
+	// json.Marshal writes map keys in sorted order, so the same struct
+	// always yields the same schema bytes.
+	data, err := json.Marshal(schemaMap)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal schema: %w", err)
+	}
+	return data, name, nil
+}
+
+// structToJSONSchema converts a Go struct to a JSON schema.
+// This is a simplified version - you may want to use a library like
+// github.com/invopop/jsonschema for production.
+func structToJSONSchema(v interface{}) (map[string]interface{}, string, error) {
+	return structToJSONSchemaWithOptions(v, schemaOptions{})
+}
+
+// structToJSONSchemaWithOptions converts a Go struct to a JSON schema.
+// Nullable fields get a ["<type>", "null"] type and are always required, since
+// strict structured output needs the key present even when its value is null.
+func structToJSONSchemaWithOptions(v interface{}, opts schemaOptions) (map[string]interface{}, string, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, "", fmt.Errorf("schema must be a struct, got nil")
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, "", fmt.Errorf("schema must be a struct, got %v", t.Kind())
+	}
+
+	schemaName := t.Name()
+	if schemaName == "" {
+		schemaName = "response"
+	}
+
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		jsonTag := field.Tag.Get("json")
 		if jsonTag == "" || jsonTag == "-" {
@@ -339,7 +1685,12 @@ func structToJSONSchema(v interface{}) (map[string]interface{}, string, error) {
 
 		// Build property schema
 		prop := make(map[string]interface{})
-		prop["type"] = goTypeToJSONType(field.Type)
+		if isNullableField(field, opts) {
+			prop["type"] = []string{goTypeToJSONType(field.Type), "null"}
+			isRequired = true
+		} else {
+			prop["type"] = goTypeToJSONType(field.Type)
+		}
 
 		// Add description from struct tag if present
 		if desc := field.Tag.Get("description"); desc != "" {
@@ -363,6 +1714,14 @@ func structToJSONSchema(v interface{}) (map[string]interface{}, string, error) {
 	return schema, schemaName, nil
 }
 
+// isNullableField reports whether a struct field should accept null.
+func isNullableField(field reflect.StructField, opts schemaOptions) bool {
+	if nullable, ok := field.Tag.Lookup("nullable"); ok {
+		return nullable == "true"
+	}
+	return opts.nullablePointers && field.Type.Kind() == reflect.Ptr
+}
+
 // goTypeToJSONType converts Go types to JSON schema types.
 func goTypeToJSONType(t reflect.Type) string {
 	if t.Kind() == reflect.Ptr {
@@ -1,12 +1,28 @@
 package ai
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWithSystem(t *testing.T) {
@@ -38,6 +54,37 @@ func TestWithSystem(t *testing.T) {
 	assert.Equal(t, "Hello", userMsg.Content[0].Text)
 }
 
+func TestWithAssistantPrefix(t *testing.T) {
+	req := &Request{Messages: []Message{{Role: "user", Content: []ContentPart{{Type: "text", Text: "Give me JSON"}}}}}
+	assert.NoError(t, WithSystem("Reply in JSON")(req))
+	assert.NoError(t, WithAssistantPrefix("{")(req))
+	assert.NoError(t, req.Validate())
+	assert.Len(t, req.Messages, 3)
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `{"role":"assistant","content":"{"}]`)
+	assert.NotContains(t, string(data), "prefill")
+
+	assert.Error(t, WithAssistantPrefix("")(&Request{}))
+
+	// A second prefill, or one followed by another message, is rejected
+	twice := &Request{}
+	assert.NoError(t, WithAssistantPrefix("{")(twice))
+	assert.NoError(t, WithAssistantPrefix("[")(twice))
+	assert.Error(t, twice.Validate())
+
+	notLast := &Request{}
+	assert.NoError(t, WithAssistantPrefix("{")(notLast))
+	notLast.Messages = append(notLast.Messages, Message{Role: "user", Content: []ContentPart{{Type: "text", Text: "more"}}})
+	assert.Error(t, notLast.Validate())
+
+	withImage := &Request{}
+	assert.NoError(t, WithAssistantPrefix("{")(withImage))
+	assert.NoError(t, WithImageURL("https://example.com/cat.png")(withImage))
+	assert.Error(t, withImage.Validate())
+}
+
 func TestWithModel(t *testing.T) {
 	req := &Request{}
 
@@ -56,6 +103,125 @@ func TestWithTemperature(t *testing.T) {
 	assert.Equal(t, temp, *req.Temperature)
 }
 
+func TestWithTopP(t *testing.T) {
+	req := &Request{}
+
+	p := 0.9
+	err := WithTopP(p)(req)
+	assert.NoError(t, err)
+	assert.NotNil(t, req.TopP)
+	assert.Equal(t, p, *req.TopP)
+
+	for _, valid := range []float64{0, 1} {
+		assert.NoError(t, WithTopP(valid)(&Request{}), valid)
+	}
+	for _, invalid := range []float64{-0.1, 1.1, math.NaN()} {
+		req := &Request{}
+		assert.Error(t, WithTopP(invalid)(req), invalid)
+		assert.Nil(t, req.TopP)
+	}
+}
+
+func TestWithSeed(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithSeed(42)(req))
+	assert.NotNil(t, req.Seed)
+	assert.Equal(t, 42, *req.Seed)
+
+	// A zero temperature must still be sent alongside the seed.
+	assert.NoError(t, WithTemperature(0)(req))
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+
+	var decoded Request
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	if assert.NotNil(t, decoded.Seed) {
+		assert.Equal(t, 42, *decoded.Seed)
+	}
+	if assert.NotNil(t, decoded.Temperature) {
+		assert.Equal(t, 0.0, *decoded.Temperature)
+	}
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "seed")
+}
+
+func TestWithPenalties(t *testing.T) {
+	tests := []struct {
+		name   string
+		option func(float64) Option
+		field  func(*Request) *float64
+		key    string
+	}{
+		{"frequency", WithFrequencyPenalty, func(r *Request) *float64 { return r.FrequencyPenalty }, "frequency_penalty"},
+		{"presence", WithPresencePenalty, func(r *Request) *float64 { return r.PresencePenalty }, "presence_penalty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{}
+			assert.NoError(t, tt.option(0.5)(req))
+			assert.NotNil(t, tt.field(req))
+			assert.Equal(t, 0.5, *tt.field(req))
+
+			data, err := json.Marshal(req)
+			assert.NoError(t, err)
+			assert.Contains(t, string(data), `"`+tt.key+`":0.5`)
+
+			data, err = json.Marshal(&Request{})
+			assert.NoError(t, err)
+			assert.NotContains(t, string(data), tt.key)
+
+			for _, valid := range []float64{MinPenalty, 0, MaxPenalty} {
+				assert.NoError(t, tt.option(valid)(&Request{}), valid)
+			}
+			for _, invalid := range []float64{-2.1, 2.5, math.NaN()} {
+				req := &Request{}
+				assert.Error(t, tt.option(invalid)(req), invalid)
+				assert.Nil(t, tt.field(req))
+			}
+		})
+	}
+}
+
+func TestWithTemperatureClamped(t *testing.T) {
+	tests := []struct {
+		name        string
+		temp        float64
+		want        float64
+		wantClamped bool
+	}{
+		{"in range", 0.7, 0.7, false},
+		{"upper bound", 2.0, 2.0, false},
+		{"too high", 5.0, MaxTemperature, true},
+		{"negative", -0.5, MinTemperature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{}
+			err := WithTemperatureClamped(tt.temp)(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *req.Temperature)
+			assert.Equal(t, tt.wantClamped, req.TemperatureClamped)
+		})
+	}
+
+	assert.Error(t, WithTemperatureClamped(math.NaN())(&Request{}))
+
+	// WithTemperature stays passthrough and clears the flag.
+	req := &Request{}
+	assert.NoError(t, WithTemperatureClamped(9)(req))
+	assert.NoError(t, WithTemperature(5.0)(req))
+	assert.Equal(t, 5.0, *req.Temperature)
+	assert.False(t, req.TemperatureClamped)
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "clamped")
+}
+
 func TestWithMaxTokens(t *testing.T) {
 	req := &Request{}
 
@@ -66,6 +232,220 @@ func TestWithMaxTokens(t *testing.T) {
 	assert.Equal(t, tokens, *req.MaxTokens)
 }
 
+func TestWithMaxCompletionTokens(t *testing.T) {
+	defaultTokens := 4096
+	req := &Request{MaxTokens: &defaultTokens}
+
+	err := WithMaxCompletionTokens(8000)(req)
+	assert.NoError(t, err)
+	assert.Nil(t, req.MaxTokens)
+	assert.NotNil(t, req.MaxCompletionTokens)
+	assert.Equal(t, 8000, *req.MaxCompletionTokens)
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"max_completion_tokens":8000`)
+	assert.NotContains(t, string(data), `"max_tokens"`)
+}
+
+func TestRequestValidate_MaxTokensConflict(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithMaxCompletionTokens(8000)(req))
+	assert.NoError(t, WithMaxTokens(1000)(req))
+
+	err := req.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mutually exclusive")
+
+	negative := -1
+	assert.Error(t, (&Request{MaxTokens: &negative}).Validate())
+	assert.Error(t, (&Request{MaxCompletionTokens: &negative}).Validate())
+	assert.NoError(t, (&Request{}).Validate())
+}
+
+func TestWithMinPAndTopK(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithMinP(0.05)(req))
+	assert.NoError(t, WithTopK(40)(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"min_p":0.05`)
+	assert.Contains(t, string(data), `"top_k":40`)
+
+	// Unset, the fields stay out of the body for providers that reject them
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "min_p")
+	assert.NotContains(t, string(data), "top_k")
+
+	negative := -1
+	assert.Error(t, (&Request{TopK: &negative}).Validate())
+	zero := 0
+	assert.NoError(t, (&Request{TopK: &zero}).Validate())
+}
+
+func TestWithRepetitionPenalty(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithRepetitionPenalty(1.1)(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"repetition_penalty":1.1`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "repetition_penalty")
+
+	for _, p := range []float64{0, -0.5, math.NaN()} {
+		req := &Request{}
+		assert.NoError(t, WithRepetitionPenalty(p)(req))
+		assert.Error(t, req.Validate(), p)
+	}
+}
+
+func TestWithGuidedChoice(t *testing.T) {
+	choices := []string{"positive", "negative"}
+	req := &Request{}
+	assert.NoError(t, WithGuidedChoice(choices...)(req))
+	assert.NoError(t, req.Validate())
+	choices[0] = "changed"
+	assert.Equal(t, []string{"positive", "negative"}, req.GuidedChoice)
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"guided_choice":["positive","negative"]`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "guided_choice")
+
+	for _, bad := range [][]string{nil, {"only"}, {"a", ""}} {
+		req := &Request{}
+		assert.NoError(t, WithGuidedChoice(bad...)(req))
+		assert.Error(t, req.Validate(), bad)
+	}
+}
+
+func TestWithTruncation(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithTruncation(TruncationAuto)(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"truncation":"auto"`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "truncation")
+
+	assert.NoError(t, WithTruncation(TruncationDisabled)(req))
+	assert.Equal(t, TruncationDisabled, req.Truncation)
+
+	err = WithTruncation("oldest")(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "oldest")
+	assert.Equal(t, TruncationDisabled, req.Truncation)
+
+	assert.Error(t, (&Request{Truncation: "oldest"}).Validate())
+}
+
+func TestWithEcho(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithEcho(true)(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"echo":true`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "echo")
+
+	assert.NoError(t, WithStream()(req))
+	assert.Error(t, req.Validate())
+	assert.NoError(t, WithEcho(false)(req))
+	assert.NoError(t, req.Validate())
+}
+
+func TestWithRoutingHint(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithRoutingHint("gpu-a100")(req))
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"routing_hint":"gpu-a100"`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "routing_hint")
+
+	assert.Error(t, WithExtraBody("routing_hint", "gpu-a100")(&Request{}))
+}
+
+func TestWithCacheKey(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithCacheKey("3f2a9c")(req))
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"prompt_cache_key":"3f2a9c"`)
+
+	assert.NoError(t, WithCacheKey("")(req))
+	data, err = json.Marshal(req)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "prompt_cache_key")
+
+	assert.Error(t, WithExtraBody("prompt_cache_key", "3f2a9c")(&Request{}))
+}
+
+func TestWithSafetySetting(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithSafetySetting("HARM_CATEGORY_HARASSMENT", "BLOCK_ONLY_HIGH")(req))
+	assert.NoError(t, WithSafetySetting("HARM_CATEGORY_DANGEROUS_CONTENT", "BLOCK_NONE")(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"safety_settings":[{"category":"HARM_CATEGORY_HARASSMENT","threshold":"BLOCK_ONLY_HIGH"},{"category":"HARM_CATEGORY_DANGEROUS_CONTENT","threshold":"BLOCK_NONE"}]`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "safety_settings")
+
+	// Typos are rejected rather than silently disabling the filter
+	assert.ErrorContains(t, WithSafetySetting("HARM_CATEGORY_HARRASSMENT", "BLOCK_ONLY_HIGH")(&Request{}), "unknown safety category")
+	assert.ErrorContains(t, WithSafetySetting("HARM_CATEGORY_HARASSMENT", "BLOCK_HIGH")(&Request{}), "unknown safety threshold")
+	assert.Error(t, (&Request{SafetySettings: []SafetySetting{{Category: "harassment", Threshold: "OFF"}}}).Validate())
+}
+
+func TestWithModalitiesAndAudioOutput(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithModalities("text", "audio")(req))
+	assert.ErrorContains(t, req.Validate(), "audio output config")
+
+	assert.NoError(t, WithAudioOutput("alloy", "wav")(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"modalities":["text","audio"]`)
+	assert.Contains(t, string(data), `"audio":{"voice":"alloy","format":"wav"}`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "modalities")
+	assert.NotContains(t, string(data), `"audio"`)
+
+	assert.NoError(t, (&Request{Modalities: []string{"text"}}).Validate())
+	assert.Error(t, WithAudioOutput("", "wav")(&Request{}))
+}
+
 func TestWithStream(t *testing.T) {
 	req := &Request{}
 
@@ -84,6 +464,39 @@ func TestWithJSONMode(t *testing.T) {
 	assert.Nil(t, req.ResponseFormat.JSONSchema)
 }
 
+func TestWithTextMode(t *testing.T) {
+	type Answer struct {
+		Text string `json:"text"`
+	}
+
+	// A template that asked for structured output can switch back to text
+	template := Request{}
+	assert.NoError(t, WithSchema(Answer{})(&template))
+
+	req := template
+	assert.NoError(t, WithTextMode()(&req))
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "text", req.ResponseFormat.Type)
+	assert.Nil(t, req.ResponseFormat.JSONSchema)
+	assert.Equal(t, "json_schema", template.ResponseFormat.Type)
+
+	data, err := json.Marshal(&req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"response_format":{"type":"text"}`)
+
+	// The last format option wins
+	assert.NoError(t, WithJSONMode()(&req))
+	assert.Equal(t, "json_object", req.ResponseFormat.Type)
+
+	// Mixing text mode with a schema by hand is rejected
+	req.ResponseFormat = &ResponseFormat{Type: "text", JSONSchema: template.ResponseFormat.JSONSchema}
+	assert.ErrorContains(t, req.Validate(), "mutually exclusive")
+	req.ResponseFormat = &ResponseFormat{Type: "json_schema"}
+	assert.Error(t, req.Validate())
+	req.ResponseFormat = &ResponseFormat{Type: "xml"}
+	assert.Error(t, req.Validate())
+}
+
 func TestWithSchema_WithStruct(t *testing.T) {
 	type TestStruct struct {
 		Name  string `json:"name"`
@@ -149,6 +562,28 @@ func TestWithSchema_WithByteSlice(t *testing.T) {
 	assert.NotNil(t, req.ResponseFormat.JSONSchema)
 }
 
+func TestWithSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	schemaJSON := `{"type":"object","properties":{"name":{"type":"string"}}}`
+	path := filepath.Join(dir, "person.schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(schemaJSON), 0o600))
+
+	req := &Request{}
+	require.NoError(t, WithSchemaFile(path)(req))
+	assert.Equal(t, "json_schema", req.ResponseFormat.Type)
+	assert.Equal(t, "response", req.ResponseFormat.JSONSchema.Name)
+	assert.JSONEq(t, schemaJSON, string(req.ResponseFormat.JSONSchema.Schema))
+
+	broken := filepath.Join(dir, "broken.json")
+	require.NoError(t, os.WriteFile(broken, []byte(`{"type":`), 0o600))
+	err := WithSchemaFile(broken)(&Request{})
+	assert.ErrorContains(t, err, broken)
+	assert.ErrorContains(t, err, "not valid JSON")
+
+	err = WithSchemaFile(filepath.Join(dir, "missing.json"))(&Request{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
 func TestWithSchema_InvalidType(t *testing.T) {
 	req := &Request{}
 
@@ -158,6 +593,63 @@ func TestWithSchema_InvalidType(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestWithNamedSchema_AnonymousStruct(t *testing.T) {
+	req := &Request{}
+
+	err := WithNamedSchema("weather report", struct {
+		City string `json:"city"`
+	}{})(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "weather_report", req.ResponseFormat.JSONSchema.Name)
+	assert.True(t, req.ResponseFormat.JSONSchema.Strict)
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(req.ResponseFormat.JSONSchema.Schema, &schema))
+	assert.Contains(t, schema["properties"], "city")
+}
+
+func TestWithNamedSchema_Map(t *testing.T) {
+	req := &Request{}
+
+	err := WithNamedSchema("user-profile", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+	})(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-profile", req.ResponseFormat.JSONSchema.Name)
+	assert.JSONEq(t, `{"type":"object","properties":{"name":{"type":"string"}}}`, string(req.ResponseFormat.JSONSchema.Schema))
+}
+
+func TestWithSchemaVersion(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithNamedSchema("report", `{"type":"object"}`)(req))
+	require.NoError(t, WithSchemaVersion("2024-06")(req))
+
+	data, err := json.Marshal(req.ResponseFormat)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"json_schema","json_schema":{"name":"report","strict":true,"schema":{"type":"object"},"version":"2024-06"}}`, string(data))
+
+	// A later schema replaces the version along with the schema.
+	require.NoError(t, WithSchema(`{"type":"object"}`)(req))
+	assert.Empty(t, req.ResponseFormat.JSONSchema.Version)
+
+	assert.Error(t, WithSchemaVersion("v1")(&Request{}))
+	assert.Error(t, WithSchemaVersion(" ")(req))
+}
+
+func TestWithNamedSchema_Name(t *testing.T) {
+	req := &Request{}
+
+	err := WithNamedSchema(strings.Repeat("a", 80), `{"type":"object"}`)(req)
+	assert.NoError(t, err)
+	assert.Len(t, req.ResponseFormat.JSONSchema.Name, 64)
+
+	err = WithNamedSchema("  ", `{"type":"object"}`)(&Request{})
+	assert.Error(t, err)
+}
+
 func TestWithImageFile(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "test_image_*.jpg")
 	assert.NoError(t, err)
@@ -181,6 +673,45 @@ func TestWithImageFile(t *testing.T) {
 	assert.Contains(t, part.ImageURL.URL, "data:image/jpeg;base64,")
 }
 
+func TestWithImageFileCtx(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(path, []byte{0x89, 'P', 'N', 'G'}, 0o600))
+
+	req := &Request{}
+	require.NoError(t, WithImageFileCtx(context.Background(), path)(req))
+	require.Len(t, req.Messages, 1)
+	assert.Contains(t, req.Messages[0].Content[0].ImageURL.URL, "data:image/png;base64,")
+
+	err := WithImageFileCtx(context.Background(), filepath.Join(t.TempDir(), "missing.png"))(&Request{})
+	assert.ErrorContains(t, err, "read image file")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WithImageFileCtx(cancelled, path)(&Request{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWithImageFileCtx_AbandonsHungRead(t *testing.T) {
+	release := make(chan struct{})
+	original := readImageFile
+	readImageFile = func(string) ([]byte, error) {
+		<-release
+		return nil, errors.New("released")
+	}
+	t.Cleanup(func() {
+		close(release)
+		readImageFile = original
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req := &Request{}
+	err := WithImageFileCtx(ctx, "/mnt/nfs/hung.png")(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, req.Messages)
+}
+
 func TestWithImageURL(t *testing.T) {
 	req := &Request{}
 	testURL := "https://example.com/image.jpg"
@@ -198,6 +729,54 @@ func TestWithImageURL(t *testing.T) {
 	assert.Equal(t, testURL, part.ImageURL.URL)
 }
 
+func TestWithImageURLFetched(t *testing.T) {
+	pngData, err := os.ReadFile(writeTestImage(t, "pixel.png", 2, 2))
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/typed.jpg":
+			w.Header().Set("Content-Type", "image/jpeg; charset=binary")
+			w.Write([]byte("jpeg-bytes"))
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pngData)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write(pngData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	req := &Request{}
+	require.NoError(t, WithImageURLFetched(server.URL+"/typed.jpg")(req))
+	require.NoError(t, WithImageURLFetched(server.URL+"/sniffed")(req))
+	content := req.Messages[0].Content
+	require.Len(t, content, 2)
+	assert.Equal(t, "data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString([]byte("jpeg-bytes")), content[0].ImageURL.URL)
+	assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(pngData), content[1].ImageURL.URL)
+
+	assert.ErrorContains(t, WithImageURLFetched(server.URL+"/page")(&Request{}), "not an image")
+	assert.ErrorContains(t, WithImageURLFetched(server.URL+"/missing")(&Request{}), "404")
+
+	previousLimit := MaxImageBytes
+	MaxImageBytes = 4
+	assert.ErrorContains(t, WithImageURLFetched(server.URL+"/sniffed")(&Request{}), "exceeding")
+	MaxImageBytes = previousLimit
+
+	previousTimeout := ImageFetchTimeout
+	ImageFetchTimeout = 20 * time.Millisecond
+	defer func() { ImageFetchTimeout = previousTimeout }()
+	err = WithImageURLFetched(server.URL + "/slow")(&Request{})
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
+
 func TestWithImageBytes(t *testing.T) {
 	req := &Request{}
 	testBytes := []byte{0xFF, 0xD8, 0xFF}
@@ -216,6 +795,173 @@ func TestWithImageBytes(t *testing.T) {
 	assert.Contains(t, part.ImageURL.URL, "data:image/jpeg;base64,")
 }
 
+func TestImageOptions_ApplyDefaultDetail(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "image.png")
+	assert.NoError(t, os.WriteFile(tempFile, []byte{0x89, 0x50, 0x4E, 0x47}, 0o600))
+
+	req := &Request{}
+	assert.NoError(t, WithImageFile(tempFile)(req))
+	assert.NoError(t, WithImageURL("https://example.com/image.jpg")(req))
+	assert.NoError(t, WithImageBytes([]byte{0xFF, 0xD8, 0xFF}, "image/jpeg")(req))
+
+	assert.Len(t, req.Messages[0].Content, 3)
+	for _, part := range req.Messages[0].Content {
+		assert.Equal(t, ImageDetailAuto, part.ImageURL.Detail)
+	}
+}
+
+func TestImageOptions_InvalidDefaultDetail(t *testing.T) {
+	original := DefaultImageDetail
+	DefaultImageDetail = "ultra"
+	defer func() { DefaultImageDetail = original }()
+
+	req := &Request{}
+	assert.Error(t, WithImageURL("https://example.com/image.jpg")(req))
+	assert.Error(t, WithImageBytes([]byte{0xFF}, "image/jpeg")(req))
+	assert.Len(t, req.Messages, 0)
+}
+
+func TestWithImageDetail(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithImageURL("https://example.com/a.jpg")(req))
+	assert.NoError(t, WithImageURL("https://example.com/b.jpg")(req))
+	assert.NoError(t, WithImageDetail(ImageDetailHigh)(req))
+
+	assert.Equal(t, ImageDetailAuto, req.Messages[0].Content[0].ImageURL.Detail)
+	assert.Equal(t, ImageDetailHigh, req.Messages[0].Content[1].ImageURL.Detail)
+
+	assert.Error(t, WithImageDetail("medium")(req))
+	assert.Error(t, WithImageDetail(ImageDetailLow)(&Request{}))
+}
+
+func TestWithDataURL(t *testing.T) {
+	dataURL := "data:image/png;base64,iVBORw0KGgo="
+
+	req := &Request{}
+	assert.NoError(t, WithDataURL(dataURL)(req))
+
+	assert.Len(t, req.Messages, 1)
+	part := req.Messages[0].Content[0]
+	assert.Equal(t, "image_url", part.Type)
+	assert.Equal(t, dataURL, part.ImageURL.URL)
+	assert.Equal(t, ImageDetailAuto, part.ImageURL.Detail)
+}
+
+func TestWithDataURL_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing scheme":    "image/png;base64,iVBORw0KGgo=",
+		"missing separator": "data:image/png;base64",
+		"not base64":        "data:image/png,rawbytes",
+		"non-image mime":    "data:text/plain;base64,aGVsbG8=",
+		"empty mime":        "data:;base64,aGVsbG8=",
+		"empty payload":     "data:image/png;base64,",
+		"corrupt payload":   "data:image/png;base64,!!!",
+	}
+
+	for name, dataURL := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &Request{}
+			assert.Error(t, WithDataURL(dataURL)(req))
+			assert.Len(t, req.Messages, 0)
+		})
+	}
+}
+
+func writeTestImage(t *testing.T, name string, width, height int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(name, ".jpg") {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	return path
+}
+
+func decodeAttachedImage(t *testing.T, req *Request) (image.Image, string) {
+	t.Helper()
+	url := req.Messages[0].Content[0].ImageURL.URL
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+	assert.True(t, ok)
+	data, err := base64.StdEncoding.DecodeString(payload)
+	assert.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	return img, meta
+}
+
+func TestWithImageFileResized(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		width      int
+		height     int
+		wantWidth  int
+		wantHeight int
+		wantMIME   string
+	}{
+		{"landscape png", "wide.png", 400, 200, 100, 50, "image/png"},
+		{"portrait jpeg", "tall.jpg", 100, 300, 33, 100, "image/jpeg"},
+		{"already small", "small.png", 80, 60, 80, 60, "image/png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestImage(t, tt.file, tt.width, tt.height)
+			req := &Request{}
+
+			err := WithImageFileResized(path, 100)(req)
+			assert.NoError(t, err)
+
+			img, mimeType := decodeAttachedImage(t, req)
+			assert.Equal(t, tt.wantMIME, mimeType)
+			assert.Equal(t, tt.wantWidth, img.Bounds().Dx())
+			assert.Equal(t, tt.wantHeight, img.Bounds().Dy())
+			assert.Equal(t, DefaultImageDetail, req.Messages[0].Content[0].ImageURL.Detail)
+		})
+	}
+}
+
+func TestWithImageFileResized_UnsupportedFormatAttachesOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photo.webp")
+	original := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+	assert.NoError(t, os.WriteFile(path, original, 0o600))
+	req := &Request{}
+
+	err := WithImageFileResized(path, 100)(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "data:image/webp;base64,"+base64.StdEncoding.EncodeToString(original), req.Messages[0].Content[0].ImageURL.URL)
+}
+
+func TestWithImageFileResized_Errors(t *testing.T) {
+	path := writeTestImage(t, "wide.png", 400, 200)
+
+	assert.Error(t, WithImageFileResized(path, 0)(&Request{}))
+	assert.Error(t, WithImageFileResized(filepath.Join(t.TempDir(), "missing.png"), 100)(&Request{}))
+
+	previous := MaxImageBytes
+	MaxImageBytes = 16
+	defer func() { MaxImageBytes = previous }()
+
+	req := &Request{}
+	assert.Error(t, WithImageFileResized(path, 100)(req))
+	assert.Error(t, WithImageBytes(make([]byte, 32), "image/png")(req))
+	assert.Len(t, req.Messages, 0)
+}
+
 func TestWithImageFile_Error(t *testing.T) {
 	req := &Request{}
 
@@ -234,6 +980,51 @@ func TestWithImageBytes_EmptyInput(t *testing.T) {
 	assert.Len(t, req.Messages, 0)
 }
 
+func TestWithDocumentFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, mimeType := range map[string]string{
+		"report.pdf":  "application/pdf",
+		"brief.DOCX":  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"notes.txt":   "text/plain",
+		"table.csv":   "text/csv",
+		"README.md":   "text/markdown",
+		"archive.bin": "application/octet-stream",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+		req := &Request{}
+		require.NoError(t, WithDocumentFile(path)(req))
+		require.Len(t, req.Messages, 1)
+		part := req.Messages[0].Content[0]
+		assert.Equal(t, "file", part.Type)
+		require.NotNil(t, part.File)
+		assert.Equal(t, name, part.File.Filename)
+		assert.Equal(t, "data:"+mimeType+";base64,aGVsbG8=", part.File.FileData)
+	}
+
+	assert.Error(t, WithDocumentFile(filepath.Join(dir, "missing.pdf"))(&Request{}))
+}
+
+func TestWithDocumentBytes(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithDocumentBytes([]byte("%PDF-1.7"), "application/pdf")(req))
+
+	// A lone document part must stay an array rather than collapse to a string.
+	data, err := json.Marshal(req.Messages[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":[{"type":"file","file":{"file_data":"data:application/pdf;base64,JVBERi0xLjc="}}]}`, string(data))
+
+	var decoded Message
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, req.Messages[0], decoded)
+
+	empty := &Request{}
+	assert.NoError(t, WithDocumentBytes(nil, "")(empty))
+	assert.Len(t, empty.Messages, 0)
+	assert.Error(t, WithDocumentBytes([]byte("x"), "")(empty))
+}
+
 func TestMultipleImages(t *testing.T) {
 	req := &Request{}
 
@@ -281,6 +1072,48 @@ func TestMultipleImages(t *testing.T) {
 	assert.Contains(t, part3.ImageURL.URL, "data:image/png;base64,")
 }
 
+func TestWithStrictTools(t *testing.T) {
+	type WeatherArgs struct {
+		City  string `json:"city"`
+		Units string `json:"units,omitempty"`
+	}
+
+	req := &Request{}
+	require.NoError(t, WithTool("get_weather", "Look up the weather", WeatherArgs{})(req))
+	require.NoError(t, WithStrictTools(true)(req))
+	require.NoError(t, WithTool("get_time", "Look up the time", nil)(req))
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Tools []struct {
+			Type     string `json:"type"`
+			Function struct {
+				Name       string                 `json:"name"`
+				Strict     bool                   `json:"strict"`
+				Parameters map[string]interface{} `json:"parameters"`
+			} `json:"function"`
+		} `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Tools, 2)
+	for _, tool := range decoded.Tools {
+		assert.Equal(t, "function", tool.Type)
+		assert.True(t, tool.Function.Strict, tool.Function.Name)
+	}
+	params := decoded.Tools[0].Function.Parameters
+	assert.Equal(t, false, params["additionalProperties"])
+	assert.Equal(t, []interface{}{"city"}, params["required"])
+	assert.Nil(t, decoded.Tools[1].Function.Parameters)
+
+	// Turning strict off clears the flag, which is then omitted.
+	require.NoError(t, WithStrictTools(false)(req))
+	data, err = json.Marshal(req)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"strict"`)
+}
+
 func TestStructToJSONSchema(t *testing.T) {
 	type User struct {
 		ID       int    `json:"id"`
@@ -310,6 +1143,81 @@ func TestStructToJSONSchema(t *testing.T) {
 	assert.NotContains(t, required, "optional")
 }
 
+func TestStructToJSONSchema_NullableTag(t *testing.T) {
+	type Result struct {
+		Answer string `json:"answer"`
+		Note   string `json:"note,omitempty" nullable:"true"`
+		Score  *int   `json:"score,omitempty"`
+	}
+
+	schema, _, err := structToJSONSchema(Result{})
+	assert.NoError(t, err)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, "string", properties["answer"].(map[string]interface{})["type"])
+	assert.Equal(t, []string{"string", "null"}, properties["note"].(map[string]interface{})["type"])
+	// Pointers are not inferred nullable without the flag
+	assert.Equal(t, "integer", properties["score"].(map[string]interface{})["type"])
+
+	required := schema["required"].([]string)
+	assert.Contains(t, required, "answer")
+	assert.Contains(t, required, "note")
+	assert.NotContains(t, required, "score")
+}
+
+func TestStructToJSONSchema_NullablePointers(t *testing.T) {
+	type Result struct {
+		Answer string  `json:"answer"`
+		Score  *int    `json:"score,omitempty"`
+		Label  *string `json:"label" nullable:"false"`
+	}
+
+	schema, _, err := structToJSONSchemaWithOptions(Result{}, schemaOptions{nullablePointers: true})
+	assert.NoError(t, err)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, "string", properties["answer"].(map[string]interface{})["type"])
+	assert.Equal(t, []string{"integer", "null"}, properties["score"].(map[string]interface{})["type"])
+	// An explicit tag overrides pointer inference
+	assert.Equal(t, "string", properties["label"].(map[string]interface{})["type"])
+
+	required := schema["required"].([]string)
+	assert.ElementsMatch(t, []string{"answer", "score", "label"}, required)
+}
+
+func TestWithNullableSchema(t *testing.T) {
+	type Result struct {
+		Score *float64 `json:"score"`
+	}
+
+	req := &Request{}
+	assert.NoError(t, WithNullableSchema(Result{})(req))
+	assert.NotNil(t, req.ResponseFormat)
+	assert.True(t, req.ResponseFormat.JSONSchema.Strict)
+	assert.JSONEq(t,
+		`{"type":"object","properties":{"score":{"type":["number","null"]}},"required":["score"],"additionalProperties":false}`,
+		string(req.ResponseFormat.JSONSchema.Schema))
+}
+
+func TestWithSchema_StableBytes(t *testing.T) {
+	type Result struct {
+		Zeta    string  `json:"zeta"`
+		Alpha   int     `json:"alpha" description:"first"`
+		Middle  bool    `json:"middle,omitempty"`
+		Beta    *string `json:"beta" nullable:"true"`
+		Omega   float64 `json:"omega"`
+		Charlie []int   `json:"charlie"`
+	}
+
+	first := &Request{}
+	require.NoError(t, WithSchema(Result{})(first))
+	for i := 0; i < 20; i++ {
+		again := &Request{}
+		require.NoError(t, WithSchema(Result{})(again))
+		require.Equal(t, string(first.ResponseFormat.JSONSchema.Schema), string(again.ResponseFormat.JSONSchema.Schema))
+	}
+}
+
 func TestStructToJSONSchema_WithPointer(t *testing.T) {
 	type TestStruct struct {
 		Value string `json:"value"`
@@ -341,6 +1249,27 @@ func TestStructToJSONSchema_InvalidType(t *testing.T) {
 	}
 }
 
+func TestSchemaOf_MatchesWithSchema(t *testing.T) {
+	type Report struct {
+		Title string   `json:"title"`
+		Tags  []string `json:"tags,omitempty"`
+	}
+
+	schema, name, err := SchemaOf(&Report{})
+	require.NoError(t, err)
+	assert.Equal(t, "Report", name)
+
+	req := &Request{}
+	require.NoError(t, WithSchema(Report{})(req))
+	assert.Equal(t, string(req.ResponseFormat.JSONSchema.Schema), string(schema))
+	assert.Equal(t, req.ResponseFormat.JSONSchema.Name, name)
+
+	_, _, err = SchemaOf(nil)
+	assert.Error(t, err)
+	_, _, err = SchemaOf("not a struct")
+	assert.Error(t, err)
+}
+
 func TestGoTypeToJSONType(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -415,3 +1344,350 @@ func TestMultipleOptions(t *testing.T) {
 	assert.NotNil(t, req.MaxTokens)
 	assert.Equal(t, 1000, *req.MaxTokens)
 }
+
+func TestWithToolChoice(t *testing.T) {
+	type WeatherArgs struct {
+		City string `json:"city"`
+	}
+
+	req := &Request{}
+	require.NoError(t, WithTool("get_weather", "Look up the weather", WeatherArgs{})(req))
+	require.NoError(t, WithTool("get_time", "Look up the time", json.RawMessage(`{"type":"object","properties":{"zone":{"type":"string"}}}`))(req))
+	require.NoError(t, WithToolChoice("get_time")(req))
+	require.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var decoded struct {
+		Tools []struct {
+			Function struct {
+				Name       string                 `json:"name"`
+				Parameters map[string]interface{} `json:"parameters"`
+			} `json:"function"`
+		} `json:"tools"`
+		ToolChoice json.RawMessage `json:"tool_choice"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Tools, 2)
+	assert.Contains(t, decoded.Tools[0].Function.Parameters["properties"], "city")
+	assert.Contains(t, decoded.Tools[1].Function.Parameters["properties"], "zone")
+	assert.JSONEq(t, `{"type":"function","function":{"name":"get_time"}}`, string(decoded.ToolChoice))
+
+	for _, choice := range []string{ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired} {
+		require.NoError(t, WithToolChoice(choice)(req))
+		assert.JSONEq(t, `"`+choice+`"`, string(req.ToolChoice))
+		assert.NoError(t, req.Validate())
+	}
+
+	require.NoError(t, WithToolChoice("missing")(req))
+	assert.ErrorContains(t, req.Validate(), "not a declared tool")
+
+	noTools := &Request{}
+	require.NoError(t, WithToolChoice(ToolChoiceAuto)(noTools))
+	assert.ErrorContains(t, noTools.Validate(), "requires at least one tool")
+
+	assert.Error(t, WithToolChoice("")(&Request{}))
+
+	data, err = json.Marshal(&Request{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "tool_choice")
+}
+
+func TestWithFunctions(t *testing.T) {
+	type WeatherArgs struct {
+		City string `json:"city"`
+	}
+
+	req := &Request{}
+	require.NoError(t, WithFunctions(
+		FunctionSpec{Name: "get_weather", Description: "Look up the weather", Params: WeatherArgs{}},
+		FunctionSpec{Name: "get_time", Params: `{"type":"object"}`},
+	)(req))
+	require.NoError(t, WithFunctionCall("get_weather")(req))
+	require.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var decoded struct {
+		Functions []struct {
+			Name       string                 `json:"name"`
+			Parameters map[string]interface{} `json:"parameters"`
+		} `json:"functions"`
+		FunctionCall json.RawMessage `json:"function_call"`
+		Tools        json.RawMessage `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Functions, 2)
+	assert.Contains(t, decoded.Functions[0].Parameters["properties"], "city")
+	assert.Equal(t, "object", decoded.Functions[1].Parameters["type"])
+	assert.JSONEq(t, `{"name":"get_weather"}`, string(decoded.FunctionCall))
+	assert.Nil(t, decoded.Tools)
+
+	require.NoError(t, WithFunctionCall("auto")(req))
+	assert.JSONEq(t, `"auto"`, string(req.FunctionCall))
+
+	require.NoError(t, WithFunctionCall("missing")(req))
+	assert.ErrorContains(t, req.Validate(), "not a declared function")
+
+	assert.Error(t, WithFunctions(FunctionSpec{Name: " "})(&Request{}))
+	assert.Error(t, WithFunctionCall("")(&Request{}))
+}
+
+func TestWithFunctions_ExclusiveWithTools(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithTool("get_time", "", nil)(req))
+	require.NoError(t, WithFunctions(FunctionSpec{Name: "get_weather"})(req))
+	assert.ErrorContains(t, req.Validate(), "cannot be combined with tools")
+
+	req = &Request{}
+	require.NoError(t, WithTool("get_time", "", nil)(req))
+	require.NoError(t, WithFunctionCall("none")(req))
+	assert.Error(t, req.Validate())
+}
+
+func TestWithToolResultJSON(t *testing.T) {
+	req := &Request{}
+
+	result := map[string]interface{}{"temperature": 21, "unit": "celsius"}
+	err := WithToolResultJSON("call_123", result)(req)
+	assert.NoError(t, err)
+	assert.Len(t, req.Messages, 1)
+
+	msg := req.Messages[0]
+	assert.Equal(t, "tool", msg.Role)
+	assert.Equal(t, "call_123", msg.ToolCallID)
+	assert.JSONEq(t, `{"temperature":21,"unit":"celsius"}`, msg.Content[0].Text)
+
+	data, err := json.Marshal(msg)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "tool", decoded["role"])
+	assert.Equal(t, "call_123", decoded["tool_call_id"])
+	assert.JSONEq(t, `{"temperature":21,"unit":"celsius"}`, decoded["content"].(string))
+}
+
+func TestWithToolResultJSON_MarshalError(t *testing.T) {
+	req := &Request{}
+
+	err := WithToolResultJSON("call_123", make(chan int))(req)
+	assert.Error(t, err)
+	assert.Len(t, req.Messages, 0)
+}
+
+func TestWithToolResult_RequiresToolCallID(t *testing.T) {
+	req := &Request{}
+
+	err := WithToolResult("", "done")(req)
+	assert.Error(t, err)
+	assert.Len(t, req.Messages, 0)
+}
+
+func TestWithToolResultImage(t *testing.T) {
+	req := &Request{}
+
+	png := []byte{0x89, 'P', 'N', 'G'}
+	err := WithToolResultImage("call_chart", png, "image/png")(req)
+	assert.NoError(t, err)
+	assert.Len(t, req.Messages, 1)
+
+	data, err := json.Marshal(req.Messages[0])
+	assert.NoError(t, err)
+
+	var decoded struct {
+		Role       string        `json:"role"`
+		ToolCallID string        `json:"tool_call_id"`
+		Content    []ContentPart `json:"content"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "tool", decoded.Role)
+	assert.Equal(t, "call_chart", decoded.ToolCallID)
+	if assert.Len(t, decoded.Content, 1) {
+		assert.Equal(t, "image_url", decoded.Content[0].Type)
+		assert.Equal(t, "data:image/png;base64,iVBORw==", decoded.Content[0].ImageURL.URL)
+	}
+}
+
+func TestWithToolResultImage_RejectsInvalidInput(t *testing.T) {
+	req := &Request{}
+
+	assert.Error(t, WithToolResultImage("", []byte{1}, "image/png")(req))
+	assert.Error(t, WithToolResultImage("call_1", nil, "image/png")(req))
+	assert.Error(t, WithToolResultImage("call_1", []byte{1}, "text/plain")(req))
+	assert.Error(t, WithToolResultImage("call_1", make([]byte, MaxImageBytes+1), "image/png")(req))
+	assert.Len(t, req.Messages, 0)
+}
+
+func TestWithMessagesJSON_RoundTrip(t *testing.T) {
+	original := []Message{
+		{Role: "system", Content: []ContentPart{{Type: "text", Text: "Be brief"}}},
+		{Role: "user", Content: []ContentPart{
+			{Type: "text", Text: "What is this?"},
+			{Type: "image_url", ImageURL: &ImageURLData{URL: "https://example.com/cat.png", Detail: "low"}},
+		}},
+		{Role: "tool", Content: []ContentPart{{Type: "text", Text: "ok"}}, ToolCallID: "call_1"},
+	}
+	data, err := json.Marshal(original)
+	assert.NoError(t, err)
+
+	req := &Request{Messages: []Message{{Role: "user", Content: []ContentPart{{Type: "text", Text: "stale"}}}}}
+	err = WithMessagesJSON(data)(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, original, req.Messages)
+}
+
+func TestWithMessagesJSON_Malformed(t *testing.T) {
+	req := &Request{}
+
+	err := WithMessagesJSON([]byte(`[{"role":"user","content":42}]`))(req)
+	assert.Error(t, err)
+	assert.Len(t, req.Messages, 0)
+
+	err = WithMessagesJSON([]byte(`not json`))(req)
+	assert.Error(t, err)
+}
+
+func TestWithExtraBody(t *testing.T) {
+	req := &Request{Model: "gemini-pro"}
+
+	err := WithExtraBody("cached_content", "cachedContents/abc123")(req)
+	assert.NoError(t, err)
+	err = WithExtraBody("generation_config", map[string]int{"candidate_count": 1})(req)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "gemini-pro", decoded["model"])
+	assert.Equal(t, "cachedContents/abc123", decoded["cached_content"])
+	assert.Equal(t, map[string]interface{}{"candidate_count": float64(1)}, decoded["generation_config"])
+
+	// safety_settings is a first-class field, so it can't be set through ExtraBody
+	assert.Error(t, WithExtraBody("safety_settings", []SafetySetting{})(req))
+
+	// Output is stable across marshals.
+	again, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestWithStreamUsage(t *testing.T) {
+	req := &Request{}
+	assert.NoError(t, WithStream()(req))
+	assert.NoError(t, WithStreamUsage()(req))
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"stream_options":{"include_usage":true}`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "stream_options")
+}
+
+func TestWithThinking(t *testing.T) {
+	maxTokens := 16000
+	req := &Request{MaxTokens: &maxTokens}
+	assert.NoError(t, WithThinking(8000)(req))
+	assert.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"thinking":{"type":"enabled","budget_tokens":8000}`)
+
+	data, err = json.Marshal(&Request{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "thinking")
+
+	// The budget must be positive and below max_tokens
+	assert.NoError(t, WithThinking(16000)(req))
+	assert.ErrorContains(t, req.Validate(), "less than max_tokens")
+	assert.NoError(t, WithThinking(0)(req))
+	assert.ErrorContains(t, req.Validate(), "must be positive")
+
+	unbounded := &Request{}
+	assert.NoError(t, WithThinking(32000)(unbounded))
+	assert.NoError(t, unbounded.Validate())
+
+	// thinking is now a typed field, so ExtraBody can no longer set it
+	assert.ErrorContains(t, WithExtraBody("thinking", map[string]int{"budget_tokens": 1024})(unbounded), "collides")
+}
+
+func TestWithRoleMapping(t *testing.T) {
+	req := &Request{Model: "o1"}
+	require.NoError(t, WithSystem("be brief")(req))
+	req.Messages = append(req.Messages, Message{Role: "user", Content: []ContentPart{{Type: "text", Text: "hi"}}})
+
+	mapping := map[string]string{"system": "developer"}
+	require.NoError(t, WithRoleMapping(mapping)(req))
+	mapping["user"] = "assistant" // later changes to the caller's map are ignored
+	require.NoError(t, req.Validate())
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Messages []struct {
+			Role string `json:"role"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Messages, 2)
+	assert.Equal(t, "developer", decoded.Messages[0].Role)
+	assert.Equal(t, "user", decoded.Messages[1].Role)
+
+	// The stored messages keep their original roles.
+	assert.Equal(t, "system", req.Messages[0].Role)
+}
+
+func TestWithRoleMapping_ValidateRejectsUnknownRole(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithRoleMapping(map[string]string{"system": "narrator"})(req))
+
+	err := req.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "narrator")
+}
+
+func TestWithHeader(t *testing.T) {
+	req := &Request{}
+	require.NoError(t, WithHeader("x-tenant", "acme")(req))
+	require.NoError(t, WithHeader("X-Model-Pool", "fast")(req))
+	require.NoError(t, WithHeader("X-TENANT", "globex")(req))
+	require.NoError(t, req.Validate())
+
+	assert.Equal(t, map[string]string{"X-Tenant": "globex", "X-Model-Pool": "fast"}, req.Headers)
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "X-Tenant")
+}
+
+func TestWithHeader_RejectsRestrictedHeaders(t *testing.T) {
+	req := &Request{}
+	assert.Error(t, WithHeader("authorization", "Bearer other")(req))
+	assert.Error(t, WithHeader("Content-Type", "text/plain")(req))
+	assert.Error(t, WithHeader(" ", "x")(req))
+	assert.Nil(t, req.Headers)
+
+	// Restricted headers added directly to the map are caught by Validate.
+	req.Headers = map[string]string{"AUTHORIZATION": "Bearer other"}
+	assert.Error(t, req.Validate())
+}
+
+func TestWithExtraBody_RejectsKnownFields(t *testing.T) {
+	req := &Request{}
+
+	assert.Error(t, WithExtraBody("model", "gpt-4o")(req))
+	assert.Error(t, WithExtraBody("", 1)(req))
+	assert.Nil(t, req.ExtraBody)
+
+	// Collisions added directly to the map are caught at marshal time.
+	req.ExtraBody = map[string]json.RawMessage{"temperature": json.RawMessage(`1`)}
+	_, err := json.Marshal(req)
+	assert.Error(t, err)
+}
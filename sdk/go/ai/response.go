@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Response represents the API response from OpenAI/OpenRouter.
@@ -14,6 +15,15 @@ type Response struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   *Usage   `json:"usage,omitempty"`
+
+	// SchemaVersion is the schema version the output was generated against.
+	// Gateways may echo it; otherwise the Client fills in the version set
+	// with WithSchemaVersion.
+	SchemaVersion string `json:"schema_version,omitempty"`
+
+	// Latency is the wall-clock duration of the HTTP round trip, set by
+	// the Client. It is not part of the API response.
+	Latency time.Duration `json:"-"`
 }
 
 // Choice represents a completion choice.
@@ -28,6 +38,57 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// PromptTokensDetails breaks down PromptTokens; nil if the provider
+	// does not report it.
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt token usage.
+type PromptTokensDetails struct {
+	// CachedTokens is the number of prompt tokens served from the
+	// provider's prompt cache.
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// CachedPromptTokens returns the number of prompt tokens served from cache,
+// or 0 if the provider did not report it.
+func (u *Usage) CachedPromptTokens() int {
+	if u == nil || u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
+}
+
+// ModelPricing holds a model's token rates in currency units per 1,000
+// tokens. Prices change, so callers supply their own; the package ships no
+// price table.
+type ModelPricing struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+
+	// CachedPer1K prices prompt tokens served from cache. Zero means the
+	// model has no cache rate and cached tokens cost PromptPer1K.
+	CachedPer1K float64
+}
+
+// EstimateCost returns the cost of usage at pricing. Cached prompt tokens,
+// which providers count within PromptTokens, are priced at the cache rate
+// and the rest of the prompt at the prompt rate.
+func EstimateCost(usage Usage, pricing ModelPricing) float64 {
+	cached := usage.CachedPromptTokens()
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	cachedRate := pricing.CachedPer1K
+	if cachedRate == 0 {
+		cachedRate = pricing.PromptPer1K
+	}
+
+	cost := float64(usage.PromptTokens-cached) * pricing.PromptPer1K
+	cost += float64(cached) * cachedRate
+	cost += float64(usage.CompletionTokens) * pricing.CompletionPer1K
+	return cost / 1000
 }
 
 // StreamChunk represents a streaming response chunk.
@@ -37,6 +98,10 @@ type StreamChunk struct {
 	Created int64         `json:"created"`
 	Model   string        `json:"model"`
 	Choices []StreamDelta `json:"choices"`
+
+	// Usage is only sent on the final chunk, and only when requested with
+	// WithStreamUsage.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // StreamDelta represents a delta in a streaming response.
@@ -48,8 +113,48 @@ type StreamDelta struct {
 
 // MessageDelta represents the incremental message content.
 type MessageDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. The first fragment of
+// a call carries its ID, type and function name; later fragments with the
+// same Index append to the function arguments.
+type ToolCallDelta struct {
+	Index    int               `json:"index"`
+	ID       string            `json:"id,omitempty"`
+	Type     string            `json:"type,omitempty"`
+	Function FunctionCallDelta `json:"function"`
+}
+
+// FunctionCallDelta holds the function name and an arguments fragment of a
+// streamed tool call.
+type FunctionCallDelta struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ToolCall is a complete tool call in a response message.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the function name and JSON-encoded arguments of a tool
+// call.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// DecodeArguments unmarshals the call's JSON arguments into dest.
+func (c *ToolCall) DecodeArguments(dest interface{}) error {
+	if strings.TrimSpace(c.Function.Arguments) == "" {
+		return fmt.Errorf("tool call %q has no arguments", c.Function.Name)
+	}
+	return json.Unmarshal([]byte(c.Function.Arguments), dest)
 }
 
 // ErrorResponse represents an error from the API.
@@ -80,6 +185,25 @@ func (r *Response) Text() string {
 	return sb.String()
 }
 
+// ToolCalls returns the tool calls of the first choice.
+func (r *Response) ToolCalls() []ToolCall {
+	if len(r.Choices) == 0 {
+		return nil
+	}
+	return r.Choices[0].Message.ToolCalls
+}
+
+// FirstToolCall returns the first tool call of the first choice, for the
+// common case of a model making a single call. It reports false when the
+// response has no tool calls.
+func (r *Response) FirstToolCall() (*ToolCall, bool) {
+	calls := r.ToolCalls()
+	if len(calls) == 0 {
+		return nil, false
+	}
+	return &calls[0], true
+}
+
 // JSON parses the response content as JSON into the provided destination.
 func (r *Response) JSON(dest interface{}) error {
 	content := r.Text()
@@ -2,9 +2,11 @@ package ai
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResponse_Text(t *testing.T) {
@@ -262,6 +264,109 @@ func TestUsage(t *testing.T) {
 	assert.Equal(t, 30, usage.TotalTokens)
 }
 
+func TestUsage_CachedPromptTokens(t *testing.T) {
+	var resp Response
+	err := json.Unmarshal([]byte(`{"usage":{"prompt_tokens":2048,"completion_tokens":10,"total_tokens":2058,"prompt_tokens_details":{"cached_tokens":1920}}}`), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 1920, resp.Usage.CachedPromptTokens())
+
+	assert.Equal(t, 0, (&Usage{PromptTokens: 5}).CachedPromptTokens())
+	assert.Equal(t, 0, (*Usage)(nil).CachedPromptTokens())
+}
+
+func TestEstimateCost(t *testing.T) {
+	pricing := ModelPricing{PromptPer1K: 0.002, CompletionPer1K: 0.008, CachedPer1K: 0.0005}
+
+	usage := Usage{PromptTokens: 2000, CompletionTokens: 500}
+	assert.InDelta(t, 0.004+0.004, EstimateCost(usage, pricing), 1e-12)
+
+	// 1500 of the 2000 prompt tokens came from cache
+	usage.PromptTokensDetails = &PromptTokensDetails{CachedTokens: 1500}
+	assert.InDelta(t, 0.001+0.00075+0.004, EstimateCost(usage, pricing), 1e-12)
+
+	// Without a cache rate, cached tokens cost the prompt rate
+	noCacheRate := pricing
+	noCacheRate.CachedPer1K = 0
+	assert.InDelta(t, 0.004+0.004, EstimateCost(usage, noCacheRate), 1e-12)
+
+	assert.Zero(t, EstimateCost(Usage{}, pricing))
+}
+
+func TestStreamChunk_FinalUsage(t *testing.T) {
+	decoder := NewSSEDecoder(strings.NewReader(
+		"data: {\"id\":\"1\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+			"data: {\"id\":\"1\",\"choices\":[],\"usage\":{\"prompt_tokens\":1200,\"completion_tokens\":1,\"total_tokens\":1201,\"prompt_tokens_details\":{\"cached_tokens\":1024}}}\n\n" +
+			"data: [DONE]\n\n"))
+
+	first, err := decoder.Decode()
+	assert.NoError(t, err)
+	assert.Nil(t, first.Usage)
+
+	last, err := decoder.Decode()
+	assert.NoError(t, err)
+	if assert.NotNil(t, last.Usage) {
+		assert.Equal(t, 1024, last.Usage.CachedPromptTokens())
+	}
+}
+
+func TestResponse_ToolCalls(t *testing.T) {
+	data := `{
+		"id": "chatcmpl-1",
+		"choices": [{
+			"index": 0,
+			"message": {
+				"role": "assistant",
+				"content": null,
+				"tool_calls": [
+					{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+					{"id": "call_2", "type": "function", "function": {"name": "get_time", "arguments": "{}"}}
+				]
+			},
+			"finish_reason": "tool_calls"
+		}]
+	}`
+
+	var resp Response
+	require.NoError(t, json.Unmarshal([]byte(data), &resp))
+	assert.Empty(t, resp.Text())
+	assert.Nil(t, resp.Choices[0].Message.Content)
+	require.Len(t, resp.ToolCalls(), 2)
+
+	call, ok := resp.FirstToolCall()
+	require.True(t, ok)
+	assert.Equal(t, "call_1", call.ID)
+	assert.Equal(t, "get_weather", call.Function.Name)
+	var args struct {
+		City string `json:"city"`
+	}
+	require.NoError(t, call.DecodeArguments(&args))
+	assert.Equal(t, "Paris", args.City)
+
+	// Text content and tool calls can arrive together.
+	mixed := `{"choices":[{"message":{"role":"assistant","content":"Checking.","tool_calls":[{"id":"call_3","type":"function","function":{"name":"lookup","arguments":""}}]}}]}`
+	require.NoError(t, json.Unmarshal([]byte(mixed), &resp))
+	assert.Equal(t, "Checking.", resp.Text())
+	call, ok = resp.FirstToolCall()
+	require.True(t, ok)
+	assert.Error(t, call.DecodeArguments(&args))
+
+	// Assistant tool-call messages round-trip, so they can be sent back.
+	encoded, err := json.Marshal(resp.Choices[0].Message)
+	require.NoError(t, err)
+	var decoded Message
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, resp.Choices[0].Message, decoded)
+
+	var plain Response
+	require.NoError(t, json.Unmarshal([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`), &plain))
+	call, ok = plain.FirstToolCall()
+	assert.False(t, ok)
+	assert.Nil(t, call)
+	call, ok = (&Response{}).FirstToolCall()
+	assert.False(t, ok)
+	assert.Nil(t, call)
+}
+
 func TestResponse_MarshalUnmarshal(t *testing.T) {
 	original := &Response{
 		ID:      "chatcmpl-123",
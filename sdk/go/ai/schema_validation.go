@@ -0,0 +1,322 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaValidator checks a response body against the JSON Schema the request
+// asked for. It returns nil when data conforms.
+type SchemaValidator func(schema json.RawMessage, data []byte) error
+
+// SchemaValidationError is returned, together with the last response, when
+// a response still fails schema validation after every retry.
+type SchemaValidationError struct {
+	// Attempts is the number of responses that were validated.
+	Attempts int
+	// Err is the validation error of the last response.
+	Err error
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("response failed schema validation after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *SchemaValidationError) Unwrap() error { return e.Err }
+
+// WithSchemaValidationRetry makes Complete and CompleteWithMessages validate
+// responses to requests with a JSON schema response format. A response that
+// does not conform is re-asked up to maxRetries times, with the invalid reply
+// and the validation error appended to the conversation. If every attempt
+// fails, the last response is returned with a *SchemaValidationError.
+// Streaming calls are not validated.
+//
+// Validation runs after each successful HTTP round trip, before the response
+// is returned, and each re-ask is a separate observed call. Re-asks belong to
+// the same logical call and resend the request's idempotency key. Responses
+// are checked with ValidateJSONSchema unless WithSchemaValidator supplies
+// another validator.
+func WithSchemaValidationRetry(maxRetries int) ClientOption {
+	return func(opts *clientOptions) error {
+		if maxRetries < 0 {
+			return fmt.Errorf("schema validation retries must be non-negative, got %d", maxRetries)
+		}
+		opts.schemaRetries = maxRetries
+		opts.validateSchema = true
+		return nil
+	}
+}
+
+// WithSchemaValidator replaces ValidateJSONSchema as the validator used by
+// WithSchemaValidationRetry, e.g. with a full JSON Schema implementation.
+func WithSchemaValidator(validator SchemaValidator) ClientOption {
+	return func(opts *clientOptions) error {
+		if validator == nil {
+			return errors.New("schema validator cannot be nil")
+		}
+		opts.schemaValidator = validator
+		return nil
+	}
+}
+
+// completeValidated sends req and, when schema validation is enabled and req
+// asks for a JSON schema, re-asks while the response does not conform.
+func (c *Client) completeValidated(ctx context.Context, req *Request) (*Response, error) {
+	send := func(r *Request) (*Response, error) {
+		return c.observe(r, func() (*Response, error) {
+			return c.send(ctx, r)
+		})
+	}
+
+	resp, err := send(req)
+	if err != nil || c.schemaValidator == nil || req.ResponseFormat == nil || req.ResponseFormat.JSONSchema == nil {
+		return resp, err
+	}
+	schema := req.ResponseFormat.JSONSchema.Schema
+
+	// A trailing prefill is part of the answer but not of the response text,
+	// and must stay the last message of every re-ask.
+	history := req.Messages
+	var prefill *Message
+	if n := len(history); n > 0 && history[n-1].Prefill {
+		prefill = &history[n-1]
+		history = history[:n-1]
+	}
+
+	for attempt := 1; ; attempt++ {
+		answer := resp.Text()
+		if prefill != nil {
+			answer = messageText(*prefill) + answer
+		}
+		verr := c.schemaValidator(schema, []byte(answer))
+		if verr == nil {
+			return resp, nil
+		}
+		if attempt > c.schemaRetries {
+			return resp, &SchemaValidationError{Attempts: attempt, Err: verr}
+		}
+
+		history = append(append([]Message(nil), history...),
+			Message{Role: "assistant", Content: []ContentPart{{Type: "text", Text: answer}}},
+			Message{Role: "user", Content: []ContentPart{{Type: "text", Text: fmt.Sprintf(
+				"Your previous reply did not match the required JSON schema: %v. Reply again with only JSON that matches the schema.", verr)}}},
+		)
+		retry := *req
+		retry.Messages = history
+		if prefill != nil {
+			retry.Messages = append(history[:len(history):len(history)], *prefill)
+		}
+
+		if resp, err = send(&retry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func messageText(msg Message) string {
+	var sb strings.Builder
+	for _, part := range msg.Content {
+		if part.Type == "text" {
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String()
+}
+
+// ValidateJSONSchema validates data against schema. It supports the keywords
+// produced by WithSchema for Go structs (type, including nullable type
+// lists, properties, required and additionalProperties) plus items, enum and
+// anyOf. Other keywords are ignored; use WithSchemaValidator to plug in a
+// complete JSON Schema implementation.
+func ValidateJSONSchema(schema json.RawMessage, data []byte) error {
+	var root interface{}
+	if err := decodeJSONNumbers(schema, &root); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	var value interface{}
+	if err := decodeJSONNumbers(data, &value); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	return validateSchemaValue(root, value, "$")
+}
+
+func decodeJSONNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func validateSchemaValue(rawSchema, value interface{}, path string) error {
+	schema, ok := rawSchema.(map[string]interface{})
+	if !ok {
+		// true, or a schema we cannot interpret, accepts anything
+		if accept, isBool := rawSchema.(bool); isBool && !accept {
+			return fmt.Errorf("%s: no value is allowed", path)
+		}
+		return nil
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok {
+		matched := false
+		for _, t := range types {
+			if jsonTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeOf(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		matched := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var firstErr error
+		for _, sub := range anyOf {
+			err := validateSchemaValue(sub, value, path)
+			if err == nil {
+				firstErr = nil
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateSchemaObject(schema, v, path)
+	case []interface{}:
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				if err := validateSchemaValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateSchemaObject(schema map[string]interface{}, obj map[string]interface{}, path string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := obj[key]; !present {
+					return fmt.Errorf("%s: missing required property %q", path, key)
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key]; ok {
+			if err := validateSchemaValue(propSchema, obj[key], childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, isBool := additional.(bool); isBool && !allowed {
+			return fmt.Errorf("%s: unexpected property %q", path, key)
+		}
+		if err := validateSchemaValue(additional, obj[key], childPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the type names of a schema's type keyword.
+func schemaTypes(raw interface{}) ([]string, bool) {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, entry := range t {
+			if name, ok := entry.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types, len(types) > 0
+	}
+	return nil, false
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == float64(int64(f))
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return jsonTypeOf(value) == t
+	}
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamReader reads StreamChunks from a text/event-stream body, such as the
+// response to a request made with WithStream.
+//
+// Events are separated by blank lines; the data: lines of an event are joined
+// with newlines and decoded as one chunk. Comments, other fields such as
+// event: and id:, and events without data are skipped. The [DONE] sentinel
+// ends the stream. Lines may end in \n or \r\n.
+type StreamReader struct {
+	reader *bufio.Reader
+	done   bool
+
+	// skipMalformed drops events whose data is not a valid chunk instead of
+	// returning an error, for SSEDecoder.
+	skipMalformed bool
+}
+
+// NewStreamReader returns a StreamReader reading from r.
+func NewStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next chunk. It returns io.EOF once [DONE] has been read or
+// the stream ends, and an error if a chunk cannot be decoded or r fails.
+func (s *StreamReader) Next() (*StreamChunk, error) {
+	for {
+		data, err := s.nextEvent()
+		if err != nil {
+			return nil, err
+		}
+		if data == "[DONE]" {
+			s.done = true
+			return nil, io.EOF
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			if s.skipMalformed {
+				continue
+			}
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		return &chunk, nil
+	}
+}
+
+// nextEvent returns the data of the next event that has any.
+func (s *StreamReader) nextEvent() (string, error) {
+	if s.done {
+		return "", io.EOF
+	}
+
+	var data []string
+	hasData := false
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		atEOF := err == io.EOF
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		switch {
+		case line == "":
+			// A blank line, or the end of the stream, dispatches the event.
+			if hasData {
+				return strings.Join(data, "\n"), nil
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment, e.g. a keep-alive.
+		default:
+			field, value, found := strings.Cut(line, ":")
+			if found {
+				value = strings.TrimPrefix(value, " ")
+			}
+			if field == "data" {
+				data = append(data, value)
+				hasData = true
+			}
+		}
+
+		if atEOF {
+			s.done = true
+			if hasData {
+				return strings.Join(data, "\n"), nil
+			}
+			return "", io.EOF
+		}
+	}
+}
+
+// Text returns the delta content of the first choice.
+func (c *StreamChunk) Text() string {
+	if len(c.Choices) == 0 {
+		return ""
+	}
+	return c.Choices[0].Delta.Content
+}
+
+// FinishReason returns the finish reason of the first choice, or "" if the
+// choice has not finished.
+func (c *StreamChunk) FinishReason() string {
+	if len(c.Choices) == 0 || c.Choices[0].FinishReason == nil {
+		return ""
+	}
+	return *c.Choices[0].FinishReason
+}
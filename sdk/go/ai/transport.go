@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// ClientOption configures a Client, such as its HTTP transport or observers.
+type ClientOption func(*clientOptions) error
+
+type clientOptions struct {
+	tlsConfig *tls.Config
+	observers []Observer
+
+	validateSchema  bool
+	schemaRetries   int
+	schemaValidator SchemaValidator
+}
+
+// applyClientOptions applies opts in order and returns the result.
+func applyClientOptions(opts []ClientOption) (clientOptions, error) {
+	var options clientOptions
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return clientOptions{}, err
+		}
+	}
+	return options, nil
+}
+
+// ensureTLS returns the TLS configuration being built, creating it on first use.
+func (o *clientOptions) ensureTLS() *tls.Config {
+	if o.tlsConfig == nil {
+		o.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return o.tlsConfig
+}
+
+// WithTLSConfig sets the TLS configuration used for gateway connections.
+// The config is cloned, so later mutations by the caller have no effect.
+// Options applied afterwards (WithCACert, WithInsecureSkipVerify) modify the
+// clone; WithCACert copies RootCAs before adding to it, so the caller's
+// certificate pool is never changed.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		if cfg == nil {
+			return errors.New("TLS config cannot be nil")
+		}
+		o.tlsConfig = cfg.Clone()
+		return nil
+	}
+}
+
+// WithCACert trusts the PEM-encoded CA certificate(s) at path in addition to
+// the system roots. This is the recommended way to reach gateways that use an
+// internal certificate authority.
+func WithCACert(path string) ClientOption {
+	return func(o *clientOptions) error {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read CA certificate: %w", err)
+		}
+
+		cfg := o.ensureTLS()
+		if cfg.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			cfg.RootCAs = pool
+		} else {
+			// tls.Config.Clone is shallow; copy the pool so appending does
+			// not modify one supplied through WithTLSConfig.
+			cfg.RootCAs = cfg.RootCAs.Clone()
+		}
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid PEM certificates found in %s", path)
+		}
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification.
+//
+// UNSAFE: this exposes every request, including the API key, to
+// man-in-the-middle attacks. Use it only for local development against a
+// self-signed gateway; prefer WithCACert for anything else.
+func WithInsecureSkipVerify(skip bool) ClientOption {
+	return func(o *clientOptions) error {
+		o.ensureTLS().InsecureSkipVerify = skip
+		return nil
+	}
+}
+
+// newHTTPClient builds the HTTP client for the given configuration and options.
+func newHTTPClient(config *Config, options clientOptions) *http.Client {
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+
+	if options.tlsConfig != nil {
+		if options.tlsConfig.InsecureSkipVerify {
+			log.Printf("[ai] WARNING: TLS certificate verification is disabled for %s; connections are open to man-in-the-middle attacks", config.BaseURL)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = options.tlsConfig
+		httpClient.Transport = transport
+	}
+
+	return httpClient
+}